@@ -0,0 +1,245 @@
+package nixplay
+
+import (
+	"context"
+	"crypto/md5"
+	"errors"
+	"hash"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/internal/errorx"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// defaultDownloadAllConcurrency is the number of photos that are downloaded at
+// once if DownloadAllOptions.Concurrency is not specified.
+const defaultDownloadAllConcurrency = 4
+
+// DownloadSink receives the content of the photos downloaded by
+// Container.DownloadAll.
+type DownloadSink interface {
+	// WritePhoto is called with the content of a photo. It may be called
+	// concurrently for different photos, see DownloadAllOptions.Concurrency.
+	//
+	// If reading from r fails then WritePhoto should return the error, the
+	// photo may then be downloaded again and WritePhoto called again with a
+	// new reader, see DownloadAllOptions.Retries. Any content that WritePhoto
+	// does not read is discarded.
+	WritePhoto(ctx context.Context, p Photo, r io.Reader) error
+}
+
+// UpToDateSink is a DownloadSink that may already have the content of some
+// photos, for example from a previous backup. Photos that the sink reports as
+// up to date are not downloaded and their result has UpToDate set.
+type UpToDateSink interface {
+	DownloadSink
+
+	// UpToDate returns true if the sink already has the content of p. To keep
+	// repeated downloads cheap it should be decided using metadata such as
+	// Photo.Size and Photo.MD5Hash rather than the content of the photo.
+	UpToDate(ctx context.Context, p Photo) (bool, error)
+}
+
+// DownloadSinkFunc is an adapter to allow the use of an ordinary function as a
+// DownloadSink.
+type DownloadSinkFunc func(ctx context.Context, p Photo, r io.Reader) error
+
+// WritePhoto calls f(ctx, p, r).
+func (f DownloadSinkFunc) WritePhoto(ctx context.Context, p Photo, r io.Reader) error {
+	return f(ctx, p, r)
+}
+
+// DownloadAllOptions are optional arguments that may be specified when
+// downloading all of the photos in a container.
+type DownloadAllOptions struct {
+	// Concurrency is the maximum number of photos that are downloaded at
+	// once. If zero a default of 4 is used.
+	Concurrency int
+
+	// Retries is the number of times the download of a photo is retried if
+	// opening or reading the photo fails. Errors returned by the DownloadSink
+	// are not retried.
+	Retries int
+
+	// VerifyMD5 specifies if the MD5 hash of the downloaded content should be
+	// verified against Photo.MD5Hash. If the hash does not match then the
+	// result for the photo reports types.ErrMD5Mismatch. Note that the sink
+	// will already have received all of the content by the time the mismatch
+	// is detected.
+	VerifyMD5 bool
+}
+
+// PhotoDownloadResult is the result of downloading a single photo with
+// Container.DownloadAll.
+type PhotoDownloadResult struct {
+	// Photo is the photo that was downloaded.
+	Photo Photo
+
+	// Err is the reason the photo could not be downloaded, or nil if the photo
+	// was downloaded successfully.
+	Err error
+
+	// Bytes is the number of bytes of the photo that were downloaded by the
+	// final attempt.
+	Bytes int64
+
+	// UpToDate is true if the photo was not downloaded because the sink
+	// already had its content, see UpToDateSink.
+	UpToDate bool
+
+	// Attempts is the number of times the download was attempted.
+	Attempts int
+
+	// Duration is the total time spent downloading the photo, including any
+	// retries.
+	Duration time.Duration
+}
+
+// DownloadAllResult is the result of Container.DownloadAll. Photos that were
+// up to date count as succeeded.
+type DownloadAllResult struct {
+	BulkResult
+
+	// Photos contains the result for each photo, in the same order as the
+	// photos were downloaded in.
+	Photos []PhotoDownloadResult
+}
+
+// DownloadPhotos downloads each of the photos and passes the content to sink,
+// see Container.DownloadAll. A failure to download one photo does not stop the
+// others from being downloaded, it is reported in the result for that photo
+// instead. If any photos fail then the result is returned along with the
+// *BulkError from BulkResult.Err.
+func DownloadPhotos(ctx context.Context, photos []Photo, sink DownloadSink, opts DownloadAllOptions) (retResult DownloadAllResult, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultDownloadAllConcurrency
+	}
+
+	results := make([]PhotoDownloadResult, len(photos))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = downloadToSink(ctx, photos[i], sink, opts)
+			}
+		}()
+	}
+	for i := range photos {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	result := DownloadAllResult{Photos: results}
+	for _, r := range results {
+		result.add(r.Photo, r.Err)
+	}
+	return result, result.Err()
+}
+
+// downloadToSink downloads a single photo to the sink, retrying failed
+// downloads as specified by opts.
+func downloadToSink(ctx context.Context, p Photo, sink DownloadSink, opts DownloadAllOptions) (result PhotoDownloadResult) {
+	result.Photo = p
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	if s, ok := sink.(UpToDateSink); ok {
+		result.UpToDate, result.Err = s.UpToDate(ctx, p)
+		if result.UpToDate || result.Err != nil {
+			return result
+		}
+	}
+
+	maxAttempts := opts.Retries + 1
+	for {
+		if err := ctx.Err(); err != nil {
+			result.Err = err
+			return result
+		}
+		result.Attempts++
+
+		var retryable bool
+		result.Bytes, retryable, result.Err = downloadAttempt(httpx.WithAttempt(ctx, result.Attempts), p, sink, opts)
+		if result.Err == nil || !retryable || result.Attempts >= maxAttempts {
+			return result
+		}
+	}
+}
+
+// downloadAttempt makes a single attempt to download the photo to the sink. If
+// the attempt fails then retryable indicates if the failure was caused by the
+// download rather than the sink.
+func downloadAttempt(ctx context.Context, p Photo, sink DownloadSink, opts DownloadAllOptions) (n int64, retryable bool, err error) {
+	rc, err := p.Open(ctx)
+	if err != nil {
+		return 0, true, err
+	}
+	defer rc.Close()
+
+	r := &downloadAllReader{r: rc}
+	if opts.VerifyMD5 {
+		r.hasher = md5.New()
+	}
+
+	if err := sink.WritePhoto(ctx, p, r); err != nil {
+		return r.n, r.err != nil, err
+	}
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return r.n, true, err
+	}
+
+	if opts.VerifyMD5 {
+		expHash, err := p.MD5Hash(ctx)
+		if err != nil {
+			return r.n, false, err
+		}
+		if *(*types.MD5Hash)(r.hasher.Sum(nil)) != expHash {
+			return r.n, false, types.ErrMD5Mismatch
+		}
+	}
+	return r.n, false, nil
+}
+
+// downloadAllReader counts and optionally hashes the content read from a
+// photo. It records any error other than io.EOF so that we can tell if an
+// error returned by a DownloadSink was caused by the download.
+type downloadAllReader struct {
+	r      io.Reader
+	hasher hash.Hash
+	n      int64
+	err    error
+}
+
+func (r *downloadAllReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.n += int64(n)
+	if r.hasher != nil {
+		r.hasher.Write(b[:n])
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.err = err
+	}
+	return n, err
+}
+
+func (c *container) DownloadAll(ctx context.Context, sink DownloadSink, opts DownloadAllOptions) (retResult DownloadAllResult, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	photos, err := c.Photos(ctx)
+	if err != nil {
+		return DownloadAllResult{}, err
+	}
+	return DownloadPhotos(ctx, photos, sink, opts)
+}
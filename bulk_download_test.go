@@ -0,0 +1,139 @@
+package nixplay_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/nixplaytest"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadAll(t *testing.T) {
+	ctx := context.Background()
+
+	errFlaky := errors.New("flaky")
+	errSink := errors.New("sink failed")
+
+	var mu sync.Mutex
+	opens := map[string]int{}
+
+	client := nixplaytest.NewFakeClient()
+	client.OnCall = func(call nixplaytest.Call) error {
+		if call.Method != "Photo.Open" {
+			return nil
+		}
+		name := string(call.Photo.Content())
+		mu.Lock()
+		defer mu.Unlock()
+		opens[name]++
+		if name == "flaky" && opens[name] == 1 {
+			return errFlaky
+		}
+		if name == "broken" {
+			return errFlaky
+		}
+		return nil
+	}
+	album := client.AddContainer(types.AlbumContainerType, "album")
+	album.AddPhotoContent("good.jpg", []byte("good"))
+	album.AddPhotoContent("flaky.jpg", []byte("flaky"))
+	album.AddPhotoContent("broken.jpg", []byte("broken"))
+	album.AddPhotoContent("rejected.jpg", []byte("rejected"))
+
+	var received sync.Map
+	sink := nixplay.DownloadSinkFunc(func(ctx context.Context, p nixplay.Photo, r io.Reader) error {
+		name, err := p.Name(ctx)
+		if err != nil {
+			return err
+		}
+		if name == "rejected.jpg" {
+			return errSink
+		}
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		received.Store(name, string(content))
+		return nil
+	})
+
+	result, err := album.DownloadAll(ctx, sink, nixplay.DownloadAllOptions{Concurrency: 2, Retries: 2, VerifyMD5: true})
+	var bulkErr *nixplay.BulkError
+	require.ErrorAs(t, err, &bulkErr)
+	assert.ErrorIs(t, err, errFlaky)
+	assert.ErrorIs(t, err, errSink)
+	assert.Len(t, bulkErr.Failures, 2)
+	assert.Equal(t, 2, result.Succeeded)
+	require.Len(t, result.Photos, 4)
+
+	byName := map[string]nixplay.PhotoDownloadResult{}
+	for _, r := range result.Photos {
+		name, err := r.Photo.Name(ctx)
+		require.NoError(t, err)
+		byName[name] = r
+	}
+
+	assert.NoError(t, byName["good.jpg"].Err)
+	assert.Equal(t, int64(len("good")), byName["good.jpg"].Bytes)
+	assert.Equal(t, 1, byName["good.jpg"].Attempts)
+	assert.NotZero(t, byName["good.jpg"].Duration)
+
+	assert.NoError(t, byName["flaky.jpg"].Err)
+	assert.Equal(t, 2, byName["flaky.jpg"].Attempts)
+
+	assert.ErrorIs(t, byName["broken.jpg"].Err, errFlaky)
+	assert.Equal(t, 3, byName["broken.jpg"].Attempts)
+
+	// Sink errors are not retried.
+	assert.ErrorIs(t, byName["rejected.jpg"].Err, errSink)
+	assert.Equal(t, 1, byName["rejected.jpg"].Attempts)
+
+	content, _ := received.Load("good.jpg")
+	assert.Equal(t, "good", content)
+	content, _ = received.Load("flaky.jpg")
+	assert.Equal(t, "flaky", content)
+	_, ok := received.Load("broken.jpg")
+	assert.False(t, ok)
+}
+
+func TestDownloadAll_VerifyMD5UnreadContent(t *testing.T) {
+	ctx := context.Background()
+	client := nixplaytest.NewFakeClient()
+	album := client.AddContainer(types.AlbumContainerType, "album")
+	album.AddPhotoContent("photo.jpg", []byte("photo"))
+
+	// The sink doesn't read anything, the rest of the content is still
+	// downloaded so the hash can be verified.
+	sink := nixplay.DownloadSinkFunc(func(ctx context.Context, p nixplay.Photo, r io.Reader) error {
+		return nil
+	})
+	result, err := album.DownloadAll(ctx, sink, nixplay.DownloadAllOptions{VerifyMD5: true})
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Succeeded)
+	require.Len(t, result.Photos, 1)
+	assert.Equal(t, int64(len("photo")), result.Photos[0].Bytes)
+}
+
+func TestDownloadAll_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client := nixplaytest.NewFakeClient()
+	album := client.AddContainer(types.AlbumContainerType, "album")
+	album.AddPhotoContent("photo.jpg", []byte("photo"))
+
+	sink := nixplay.DownloadSinkFunc(func(ctx context.Context, p nixplay.Photo, r io.Reader) error {
+		t.Error("sink should not be called")
+		return nil
+	})
+	result, err := album.DownloadAll(ctx, sink, nixplay.DownloadAllOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+	require.Len(t, result.Photos, 1)
+	assert.ErrorIs(t, result.Photos[0].Err, context.Canceled)
+}
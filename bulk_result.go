@@ -0,0 +1,98 @@
+package nixplay
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// BulkResult describes the outcome of an operation that is applied to many
+// photos, such as Container.DownloadAll or PendingDeletes.Commit. Bulk
+// operations do not stop at the first photo that fails, the operation is
+// attempted for every photo and the failures are collected in the result.
+type BulkResult struct {
+	// Succeeded is the number of photos the operation succeeded for.
+	Succeeded int
+
+	// DryRun is the number of photos the operation was validated for but not
+	// applied to because the client or context is in dry-run mode, see
+	// WithDryRun. They are not counted in Succeeded.
+	DryRun int
+
+	// Failures contains an error for each photo the operation failed for, in
+	// the order the photos were given to the operation.
+	Failures []*BulkItemError
+}
+
+// Err returns nil if the operation succeeded for every photo, otherwise it
+// returns a *BulkError containing all of the failures.
+func (r BulkResult) Err() error {
+	if len(r.Failures) == 0 {
+		return nil
+	}
+	return &BulkError{Failures: r.Failures}
+}
+
+// add records the outcome of the operation for a single photo.
+func (r *BulkResult) add(p Photo, err error) {
+	if err == nil {
+		r.Succeeded++
+		return
+	}
+	r.Failures = append(r.Failures, &BulkItemError{Photo: p, Err: err})
+}
+
+// BulkItemError is the reason a bulk operation failed for a single photo.
+type BulkItemError struct {
+	// Photo is the photo the operation failed for.
+	Photo Photo
+
+	// Err is the reason the operation failed.
+	Err error
+}
+
+func (e *BulkItemError) Error() string {
+	return fmt.Sprintf("photo %s: %s", e.Photo.ID(), e.Err)
+}
+
+func (e *BulkItemError) Unwrap() error {
+	return e.Err
+}
+
+// BulkError is the error returned by a bulk operation that failed for one or
+// more photos. The message has one line per failure and errors.Is and
+// errors.As match against the error of every failure.
+type BulkError struct {
+	Failures []*BulkItemError
+}
+
+func (e *BulkError) Error() string {
+	msgs := make([]string, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		msgs = append(msgs, f.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Is returns true if the error of any of the failures matches target.
+//
+// Ideally Unwrap would return all of the failures, but errors.Is only
+// supports that from go 1.20 and we are stuck on go 1.18.
+func (e *BulkError) Is(target error) bool {
+	for _, f := range e.Failures {
+		if errors.Is(f, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first failure that matches target, see errors.As.
+func (e *BulkError) As(target any) bool {
+	for _, f := range e.Failures {
+		if errors.As(f, target) {
+			return true
+		}
+	}
+	return false
+}
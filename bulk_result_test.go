@@ -0,0 +1,41 @@
+package nixplay_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/nixplaytest"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkResult(t *testing.T) {
+	assert.NoError(t, nixplay.BulkResult{Succeeded: 3}.Err())
+
+	album := nixplaytest.NewFakeClient().AddContainer(types.AlbumContainerType, "album")
+	a := album.AddPhotoContent("a.jpg", []byte("a"))
+	b := album.AddPhotoContent("b.jpg", []byte("b"))
+
+	result := nixplay.BulkResult{
+		Succeeded: 1,
+		Failures: []*nixplay.BulkItemError{
+			{Photo: a, Err: types.ErrNotFound},
+			{Photo: b, Err: types.ErrMD5Mismatch},
+		},
+	}
+	err := result.Err()
+	require.Error(t, err)
+	assert.ErrorIs(t, err, types.ErrNotFound)
+	assert.ErrorIs(t, err, types.ErrMD5Mismatch)
+	assert.NotErrorIs(t, err, types.ErrDryRun)
+	assert.ErrorIs(t, fmt.Errorf("wrapped: %w", err), types.ErrMD5Mismatch)
+	assert.Equal(t, "photo "+a.ID().String()+": "+types.ErrNotFound.Error()+"\n"+
+		"photo "+b.ID().String()+": "+types.ErrMD5Mismatch.Error(), err.Error())
+
+	var itemErr *nixplay.BulkItemError
+	require.True(t, errors.As(err, &itemErr))
+	assert.Equal(t, a, itemErr.Photo)
+}
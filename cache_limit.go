@@ -0,0 +1,76 @@
+package nixplay
+
+import (
+	"container/list"
+	"sync"
+)
+
+// CacheStats describes the number of entries currently held in the cache of a
+// client.
+type CacheStats struct {
+	// Containers is the number of albums and playlists in the cache.
+	Containers int
+
+	// ContainersWithPhotos is the number of containers that currently have
+	// photos in their cache.
+	ContainersWithPhotos int
+
+	// Photos is the total number of photos in the caches of all containers.
+	Photos int
+}
+
+// photoCacheLimiter bounds the total number of photos cached across all
+// containers by resetting the photo cache of the least recently used
+// containers.
+type photoCacheLimiter struct {
+	maxPhotos int
+
+	mu         sync.Mutex
+	order      *list.List // of *container, most recently used at the front
+	containers map[*container]*list.Element
+}
+
+func newPhotoCacheLimiter(maxPhotos int) *photoCacheLimiter {
+	return &photoCacheLimiter{
+		maxPhotos:  maxPhotos,
+		order:      list.New(),
+		containers: make(map[*container]*list.Element),
+	}
+}
+
+// touch records that the photos in c were used and evicts the photos of other
+// containers if the total number of cached photos is more than the limit. The
+// photos of c itself are never evicted by touch so that a single container
+// with more photos than the limit can still be used. It is safe to call on a
+// nil photoCacheLimiter.
+func (l *photoCacheLimiter) touch(c *container) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.containers[c]; ok {
+		l.order.MoveToFront(e)
+	} else {
+		l.containers[c] = l.order.PushFront(c)
+	}
+
+	total := 0
+	for e := l.order.Front(); e != nil; e = e.Next() {
+		total += e.Value.(*container).photoCache.Len()
+	}
+
+	for total > l.maxPhotos {
+		oldest := l.order.Back()
+		evict := oldest.Value.(*container)
+		if evict == c {
+			return
+		}
+		total -= evict.photoCache.Len()
+		evict.photoCache.Reset()
+		l.order.Remove(oldest)
+		delete(l.containers, evict)
+	}
+}
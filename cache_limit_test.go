@@ -0,0 +1,58 @@
+package nixplay
+
+import (
+	"context"
+	"crypto/md5"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhotoCacheLimiter(t *testing.T) {
+	ctx := context.Background()
+	settings := &clientSettings{
+		metrics:      nopMetrics{},
+		photoLimiter: newPhotoCacheLimiter(5),
+	}
+
+	// Every container has 3 photos.
+	pageFunc := func(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error) {
+		if page > 0 {
+			return nil, nil
+		}
+		var photos []Photo
+		for i := 0; i < 3; i++ {
+			h := types.MD5Hash(md5.Sum([]byte{byte(nixplayID), byte(i)}))
+			p, err := newPhoto(container, client, "photo.jpg", &h, uint64(i+1), "", 10, "")
+			require.NoError(t, err)
+			photos = append(photos, p)
+		}
+		return photos, nil
+	}
+	newTestContainer := func(nixplayID uint64) *container {
+		return newContainer(nil, nil, settings, types.AlbumContainerType, "album", nixplayID, 3, pageFunc, nil, albumAddIDName)
+	}
+
+	a := newTestContainer(1)
+	b := newTestContainer(2)
+
+	_, err := a.Photos(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, a.photoCache.Len())
+
+	// Loading b takes the total over the limit so the least recently used
+	// container, a, is evicted.
+	_, err = b.Photos(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, a.photoCache.Len())
+	assert.Equal(t, 3, b.photoCache.Len())
+
+	// A container is never evicted to make room for itself.
+	settings.photoLimiter = newPhotoCacheLimiter(1)
+	_, err = b.Photos(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, b.photoCache.Len())
+}
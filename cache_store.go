@@ -0,0 +1,196 @@
+package nixplay
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/anitschke/go-nixplay/internal/cache"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// persistedPhotoListVersion is the version of the format used to persist the
+// photos in a container. It must be incremented whenever persistedPhoto
+// changes, including when fields are added, otherwise photos persisted by an
+// older version are loaded with the new fields missing and are never listed
+// again to fill them in. TestPersistedPhotoListVersion pins the fields to the
+// version.
+const persistedPhotoListVersion = 4
+
+// CacheStore is a store used to persist the cached list of photos in each
+// container so that they can be reused by later runs of a program instead of
+// listing every container again.
+//
+// Before persisted photos are used they are validated against the number of
+// photos Nixplay reports for the container. If the number of photos does not
+// match the photos are listed again from Nixplay. Note that this validation
+// will not detect a photo being replaced by another photo.
+//
+// NewFileCacheStore provides a CacheStore that saves to JSON files, other
+// stores such as a database can be used by implementing this interface.
+type CacheStore interface {
+	// Load returns the data that was previously saved for key. If no data
+	// has been saved for key then Load returns nil data and a nil error.
+	Load(ctx context.Context, key string) ([]byte, error)
+
+	// Save saves data for key, replacing any data that was previously saved.
+	Save(ctx context.Context, key string, data []byte) error
+}
+
+// NewFileCacheStore returns a CacheStore that saves data as JSON files in the
+// provided directory. The directory is created if it does not already exist.
+func NewFileCacheStore(dir string) CacheStore {
+	return &fileCacheStore{dir: dir}
+}
+
+type fileCacheStore struct {
+	dir string
+}
+
+func (s *fileCacheStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
+
+func (s *fileCacheStore) Load(ctx context.Context, key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+func (s *fileCacheStore) Save(ctx context.Context, key string, data []byte) (err error) {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it into place so that a reader
+	// never sees a partially written file.
+	f, err := os.CreateTemp(s.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path(key))
+}
+
+type persistedPhotoList struct {
+	Version int              `json:"version"`
+	Photos  []persistedPhoto `json:"photos"`
+}
+
+type persistedPhoto struct {
+	Name                  string        `json:"name,omitempty"`
+	MD5Hash               string        `json:"md5"`
+	NixplayID             uint64        `json:"nixplayId,omitempty"`
+	NixplayPlaylistItemID string        `json:"nixplayPlaylistItemId,omitempty"`
+	Size                  int64         `json:"size"`
+	URL                   string        `json:"url,omitempty"`
+	Duration              time.Duration `json:"duration,omitempty"`
+
+	Hashes map[types.HashType]string `json:"hashes,omitempty"`
+}
+
+// photoPersistence returns the cache.Persistence used to persist the photos in
+// the container to the CacheStore. reportedPhotoCount is the number of photos
+// Nixplay reported for the container which is used to validate the persisted
+// photos.
+func (c *container) photoPersistence(store CacheStore, reportedPhotoCount int64) cache.Persistence[Photo] {
+	key := "photos-" + base64.RawURLEncoding.EncodeToString(c.id[:])
+
+	load := func(ctx context.Context) ([]Photo, bool, error) {
+		if reportedPhotoCount < 0 {
+			return nil, false, nil
+		}
+
+		data, err := store.Load(ctx, key)
+		if err != nil || data == nil {
+			return nil, false, err
+		}
+		var list persistedPhotoList
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, false, err
+		}
+		if list.Version != persistedPhotoListVersion || int64(len(list.Photos)) != reportedPhotoCount {
+			return nil, false, nil
+		}
+
+		photos := make([]Photo, 0, len(list.Photos))
+		for _, pp := range list.Photos {
+			var md5Hash types.MD5Hash
+			if err := md5Hash.UnmarshalText([]byte(pp.MD5Hash)); err != nil {
+				return nil, false, err
+			}
+			photos = append(photos, &photo{
+				id:        photoIDInContainer(c, md5Hash, pp.NixplayPlaylistItemID),
+				md5Hash:   md5Hash,
+				container: c,
+				client:    c.client,
+				state: photoState{
+					name:                  pp.Name,
+					nixplayID:             pp.NixplayID,
+					nixplayPlaylistItemID: pp.NixplayPlaylistItemID,
+					size:                  pp.Size,
+					url:                   pp.URL,
+					duration:              pp.Duration,
+					hashes:                pp.Hashes,
+				},
+			})
+		}
+		return photos, true, nil
+	}
+
+	save := func(ctx context.Context, photos []Photo) error {
+		list := persistedPhotoList{
+			Version: persistedPhotoListVersion,
+			Photos:  make([]persistedPhoto, 0, len(photos)),
+		}
+		for _, ph := range photos {
+			p, ok := ph.(*photo)
+			if !ok {
+				return errors.New("unexpected photo type")
+			}
+			list.Photos = append(list.Photos, p.persisted())
+		}
+
+		data, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		return store.Save(ctx, key, data)
+	}
+
+	return cache.Persistence[Photo]{Load: load, Save: save}
+}
+
+// persisted returns the data about the photo that is saved to a CacheStore.
+func (p *photo) persisted() persistedPhoto {
+	s := p.snapshot()
+	return persistedPhoto{
+		Name:                  s.name,
+		MD5Hash:               p.md5Hash.String(),
+		NixplayID:             s.nixplayID,
+		NixplayPlaylistItemID: s.nixplayPlaylistItemID,
+		Size:                  s.size,
+		URL:                   s.url,
+		Duration:              s.duration,
+		Hashes:                s.hashes,
+	}
+}
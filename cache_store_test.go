@@ -0,0 +1,113 @@
+package nixplay
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileCacheStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileCacheStore(t.TempDir())
+
+	data, err := store.Load(ctx, "key")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	require.NoError(t, store.Save(ctx, "key", []byte("first")))
+	require.NoError(t, store.Save(ctx, "key", []byte("second")))
+
+	data, err = store.Load(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("second"), data)
+}
+
+func TestContainer_PersistedPhotos(t *testing.T) {
+	ctx := context.Background()
+	settings := &clientSettings{
+		metrics:    nopMetrics{},
+		cacheStore: NewFileCacheStore(t.TempDir()),
+	}
+
+	hashes := []types.MD5Hash{md5.Sum([]byte("a")), md5.Sum([]byte("b"))}
+	pageRequests := 0
+	pageFunc := func(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error) {
+		pageRequests++
+		if page > 0 {
+			return nil, nil
+		}
+		photos := make([]Photo, 0, len(hashes))
+		for i, h := range hashes {
+			h := h
+			p, err := newPhoto(container, client, string(rune('a'+i))+".jpg", &h, uint64(i+1), "", 10, "")
+			require.NoError(t, err)
+			photos = append(photos, p)
+		}
+		return photos, nil
+	}
+
+	newTestContainer := func(photoCount int64) *container {
+		return newContainer(nil, nil, settings, types.AlbumContainerType, "album", 1234, photoCount, pageFunc, nil, albumAddIDName)
+	}
+
+	first, err := newTestContainer(2).Photos(ctx)
+	require.NoError(t, err)
+	require.Len(t, first, 2)
+	assert.Equal(t, 2, pageRequests)
+
+	t.Run("Reused", func(t *testing.T) {
+		pageRequests = 0
+		second, err := newTestContainer(2).Photos(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 0, pageRequests)
+		require.Len(t, second, 2)
+		for i := range first {
+			assert.Equal(t, first[i].ID(), second[i].ID())
+			expName, err := first[i].Name(ctx)
+			require.NoError(t, err)
+			actName, err := second[i].Name(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, expName, actName)
+		}
+	})
+
+	t.Run("CountMismatch", func(t *testing.T) {
+		pageRequests = 0
+		_, err := newTestContainer(3).Photos(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, pageRequests)
+	})
+}
+
+// TestPersistedPhotoListVersion fails when the fields of persistedPhoto change
+// as a reminder to increment persistedPhotoListVersion, so that photos
+// persisted in the old format are listed again rather than loaded with the
+// new fields missing.
+func TestPersistedPhotoListVersion(t *testing.T) {
+	typ := reflect.TypeOf(persistedPhoto{})
+	fields := make([]string, 0, typ.NumField())
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		fields = append(fields, fmt.Sprintf("%s %s `%s`", f.Name, f.Type, f.Tag))
+	}
+
+	const msg = "the persisted photo format changed, increment persistedPhotoListVersion and update this test"
+	assert.Equal(t, 4, persistedPhotoListVersion, msg)
+	assert.Equal(t, []string{
+		"Name string `json:\"name,omitempty\"`",
+		"MD5Hash string `json:\"md5\"`",
+		"NixplayID uint64 `json:\"nixplayId,omitempty\"`",
+		"NixplayPlaylistItemID string `json:\"nixplayPlaylistItemId,omitempty\"`",
+		"Size int64 `json:\"size\"`",
+		"URL string `json:\"url,omitempty\"`",
+		"Duration time.Duration `json:\"duration,omitempty\"`",
+		"Hashes map[types.HashType]string `json:\"hashes,omitempty\"`",
+	}, fields, msg)
+}
@@ -0,0 +1,91 @@
+package nixplay
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/anitschke/go-nixplay/internal/errorx"
+	"github.com/anitschke/go-nixplay/rawapi"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// ChangeToken computes a token from the current number of photos in the
+// container along with the photos on the first and last page of the container.
+// Nixplay adds new photos to the start or end of a container, so adding a photo
+// changes one of these pages and deleting a photo changes the number of photos.
+// This takes at most four requests regardless of the number of photos in the
+// container.
+func (c *container) ChangeToken(ctx context.Context) (retToken string, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	ctx = withContainerOperation(ctx, "ChangeToken", c.containerType, c.nixplayID)
+
+	count, err := c.loadPhotoCount(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n", count)
+
+	pages := []uint64{0}
+	if count > 0 {
+		if last := uint64(count-1) / photoPageSize; last > 0 {
+			pages = append(pages, last)
+		}
+	}
+	for _, page := range pages {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		photos, err := c.photosPage(ctx, page)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%d\n", page)
+		for _, p := range photos {
+			fmt.Fprintf(h, "%s\n", p.ID())
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)[:16]), nil
+}
+
+// loadPhotoCount gets the number of photos in the container from Nixplay
+// without using the cached count. If the container no longer exists then
+// types.ErrNotFound is returned.
+func (c *container) loadPhotoCount(ctx context.Context) (int64, error) {
+	ctx, cancel := withTimeout(ctx, c.settings.timeouts.Metadata)
+	defer cancel()
+
+	raw := c.settings.rawAPI(c.client)
+	switch c.containerType {
+	case types.AlbumContainerType:
+		for _, list := range []func(context.Context) ([]rawapi.Album, error){raw.WebAlbums, raw.EmailAlbums} {
+			albums, err := list(ctx)
+			if err != nil {
+				return 0, err
+			}
+			for _, a := range albums {
+				if a.ID == c.nixplayID {
+					return a.PhotoCount, nil
+				}
+			}
+		}
+		return 0, types.ErrNotFound
+	case types.PlaylistContainerType:
+		playlists, err := raw.Playlists(ctx)
+		if err != nil {
+			return 0, err
+		}
+		for _, p := range playlists {
+			if p.ID == c.nixplayID {
+				return p.PictureCount, nil
+			}
+		}
+		return 0, types.ErrNotFound
+	}
+	return 0, types.ErrInvalidContainerType
+}
@@ -0,0 +1,72 @@
+package nixplay
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/rawapi"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainer_ChangeToken(t *testing.T) {
+	ctx := context.Background()
+
+	photoCount := 150
+	albumExists := true
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		body := "[]"
+		if req.URL.Path == "/v2/albums/web/json/" && albumExists {
+			body = fmt.Sprintf(`[{"id":1234,"title":"album","photo_count":%d}]`, photoCount)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})
+
+	settings := &clientSettings{
+		metrics: nopMetrics{},
+		changes: &changeNotifier{},
+	}
+	var requestedPages []uint64
+	lastPhoto := "last"
+	pageFunc := func(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error) {
+		requestedPages = append(requestedPages, page)
+		content := fmt.Sprintf("page %d", page)
+		if page == 1 {
+			content = lastPhoto
+		}
+		h := types.MD5Hash(md5.Sum([]byte(content)))
+		p, err := newPhoto(container, client, "photo.jpg", &h, page+1, "", -1, "")
+		require.NoError(t, err)
+		return []Photo{p}, nil
+	}
+	c := newContainer(client, nil, settings, types.AlbumContainerType, "album", 1234, int64(photoCount), pageFunc, (*rawapi.Client).DeleteAlbum, albumAddIDName)
+
+	token, err := c.ChangeToken(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{0, 1}, requestedPages, "only the first and last pages should be listed")
+
+	same, err := c.ChangeToken(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, token, same)
+
+	lastPhoto = "added"
+	changedLastPage, err := c.ChangeToken(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, token, changedLastPage)
+
+	photoCount = 149
+	changedCount, err := c.ChangeToken(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, changedLastPage, changedCount)
+
+	albumExists = false
+	_, err = c.ChangeToken(ctx)
+	assert.ErrorIs(t, err, types.ErrNotFound)
+}
@@ -0,0 +1,109 @@
+package nixplay
+
+import (
+	"sync"
+
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// ChangeEvent describes a change that was made to the Nixplay account through
+// the client.
+type ChangeEvent struct {
+	// Type of change that was made.
+	Type types.ChangeType
+
+	// Container that was changed, or the container of the photo that was
+	// changed.
+	Container Container
+
+	// Photo that was changed. Photo is nil for changes to containers.
+	Photo Photo
+
+	// Refreshed is true if the change was not made through the client but was
+	// found when cached data was refreshed from Nixplay, for example by
+	// Refresh or CacheTTL.StaleWhileRevalidate.
+	Refreshed bool
+}
+
+// ChangeListener is a function that is called when a change is made to the
+// Nixplay account through the client. Listeners are called synchronously after
+// the change is made so they should return quickly.
+//
+// Changes made by other means, such as the Nixplay mobile app, are only
+// reported if they are found when cached data is refreshed, see
+// ChangeEvent.Refreshed.
+type ChangeListener func(event ChangeEvent)
+
+// changeNotifier keeps track of the ChangeListeners registered with a client
+// and sends ChangeEvents to them.
+type changeNotifier struct {
+	mu        sync.Mutex
+	nextID    uint64
+	listeners map[uint64]ChangeListener
+}
+
+// add registers a listener and returns a function that removes it.
+func (n *changeNotifier) add(l ChangeListener) (remove func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.listeners == nil {
+		n.listeners = make(map[uint64]ChangeListener)
+	}
+	id := n.nextID
+	n.nextID++
+	n.listeners[id] = l
+
+	return func() {
+		n.mu.Lock()
+		defer n.mu.Unlock()
+		delete(n.listeners, id)
+	}
+}
+
+// containerRefreshObserver returns a function that reports containers found to
+// be created or deleted when refreshing a cache of containers.
+func (n *changeNotifier) containerRefreshObserver() func(added []Container, removed []Container) {
+	return func(added []Container, removed []Container) {
+		for _, c := range added {
+			n.notify(ChangeEvent{Type: types.ContainerCreatedChangeType, Container: c, Refreshed: true})
+		}
+		for _, c := range removed {
+			n.notify(ChangeEvent{Type: types.ContainerDeletedChangeType, Container: c, Refreshed: true})
+		}
+	}
+}
+
+// photoRefreshObserver returns a function that reports photos found to be
+// added or deleted when refreshing the cache of photos in container.
+func (n *changeNotifier) photoRefreshObserver(container Container) func(added []Photo, removed []Photo) {
+	return func(added []Photo, removed []Photo) {
+		for _, p := range added {
+			n.notify(ChangeEvent{Type: types.PhotoAddedChangeType, Container: container, Photo: p, Refreshed: true})
+		}
+		for _, p := range removed {
+			n.notify(ChangeEvent{Type: types.PhotoDeletedChangeType, Container: container, Photo: p, Refreshed: true})
+		}
+	}
+}
+
+// notify sends the event to all registered listeners. It is safe to call on a
+// nil changeNotifier.
+func (n *changeNotifier) notify(event ChangeEvent) {
+	if n == nil {
+		return
+	}
+
+	// Copy the listeners so they are not called while holding the mutex, a
+	// listener may want to add or remove listeners.
+	n.mu.Lock()
+	listeners := make([]ChangeListener, 0, len(n.listeners))
+	for _, l := range n.listeners {
+		listeners = append(listeners, l)
+	}
+	n.mu.Unlock()
+
+	for _, l := range listeners {
+		l(event)
+	}
+}
@@ -0,0 +1,31 @@
+package nixplay
+
+import (
+	"testing"
+
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangeNotifier(t *testing.T) {
+	n := &changeNotifier{}
+
+	var first, second []ChangeEvent
+	removeFirst := n.add(func(event ChangeEvent) { first = append(first, event) })
+	n.add(func(event ChangeEvent) { second = append(second, event) })
+
+	created := ChangeEvent{Type: types.ContainerCreatedChangeType}
+	n.notify(created)
+	assert.Equal(t, []ChangeEvent{created}, first)
+	assert.Equal(t, []ChangeEvent{created}, second)
+
+	removeFirst()
+	deleted := ChangeEvent{Type: types.ContainerDeletedChangeType}
+	n.notify(deleted)
+	assert.Equal(t, []ChangeEvent{created}, first)
+	assert.Equal(t, []ChangeEvent{created, deleted}, second)
+
+	// notify is safe to call when there is no notifier.
+	var nilNotifier *changeNotifier
+	nilNotifier.notify(deleted)
+}
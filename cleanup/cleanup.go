@@ -0,0 +1,100 @@
+// Package cleanup provides ways to find and remove photos that are taking up
+// storage in a Nixplay account but are no longer being used.
+package cleanup
+
+import (
+	"context"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/internal/errorx"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// Orphans returns the photos in the "My Uploads" album that are not in any
+// playlist.
+//
+// Photos that are uploaded to a playlist are stored in the "My Uploads" album,
+// deleting the photo from the playlist leaves the photo in "My Uploads" where
+// it continues to use storage. See
+// https://github.com/anitschke/go-nixplay/#photo-additiondelete-is-not-atomic
+//
+// Photos are matched to playlists by their MD5 hash, so a photo in "My
+// Uploads" is not considered orphaned if a playlist contains a photo with the
+// same content from a different album.
+//
+// The album is found with Client.MyUploads, if it can not be found then an
+// error wrapping types.ErrNotFound is returned rather than risking treating the
+// photos of another album as orphans.
+func Orphans(ctx context.Context, client nixplay.Client) (retPhotos []nixplay.Photo, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	myUploads, err := client.MyUploads(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inPlaylist := make(map[types.MD5Hash]bool)
+	playlists, err := client.Containers(ctx, types.PlaylistContainerType)
+	if err != nil {
+		return nil, err
+	}
+	for _, playlist := range playlists {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		photos, err := playlist.Photos(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range photos {
+			md5Hash, err := p.MD5Hash(ctx)
+			if err != nil {
+				return nil, err
+			}
+			inPlaylist[md5Hash] = true
+		}
+	}
+
+	photos, err := myUploads.Photos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var orphans []nixplay.Photo
+	for _, p := range photos {
+		md5Hash, err := p.MD5Hash(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !inPlaylist[md5Hash] {
+			orphans = append(orphans, p)
+		}
+	}
+	return orphans, nil
+}
+
+// PurgeOrphans deletes the photos returned by Orphans and returns the photos
+// that were deleted. If an error occurs the photos that were deleted before
+// the error are returned along with the error.
+//
+// Deleting a photo from an album is permanent, so consider reviewing the
+// result of Orphans before purging.
+func PurgeOrphans(ctx context.Context, client nixplay.Client) (retDeleted []nixplay.Photo, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	orphans, err := Orphans(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := make([]nixplay.Photo, 0, len(orphans))
+	for _, p := range orphans {
+		if err := ctx.Err(); err != nil {
+			return deleted, err
+		}
+		if err := p.Delete(ctx, nixplay.DeleteOptions{}); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, p)
+	}
+	return deleted, nil
+}
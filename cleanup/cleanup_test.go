@@ -0,0 +1,116 @@
+package cleanup
+
+import (
+	"context"
+	"crypto/md5"
+	"testing"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient, fakeContainer and fakePhoto implement just enough of the nixplay
+// interfaces to test this package. Calling any other method panics.
+type fakeClient struct {
+	nixplay.Client
+	myUploads *fakeContainer
+	playlists []nixplay.Container
+}
+
+func (c *fakeClient) Containers(ctx context.Context, containerType types.ContainerType) ([]nixplay.Container, error) {
+	return c.playlists, nil
+}
+
+func (c *fakeClient) MyUploads(ctx context.Context) (nixplay.Container, error) {
+	if c.myUploads == nil {
+		return nil, types.ErrNotFound
+	}
+	return c.myUploads, nil
+}
+
+type fakeContainer struct {
+	nixplay.Container
+	photos []nixplay.Photo
+}
+
+func (c *fakeContainer) Photos(ctx context.Context) ([]nixplay.Photo, error) {
+	var photos []nixplay.Photo
+	for _, p := range c.photos {
+		if !p.(*fakePhoto).deleted {
+			photos = append(photos, p)
+		}
+	}
+	return photos, nil
+}
+
+type fakePhoto struct {
+	nixplay.Photo
+	content string
+	deleted bool
+
+	// onDelete is an optional function that is called by Delete.
+	onDelete func()
+}
+
+func (p *fakePhoto) MD5Hash(ctx context.Context) (types.MD5Hash, error) {
+	return md5.Sum([]byte(p.content)), nil
+}
+
+func (p *fakePhoto) Delete(ctx context.Context, opts nixplay.DeleteOptions) error {
+	p.deleted = true
+	if p.onDelete != nil {
+		p.onDelete()
+	}
+	return nil
+}
+
+func TestOrphans(t *testing.T) {
+	ctx := context.Background()
+
+	inPlaylist := &fakePhoto{content: "a"}
+	orphan := &fakePhoto{content: "b"}
+	client := &fakeClient{
+		myUploads: &fakeContainer{photos: []nixplay.Photo{inPlaylist, orphan}},
+		playlists: []nixplay.Container{
+			&fakeContainer{photos: []nixplay.Photo{&fakePhoto{content: "a"}}},
+		},
+	}
+
+	orphans, err := Orphans(ctx, client)
+	require.NoError(t, err)
+	assert.Equal(t, []nixplay.Photo{orphan}, orphans)
+	assert.False(t, orphan.deleted)
+
+	deleted, err := PurgeOrphans(ctx, client)
+	require.NoError(t, err)
+	assert.Equal(t, []nixplay.Photo{orphan}, deleted)
+	assert.True(t, orphan.deleted)
+	assert.False(t, inPlaylist.deleted)
+
+	orphans, err = Orphans(ctx, client)
+	require.NoError(t, err)
+	assert.Empty(t, orphans)
+
+	// Without "My Uploads" nothing is treated as an orphan.
+	_, err = PurgeOrphans(ctx, &fakeClient{})
+	assert.ErrorIs(t, err, types.ErrNotFound)
+}
+
+func TestPurgeOrphans_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := &fakePhoto{content: "a", onDelete: cancel}
+	second := &fakePhoto{content: "b"}
+	client := &fakeClient{
+		myUploads: &fakeContainer{photos: []nixplay.Photo{first, second}},
+	}
+
+	// Once the context is canceled no more photos are deleted.
+	deleted, err := PurgeOrphans(ctx, client)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, []nixplay.Photo{first}, deleted)
+	assert.False(t, second.deleted)
+}
@@ -2,7 +2,9 @@ package nixplay
 
 import (
 	"context"
+	"encoding/json"
 	"io"
+	"time"
 
 	_ "github.com/anitschke/go-nixplay/internal/mime"
 	"github.com/anitschke/go-nixplay/types"
@@ -30,10 +32,100 @@ type AddPhotoOptions struct {
 	// Specifying the MIME Type is optional. However Nixplay does require that
 	// the file size is provided, so if the the size is not specified then it
 	// will be computed based on the io.Reader provided. An attempt will be made
-	// to efficiently compute the size without buffering the entire photo into
-	// memory however in some cases it may be necessary to buffer the full photo
-	// into memory.
+	// to efficiently compute the size without buffering the entire photo
+	// however in some cases it may be necessary to buffer the full photo, see
+	// MaxMemoryBuffer.
 	FileSize int64
+
+	// MaxMemoryBuffer is the maximum number of bytes that will be buffered in
+	// memory when the file size needs to be computed from an io.Reader that
+	// can not seek. Photos larger than this are buffered to a temporary file
+	// instead.
+	//
+	// If MaxMemoryBuffer is 0 then a default of 32 MiB is used. If
+	// MaxMemoryBuffer is negative then the photo is always buffered to a
+	// temporary file.
+	MaxMemoryBuffer int64
+
+	// MaxFileSize is the maximum size in bytes of the file to upload. Larger
+	// files are rejected with an error wrapping types.ErrFileTooLarge before
+	// anything is uploaded. Nixplay does not document the limits it enforces,
+	// so if MaxFileSize is 0 then there is no limit and files that are too
+	// large are rejected by Nixplay instead. The same limit applies to photos
+	// and videos, see the README for why there are no separate limits.
+	MaxFileSize int64
+
+	// SkipExisting specifies that the MD5 hash of the photo should be computed
+	// before uploading and if a photo with the same content already exists in
+	// the container then the upload is skipped and the existing photo is
+	// returned. When adding to a playlist the "My Uploads" album is checked
+	// too, if the photo is already there it is added to the playlist with
+	// Container.AddSlideFromPhoto rather than being uploaded again.
+	//
+	// Note that computing the hash requires reading the photo twice, so if the
+	// io.Reader can not seek then the photo will be buffered, see
+	// MaxMemoryBuffer.
+	SkipExisting bool
+
+	// Verify specifies that once Nixplay has finished processing the photo the
+	// MD5 hash reported by Nixplay should be checked against the MD5 hash of
+	// the content that was uploaded. If Nixplay does not report a photo with
+	// the expected hash then types.ErrMD5Mismatch is returned.
+	//
+	// Verification requires reloading the list of photos in the container.
+	Verify bool
+
+	// Transforms are optional functions that are applied in order to the
+	// photo before it is uploaded, for example to downscale photos that are
+	// much larger than the resolution of the frame. See PhotoTransform and
+	// ResizeTransform.
+	Transforms []PhotoTransform
+}
+
+// DownloadOptions are optional arguments that may be specified when
+// downloading photos from Nixplay.
+type DownloadOptions struct {
+	// Progress is an optional callback that is called as the photo is
+	// downloaded. written is the number of bytes written so far and total is
+	// the total size of the photo in bytes.
+	Progress func(written int64, total int64)
+
+	// VerifyMD5 specifies if the MD5 hash of the downloaded content should be
+	// verified against Photo.MD5Hash. If the hash does not match then
+	// types.ErrMD5Mismatch is returned. Note that all of the content will
+	// already have been written to the io.Writer by the time the mismatch is
+	// detected.
+	VerifyMD5 bool
+}
+
+// PhotosOptions are optional arguments that may be specified when listing the
+// photos in a container.
+type PhotosOptions struct {
+	// SortBy specifies what the photos are sorted by. By default photos are
+	// listed in the order Nixplay returns them.
+	//
+	// Nixplay does not report when photos were taken or uploaded so photos
+	// can not be sorted by date.
+	SortBy types.PhotoSortBy
+
+	// Order specifies if photos are sorted in ascending or descending order.
+	Order types.SortOrder
+}
+
+// DeleteOptions are optional arguments that may be specified when deleting a
+// container or photo.
+type DeleteOptions struct {
+	// Force specifies that the container should be deleted even if it still
+	// contains photos. By default a *NonEmptyContainerError is returned
+	// rather than deleting a container that contains photos. Force is ignored
+	// when deleting photos.
+	Force bool
+
+	// Scope specifies what a photo is deleted from, see types.DeleteScope. If
+	// the scope is not supported for the type of container the photo was
+	// obtained from then an *UnsupportedDeleteScopeError is returned. Scope is
+	// ignored when deleting containers.
+	Scope types.DeleteScope
 }
 
 // Client is the interface that is essentially the entrypoint into communicating
@@ -64,10 +156,47 @@ type Client interface {
 	// for more details.
 	CreateContainer(ctx context.Context, containerType types.ContainerType, name string) (Container, error)
 
+	// MyUploads returns the "My Uploads" album that Nixplay adds photos to
+	// when they are uploaded to a playlist. If the album can not be found
+	// then types.ErrNotFound is returned. See
+	// [README.md special-containers](./README.md#special-containers) for
+	// how the album is found.
+	//
+	// The album can not be deleted, Container.Delete returns
+	// types.ErrSpecialContainer.
+	MyUploads(ctx context.Context) (Container, error)
+
+	// EmailAlbum returns the album that holds the photos emailed to the
+	// ${username}@mynixplay.com address of the account. If the account does
+	// not have the album then types.ErrNotFound is returned.
+	//
+	// The album can not be deleted, Container.Delete returns
+	// types.ErrSpecialContainer.
+	EmailAlbum(ctx context.Context) (Container, error)
+
+	// Favorites returns the playlist that holds the photos marked as
+	// favorites, see IsFavorite, AddFavorite and RemoveFavorite. If the
+	// playlist can not be found then types.ErrNotFound is returned. See
+	// [README.md special-containers](./README.md#special-containers) for
+	// how the playlist is found.
+	//
+	// The playlist can not be deleted, Container.Delete returns
+	// types.ErrSpecialContainer.
+	Favorites(ctx context.Context) (Container, error)
+
 	// Reset cache resets the internal cache of containers
 	//
 	// For more details see https://github.com/anitschke/go-nixplay/#caching
 	ResetCache()
+
+	// AddChangeListener registers a listener that is called whenever a
+	// container or photo is created or deleted through this client. The
+	// returned function removes the listener.
+	AddChangeListener(l ChangeListener) (remove func())
+
+	// Refresh loads the list of albums and playlists again from Nixplay.
+	// Unlike ResetCache containers that still exist keep their cached photos.
+	Refresh(ctx context.Context) error
 }
 
 // Container is the interface for an object that contains photos, either an
@@ -94,9 +223,24 @@ type Container interface {
 	// Note that this API is often times more efficient than len(c.Photos)
 	PhotoCount(ctx context.Context) (int64, error)
 
+	// ChangeToken returns an opaque token that changes when photos are added
+	// to or removed from the container. Comparing it with a token saved
+	// earlier is a cheap way to find out if the container needs to be listed
+	// again, it makes a few requests to Nixplay regardless of the number of
+	// photos in the container and does not use or update the cache.
+	//
+	// Changes that do not add or remove photos, such as reordering the
+	// slides of a playlist, may not change the token. If the container no
+	// longer exists then types.ErrNotFound is returned.
+	ChangeToken(ctx context.Context) (string, error)
+
 	// Photos gets all photos in the container
 	Photos(ctx context.Context) ([]Photo, error)
 
+	// PhotosWithOptions gets all photos in the container sorted as specified
+	// by opts.
+	PhotosWithOptions(ctx context.Context, opts PhotosOptions) ([]Photo, error)
+
 	// PhotosWithName gets all photos in the container with the specified name.
 	PhotosWithName(ctx context.Context, name string) ([]Photo, error)
 
@@ -110,24 +254,105 @@ type Container interface {
 	// returned.
 	PhotoWithID(ctx context.Context, id types.ID) (Photo, error)
 
+	// DownloadAll downloads all photos in the container and passes the
+	// content of each photo to sink, downloading up to opts.Concurrency
+	// photos at once. There is one result for each photo, in the order
+	// returned by Photos, which reports whether the download succeeded along
+	// with how many bytes were downloaded and how long it took. A photo that
+	// fails to download does not stop the remaining photos from being
+	// downloaded, if any photos fail a *BulkError is returned along with the
+	// result. See DownloadPhotos.
+	DownloadAll(ctx context.Context, sink DownloadSink, opts DownloadAllOptions) (DownloadAllResult, error)
+
 	// Delete deletes the container.
 	//
+	// Unless opts.Force is set a container that still contains photos is not
+	// deleted and a *NonEmptyContainerError is returned, so that a mistargeted
+	// delete can not remove an album full of photos.
+	//
 	// See
 	// https://github.com/anitschke/go-nixplay/#photo-additiondelete-is-not-atomic
 	// for further discussion of delete behavior.
-	Delete(ctx context.Context) error
+	Delete(ctx context.Context, opts DeleteOptions) error
 
 	// AddPhoto uploads a photo into the container.
 	//
 	// Note that the name of the container will be encoded before passing the
 	// name to Nixplay. See [README.md name-encoding](./README.md#name-encoding)
 	// for more details.
+	//
+	// If the container is an album that already contains a photo with the same
+	// content then a *DuplicateImageError is returned.
 	AddPhoto(ctx context.Context, name string, r io.Reader, opts AddPhotoOptions) (Photo, error)
 
+	// AddPhotoAsync uploads a photo into the container without waiting for
+	// Nixplay to finish processing the photo. Once AddPhotoAsync returns the
+	// content of the photo has been uploaded and r is no longer needed. The
+	// returned UploadHandle can be used to wait for Nixplay to finish
+	// processing the photo.
+	//
+	// Nixplay keeps processing the photo, and the upload keeps being
+	// monitored in the background, after ctx is done. Monitoring stops once
+	// Nixplay finishes processing the photo, once the time allowed by
+	// Timeouts.UploadMonitor and UploadMonitorOptions.MaxWait runs out, or
+	// when UploadHandle.Cancel is called.
+	AddPhotoAsync(ctx context.Context, name string, r io.Reader, opts AddPhotoOptions) (UploadHandle, error)
+
+	// AddSlideFromPhoto adds a slide to the end of the playlist that shows p,
+	// which must have been obtained from the same client. Unlike AddPhoto the
+	// content of p is not uploaded again, the slide refers to the photo in
+	// the album it resides in so no copy is added to the "My Uploads" album.
+	//
+	// If the container is not a playlist then types.ErrInvalidContainerType
+	// is returned.
+	AddSlideFromPhoto(ctx context.Context, p Photo) (Photo, error)
+
+	// RemoveSlide removes slide, which must have been obtained from this
+	// playlist, from the playlist. Only the slide is removed, the photo it
+	// shows is not deleted from the album it resides in or from any other
+	// playlist.
+	//
+	// Unlike calling slide.Delete directly the slide is checked to belong to
+	// this playlist first, so passing a photo from an album by mistake can
+	// not delete it. If slide is not in the playlist then types.ErrNotFound
+	// is returned. If the container is not a playlist then
+	// types.ErrInvalidContainerType is returned.
+	RemoveSlide(ctx context.Context, slide Photo) error
+
 	// Reset cache resets the internal cache of photos
 	//
 	// For more details see https://github.com/anitschke/go-nixplay/#caching
 	ResetCache()
+
+	// Refresh loads the list of photos in the container again from Nixplay.
+	// Unlike ResetCache photos that still exist keep any data that has
+	// already been loaded for them, such as their name.
+	Refresh(ctx context.Context) error
+
+	// RawMetadata returns the JSON object Nixplay listed the album or
+	// playlist with, exactly as it was received. This gives access to fields
+	// that this library does not model yet.
+	RawMetadata(ctx context.Context) (json.RawMessage, error)
+}
+
+// UploadHandle is a handle to a photo that was uploaded using
+// Container.AddPhotoAsync that Nixplay may still be processing.
+type UploadHandle interface {
+	// Wait waits for Nixplay to finish processing the photo and returns the
+	// uploaded photo. If ctx is done before Nixplay finishes processing the
+	// photo then ctx.Err() is returned but the upload will continue to be
+	// monitored in the background.
+	Wait(ctx context.Context) (Photo, error)
+
+	// Status returns the current status of the upload.
+	Status() types.UploadStatus
+
+	// Cancel stops monitoring the upload in the background. If Nixplay has
+	// not finished processing the photo yet then the upload fails with
+	// context.Canceled, although Nixplay may still add the photo later since
+	// its content has already been uploaded. Cancel does nothing once the
+	// upload is complete.
+	Cancel()
 }
 
 // Photo is an interface for an object that represents a photo. Even though a
@@ -161,17 +386,67 @@ type Photo interface {
 	Size(ctx context.Context) (int64, error)
 	MD5Hash(ctx context.Context) (types.MD5Hash, error)
 
+	// Hash returns the hex encoded hash of the content of the photo. Nixplay
+	// only reports MD5 hashes, other hashes are computed from the content
+	// while it is uploaded or by downloading the photo once, and cached. See
+	// HashPolicy for more details.
+	Hash(ctx context.Context, hashType types.HashType) (string, error)
+
 	// URL returns the URL for the original photo that was uploaded to Nixplay.
 	URL(ctx context.Context) (string, error)
 
+	// MediaType returns if this is a still photo or a video. The media type is
+	// determined based on the file extension of the name of the photo.
+	MediaType(ctx context.Context) (types.MediaType, error)
+
+	// Duration returns the length of the video for photos with a media type of
+	// types.VideoMediaType. For still photos 0 is returned. Nixplay does not
+	// report the length of videos, so it is read from the header of the MP4
+	// video which downloads a few small ranges of the video the first time it
+	// is called.
+	Duration(ctx context.Context) (time.Duration, error)
+
 	// Open opens the photo for reading the contents of the photo.
 	Open(ctx context.Context) (io.ReadCloser, error)
 
+	// OpenRange opens the photo for reading length bytes of the contents of
+	// the photo starting at offset. If length is negative then the photo is
+	// read until the end.
+	//
+	// This is more efficient than Open when only part of the photo is needed
+	// as only the requested portion is downloaded.
+	OpenRange(ctx context.Context, offset int64, length int64) (io.ReadCloser, error)
+
+	// DownloadTo downloads the contents of the photo and writes them to w.
+	DownloadTo(ctx context.Context, w io.Writer, opts DownloadOptions) error
+
 	// Delete deletes the photo from the parent container that this photo object
-	// was obtained from.
+	// was obtained from. For photos in a playlist only the slide is removed
+	// unless opts.Scope is types.GlobalDeleteScope, see Container.RemoveSlide.
 	//
 	// See
 	// https://github.com/anitschke/go-nixplay/#photo-additiondelete-is-not-atomic
 	// for further discussion of delete behavior.
-	Delete(ctx context.Context) error
+	Delete(ctx context.Context, opts DeleteOptions) error
+
+	// Refresh loads the current data of the photo again from Nixplay, such as
+	// its name and URL. If the photo no longer exists in its container, for
+	// example because it was deleted in the Nixplay app, then it is removed
+	// from the cache of the container and types.ErrNotFound is returned.
+	//
+	// This lists the photos in the container without using the cache so it is
+	// as expensive as Container.Refresh.
+	Refresh(ctx context.Context) error
+
+	// RawMetadata returns the JSON object Nixplay listed the photo with,
+	// exactly as it was received. This gives access to fields that this
+	// library does not model yet. For photos that have not been listed, such
+	// as photos that were just uploaded, the photo is refreshed first, see
+	// Refresh.
+	RawMetadata(ctx context.Context) (json.RawMessage, error)
+
+	// Exists reports if the photo still exists in its container. It calls
+	// Refresh and returns false rather than types.ErrNotFound if the photo no
+	// longer exists.
+	Exists(ctx context.Context) (bool, error)
 }
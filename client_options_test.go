@@ -0,0 +1,49 @@
+package nixplay
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientOptionsMiddleware(t *testing.T) {
+	var sent http.Header
+	base := httpx.ClientFunc(func(req *http.Request) (*http.Response, error) {
+		sent = req.Header
+		return newTestResponse(http.StatusOK), nil
+	})
+
+	var order []string
+	var capture bytes.Buffer
+	opts := DefaultClientOptions{
+		Middleware: []httpx.Middleware{func(client httpx.Client) httpx.Client {
+			return httpx.ClientFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, "middleware")
+				return client.Do(req)
+			})
+		}},
+		RequestHook:  func(info httpx.RequestInfo) { order = append(order, "hook") },
+		UserAgent:    "photo-frame-sync/1.0",
+		Header:       http.Header{"X-Automation": {"photo-frame-sync"}},
+		DebugCapture: &capture,
+	}
+	client := httpx.Chain(base, opts.middleware()...)
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.nixplay.com/", http.NoBody)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	require.NoError(t, err)
+
+	// The middleware of the caller is outside of the request hook.
+	assert.Equal(t, []string{"middleware", "hook"}, order)
+	assert.Equal(t, "photo-frame-sync/1.0", sent.Get("User-Agent"))
+	assert.Equal(t, "photo-frame-sync", sent.Get("X-Automation"))
+	assert.NotEmpty(t, sent.Get("Accept-Encoding"))
+
+	// The capture shows the headers as they are sent.
+	assert.Contains(t, capture.String(), "User-Agent: photo-frame-sync/1.0\n")
+}
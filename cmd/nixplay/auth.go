@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+func runAuth(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(e.stderr, "Usage: nixplay auth login|logout\n")
+		return errUsage
+	}
+	switch args[0] {
+	case "login":
+		return runAuthLogin(ctx, e, args[1:])
+	case "logout":
+		return runAuthLogout(ctx, e, args[1:])
+	}
+	fmt.Fprintf(e.stderr, "unknown auth command %q\n", args[0])
+	return errUsage
+}
+
+func runAuthLogin(ctx context.Context, e *env, args []string) error {
+	flags := newFlagSet(e, "auth login", "")
+	username := flags.String("username", "", "username of the Nixplay account, prompted for if not specified")
+	if err := parseFlags(flags, args, 0, 0); err != nil {
+		return err
+	}
+	if e.sessionPath == "" {
+		return errors.New("unable to determine where to store the session")
+	}
+
+	stdin := bufio.NewReader(e.stdin)
+	if *username == "" {
+		fmt.Fprint(e.stderr, "Username: ")
+		line, err := readLine(stdin)
+		if err != nil {
+			return err
+		}
+		*username = line
+	}
+	fmt.Fprint(e.stderr, "Password: ")
+	password, err := readPassword(e.stdin, stdin)
+	fmt.Fprintln(e.stderr)
+	if err != nil {
+		return err
+	}
+
+	session, err := e.login(ctx, types.Authorization{Username: *username, Password: password})
+	if err != nil {
+		return err
+	}
+	if err := saveSession(e.sessionPath, session); err != nil {
+		return err
+	}
+	fmt.Fprintf(e.stdout, "logged in as %s\n", *username)
+	return nil
+}
+
+func runAuthLogout(ctx context.Context, e *env, args []string) error {
+	flags := newFlagSet(e, "auth logout", "")
+	if err := parseFlags(flags, args, 0, 0); err != nil {
+		return err
+	}
+	if e.sessionPath == "" {
+		return nil
+	}
+	if err := os.Remove(e.sessionPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// login signs in to Nixplay and returns the session.
+func login(ctx context.Context, a types.Authorization) (types.Session, error) {
+	client, err := nixplay.NewDefaultClient(ctx, a, nixplay.DefaultClientOptions{})
+	if err != nil {
+		return types.Session{}, err
+	}
+	return client.Session(), nil
+}
+
+// defaultSessionPath returns the path of the file the session is stored in,
+// or an empty string if there is no suitable location.
+func defaultSessionPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "nixplay", "session.json")
+}
+
+// saveSession writes the session to path. Since the session grants full access
+// to the account the file is only readable by the current user.
+func saveSession(path string, session types.Session) (err error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	// CreateTemp creates the file with 0600 permissions.
+	f, err := os.CreateTemp(dir, ".session-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// loadSession reads the session written by saveSession. If there is no
+// session a nil session is returned.
+func loadSession(path string) (*types.Session, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var session types.Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("failed to read session %q: %w", path, err)
+	}
+	return &session, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readPassword reads a line from r without echoing it if stdin is a terminal
+// that supports disabling echo with stty. Otherwise the password is read
+// normally.
+func readPassword(stdin io.Reader, r *bufio.Reader) (string, error) {
+	if isTerminal(stdin) {
+		tty := stdin.(*os.File)
+		if stty(tty, "-echo") == nil {
+			defer stty(tty, "echo")
+		}
+	}
+	return readLine(r)
+}
+
+func stty(tty *os.File, arg string) error {
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = tty
+	return cmd.Run()
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthLogin(t *testing.T) {
+	ctx := context.Background()
+	e, stdout, stderr := newTestEnv(nil)
+	e.sessionPath = filepath.Join(t.TempDir(), "config", "session.json")
+	e.stdin = strings.NewReader("user@example.com\nhunter2\n")
+
+	expSession := types.Session{Token: "token", CSRFToken: "csrf", Cookies: map[string]string{"a": "b"}}
+	var gotAuth types.Authorization
+	e.login = func(ctx context.Context, a types.Authorization) (types.Session, error) {
+		gotAuth = a
+		return expSession, nil
+	}
+
+	require.NoError(t, run(ctx, e, []string{"auth", "login"}))
+	assert.Equal(t, types.Authorization{Username: "user@example.com", Password: "hunter2"}, gotAuth)
+	assert.Equal(t, "logged in as user@example.com\n", stdout.String())
+	assert.Equal(t, "Username: Password: \n", stderr.String())
+
+	session, err := loadSession(e.sessionPath)
+	require.NoError(t, err)
+	assert.Equal(t, &expSession, session)
+
+	// The password is never stored.
+	data, err := os.ReadFile(e.sessionPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "hunter2")
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(e.sessionPath)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+
+	require.NoError(t, run(ctx, e, []string{"auth", "logout"}))
+	session, err = loadSession(e.sessionPath)
+	require.NoError(t, err)
+	assert.Nil(t, session)
+
+	// Logging out again is not an error.
+	require.NoError(t, run(ctx, e, []string{"auth", "logout"}))
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	nixplay "github.com/anitschke/go-nixplay"
+)
+
+func runDownload(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "download", "CONTAINER DIR")
+	asJSON := fs.Bool("json", false, "print output as JSON")
+	if err := parseFlags(fs, args, 2, 2); err != nil {
+		return err
+	}
+	dir := fs.Arg(1)
+
+	client, err := e.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	container, err := getContainer(ctx, client, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	photos, err := container.Photos(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	downloaded := []photoInfo{}
+	for _, p := range photos {
+		info, err := getPhotoInfo(ctx, p)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, filepath.Base(info.Name))
+		if err := downloadFile(ctx, p, path); err != nil {
+			return fmt.Errorf("failed to download %q: %w", info.Name, err)
+		}
+		downloaded = append(downloaded, info)
+		if !*asJSON {
+			fmt.Fprintf(e.stdout, "downloaded %s\n", path)
+		}
+	}
+
+	if *asJSON {
+		return e.printJSON(downloaded)
+	}
+	return nil
+}
+
+func downloadFile(ctx context.Context, p nixplay.Photo, path string) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+
+	return p.DownloadTo(ctx, f, nixplay.DownloadOptions{VerifyMD5: true})
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// containerInfo is the output of ls for a container.
+type containerInfo struct {
+	Type       types.ContainerType `json:"type"`
+	Name       string              `json:"name"`
+	PhotoCount int64               `json:"photoCount"`
+}
+
+// photoInfo is the output of ls for a photo.
+type photoInfo struct {
+	Name    string `json:"name"`
+	Size    int64  `json:"size"`
+	MD5Hash string `json:"md5"`
+}
+
+func runLs(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "ls", "[CONTAINER]")
+	asJSON := fs.Bool("json", false, "print output as JSON")
+	if err := parseFlags(fs, args, 0, 1); err != nil {
+		return err
+	}
+
+	client, err := e.newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		containers, err := listContainers(ctx, client)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return e.printJSON(containers)
+		}
+		w := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "CONTAINER\tPHOTOS")
+		for _, c := range containers {
+			fmt.Fprintf(w, "%s\t%d\n", containerRef{containerType: c.Type, name: c.Name}, c.PhotoCount)
+		}
+		return w.Flush()
+	}
+
+	container, err := getContainer(ctx, client, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	photos, err := listPhotos(ctx, container)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return e.printJSON(photos)
+	}
+	w := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSIZE\tMD5")
+	for _, p := range photos {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", p.Name, p.Size, p.MD5Hash)
+	}
+	return w.Flush()
+}
+
+func listContainers(ctx context.Context, client nixplay.Client) ([]containerInfo, error) {
+	infos := []containerInfo{}
+	for _, containerType := range []types.ContainerType{types.AlbumContainerType, types.PlaylistContainerType} {
+		containers, err := client.Containers(ctx, containerType)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range containers {
+			name, err := c.NameUnique(ctx)
+			if err != nil {
+				return nil, err
+			}
+			count, err := c.PhotoCount(ctx)
+			if err != nil {
+				return nil, err
+			}
+			infos = append(infos, containerInfo{Type: containerType, Name: name, PhotoCount: count})
+		}
+	}
+	return infos, nil
+}
+
+func listPhotos(ctx context.Context, container nixplay.Container) ([]photoInfo, error) {
+	photos, err := container.Photos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]photoInfo, 0, len(photos))
+	for _, p := range photos {
+		info, err := getPhotoInfo(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func getPhotoInfo(ctx context.Context, p nixplay.Photo) (photoInfo, error) {
+	name, err := p.NameUnique(ctx)
+	if err != nil {
+		return photoInfo{}, err
+	}
+	size, err := p.Size(ctx)
+	if err != nil {
+		return photoInfo{}, err
+	}
+	md5Hash, err := p.MD5Hash(ctx)
+	if err != nil {
+		return photoInfo{}, err
+	}
+	return photoInfo{Name: name, Size: size, MD5Hash: md5Hash.String()}, nil
+}
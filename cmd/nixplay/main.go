@@ -0,0 +1,244 @@
+// Command nixplay is a command line interface for managing the photos in a
+// Nixplay account.
+//
+// Usage:
+//
+//	nixplay <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	ls        list containers, or the photos in a container
+//	upload    upload files or directories to a container
+//	download  download the photos in a container to a directory
+//	rm        delete photos from a container, or a container itself
+//	sync      sync a local directory to a container
+//	auth      sign in to or out of a Nixplay account
+//
+// Containers are specified as "album:NAME" or "playlist:NAME" where NAME is the
+// unique name of the container as shown by "nixplay ls".
+//
+// Use "nixplay auth login" to sign in to a Nixplay account. The password is not
+// stored, instead the signed in session is stored in the user's configuration
+// directory in a file that is only readable by the user. Alternatively the
+// credentials of the account can be specified with the NIXPLAY_USERNAME and
+// NIXPLAY_PASSWORD environment variables.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+const (
+	usernameEnvVar = "NIXPLAY_USERNAME"
+	passwordEnvVar = "NIXPLAY_PASSWORD"
+)
+
+const usage = `Usage: nixplay <command> [flags] [arguments]
+
+Commands:
+  ls [CONTAINER]                 list containers, or the photos in a container
+  upload CONTAINER PATH...       upload files or directories to a container
+  download CONTAINER DIR         download the photos in a container to a directory
+  rm CONTAINER PHOTO...          delete photos from a container, -global everywhere
+  rm -container CONTAINER        delete a container, -force if it has photos
+  sync DIR CONTAINER             sync a local directory to a container
+  auth login                     sign in and store the session for other commands
+  auth logout                    remove the stored session
+
+Containers are specified as "album:NAME" or "playlist:NAME".
+
+Run "nixplay <command> -h" for the flags of a command.
+`
+
+// errUsage is returned when the command line arguments are invalid. The usage
+// has already been printed when it is returned.
+var errUsage = errors.New("invalid usage")
+
+// command is a subcommand of the CLI.
+type command func(ctx context.Context, env *env, args []string) error
+
+var commands = map[string]command{
+	"ls":       runLs,
+	"upload":   runUpload,
+	"download": runDownload,
+	"rm":       runRm,
+	"sync":     runSync,
+	"auth":     runAuth,
+}
+
+// env is the environment that commands are run in.
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	// sessionPath is the path of the file the session created by "auth login"
+	// is stored in.
+	sessionPath string
+
+	// newClient creates the client used to communicate with Nixplay and login
+	// signs in to Nixplay. They are replaced in tests.
+	newClient func(ctx context.Context) (nixplay.Client, error)
+	login     func(ctx context.Context, a types.Authorization) (types.Session, error)
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	e := &env{
+		stdin:       os.Stdin,
+		stdout:      os.Stdout,
+		stderr:      os.Stderr,
+		sessionPath: defaultSessionPath(),
+		login:       login,
+	}
+	e.newClient = e.newDefaultClient
+	if err := run(ctx, e, os.Args[1:]); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, "nixplay:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, e *env, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprint(e.stderr, usage)
+		return errUsage
+	}
+	switch args[0] {
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(e.stdout, usage)
+		return nil
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(e.stderr, "unknown command %q\n\n%s", args[0], usage)
+		return errUsage
+	}
+	return cmd(ctx, e, args[1:])
+}
+
+// newDefaultClient creates a client using the credentials from the
+// environment variables if they are set, otherwise using the session stored by
+// "auth login".
+func (e *env) newDefaultClient(ctx context.Context) (nixplay.Client, error) {
+	var a types.Authorization
+	a.Username = os.Getenv(usernameEnvVar)
+	a.Password = os.Getenv(passwordEnvVar)
+	if a.Username == "" || a.Password == "" {
+		session, err := loadSession(e.sessionPath)
+		if err != nil {
+			return nil, err
+		}
+		if session == nil {
+			return nil, fmt.Errorf("not logged in, run \"nixplay auth login\" or set the environment variables %q and %q to the credentials of the Nixplay account", usernameEnvVar, passwordEnvVar)
+		}
+		a = types.Authorization{Session: session}
+	}
+	return nixplay.NewDefaultClient(ctx, a, nixplay.DefaultClientOptions{})
+}
+
+// newFlagSet creates the flag set for a command. Errors are reported to the
+// stderr of the environment rather than exiting.
+func newFlagSet(e *env, name string, argsUsage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: nixplay %s [flags] %s\n", name, argsUsage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags parses the flags of a command and checks the number of remaining
+// arguments is at least minArgs, and at most maxArgs if maxArgs is not
+// negative.
+//
+// Flags may be given before, after or in between the arguments.
+func parseFlags(fs *flag.FlagSet, args []string, minArgs int, maxArgs int) error {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return errUsage
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	// Parse the arguments again after "--" so that fs.Args returns them.
+	if err := fs.Parse(append([]string{"--"}, positional...)); err != nil {
+		return errUsage
+	}
+	return checkArgs(fs, minArgs, maxArgs)
+}
+
+// checkArgs checks the number of arguments remaining after parsing flags, see
+// parseFlags.
+func checkArgs(fs *flag.FlagSet, minArgs int, maxArgs int) error {
+	if fs.NArg() < minArgs || (maxArgs >= 0 && fs.NArg() > maxArgs) {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// containerRef is a reference to a container given on the command line.
+type containerRef struct {
+	containerType types.ContainerType
+	name          string
+}
+
+// parseContainerRef parses a container given as "album:NAME" or
+// "playlist:NAME".
+func parseContainerRef(s string) (containerRef, error) {
+	typ, name, ok := strings.Cut(s, ":")
+	containerType := types.ContainerType(typ)
+	if !ok || name == "" || (containerType != types.AlbumContainerType && containerType != types.PlaylistContainerType) {
+		return containerRef{}, fmt.Errorf("invalid container %q, containers must be specified as \"album:NAME\" or \"playlist:NAME\"", s)
+	}
+	return containerRef{containerType: containerType, name: name}, nil
+}
+
+func (r containerRef) String() string {
+	return string(r.containerType) + ":" + r.name
+}
+
+// getContainer gets the container referred to by s.
+func getContainer(ctx context.Context, client nixplay.Client, s string) (nixplay.Container, error) {
+	ref, err := parseContainerRef(s)
+	if err != nil {
+		return nil, err
+	}
+	container, err := client.ContainerWithUniqueName(ctx, ref.containerType, ref.name)
+	if err != nil {
+		return nil, err
+	}
+	if container == nil {
+		return nil, fmt.Errorf("%s does not exist", ref)
+	}
+	return container, nil
+}
+
+// printJSON writes v to the stdout of the environment as indented JSON.
+func (e *env) printJSON(v any) error {
+	enc := json.NewEncoder(e.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
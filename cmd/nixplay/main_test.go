@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"testing"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient, fakeContainer and fakePhoto implement just enough of the nixplay
+// interfaces to test the CLI. Calling any other method panics.
+type fakeClient struct {
+	nixplay.Client
+	containers []*fakeContainer
+}
+
+func (c *fakeClient) Containers(ctx context.Context, containerType types.ContainerType) ([]nixplay.Container, error) {
+	var containers []nixplay.Container
+	for _, container := range c.containers {
+		if container.containerType == containerType {
+			containers = append(containers, container)
+		}
+	}
+	return containers, nil
+}
+
+func (c *fakeClient) ContainerWithUniqueName(ctx context.Context, containerType types.ContainerType, name string) (nixplay.Container, error) {
+	for _, container := range c.containers {
+		if container.containerType == containerType && container.name == name {
+			return container, nil
+		}
+	}
+	return nil, nil
+}
+
+type fakeContainer struct {
+	nixplay.Container
+	containerType types.ContainerType
+	name          string
+	photos        []*fakePhoto
+}
+
+func (c *fakeContainer) NameUnique(ctx context.Context) (string, error) { return c.name, nil }
+func (c *fakeContainer) PhotoCount(ctx context.Context) (int64, error) {
+	return int64(len(c.photos)), nil
+}
+func (c *fakeContainer) Photos(ctx context.Context) ([]nixplay.Photo, error) {
+	photos := make([]nixplay.Photo, 0, len(c.photos))
+	for _, p := range c.photos {
+		photos = append(photos, p)
+	}
+	return photos, nil
+}
+func (c *fakeContainer) PhotoWithUniqueName(ctx context.Context, name string) (nixplay.Photo, error) {
+	for _, p := range c.photos {
+		if p.name == name {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+type fakePhoto struct {
+	nixplay.Photo
+	container *fakeContainer
+	name      string
+	content   string
+}
+
+func (p *fakePhoto) Name(ctx context.Context) (string, error)       { return p.name, nil }
+func (p *fakePhoto) NameUnique(ctx context.Context) (string, error) { return p.name, nil }
+func (p *fakePhoto) Size(ctx context.Context) (int64, error)        { return int64(len(p.content)), nil }
+func (p *fakePhoto) MD5Hash(ctx context.Context) (types.MD5Hash, error) {
+	return md5.Sum([]byte(p.content)), nil
+}
+func (p *fakePhoto) Delete(ctx context.Context, opts nixplay.DeleteOptions) error {
+	for i, other := range p.container.photos {
+		if other == p {
+			p.container.photos = append(p.container.photos[:i], p.container.photos[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func newTestEnv(client nixplay.Client) (*env, *bytes.Buffer, *bytes.Buffer) {
+	var stdout, stderr bytes.Buffer
+	return &env{
+		stdout:    &stdout,
+		stderr:    &stderr,
+		newClient: func(ctx context.Context) (nixplay.Client, error) { return client, nil },
+	}, &stdout, &stderr
+}
+
+func newTestClient() *fakeClient {
+	album := &fakeContainer{containerType: types.AlbumContainerType, name: "Family"}
+	album.photos = []*fakePhoto{
+		{container: album, name: "a.jpg", content: "a"},
+		{container: album, name: "b.jpg", content: "bb"},
+	}
+	playlist := &fakeContainer{containerType: types.PlaylistContainerType, name: "Favorites"}
+	return &fakeClient{containers: []*fakeContainer{album, playlist}}
+}
+
+func TestLs(t *testing.T) {
+	ctx := context.Background()
+	e, stdout, _ := newTestEnv(newTestClient())
+
+	require.NoError(t, run(ctx, e, []string{"ls", "-json"}))
+	var containers []containerInfo
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &containers))
+	assert.Equal(t, []containerInfo{
+		{Type: types.AlbumContainerType, Name: "Family", PhotoCount: 2},
+		{Type: types.PlaylistContainerType, Name: "Favorites", PhotoCount: 0},
+	}, containers)
+
+	stdout.Reset()
+	require.NoError(t, run(ctx, e, []string{"ls", "album:Family"}))
+	assert.Equal(t, "NAME   SIZE  MD5\n"+
+		"a.jpg  1     0cc175b9c0f1b6a831c399e269772661\n"+
+		"b.jpg  2     21ad0bd836b90d08f4cf640b4c298e7c\n", stdout.String())
+
+	err := run(ctx, e, []string{"ls", "album:Missing"})
+	assert.ErrorContains(t, err, "album:Missing does not exist")
+}
+
+func TestRm(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient()
+	e, stdout, _ := newTestEnv(client)
+
+	require.NoError(t, run(ctx, e, []string{"rm", "album:Family", "a.jpg"}))
+	assert.Equal(t, "deleted a.jpg\n", stdout.String())
+	require.Len(t, client.containers[0].photos, 1)
+	assert.Equal(t, "b.jpg", client.containers[0].photos[0].name)
+
+	err := run(ctx, e, []string{"rm", "album:Family", "a.jpg"})
+	assert.ErrorContains(t, err, `photo "a.jpg" does not exist`)
+}
+
+func TestUsage(t *testing.T) {
+	ctx := context.Background()
+	e, _, stderr := newTestEnv(newTestClient())
+
+	assert.ErrorIs(t, run(ctx, e, nil), errUsage)
+	assert.ErrorIs(t, run(ctx, e, []string{"bogus"}), errUsage)
+	assert.ErrorIs(t, run(ctx, e, []string{"upload", "album:Family"}), errUsage)
+	assert.ErrorIs(t, run(ctx, e, []string{"rm", "album:Family"}), errUsage)
+	assert.Contains(t, stderr.String(), "Usage: nixplay rm")
+}
+
+func TestParseContainerRef(t *testing.T) {
+	ref, err := parseContainerRef("playlist:Family: 2024")
+	require.NoError(t, err)
+	assert.Equal(t, containerRef{containerType: types.PlaylistContainerType, name: "Family: 2024"}, ref)
+	assert.Equal(t, "playlist:Family: 2024", ref.String())
+
+	for _, s := range []string{"Family", "album:", "folder:Family"} {
+		_, err := parseContainerRef(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+func runRm(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "rm", "CONTAINER PHOTO...")
+	asJSON := fs.Bool("json", false, "print output as JSON")
+	deleteContainer := fs.Bool("container", false, "delete the container itself rather than photos within it")
+	force := fs.Bool("force", false, "with -container, delete the container even if it still contains photos")
+	global := fs.Bool("global", false, "delete photos in a playlist from the albums that own them rather than only removing them from the playlist")
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	minArgs, maxArgs := 2, -1
+	if *deleteContainer {
+		minArgs, maxArgs = 1, 1
+	}
+	if err := checkArgs(fs, minArgs, maxArgs); err != nil {
+		return err
+	}
+
+	client, err := e.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	container, err := getContainer(ctx, client, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *deleteContainer {
+		if err := container.Delete(ctx, nixplay.DeleteOptions{Force: *force}); err != nil {
+			return err
+		}
+		if *asJSON {
+			return e.printJSON(map[string]string{"deleted": fs.Arg(0)})
+		}
+		fmt.Fprintf(e.stdout, "deleted %s\n", fs.Arg(0))
+		return nil
+	}
+
+	opts := nixplay.DeleteOptions{}
+	if *global {
+		opts.Scope = types.GlobalDeleteScope
+	}
+	deleted := []photoInfo{}
+	for _, name := range fs.Args()[1:] {
+		p, err := container.PhotoWithUniqueName(ctx, name)
+		if err != nil {
+			return err
+		}
+		if p == nil {
+			return fmt.Errorf("photo %q does not exist in %s", name, fs.Arg(0))
+		}
+		info, err := getPhotoInfo(ctx, p)
+		if err != nil {
+			return err
+		}
+		if err := p.Delete(ctx, opts); err != nil {
+			return fmt.Errorf("failed to delete %q: %w", name, err)
+		}
+		deleted = append(deleted, info)
+		if !*asJSON {
+			fmt.Fprintf(e.stdout, "deleted %s\n", name)
+		}
+	}
+
+	if *asJSON {
+		return e.printJSON(deleted)
+	}
+	return nil
+}
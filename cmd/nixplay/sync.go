@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/anitschke/go-nixplay/nixsync"
+)
+
+// progressBarWidth is the number of characters in the progress bar shown by
+// sync.
+const progressBarWidth = 30
+
+func runSync(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "sync", "DIR CONTAINER")
+	asJSON := fs.Bool("json", false, "print output as JSON")
+	deleteExtraneous := fs.Bool("delete", false, "delete photos from the container that are not in the directory")
+	dryRun := fs.Bool("dry-run", false, "show what would be done without making any changes")
+	if err := parseFlags(fs, args, 2, 2); err != nil {
+		return err
+	}
+	ref, err := parseContainerRef(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	client, err := e.newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	showProgress := !*asJSON && isTerminal(e.stderr)
+	plan := nixsync.Plan{
+		LocalDir:         fs.Arg(0),
+		ContainerType:    ref.containerType,
+		Container:        ref.name,
+		DeleteExtraneous: *deleteExtraneous,
+		DryRun:           *dryRun,
+		OnAction: func(action nixsync.Action, done int, total int) {
+			if *asJSON {
+				return
+			}
+			if showProgress {
+				clearLine(e.stderr)
+			}
+			fmt.Fprintf(e.stdout, "%s %s\n", actionVerb(action.Type, *dryRun), action.Name)
+			if showProgress {
+				printProgress(e.stderr, done, total)
+			}
+		},
+	}
+
+	result, err := nixsync.Run(ctx, client, plan)
+	if showProgress && len(result.Actions) > 0 {
+		clearLine(e.stderr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return e.printJSON(result)
+	}
+	fmt.Fprintln(e.stdout, summary(result, *dryRun))
+	return nil
+}
+
+func actionVerb(actionType nixsync.ActionType, dryRun bool) string {
+	var verb string
+	switch actionType {
+	case nixsync.UploadAction:
+		verb = "uploaded"
+	case nixsync.DeleteAction:
+		verb = "deleted"
+	default:
+		verb = string(actionType)
+	}
+	if dryRun {
+		verb = "would have " + verb
+	}
+	return verb
+}
+
+func summary(result nixsync.Result, dryRun bool) string {
+	var uploaded, deleted int
+	for _, action := range result.Actions {
+		switch action.Type {
+		case nixsync.UploadAction:
+			uploaded++
+		case nixsync.DeleteAction:
+			deleted++
+		}
+	}
+	prefix := "sync complete"
+	if dryRun {
+		prefix = "dry run"
+	}
+	return fmt.Sprintf("%s: %d uploaded, %d unchanged, %d deleted", prefix, uploaded, result.Unchanged, deleted)
+}
+
+// printProgress prints a progress bar that is overwritten by the next call to
+// printProgress or clearLine.
+func printProgress(w io.Writer, done int, total int) {
+	filled := progressBarWidth
+	if total > 0 {
+		filled = progressBarWidth * done / total
+	}
+	fmt.Fprintf(w, "\r[%s%s] %d/%d", strings.Repeat("=", filled), strings.Repeat(" ", progressBarWidth-filled), done, total)
+}
+
+func clearLine(w io.Writer) {
+	fmt.Fprintf(w, "\r%s\r", strings.Repeat(" ", progressBarWidth+24))
+}
+
+// isTerminal returns true if v is a file that is a terminal.
+func isTerminal(v any) bool {
+	f, ok := v.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
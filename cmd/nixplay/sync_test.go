@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSync_DryRun(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient()
+	e, stdout, _ := newTestEnv(client)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.jpg"), []byte("a"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "c.jpg"), []byte("c"), 0o600))
+
+	// Flags may come after the arguments.
+	require.NoError(t, run(ctx, e, []string{"sync", dir, "album:Family", "--delete", "--dry-run"}))
+	assert.Equal(t, "would have uploaded c.jpg\n"+
+		"would have deleted b.jpg\n"+
+		"dry run: 1 uploaded, 1 unchanged, 1 deleted\n", stdout.String())
+	assert.Len(t, client.containers[0].photos, 2)
+}
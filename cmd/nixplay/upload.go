@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/internal/mime"
+)
+
+func runUpload(ctx context.Context, e *env, args []string) error {
+	fs := newFlagSet(e, "upload", "CONTAINER PATH...")
+	asJSON := fs.Bool("json", false, "print output as JSON")
+	skipExisting := fs.Bool("skip-existing", false, "skip files that already have a photo with the same content in the container")
+	if err := parseFlags(fs, args, 2, -1); err != nil {
+		return err
+	}
+
+	files, err := expandPaths(fs.Args()[1:])
+	if err != nil {
+		return err
+	}
+
+	client, err := e.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	container, err := getContainer(ctx, client, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	uploaded := []photoInfo{}
+	for _, path := range files {
+		p, err := uploadFile(ctx, container, path, *skipExisting)
+		if err != nil {
+			return fmt.Errorf("failed to upload %q: %w", path, err)
+		}
+		info, err := getPhotoInfo(ctx, p)
+		if err != nil {
+			return err
+		}
+		uploaded = append(uploaded, info)
+		if !*asJSON {
+			fmt.Fprintf(e.stdout, "uploaded %s\n", path)
+		}
+	}
+
+	if *asJSON {
+		return e.printJSON(uploaded)
+	}
+	return nil
+}
+
+// expandPaths expands the paths given on the command line into a list of
+// files to upload. Directories are expanded to the files directly inside them
+// that Nixplay supports, subdirectories are ignored.
+func expandPaths(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() && mime.IsSupported(mime.TypeByFileName(entry.Name())) {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+func uploadFile(ctx context.Context, container nixplay.Container, path string, skipExisting bool) (nixplay.Photo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return container.AddPhoto(ctx, filepath.Base(path), f, nixplay.AddPhotoOptions{SkipExisting: skipExisting})
+}
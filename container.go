@@ -2,19 +2,18 @@ package nixplay
 
 import (
 	"context"
-	"crypto/sha256"
 	"encoding/base64"
-	"encoding/binary"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
-	"net/http"
 	"strconv"
 	"sync"
 
-	"github.com/anitschke/go-nixplay/encoding"
 	"github.com/anitschke/go-nixplay/httpx"
 	"github.com/anitschke/go-nixplay/internal/cache"
 	"github.com/anitschke/go-nixplay/internal/errorx"
+	"github.com/anitschke/go-nixplay/rawapi"
 	"github.com/anitschke/go-nixplay/types"
 )
 
@@ -28,9 +27,9 @@ const photoPageSize = uint64(100)
 // The first page is page 0.
 type photoPageFunc = func(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error)
 
-// deleteRequestFunc is a function that can be used to create a *http.Request to
-// delete a photo.
-type deleteRequestFunc = func(ctx context.Context, nixplayID uint64) (*http.Request, error)
+// deleteFunc is a function that deletes the container with the specified
+// nixplayID.
+type deleteFunc = func(raw *rawapi.Client, ctx context.Context, nixplayID uint64) error
 
 type container struct {
 	containerType types.ContainerType
@@ -43,17 +42,26 @@ type container struct {
 
 	client        httpx.Client
 	nixplayClient Client
+	settings      *clientSettings
 	nixplayID     uint64
 
-	photoCache             *cache.Cache[Photo]
-	elementDeletedListener []cache.ElementDeletedListener
+	photoCache *cache.Cache[Photo]
+	cache.DeletedListeners
 
-	photoPageFunc     photoPageFunc
-	deleteRequestFunc deleteRequestFunc
-	addIDName         string
+	photoPageFunc photoPageFunc
+	deleteFunc    deleteFunc
+	addIDName     string
+
+	// special is set for the albums and playlists that Nixplay creates in
+	// every account, which can not be deleted.
+	special specialContainer
+
+	// raw is the JSON object Nixplay listed the container with, or nil if
+	// the container has not been listed.
+	raw json.RawMessage
 }
 
-func newContainer(client httpx.Client, nixplayClient Client, containerType types.ContainerType, name string, nixplayID uint64, photoCount int64, photoPageFunc photoPageFunc, deleteRequestFunc deleteRequestFunc, addIDName string) *container {
+func newContainer(client httpx.Client, nixplayClient Client, settings *clientSettings, containerType types.ContainerType, name string, nixplayID uint64, photoCount int64, photoPageFunc photoPageFunc, deleteFunc deleteFunc, addIDName string) *container {
 
 	// There is no guarantee that we will be able to successfully decode the
 	// name. The user may have manually created this with a name that does not
@@ -61,32 +69,36 @@ func newContainer(client httpx.Client, nixplayClient Client, containerType types
 	// just use the raw un-decoded string. This should be fine since we are safe
 	// to duplicate containers with the same name that could come about as a
 	// result of using the raw un-decoded string.
-	if decodedName, err := encoding.Decode(name); err == nil {
+	if decodedName, err := settings.encoder().Decode(name); err == nil {
 		name = decodedName
 	}
 
-	nixplayIdAsBytes := make([]byte, 8)
-	binary.LittleEndian.PutUint64(nixplayIdAsBytes, nixplayID)
-	hasher := sha256.New()
-	hasher.Write([]byte(containerType))
-	hasher.Write(nixplayIdAsBytes)
-	id := *(*types.ID)(hasher.Sum([]byte{}))
-
 	c := &container{
-		containerType:     containerType,
-		client:            client,
-		nixplayClient:     nixplayClient,
-		name:              name,
-		id:                id,
-		nixplayID:         nixplayID,
-		photoCount:        photoCount,
-		photoPageFunc:     photoPageFunc,
-		deleteRequestFunc: deleteRequestFunc,
-		addIDName:         addIDName,
+		containerType: containerType,
+		client:        client,
+		nixplayClient: nixplayClient,
+		settings:      settings,
+		name:          name,
+		id:            containerID(containerType, nixplayID),
+		nixplayID:     nixplayID,
+		photoCount:    photoCount,
+		photoPageFunc: photoPageFunc,
+		deleteFunc:    deleteFunc,
+		addIDName:     addIDName,
 	}
 
 	c.photoCache = cache.NewCache(c.photosPage)
+	c.photoCache.SetLookupObserver(cacheLookupObserver(settings.metrics, PhotoCacheName))
+	c.photoCache.SetTTL(settings.cacheTTL.Photos)
+	c.photoCache.SetStaleWhileRevalidate(settings.cacheTTL.StaleWhileRevalidate)
+	c.photoCache.SetRefreshObserver(settings.changes.photoRefreshObserver(c))
 	c.photoCache.AddDeletedListener(c)
+	if containerType == types.PlaylistContainerType {
+		c.photoCache.SetNameHydrator(c.hydratePhotoNames)
+	}
+	if settings.cacheStore != nil {
+		c.photoCache.SetPersistence(c.photoPersistence(settings.cacheStore, photoCount))
+	}
 
 	return c
 }
@@ -103,6 +115,10 @@ func (c *container) Name(ctx context.Context) (string, error) {
 	return c.name, nil
 }
 
+func (c *container) KnownName() (string, bool) {
+	return c.name, true
+}
+
 func (c *container) NameUnique(ctx context.Context) (string, error) {
 	name, err := c.Name(ctx)
 	if err != nil {
@@ -167,73 +183,261 @@ func (c *container) PhotoCount(ctx context.Context) (retCount int64, err error)
 	return c.photoCount, nil
 }
 
-func (c *container) Delete(ctx context.Context) (err error) {
+// ErrNonEmptyContainer is the error that is returned when deleting a container
+// that still contains photos without DeleteOptions.Force. The actual error
+// returned by Container.Delete will be a *NonEmptyContainerError.
+var ErrNonEmptyContainer = errors.New("container is not empty")
+
+// NonEmptyContainerError is the error returned by Container.Delete when the
+// container still contains photos. errors.Is(err, ErrNonEmptyContainer) will
+// return true for a NonEmptyContainerError.
+type NonEmptyContainerError struct {
+	// PhotoCount is the number of photos in the container.
+	PhotoCount int64
+}
+
+func (e *NonEmptyContainerError) Error() string {
+	return fmt.Sprintf("%s: it contains %d photos, use DeleteOptions.Force to delete it anyway", ErrNonEmptyContainer, e.PhotoCount)
+}
+
+func (e *NonEmptyContainerError) Unwrap() error {
+	return ErrNonEmptyContainer
+}
+
+func (c *container) Delete(ctx context.Context, opts DeleteOptions) (err error) {
 	defer errorx.WrapWithFuncNameIfError(&err)
 
-	req, err := c.deleteRequestFunc(ctx, c.nixplayID)
-	if err != nil {
-		return err
+	ctx = withContainerOperation(ctx, "DeleteContainer", c.containerType, c.nixplayID)
+	ctx, cancel := withTimeout(ctx, c.settings.timeouts.Metadata)
+	defer cancel()
+
+	if c.special != notSpecialContainer {
+		return fmt.Errorf("can not delete the %s: %w", c.special, types.ErrSpecialContainer)
 	}
-	resp, err := c.client.Do(req)
-	if err != nil {
+	if !opts.Force {
+		// The cached count may be out of date, for example if photos were
+		// added in the Nixplay app, so get the current count.
+		count, err := c.loadPhotoCount(ctx)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			return &NonEmptyContainerError{PhotoCount: count}
+		}
+	}
+	if c.settings.isDryRun(ctx) {
+		return c.settings.logContainerDryRun(ctx, c, DryRunAction{Type: types.ContainerDeletedChangeType})
+	}
+	if err := c.deleteFunc(c.settings.rawAPI(c.client), ctx, c.nixplayID); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	defer io.Copy(io.Discard, resp.Body)
 
-	if err := httpx.StatusError(resp); err != nil {
+	if err := c.NotifyDeleted(ctx, c); err != nil {
 		return err
 	}
 
-	for _, l := range c.elementDeletedListener {
-		if err := l.ElementDeleted(ctx, c); err != nil {
-			return err
-		}
-	}
+	c.settings.changes.notify(ChangeEvent{
+		Type:      types.ContainerDeletedChangeType,
+		Container: c,
+	})
 
 	return nil
 }
 
-func (c *container) AddDeletedListener(l cache.ElementDeletedListener) {
-	c.elementDeletedListener = append(c.elementDeletedListener, l)
-}
-
 func (c *container) Photos(ctx context.Context) (retPhotos []Photo, err error) {
 	defer errorx.WrapWithFuncNameIfError(&err)
+	defer c.settings.photoLimiter.touch(c)
 	return c.photoCache.All(ctx)
 }
 
+func (c *container) PhotosWithOptions(ctx context.Context, opts PhotosOptions) (retPhotos []Photo, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+	photos, err := c.Photos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// The cache returns a copy of its photos so they can be sorted in place.
+	if err := SortPhotos(ctx, photos, opts); err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
 func (c *container) PhotosWithName(ctx context.Context, name string) (retPhoto []Photo, err error) {
 	defer errorx.WrapWithFuncNameIfError(&err)
+	defer c.settings.photoLimiter.touch(c)
 	return c.photoCache.ElementsWithName(ctx, name)
 }
 
 func (c *container) PhotoWithUniqueName(ctx context.Context, name string) (retPhoto Photo, err error) {
 	defer errorx.WrapWithFuncNameIfError(&err)
+	defer c.settings.photoLimiter.touch(c)
 	return c.photoCache.ElementWithUniqueName(ctx, name)
 }
 
 func (c *container) PhotoWithID(ctx context.Context, id types.ID) (retPhoto Photo, err error) {
 	defer errorx.WrapWithFuncNameIfError(&err)
+	defer c.settings.photoLimiter.touch(c)
 	return c.photoCache.ElementWithID(ctx, id)
 }
 
 func (c *container) photosPage(ctx context.Context, page uint64) ([]Photo, error) {
+	ctx = withContainerOperation(ctx, "ListPhotos", c.containerType, c.nixplayID)
+	ctx, cancel := withTimeout(ctx, c.settings.timeouts.Metadata)
+	defer cancel()
+
 	return c.photoPageFunc(ctx, c.client, c, c.nixplayID, page, photoPageSize)
 }
 
+// loadPhotoWithID lists the photos in the container from Nixplay, without using
+// the cache, and returns the photo with the specified ID. If there is no photo
+// with the ID then nil is returned.
+func (c *container) loadPhotoWithID(ctx context.Context, id types.ID) (*photo, error) {
+	for page := uint64(0); ; page++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		photos, err := c.photosPage(ctx, page)
+		if err != nil {
+			return nil, err
+		}
+		if len(photos) == 0 {
+			return nil, nil
+		}
+		for _, p := range photos {
+			if p.ID() != id {
+				continue
+			}
+			pp, ok := p.(*photo)
+			if !ok {
+				return nil, errors.New("failed to cast to *photo in loadPhotoWithID")
+			}
+			return pp, nil
+		}
+	}
+}
+
 func (c *container) AddPhoto(ctx context.Context, name string, r io.Reader, opts AddPhotoOptions) (retPhoto Photo, err error) {
-	name = encoding.Encode(name)
+	defer errorx.WrapWithFuncNameIfError(&err)
 
+	h, err := c.AddPhotoAsync(ctx, name, r, opts)
+	if err != nil {
+		return nil, err
+	}
+	return h.Wait(ctx)
+}
+
+func (c *container) AddPhotoAsync(ctx context.Context, name string, r io.Reader, opts AddPhotoOptions) (retHandle UploadHandle, err error) {
 	defer errorx.WrapWithFuncNameIfError(&err)
 
+	ctx = withContainerOperation(ctx, "AddPhoto", c.containerType, c.nixplayID)
+
+	name, r, opts, err = applyTransforms(ctx, name, r, opts)
+	if err != nil {
+		return nil, err
+	}
+	name = c.settings.encoder().Encode(name)
+
+	if opts.SkipExisting {
+		md5Hash, hashedR, cleanup, err := hashUpload(r, opts.MaxMemoryBuffer)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		r = hashedR
+
+		existing, err := c.photoWithMD5Hash(ctx, md5Hash)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil && c.containerType == types.PlaylistContainerType {
+			// Uploading to a playlist stores the photo in the "My Uploads"
+			// album too, so if it is already there the upload is a duplicate
+			// and the existing photo is linked into the playlist instead.
+			uploaded, err := c.myUploadsPhotoWithMD5Hash(ctx, md5Hash)
+			if err != nil {
+				return nil, err
+			}
+			if uploaded != nil {
+				existing, err = c.AddSlideFromPhoto(ctx, uploaded)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		if existing != nil {
+			return newCompletedUploadHandle(existing), nil
+		}
+	}
+
+	if c.settings.isDryRun(ctx) {
+		photoData, _, cleanup, err := getUploadPhotoData(name, r, opts)
+		if err != nil {
+			return nil, err
+		}
+		cleanup()
+		photoName, err := c.settings.encoder().Decode(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.settings.logContainerDryRun(ctx, c, DryRunAction{
+			Type:  types.PhotoAddedChangeType,
+			Photo: photoName,
+			Size:  photoData.FileSize,
+		}); err != nil {
+			return nil, err
+		}
+		return nil, types.ErrDryRun
+	}
+
 	albumID := uploadContainerID{
 		idName: c.addIDName,
 		id:     strconv.FormatUint(c.nixplayID, 10),
 	}
 
-	photoData, err := addPhoto(ctx, c.client, albumID, name, r, opts)
-	if errors.Is(err, errDuplicateImage) && c.containerType == types.PlaylistContainerType {
+	// When photos are identified by their playlist item ID we can only find
+	// out the ID of the new slide by listing the photos in the playlist after
+	// the upload, so remember which slides already exist.
+	var knownIDs map[types.ID]bool
+	if c.usesPlaylistItemIdentity() {
+		knownIDs, err = c.photoIDs(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	photoData, err := startUpload(ctx, c.settings.rawAPI(c.client), c.settings.timeouts, c.settings.uploadRetry, c.settings.hashes, albumID, name, r, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.settings.metrics.BytesUploaded(photoData.size)
+
+	// The upload is monitored on a context that is not canceled along with
+	// ctx, since the caller is not expected to keep ctx alive until Nixplay
+	// finishes processing the photo. Monitoring is bounded by
+	// Timeouts.UploadMonitor and UploadMonitorOptions.MaxWait instead, and
+	// can be stopped with UploadHandle.Cancel.
+	monitorCtx, cancel := context.WithCancel(detachedContext{parent: ctx})
+	h := newUploadHandle(cancel)
+	go func() {
+		defer cancel()
+		err := monitorUpload(monitorCtx, c.client, c.settings.timeouts, c.settings.uploadMonitor, photoData.monitorID)
+		h.complete(c.finishUpload(monitorCtx, photoData, opts.Verify, knownIDs, err))
+	}()
+	return h, nil
+}
+
+// finishUpload is called once Nixplay has finished processing an uploaded
+// photo to create the Photo object for the uploaded photo. monitorErr is the
+// error returned by monitorUpload. If verify is true the photo is checked
+// against the photos reported by Nixplay, see AddPhotoOptions.Verify.
+// knownIDs are the IDs of the photos in the container before the upload when
+// photos are identified by their playlist item ID.
+func (c *container) finishUpload(ctx context.Context, photoData uploadedPhoto, verify bool, knownIDs map[types.ID]bool, monitorErr error) (retPhoto Photo, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	err = monitorErr
+	if errors.Is(err, ErrDuplicateImage) && c.containerType == types.PlaylistContainerType {
 		// See https://github.com/anitschke/go-nixplay/#nixplay-meta-model
 		//
 		// Nixplay doesn't allow photos with duplicate content in the same
@@ -249,29 +453,244 @@ func (c *container) AddPhoto(ctx context.Context, name string, r io.Reader, opts
 		// the photo still gets added to the playlist so like we wanted.
 		//
 		// So long story short if we are uploading to a container and we get the
-		// errDuplicateImage we can just ignore the error and continue like
+		// ErrDuplicateImage we can just ignore the error and continue like
 		// normal.
 		err = nil
 	}
+	if errors.Is(err, ErrDuplicateImage) {
+		return nil, c.duplicateImageError(ctx, photoData.md5Hash)
+	}
+	if errors.Is(err, ErrUploadProcessingTimeout) && c.settings.uploadRetry.LookupOnMonitorTimeout {
+		// The upload monitor may time out even though Nixplay did finish
+		// processing the photo, in which case we report the photo rather than
+		// have the caller upload it again. If the lookup fails we still
+		// report the timeout.
+		if p, lookupErr := c.lookupUploadedPhoto(ctx, photoData.md5Hash, knownIDs); lookupErr == nil && p != nil {
+			c.uploadFinished(p, photoData)
+			return p, nil
+		}
+	}
+	if errors.Is(err, ErrUploadProcessingTimeout) {
+		timeoutErr := &UploadProcessingTimeoutError{MD5Hash: photoData.md5Hash}
+		if !c.usesPlaylistItemIdentity() {
+			timeoutErr.ID = photoID(c.ID(), photoData.md5Hash)
+		}
+		return nil, timeoutErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var p Photo
+	if c.usesPlaylistItemIdentity() {
+		p, err = c.findUploadedSlide(ctx, photoData.md5Hash, knownIDs)
+		if err != nil {
+			return nil, err
+		}
+	} else if verify {
+		p, err = c.verifyUpload(ctx, photoData.md5Hash)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		nixplayPhotoID := uint64(0)
+		nixplayPlaylistItemID := ""
+		photoURL := ""
+		newP, err := newPhoto(c, c.client, photoData.name, &photoData.md5Hash, nixplayPhotoID, nixplayPlaylistItemID, photoData.size, photoURL)
+		if err != nil {
+			return nil, err
+		}
+		c.photoCache.Add(newP)
+		p = newP
+	}
+
+	c.uploadFinished(p, photoData)
+	return p, nil
+}
+
+// uploadFinished records that p was added to the container by the upload
+// described by photoData.
+func (c *container) uploadFinished(p Photo, photoData uploadedPhoto) {
+	if asPhoto, ok := p.(*photo); ok {
+		asPhoto.setHashes(photoData.hashes)
+	}
+	c.incrementPhotoCount()
+
+	c.settings.changes.notify(ChangeEvent{
+		Type:      types.PhotoAddedChangeType,
+		Container: c,
+		Photo:     p,
+	})
+}
+
+// lookupUploadedPhoto reloads the photos in the container from Nixplay and
+// returns the photo that was added by uploading the photo with the provided
+// MD5 hash, or nil if Nixplay does not report one. knownIDs are the IDs of the
+// photos in the container before the upload when photos are identified by
+// their playlist item ID.
+func (c *container) lookupUploadedPhoto(ctx context.Context, md5Hash types.MD5Hash, knownIDs map[types.ID]bool) (Photo, error) {
+	c.ResetCache()
+	if !c.usesPlaylistItemIdentity() {
+		return c.photoWithMD5Hash(ctx, md5Hash)
+	}
+
+	photos, err := c.Photos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range photos {
+		if knownIDs[p.ID()] {
+			continue
+		}
+		pMD5Hash, err := p.MD5Hash(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if pMD5Hash == md5Hash {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+// verifyUpload reloads the photos in the container from Nixplay and returns
+// the photo Nixplay reports with the provided MD5 hash.
+func (c *container) verifyUpload(ctx context.Context, md5Hash types.MD5Hash) (retPhoto Photo, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	c.ResetCache()
+	p, err := c.photoWithMD5Hash(ctx, md5Hash)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		return nil, fmt.Errorf("%w: Nixplay does not report a photo with MD5 hash %x", types.ErrMD5Mismatch, md5Hash)
+	}
+	return p, nil
+}
+
+// findUploadedSlide reloads the photos in the playlist from Nixplay and
+// returns the slide that was added by uploading the photo with the provided
+// MD5 hash. knownIDs are the IDs of the photos in the playlist before the
+// upload.
+func (c *container) findUploadedSlide(ctx context.Context, md5Hash types.MD5Hash, knownIDs map[types.ID]bool) (retPhoto Photo, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	c.ResetCache()
+	photos, err := c.Photos(ctx)
 	if err != nil {
 		return nil, err
 	}
+	var existing Photo
+	for _, p := range photos {
+		pMD5Hash, err := p.MD5Hash(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if pMD5Hash != md5Hash {
+			continue
+		}
+		if !knownIDs[p.ID()] {
+			return p, nil
+		}
+		existing = p
+	}
+
+	// Uploading a photo that is already in the playlist doesn't always add
+	// another slide, in which case the existing slide is the uploaded photo.
+	if existing != nil {
+		return existing, nil
+	}
+	return nil, fmt.Errorf("%w: Nixplay does not report a photo with MD5 hash %x", types.ErrMD5Mismatch, md5Hash)
+}
+
+// usesPlaylistItemIdentity returns true if photos in the container are
+// identified by their playlist item ID, see types.PlaylistItemPhotoIdentity.
+func (c *container) usesPlaylistItemIdentity() bool {
+	return c.containerType == types.PlaylistContainerType && c.settings != nil && c.settings.photoIdentity == types.PlaylistItemPhotoIdentity
+}
+
+// photoWithMD5Hash returns a photo in the container with the provided MD5 hash
+// or nil if there isn't one.
+func (c *container) photoWithMD5Hash(ctx context.Context, md5Hash types.MD5Hash) (Photo, error) {
+	if !c.usesPlaylistItemIdentity() {
+		return c.PhotoWithID(ctx, photoID(c.ID(), md5Hash))
+	}
 
-	nixplayPhotoID := uint64(0)
-	nixplayPlaylistItemID := ""
-	photoURL := ""
-	p, err := newPhoto(c, c.client, name, &photoData.md5Hash, nixplayPhotoID, nixplayPlaylistItemID, photoData.size, photoURL)
+	photos, err := c.Photos(ctx)
 	if err != nil {
 		return nil, err
 	}
+	for _, p := range photos {
+		pMD5Hash, err := p.MD5Hash(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if pMD5Hash == md5Hash {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
 
-	c.photoCache.Add(p)
+// myUploadsPhotoWithMD5Hash returns the photo with the given MD5 hash in the
+// "My Uploads" album, or nil if the album does not have such a photo. If the
+// account does not have the album then nil is returned too.
+func (c *container) myUploadsPhotoWithMD5Hash(ctx context.Context, md5Hash types.MD5Hash) (Photo, error) {
+	if c.nixplayClient == nil {
+		return nil, nil
+	}
+	myUploads, err := c.nixplayClient.MyUploads(ctx)
+	if errors.Is(err, types.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	album, ok := myUploads.(*container)
+	if !ok {
+		return nil, errors.New("failed to cast to *container in myUploadsPhotoWithMD5Hash")
+	}
+	return album.photoWithMD5Hash(ctx, md5Hash)
+}
+
+// photoIDs returns the set of IDs of the photos in the container.
+func (c *container) photoIDs(ctx context.Context) (map[types.ID]bool, error) {
+	photos, err := c.Photos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[types.ID]bool, len(photos))
+	for _, p := range photos {
+		ids[p.ID()] = true
+	}
+	return ids, nil
+}
+
+func (c *container) incrementPhotoCount() {
 
 	c.photoCountMu.Lock()
 	defer c.photoCountMu.Unlock()
 	c.photoCount++
+}
 
-	return p, nil
+// duplicateImageError creates a DuplicateImageError that refers to the photo
+// that already exists in the container with the provided MD5 hash.
+func (c *container) duplicateImageError(ctx context.Context, md5Hash types.MD5Hash) error {
+	existing, err := c.photoWithMD5Hash(ctx, md5Hash)
+	if err == nil && existing == nil {
+		// The existing photo may have been added since the cache was
+		// populated, so try again with fresh data.
+		c.ResetCache()
+		existing, err = c.photoWithMD5Hash(ctx, md5Hash)
+	}
+	if err != nil {
+		// We already know the upload failed because it was a duplicate so
+		// rather than returning the error from trying to find the existing
+		// photo we just don't provide it.
+		existing = nil
+	}
+	return &DuplicateImageError{Existing: existing}
 }
 
 // Listens to deletes of photos from the cache
@@ -285,3 +704,15 @@ func (c *container) ElementDeleted(ctx context.Context, e cache.Element) (err er
 func (c *container) ResetCache() {
 	c.photoCache.Reset()
 }
+
+func (c *container) RawMetadata(ctx context.Context) (json.RawMessage, error) {
+	if c.raw == nil {
+		return nil, errors.New("container has not been listed by Nixplay, refresh the client to load its metadata")
+	}
+	return append(json.RawMessage(nil), c.raw...), nil
+}
+
+func (c *container) Refresh(ctx context.Context) (err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+	return c.photoCache.Refresh(ctx)
+}
@@ -0,0 +1,69 @@
+package nixplay_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/internal/auth"
+	"github.com/anitschke/go-nixplay/nixplaytest"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultClient_Contract(t *testing.T) {
+	nixplaytest.RunClientContract(t, contractClientFactory(types.ContentPhotoIdentity))
+}
+
+func TestDefaultClient_DuplicateSlideContract(t *testing.T) {
+	nixplaytest.RunDuplicateSlideContract(t, contractClientFactory(types.PlaylistItemPhotoIdentity))
+}
+
+// contractClientFactory returns a factory for clients of the test account that
+// use photoIdentity and clean up the photos the contract tests upload.
+func contractClientFactory(photoIdentity types.PhotoIdentity) nixplaytest.ClientFactory {
+	return func(t *testing.T) nixplay.Client {
+		ctx := context.Background()
+		authorization, err := auth.TestAccountAuth()
+		require.NoError(t, err)
+		httpClient, err := auth.TestHTTPClient()
+		require.NoError(t, err)
+		client, err := nixplay.NewDefaultClient(ctx, authorization, nixplay.DefaultClientOptions{HTTPClient: httpClient, DecodingMode: types.StrictDecodingMode, PhotoIdentity: photoIdentity})
+		require.NoError(t, err)
+
+		// Photos uploaded to playlists are also added to "My Uploads", so
+		// delete the ones uploaded by the test once it is done.
+		var mu sync.Mutex
+		uploaded := make(map[types.MD5Hash]bool)
+		remove := client.AddChangeListener(func(event nixplay.ChangeEvent) {
+			if event.Type != types.PhotoAddedChangeType || event.Refreshed {
+				return
+			}
+			if md5Hash, err := event.Photo.MD5Hash(ctx); err == nil {
+				mu.Lock()
+				uploaded[md5Hash] = true
+				mu.Unlock()
+			}
+		})
+		t.Cleanup(func() {
+			remove()
+			mu.Lock()
+			defer mu.Unlock()
+			myUploads, err := client.ContainerWithUniqueName(nixplay.WithFreshData(ctx), types.AlbumContainerType, nixplaytest.MyUploadsAlbumName)
+			if !assert.NoError(t, err) || myUploads == nil {
+				return
+			}
+			photos, err := myUploads.Photos(nixplay.WithFreshData(ctx))
+			require.NoError(t, err)
+			for _, p := range photos {
+				if md5Hash, err := p.MD5Hash(ctx); err == nil && uploaded[md5Hash] {
+					assert.NoError(t, p.Delete(ctx, nixplay.DeleteOptions{}))
+				}
+			}
+		})
+
+		return client
+	}
+}
@@ -1,18 +1,17 @@
 package nixplay
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"net/url"
+	"time"
 
 	"github.com/anitschke/go-nixplay/encoding"
 	"github.com/anitschke/go-nixplay/httpx"
 	"github.com/anitschke/go-nixplay/internal/auth"
 	"github.com/anitschke/go-nixplay/internal/cache"
+	"github.com/anitschke/go-nixplay/rawapi"
 	"github.com/anitschke/go-nixplay/types"
 )
 
@@ -22,12 +21,212 @@ type DefaultClientOptions struct {
 	// HTTPClient is the HTTP Client that will be used to communicate with the
 	// Nixplay servers.
 	//
-	// If no client is specified then the default http.Client will be used.
+	// If no client is specified then an http.Client using a transport
+	// configured by Transport will be used.
 	HTTPClient httpx.Client
+
+	// Transport tunes the connections used to communicate with Nixplay when
+	// no HTTPClient is specified, see httpx.TransportOptions. It is ignored
+	// if HTTPClient is specified.
+	Transport httpx.TransportOptions
+
+	// Middleware decorates HTTPClient with additional behavior, such as
+	// retrying, rate limiting or logging requests. The first middleware is the
+	// outermost one, see httpx.Chain. The middleware is applied outside of the
+	// behavior configured by the other options, such as Tracer and
+	// RequestHook, and inside of the authentication of requests, so it sees
+	// the cookies and headers that authenticate each request.
+	Middleware []httpx.Middleware
+
+	// UserAgent is the User-Agent header sent with every request, for example
+	// to identify an automation to Nixplay support. If empty the default of
+	// the HTTP client is used.
+	UserAgent string
+
+	// Header are additional headers sent with every request. Headers that the
+	// client sets itself for a request, such as Content-Type and the headers
+	// used for authentication, take precedence.
+	Header http.Header
+
+	// AuthMethod controls how requests to Nixplay are authorized. By default
+	// the session cookies and CSRF token are used like the Nixplay web app,
+	// see types.BearerAuthMethod to use the token returned when signing in
+	// instead.
+	AuthMethod types.AuthMethod
+
+	// DisableCompression stops the client from asking Nixplay for gzip or
+	// deflate compressed responses. By default responses are compressed, which
+	// makes listing large accounts faster on slow connections.
+	DisableCompression bool
+
+	// RequestHook is an optional hook that will be invoked after every HTTP
+	// request made by the client. This can be used for logging or debugging
+	// requests made to Nixplay. Any secrets in the URL of the request are
+	// redacted before being passed to the hook.
+	RequestHook httpx.RequestHook
+
+	// DebugCapture is an optional writer that every request sent to Nixplay,
+	// and the response received for it, is written to with secrets such as
+	// cookies, tokens and signatures redacted. This is intended for capturing
+	// unexpected behavior of the Nixplay API to share in bug reports. See
+	// httpx.NewDebugCaptureClient for more details.
+	DebugCapture io.Writer
+
+	// Tracer is an optional tracer that will be used to start a span for every
+	// HTTP request made by the client. Spans are named after the operation
+	// being performed (for example "AddPhoto") and include attributes such as
+	// the container ID and photo size when they are known.
+	Tracer httpx.Tracer
+
+	// Metrics is an optional interface that will be notified of requests
+	// made, bytes uploaded and downloaded, and cache hits and misses so that
+	// long running applications can monitor the behavior of the client.
+	Metrics Metrics
+
+	// Timeouts are optional timeouts that are applied to the requests made by
+	// the client. See Timeouts for more details.
+	Timeouts Timeouts
+
+	// UploadMonitor controls how the client waits for Nixplay to finish
+	// processing uploaded photos. See UploadMonitorOptions for more details.
+	UploadMonitor UploadMonitorOptions
+
+	// UploadRetry controls how failures in each phase of an upload are
+	// retried. See UploadRetryPolicy for more details.
+	UploadRetry UploadRetryPolicy
+
+	// Hashes controls how hashes other than MD5 are computed by Photo.Hash.
+	// See HashPolicy for more details.
+	Hashes HashPolicy
+
+	// CacheStore is an optional store used to persist the cached list of
+	// photos in each container so they can be reused by later runs of a
+	// program. See CacheStore for more details.
+	CacheStore CacheStore
+
+	// CacheTTL controls how long cached containers and photos are used before
+	// they are automatically loaded again from Nixplay. See CacheTTL for more
+	// details.
+	CacheTTL CacheTTL
+
+	// MaxCachedPhotos is the maximum number of photos that are kept in the
+	// cache across all containers. When the limit is exceeded the cached
+	// photos of the least recently used containers are discarded, they will be
+	// loaded again from Nixplay if they are needed. A value of zero means
+	// there is no limit.
+	MaxCachedPhotos int
+
+	// PhotoIdentity controls how the IDs of photos in playlists are computed.
+	// By default copies of the same photo within a playlist share an ID, see
+	// types.PlaylistItemPhotoIdentity to give each copy its own ID.
+	PhotoIdentity types.PhotoIdentity
+
+	// NameEncoder is used to encode the names of containers and photos before
+	// they are sent to Nixplay and to decode the names received from Nixplay.
+	// If no encoder is specified then encoding.GoEscape is used. Note that
+	// changing the encoder of an existing account changes how the names of
+	// existing containers and photos are decoded.
+	NameEncoder encoding.NameEncoder
+
+	// NameDecoding controls which names received from Nixplay are decoded. By
+	// default all names are decoded, see types.MarkedNameDecoding to only
+	// decode names that were encoded by this library.
+	NameDecoding types.NameDecoding
+
+	// DecodingMode controls how strictly the responses from Nixplay are
+	// checked against the fields this library expects. By default missing and
+	// unknown fields are tolerated, see types.StrictDecodingMode to detect
+	// changes to the Nixplay API.
+	DecodingMode types.DecodingMode
+
+	// MaxResponseSize is the maximum size in bytes of the JSON responses that
+	// are accepted from Nixplay, larger responses result in an error wrapping
+	// httpx.ErrResponseTooLarge. If it is zero then
+	// httpx.DefaultMaxResponseSize is used.
+	MaxResponseSize int64
+
+	// DryRun puts the client into dry-run mode where operations that would
+	// change the Nixplay account, such as uploading or deleting photos, are
+	// validated but not executed. See WithDryRun to enable dry-run mode for a
+	// single call.
+	DryRun bool
+
+	// DryRunLog is an optional function that is called with every change that
+	// is not made because of dry-run mode.
+	DryRunLog DryRunLogger
+
+	// SkipCreatedPlaylistLookup stops CreateContainer from loading the list of
+	// playlists after creating a playlist. Nixplay only reports the ID of a
+	// new playlist, so by default the playlist is looked up to get the name
+	// and photo count Nixplay recorded for it. When the lookup is skipped the
+	// playlist is assumed to have exactly the requested name and no photos,
+	// which saves a request.
+	SkipCreatedPlaylistLookup bool
+}
+
+// CacheTTL is the maximum age of cached data before it is automatically
+// loaded again from Nixplay. This is useful for long running programs where
+// the account may be modified by other means, such as the Nixplay mobile app.
+// A zero value means that the data never expires and is only loaded again
+// after ResetCache is called.
+type CacheTTL struct {
+	// Containers is the maximum age of the cached list of albums and
+	// playlists.
+	Containers time.Duration
+
+	// Photos is the maximum age of the cached list of photos in each
+	// container.
+	Photos time.Duration
+
+	// StaleWhileRevalidate specifies that once cached data has expired it
+	// should continue to be returned immediately while it is refreshed from
+	// Nixplay in the background, rather than waiting for it to be loaded
+	// again. Changes found by the refresh are reported to ChangeListeners.
+	StaleWhileRevalidate bool
+}
+
+// clientSettings are the settings derived from DefaultClientOptions that are
+// shared between the client and all of the containers and photos it creates.
+type clientSettings struct {
+	metrics            Metrics
+	timeouts           Timeouts
+	uploadMonitor      UploadMonitorOptions
+	uploadRetry        UploadRetryPolicy
+	hashes             HashPolicy
+	cacheStore         CacheStore
+	cacheTTL           CacheTTL
+	changes            *changeNotifier
+	photoLimiter       *photoCacheLimiter
+	dryRun             bool
+	dryRunLog          DryRunLogger
+	skipPlaylistLookup bool
+	photoIdentity      types.PhotoIdentity
+	decodingMode       types.DecodingMode
+	maxResponseSize    int64
+	nameEncoder        encoding.NameEncoder
+}
+
+// encoder returns the encoder used for the names of containers and photos.
+func (s *clientSettings) encoder() encoding.NameEncoder {
+	if s.nameEncoder == nil {
+		return encoding.GoEscape
+	}
+	return s.nameEncoder
+}
+
+// rawAPI returns a client for making requests to the Nixplay REST endpoints
+// with client.
+func (s *clientSettings) rawAPI(client httpx.Client) *rawapi.Client {
+	return rawapi.New(client, rawapi.Options{
+		DecodingMode:    s.decodingMode,
+		MaxResponseSize: s.maxResponseSize,
+	})
 }
 
 type DefaultClient struct {
-	client httpx.Client
+	client   httpx.Client
+	auth     *auth.AuthorizedClient
+	settings *clientSettings
 
 	albumCache    *cache.Cache[Container]
 	playlistCache *cache.Cache[Container]
@@ -35,21 +234,93 @@ type DefaultClient struct {
 
 var _ = (Client)((*DefaultClient)(nil))
 
+// middleware returns the chain of middleware that HTTPClient is decorated
+// with, outermost first. The middleware provided by the caller is outside of
+// the middleware for the built in options so that, for example, each request
+// retried by the caller is traced and reported to the RequestHook.
+func (opts DefaultClientOptions) middleware() []httpx.Middleware {
+	middleware := append([]httpx.Middleware(nil), opts.Middleware...)
+	if opts.Tracer != nil {
+		middleware = append(middleware, httpx.Tracing(opts.Tracer))
+	}
+	if opts.Metrics != nil {
+		middleware = append(middleware, httpx.Hook(metricsRequestHook(opts.Metrics)))
+	}
+	if opts.RequestHook != nil {
+		middleware = append(middleware, httpx.Hook(opts.RequestHook))
+	}
+	if !opts.DisableCompression {
+		middleware = append(middleware, httpx.Decompression())
+	}
+	if opts.UserAgent != "" {
+		middleware = append(middleware, httpx.UserAgent(opts.UserAgent))
+	}
+	if len(opts.Header) > 0 {
+		middleware = append(middleware, httpx.Header(opts.Header))
+	}
+	if opts.DebugCapture != nil {
+		middleware = append(middleware, httpx.DebugCapture(opts.DebugCapture))
+	}
+	return middleware
+}
+
 func NewDefaultClient(ctx context.Context, a types.Authorization, opts DefaultClientOptions) (*DefaultClient, error) {
+	if err := opts.Hashes.validate(); err != nil {
+		return nil, err
+	}
 	if opts.HTTPClient == nil {
-		opts.HTTPClient = &http.Client{}
+		opts.HTTPClient = &http.Client{Transport: httpx.NewTransport(opts.Transport)}
+	}
+	opts.HTTPClient = httpx.Chain(opts.HTTPClient, opts.middleware()...)
+	if opts.Metrics == nil {
+		opts.Metrics = nopMetrics{}
+	}
+	if opts.NameEncoder == nil {
+		opts.NameEncoder = encoding.GoEscape
+	}
+	if opts.NameDecoding == types.MarkedNameDecoding {
+		opts.NameEncoder = encoding.Marked(opts.NameEncoder)
 	}
 
-	client, err := auth.NewAuthorizedClient(ctx, opts.HTTPClient, a)
+	client, err := auth.NewAuthorizedClient(ctx, opts.HTTPClient, a, opts.AuthMethod)
 	if err != nil {
 		return nil, fmt.Errorf("authorization failed: %w", err)
 	}
 
 	c := &DefaultClient{
 		client: client,
+		auth:   client,
+		settings: &clientSettings{
+			metrics:            opts.Metrics,
+			timeouts:           opts.Timeouts,
+			uploadMonitor:      opts.UploadMonitor,
+			uploadRetry:        opts.UploadRetry,
+			hashes:             opts.Hashes,
+			cacheStore:         opts.CacheStore,
+			cacheTTL:           opts.CacheTTL,
+			changes:            &changeNotifier{},
+			dryRun:             opts.DryRun,
+			dryRunLog:          opts.DryRunLog,
+			skipPlaylistLookup: opts.SkipCreatedPlaylistLookup,
+			photoIdentity:      opts.PhotoIdentity,
+			decodingMode:       opts.DecodingMode,
+			maxResponseSize:    opts.MaxResponseSize,
+			nameEncoder:        opts.NameEncoder,
+		},
+	}
+	if opts.MaxCachedPhotos > 0 {
+		c.settings.photoLimiter = newPhotoCacheLimiter(opts.MaxCachedPhotos)
 	}
 	c.albumCache = cache.NewCache(c.albumsPage)
+	c.albumCache.SetLookupObserver(cacheLookupObserver(c.settings.metrics, AlbumCacheName))
+	c.albumCache.SetTTL(c.settings.cacheTTL.Containers)
+	c.albumCache.SetStaleWhileRevalidate(c.settings.cacheTTL.StaleWhileRevalidate)
+	c.albumCache.SetRefreshObserver(c.settings.changes.containerRefreshObserver())
 	c.playlistCache = cache.NewCache(c.playlistsPage)
+	c.playlistCache.SetLookupObserver(cacheLookupObserver(c.settings.metrics, PlaylistCacheName))
+	c.playlistCache.SetTTL(c.settings.cacheTTL.Containers)
+	c.playlistCache.SetStaleWhileRevalidate(c.settings.cacheTTL.StaleWhileRevalidate)
+	c.playlistCache.SetRefreshObserver(c.settings.changes.containerRefreshObserver())
 
 	return c, nil
 }
@@ -70,35 +341,34 @@ func (c *DefaultClient) albumsPage(ctx context.Context, page uint64) ([]Containe
 	// once for containers so we just need to write a quick and dirty adaptor to return all the data
 	// in the first page any always return empty data for subsequent data.
 	if page == 0 {
-		return c.albums(ctx)
+		return c.albums(httpx.WithOperation(ctx, "ListAlbums"))
 	}
 	return nil, nil
 
 }
 
 func (c *DefaultClient) albums(ctx context.Context) ([]Container, error) {
-	webAlbums, err := c.albumsFromURL(ctx, "https://api.nixplay.com/v2/albums/web/json/")
+	webAlbums, err := c.albumsFrom(ctx, c.RawAPI().WebAlbums)
 	if err != nil {
 		return nil, err
 	}
-	emailAlbums, err := c.albumsFromURL(ctx, "https://api.nixplay.com/v2/albums/email/json/")
+	emailAlbums, err := c.albumsFrom(ctx, c.RawAPI().EmailAlbums)
 	if err != nil {
 		return nil, err
 	}
+	markSpecialAlbums(webAlbums, emailAlbums)
 	return append(webAlbums, emailAlbums...), nil
 }
 
-func (c *DefaultClient) albumsFromURL(ctx context.Context, url string) ([]Container, error) {
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, http.NoBody)
-	if err != nil {
-		return nil, err
-	}
+func (c *DefaultClient) albumsFrom(ctx context.Context, list func(context.Context) ([]rawapi.Album, error)) ([]Container, error) {
+	ctx, cancel := withTimeout(ctx, c.settings.timeouts.Metadata)
+	defer cancel()
 
-	var albums albumsResponse
-	if err := httpx.DoUnmarshalJSONResponse(c.client, req, &albums); err != nil {
+	albums, err := list(ctx)
+	if err != nil {
 		return nil, err
 	}
-	return albums.ToContainers(c.client, c), nil
+	return albumsToContainers(albums, c.client, c, c.settings), nil
 }
 
 func (c *DefaultClient) playlistsPage(ctx context.Context, page uint64) ([]Container, error) {
@@ -106,23 +376,36 @@ func (c *DefaultClient) playlistsPage(ctx context.Context, page uint64) ([]Conta
 	// once for containers so we just need to write a quick and dirty adaptor to return all the data
 	// in the first page any always return empty data for subsequent data.
 	if page == 0 {
-		return c.playlists(ctx)
+		return c.playlists(httpx.WithOperation(ctx, "ListPlaylists"))
 	}
 	return nil, nil
 
 }
 
 func (c *DefaultClient) playlists(ctx context.Context) ([]Container, error) {
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "https://api.nixplay.com/v3/playlists", http.NoBody)
+	ctx, cancel := withTimeout(ctx, c.settings.timeouts.Metadata)
+	defer cancel()
+
+	playlists, err := c.RawAPI().Playlists(ctx)
 	if err != nil {
 		return nil, err
 	}
+	containers := playlistsToContainers(playlists, c.client, c, c.settings)
 
-	var playlists playlistsResponse
-	if err := httpx.DoUnmarshalJSONResponse(c.client, req, &playlists); err != nil {
+	// The email playlist is found from the email albums, see
+	// markSpecialPlaylists.
+	albums, err := c.Containers(ctx, types.AlbumContainerType)
+	if err != nil {
 		return nil, err
 	}
-	return playlists.ToContainers(c.client, c), nil
+	var emailAlbums []Container
+	for _, a := range albums {
+		if ac, ok := a.(*container); ok && ac.special == emailSpecialAlbum {
+			emailAlbums = append(emailAlbums, a)
+		}
+	}
+	markSpecialPlaylists(containers, emailAlbums)
+	return containers, nil
 
 }
 
@@ -172,71 +455,171 @@ func (c *DefaultClient) ContainerWithUniqueName(ctx context.Context, containerTy
 }
 
 func (c *DefaultClient) CreateContainer(ctx context.Context, containerType types.ContainerType, name string) (Container, error) {
-	name = encoding.Encode(name)
+	if c.settings.isDryRun(ctx) {
+		if containerType != types.AlbumContainerType && containerType != types.PlaylistContainerType {
+			return nil, types.ErrInvalidContainerType
+		}
+		c.settings.logDryRun(ctx, DryRunAction{
+			Type:          types.ContainerCreatedChangeType,
+			ContainerType: containerType,
+			Container:     name,
+		})
+		return nil, types.ErrDryRun
+	}
+
+	name = c.settings.encoder().Encode(name)
+	ctx = httpx.WithOperation(ctx, "CreateContainer")
+	ctx, cancel := withTimeout(ctx, c.settings.timeouts.Metadata)
+	defer cancel()
 
+	var container Container
+	var err error
 	switch containerType {
 	case types.AlbumContainerType:
-		return c.createAlbum(ctx, name)
+		container, err = c.createAlbum(ctx, name)
 	case types.PlaylistContainerType:
-		return c.createPlaylist(ctx, name)
+		container, err = c.createPlaylist(ctx, name)
 	default:
 		return nil, types.ErrInvalidContainerType
 	}
-}
-
-func (c *DefaultClient) createAlbum(ctx context.Context, name string) (Container, error) {
-	formData := url.Values{
-		"name": {name},
-	}
-	req, err := httpx.NewPostFormRequest(ctx, "https://api.nixplay.com/album/create/json/", formData)
 	if err != nil {
 		return nil, err
 	}
 
-	var albums albumsResponse
-	if err := httpx.DoUnmarshalJSONResponse(c.client, req, &albums); err != nil {
+	c.settings.changes.notify(ChangeEvent{
+		Type:      types.ContainerCreatedChangeType,
+		Container: container,
+	})
+	return container, nil
+}
+
+func (c *DefaultClient) createAlbum(ctx context.Context, name string) (Container, error) {
+	album, err := c.RawAPI().CreateAlbum(ctx, name)
+	if err != nil {
 		return nil, err
 	}
-	if len(albums) != 1 {
-		return nil, errors.New("incorrect number of created containers returned")
-	}
 
-	a := albums[0].ToContainer(c.client, c)
+	a := albumToContainer(album, c.client, c, c.settings)
 	c.albumCache.Add(a)
 	return a, nil
 }
 
 func (c *DefaultClient) createPlaylist(ctx context.Context, name string) (Container, error) {
-
-	createRequest := createPlaylistRequest{
-		Name: name,
-	}
-	createBytes, err := json.Marshal(createRequest)
+	playlistID, err := c.RawAPI().CreatePlaylist(ctx, name)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "https://api.nixplay.com/v3/playlists", bytes.NewReader(createBytes))
+	// Unfortunately the only data we get back is the playlist ID. So unless
+	// the lookup is skipped we get the playlist Nixplay recorded, otherwise we
+	// will just assume that nixplay honored the exact name we asked it to
+	// create. I think this should be reasonably safe given the encoding that
+	// we do.
+	playlist := rawapi.Playlist{ID: playlistID, Name: name}
+	if !c.settings.skipPlaylistLookup {
+		created, found, err := c.createdPlaylist(ctx, playlistID)
+		if err != nil {
+			return nil, err
+		}
+		// If Nixplay doesn't list the new playlist yet we fall back to the
+		// assumed data rather than failing after the playlist was created.
+		if found {
+			playlist = created
+		}
+	}
+
+	p := playlistToContainer(playlist, c.client, c, c.settings)
+	c.playlistCache.Add(p)
+	return p, nil
+}
+
+// createdPlaylist gets the playlist with the specified ID from the list of
+// playlists. found is false if Nixplay does not list the playlist.
+func (c *DefaultClient) createdPlaylist(ctx context.Context, playlistID uint64) (playlist rawapi.Playlist, found bool, err error) {
+	playlists, err := c.RawAPI().Playlists(ctx)
 	if err != nil {
-		return nil, nil
+		return rawapi.Playlist{}, false, err
+	}
+	for _, p := range playlists {
+		if p.ID == playlistID {
+			return p, true, nil
+		}
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return rawapi.Playlist{}, false, nil
+}
 
-	var createResponse createPlaylistResponse
-	if err := httpx.DoUnmarshalJSONResponse(c.client, req, &createResponse); err != nil {
-		return nil, err
+func (c *DefaultClient) AddChangeListener(l ChangeListener) (remove func()) {
+	return c.settings.changes.add(l)
+}
+
+// CacheStats returns the number of entries currently held in the cache. It does
+// not load anything from Nixplay.
+func (c *DefaultClient) CacheStats() CacheStats {
+	var stats CacheStats
+	containers := append(c.albumCache.Cached(), c.playlistCache.Cached()...)
+	stats.Containers = len(containers)
+	for _, containerI := range containers {
+		cc, ok := containerI.(*container)
+		if !ok {
+			continue
+		}
+		if n := cc.photoCache.Len(); n > 0 {
+			stats.ContainersWithPhotos++
+			stats.Photos += n
+		}
 	}
+	return stats
+}
 
-	// Unfortunately the only data we get back is the playlist ID. So we will
-	// just assume that nixplay honored the exact name we asked it to create. I
-	// think this should be reasonably safe given the encoding that we do.
-	nPhotos := int64(0)
-	p := newPlaylist(c.client, c, name, createResponse.PlaylistId, nPhotos)
-	c.playlistCache.Add(p)
-	return p, nil
+// Session returns the current state of the signed in session. The session can
+// be saved and passed as types.Authorization.Session to NewDefaultClient to
+// create a client later without signing in again.
+func (c *DefaultClient) Session() types.Session {
+	return c.auth.Session()
+}
+
+// Profile gets the profile of the signed in account.
+//
+// Only the parts of the profile that are known to be reported by Nixplay are
+// included, updating the profile is not supported.
+func (c *DefaultClient) Profile(ctx context.Context) (types.Profile, error) {
+	ctx, cancel := withTimeout(httpx.WithOperation(ctx, "GetProfile"), c.settings.timeouts.Metadata)
+	defer cancel()
+
+	profile, err := c.RawAPI().Profile(ctx)
+	if err != nil {
+		return types.Profile{}, err
+	}
+	return profileToProfile(profile), nil
+}
+
+// RawAPI returns a client for making requests directly to the individual
+// Nixplay REST endpoints using the signed in session of this client. Changes
+// made through it are not reflected in the cache of this client and are not
+// reported to change listeners, use ResetCache or Refresh after making changes.
+func (c *DefaultClient) RawAPI() *rawapi.Client {
+	return c.settings.rawAPI(c.client)
 }
 
 func (c *DefaultClient) ResetCache() {
 	c.albumCache.Reset()
 	c.playlistCache.Reset()
 }
+
+func (c *DefaultClient) Refresh(ctx context.Context) error {
+	if err := c.albumCache.Refresh(ctx); err != nil {
+		return err
+	}
+	return c.playlistCache.Refresh(ctx)
+}
+
+// WithFreshData returns a copy of the context that bypasses the cache for
+// calls made with it. The first lookup in each cache of containers or photos
+// made using the returned context loads the data again from Nixplay, later
+// lookups using the same context use the freshly loaded data.
+//
+// This allows getting up to date data for a single call without discarding
+// the entire cache with ResetCache.
+func WithFreshData(ctx context.Context) context.Context {
+	return cache.WithFreshData(ctx)
+}
@@ -171,7 +171,7 @@ func TestDefaultClient_Photos_Stress(t *testing.T) {
 			// Delete
 			//////////////////////////
 			for _, p := range photos {
-				err := p.Delete(ctx)
+				err := p.Delete(ctx, DeleteOptions{})
 				assert.NoError(t, err)
 			}
 
@@ -6,12 +6,13 @@ import (
 	"crypto/md5"
 	"image/jpeg"
 	"io"
-	"math/rand"
+	"net/http"
 	"regexp"
-	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/anitschke/go-nixplay/internal/auth"
+	"github.com/anitschke/go-nixplay/internal/cache"
 	"github.com/anitschke/go-nixplay/internal/test-resources/photos"
 	"github.com/anitschke/go-nixplay/types"
 	"github.com/stretchr/testify/assert"
@@ -28,24 +29,24 @@ func testClient() *DefaultClient {
 	if err != nil {
 		panic(err)
 	}
-	client, err := NewDefaultClient(context.Background(), authorization, DefaultClientOptions{})
+	httpClient, err := auth.TestHTTPClient()
+	if err != nil {
+		panic(err)
+	}
+	client, err := NewDefaultClient(context.Background(), authorization, DefaultClientOptions{HTTPClient: httpClient, DecodingMode: types.StrictDecodingMode})
 	if err != nil {
 		panic(err)
 	}
 	return client
 }
 
-func randomName() string {
-	return strconv.FormatUint(rand.Uint64(), 36)
-}
-
 func tempContainer(t *testing.T, client Client, containerType types.ContainerType) Container {
 	name := randomName()
 	container, err := client.CreateContainer(context.Background(), containerType, name)
 	require.NoError(t, err)
 
 	t.Cleanup(func() {
-		err := container.Delete(context.Background())
+		err := container.Delete(context.Background(), DeleteOptions{Force: true})
 		assert.NoError(t, err)
 	})
 
@@ -136,79 +137,30 @@ func photoDataSlice(photos []Photo) ([]photoData, error) {
 	return data, nil
 }
 
-func addMyUploadsCleanup(t *testing.T, c Client) {
-	t.Cleanup(func() {
-		deleteAllPhotosFromMyUploads(t, c)
-	})
-}
-
-func deleteAllPhotosFromMyUploads(t *testing.T, c Client) {
-	ctx := context.Background()
-	myUploads, err := c.ContainersWithName(ctx, types.AlbumContainerType, "My Uploads")
-	require.Len(t, myUploads, 1)
-	require.NoError(t, err)
-
-	photos, err := myUploads[0].Photos(ctx)
-	require.NoError(t, err)
-
-	for _, p := range photos {
-		err := p.Delete(ctx)
-		assert.NoError(t, err)
-	}
-}
-
 func TestDefaultClient_Containers(t *testing.T) {
 
 	auth, err := auth.TestAccountAuth()
 	require.NoError(t, err)
 
 	type testData struct {
-		containerType           types.ContainerType
-		verifyInitialContainers func(containers []Container) (initialContainerNames []string)
+		containerType         types.ContainerType
+		defaultContainerNames []string
 	}
 
 	tests := []testData{
 		{
-			containerType: types.AlbumContainerType,
-			verifyInitialContainers: func(containers []Container) []string {
-				// By default every nixplay account seems to have two albums.
-				// This album is the ${username}@mynixplay.com album. The other
-				// is a "My Uploads" album.
-				assert.Len(t, containers, 2)
-
-				var names []string
-				for _, c := range containers {
-					name, err := c.Name(context.Background())
-					assert.NoError(t, err)
-					names = append(names, name)
-				}
-
-				expNames := []string{auth.Username + "@mynixplay.com", "My Uploads"}
-				assert.ElementsMatch(t, names, expNames)
-
-				return names
-			},
+			// By default every nixplay account seems to have two albums. This
+			// album is the ${username}@mynixplay.com album. The other is a "My
+			// Uploads" album.
+			containerType:         types.AlbumContainerType,
+			defaultContainerNames: []string{auth.Username + "@mynixplay.com", "My Uploads"},
 		},
 		{
-			containerType: types.PlaylistContainerType,
-			verifyInitialContainers: func(containers []Container) []string {
-				// By default every nixplay account seems to have two playlists.
-				// These are a playlist for the @mynixplay.com email address and
-				// a favorites playlist.
-				assert.Len(t, containers, 2)
-
-				var names []string
-				for _, c := range containers {
-					name, err := c.Name(context.Background())
-					assert.NoError(t, err)
-					names = append(names, name)
-				}
-
-				expNames := []string{auth.Username + "@mynixplay.com", "Favorites"}
-				assert.ElementsMatch(t, names, expNames)
-
-				return names
-			},
+			// By default every nixplay account seems to have two playlists.
+			// These are a playlist for the @mynixplay.com email address and a
+			// favorites playlist.
+			containerType:         types.PlaylistContainerType,
+			defaultContainerNames: []string{auth.Username + "@mynixplay.com", "Favorites"},
 		},
 	}
 
@@ -217,18 +169,33 @@ func TestDefaultClient_Containers(t *testing.T) {
 			ctx := context.Background()
 			client := testClient()
 
+			getNamesAndCheckContainerType := func(containers []Container) []string {
+				names := []string{}
+				for _, c := range containers {
+					name, err := c.Name(ctx)
+					assert.NoError(t, err)
+					names = append(names, name)
+					assert.Equal(t, c.ContainerType(), tc.containerType)
+				}
+				return names
+			}
+
+			// The account may be shared with other runs of the tests so other
+			// containers may exist, but the default containers should always
+			// be there and nothing in our namespace should exist yet.
+
 			//////////////////////////
 			// List
 			//////////////////////////
 			containers, err := client.Containers(ctx, tc.containerType)
 			assert.NoError(t, err)
-
-			initialContainerNames := tc.verifyInitialContainers(containers)
+			assert.Subset(t, getNamesAndCheckContainerType(containers), tc.defaultContainerNames)
+			assert.Empty(t, namespacedContainerNames(t, containers))
 
 			//////////////////////////
 			// Get
 			//////////////////////////
-			newName := "MyNewContainer"
+			newName := randomName()
 			containers, err = client.ContainersWithName(ctx, tc.containerType, newName)
 			assert.NoError(t, err)
 			assert.Len(t, containers, 0)
@@ -255,22 +222,8 @@ func TestDefaultClient_Containers(t *testing.T) {
 			//////////////////////////
 			containers, err = client.Containers(ctx, tc.containerType)
 			assert.NoError(t, err)
-
-			getNamesAndCheckContainerType := func(containers []Container) []string {
-				names := []string{}
-				for _, c := range containers {
-					name, err := c.Name(ctx)
-					assert.NoError(t, err)
-					names = append(names, name)
-					assert.Equal(t, c.ContainerType(), tc.containerType)
-				}
-				return names
-			}
-
-			names := getNamesAndCheckContainerType(containers)
-			assert.Len(t, containers, len(initialContainerNames)+1)
-			expNames := append([]string{newName}, initialContainerNames...)
-			assert.ElementsMatch(t, names, expNames)
+			assert.Subset(t, getNamesAndCheckContainerType(containers), tc.defaultContainerNames)
+			assert.Equal(t, []string{newName}, namespacedContainerNames(t, containers))
 
 			//////////////////////////
 			// Get
@@ -286,7 +239,7 @@ func TestDefaultClient_Containers(t *testing.T) {
 			//////////////////////////
 			// Delete
 			//////////////////////////
-			newContainer.Delete(context.Background())
+			err = newContainer.Delete(context.Background(), DeleteOptions{})
 			assert.NoError(t, err)
 
 			//////////////////////////
@@ -294,9 +247,8 @@ func TestDefaultClient_Containers(t *testing.T) {
 			//////////////////////////
 			containers, err = client.Containers(ctx, tc.containerType)
 			assert.NoError(t, err)
-			assert.Len(t, containers, len(initialContainerNames))
-			names = getNamesAndCheckContainerType(containers)
-			assert.ElementsMatch(t, names, initialContainerNames)
+			assert.Subset(t, getNamesAndCheckContainerType(containers), tc.defaultContainerNames)
+			assert.Empty(t, namespacedContainerNames(t, containers))
 
 			//////////////////////////
 			// Get
@@ -311,9 +263,8 @@ func TestDefaultClient_Containers(t *testing.T) {
 			client.ResetCache()
 			containers, err = client.Containers(ctx, tc.containerType)
 			assert.NoError(t, err)
-			assert.Len(t, containers, len(initialContainerNames))
-			names = getNamesAndCheckContainerType(containers)
-			assert.ElementsMatch(t, names, initialContainerNames)
+			assert.Subset(t, getNamesAndCheckContainerType(containers), tc.defaultContainerNames)
+			assert.Empty(t, namespacedContainerNames(t, containers))
 
 			//////////////////////////
 			// Reset Cache and Get
@@ -456,7 +407,7 @@ func TestDefaultClient_DuplicateContainerName(t *testing.T) {
 			//////////////////////////
 			// Delete Second Container
 			//////////////////////////
-			assert.NoError(t, container2.Delete(ctx))
+			assert.NoError(t, container2.Delete(ctx, DeleteOptions{}))
 
 			actName, err = container1.Name(ctx)
 			assert.NoError(t, err)
@@ -477,7 +428,7 @@ func TestDefaultClient_DuplicateContainerName(t *testing.T) {
 			//////////////////////////
 			// Delete First Container
 			//////////////////////////
-			assert.NoError(t, container1.Delete(ctx))
+			assert.NoError(t, container1.Delete(ctx, DeleteOptions{}))
 
 			containers, err = client.ContainersWithName(ctx, tc.containerType, name)
 			assert.NoError(t, err)
@@ -677,11 +628,61 @@ func TestDefaultClient_Photos(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
+			//////////////////////////
+			// Download To
+			//////////////////////////
+			for i, p := range addedPhotos {
+				tp := allTestPhotos[i]
+
+				var downloadedPhotoBytes bytes.Buffer
+				var lastWritten, lastTotal int64
+				err := p.DownloadTo(ctx, &downloadedPhotoBytes, DownloadOptions{
+					Progress: func(written int64, total int64) {
+						assert.GreaterOrEqual(t, written, lastWritten)
+						lastWritten = written
+						lastTotal = total
+					},
+					VerifyMD5: true,
+				})
+				assert.NoError(t, err)
+				assert.Equal(t, int64(downloadedPhotoBytes.Len()), tp.Size)
+				assert.Equal(t, lastWritten, tp.Size)
+				assert.Equal(t, lastTotal, tp.Size)
+			}
+
+			//////////////////////////
+			// Download Range
+			//////////////////////////
+			for i, p := range addedPhotos {
+				tp := allTestPhotos[i]
+
+				localPhoto, err := tp.Open()
+				require.NoError(t, err)
+				localPhotoBytes, err := io.ReadAll(localPhoto)
+				localPhoto.Close()
+				require.NoError(t, err)
+
+				offset, length := int64(10), int64(100)
+				r, err := p.OpenRange(ctx, offset, length)
+				require.NoError(t, err)
+				rangeBytes, err := io.ReadAll(r)
+				assert.NoError(t, err)
+				assert.NoError(t, r.Close())
+				assert.Equal(t, localPhotoBytes[offset:offset+length], rangeBytes)
+
+				r, err = p.OpenRange(ctx, offset, -1)
+				require.NoError(t, err)
+				rangeBytes, err = io.ReadAll(r)
+				assert.NoError(t, err)
+				assert.NoError(t, r.Close())
+				assert.Equal(t, localPhotoBytes[offset:], rangeBytes)
+			}
+
 			//////////////////////////
 			// Delete
 			//////////////////////////
 			for i, p := range addedPhotos {
-				err := p.Delete(ctx)
+				err := p.Delete(ctx, DeleteOptions{})
 				assert.NoError(t, err)
 
 				expPhotoData := addedPhotoData[i+1:]
@@ -845,7 +846,7 @@ func TestDefaultClient_SamePhotoInTwoContainers(t *testing.T) {
 			// Delete from the photos in the containers should happen
 			// individually. If we delete the photo from container1 the photo
 			// should remain in container2
-			err = p1.Delete(ctx)
+			err = p1.Delete(ctx, DeleteOptions{})
 			assert.NoError(t, err)
 
 			container2.ResetCache()
@@ -854,7 +855,7 @@ func TestDefaultClient_SamePhotoInTwoContainers(t *testing.T) {
 			require.NotNil(t, p2Check)
 			assert.Equal(t, p2Check.ID(), p2.ID())
 
-			err = p2.Delete(ctx)
+			err = p2.Delete(ctx, DeleteOptions{})
 			assert.NoError(t, err)
 
 			container1.ResetCache()
@@ -966,9 +967,9 @@ func TestDefaultClient_DuplicatePhotoNameInSameContainer(t *testing.T) {
 			//////////////////////////
 			// Delete
 			//////////////////////////
-			err = addedPhotos[0].Delete(ctx)
+			err = addedPhotos[0].Delete(ctx, DeleteOptions{})
 			assert.NoError(t, err)
-			err = addedPhotos[1].Delete(ctx)
+			err = addedPhotos[1].Delete(ctx, DeleteOptions{})
 			assert.NoError(t, err)
 
 			// Now that we have deleted 2 of the 3 photos with the same name the
@@ -991,7 +992,7 @@ func TestDefaultClient_DuplicatePhotoNameInSameContainer(t *testing.T) {
 			assert.Equal(t, addedPhotos[2].ID(), p.ID())
 
 			for _, p := range addedPhotos {
-				err := p.Delete(ctx)
+				err := p.Delete(ctx, DeleteOptions{})
 				assert.NoError(t, err)
 			}
 
@@ -1064,15 +1065,20 @@ func TestDefaultClient_UnusualFileNames(t *testing.T) {
 					assert.NoError(t, err)
 					assert.Equal(t, actName, tt.name)
 
+					// Other runs of the tests sharing the account may have
+					// created containers with the same name, so only look for
+					// the one we created.
 					client.ResetCache()
 					containersFromSearch, err := client.ContainersWithName(ctx, ct, tt.name)
 					require.NoError(t, err)
-					require.Len(t, containersFromSearch, 1)
-					actName, err = containersFromSearch[0].Name(ctx)
-					assert.NoError(t, err)
-					assert.Equal(t, actName, tt.name)
+					require.Contains(t, containerIDs(containersFromSearch), container.ID())
+					for _, c := range containersFromSearch {
+						actName, err = c.Name(ctx)
+						assert.NoError(t, err)
+						assert.Equal(t, actName, tt.name)
+					}
 
-					err = container.Delete(ctx)
+					err = container.Delete(ctx, DeleteOptions{})
 					assert.NoError(t, err)
 				})
 			}
@@ -1103,7 +1109,7 @@ func TestDefaultClient_UnusualFileNames(t *testing.T) {
 				require.NoError(t, err)
 
 				defer func() {
-					err = photo.Delete(ctx)
+					err = photo.Delete(ctx, DeleteOptions{})
 					assert.NoError(t, err)
 				}()
 
@@ -1138,3 +1144,185 @@ func TestDefaultClient_UnusualFileNames(t *testing.T) {
 		}
 	})
 }
+
+func TestDefaultClient_SkipExisting(t *testing.T) {
+	for _, containerType := range []types.ContainerType{types.AlbumContainerType, types.PlaylistContainerType} {
+		t.Run(string(containerType), func(t *testing.T) {
+			ctx := context.Background()
+			client := testClient()
+			addMyUploadsCleanup(t, client)
+
+			container := tempContainer(t, client, containerType)
+			allTestPhotos, err := photos.AllPhotos()
+			require.NoError(t, err)
+			tp := allTestPhotos[0]
+
+			upload := func(opts AddPhotoOptions) Photo {
+				file, err := tp.Open()
+				require.NoError(t, err)
+				defer file.Close()
+				p, err := container.AddPhoto(ctx, tp.Name, file, opts)
+				require.NoError(t, err)
+				return p
+			}
+
+			first := upload(AddPhotoOptions{SkipExisting: true})
+			second := upload(AddPhotoOptions{SkipExisting: true})
+			assert.Equal(t, first.ID(), second.ID())
+
+			container.ResetCache()
+			photos, err := container.Photos(ctx)
+			assert.NoError(t, err)
+			assert.Len(t, photos, 1)
+		})
+	}
+}
+
+func TestDefaultClient_DuplicateImage(t *testing.T) {
+	ctx := context.Background()
+	client := testClient()
+
+	container := tempContainer(t, client, types.AlbumContainerType)
+	allTestPhotos, err := photos.AllPhotos()
+	require.NoError(t, err)
+	tp := allTestPhotos[0]
+
+	upload := func() (Photo, error) {
+		file, err := tp.Open()
+		require.NoError(t, err)
+		defer file.Close()
+		return container.AddPhoto(ctx, tp.Name, file, AddPhotoOptions{})
+	}
+
+	first, err := upload()
+	require.NoError(t, err)
+
+	_, err = upload()
+	assert.ErrorIs(t, err, ErrDuplicateImage)
+	var dupErr *DuplicateImageError
+	require.ErrorAs(t, err, &dupErr)
+	require.NotNil(t, dupErr.Existing)
+	assert.Equal(t, first.ID(), dupErr.Existing.ID())
+}
+
+func TestDefaultClient_AddPhotoAsync(t *testing.T) {
+	ctx := context.Background()
+	client := testClient()
+
+	for _, containerType := range []types.ContainerType{types.AlbumContainerType, types.PlaylistContainerType} {
+		t.Run(string(containerType), func(t *testing.T) {
+			container := tempContainer(t, client, containerType)
+			allTestPhotos, err := photos.AllPhotos()
+			require.NoError(t, err)
+			tp := allTestPhotos[0]
+
+			file, err := tp.Open()
+			require.NoError(t, err)
+			h, err := container.AddPhotoAsync(ctx, tp.Name, file, AddPhotoOptions{})
+			file.Close()
+			require.NoError(t, err)
+
+			p, err := h.Wait(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, types.UploadCompleteStatus, h.Status())
+			name, err := p.Name(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tp.Name, name)
+
+			count, err := container.PhotoCount(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), count)
+		})
+	}
+}
+
+func TestDefaultClient_VerifyUpload(t *testing.T) {
+	ctx := context.Background()
+	client := testClient()
+
+	for _, containerType := range []types.ContainerType{types.AlbumContainerType, types.PlaylistContainerType} {
+		t.Run(string(containerType), func(t *testing.T) {
+			container := tempContainer(t, client, containerType)
+			allTestPhotos, err := photos.AllPhotos()
+			require.NoError(t, err)
+			tp := allTestPhotos[0]
+
+			file, err := tp.Open()
+			require.NoError(t, err)
+			defer file.Close()
+			p, err := container.AddPhoto(ctx, tp.Name, file, AddPhotoOptions{Verify: true})
+			require.NoError(t, err)
+
+			name, err := p.Name(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, tp.Name, name)
+
+			count, err := container.PhotoCount(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), count)
+		})
+	}
+}
+
+func TestDefaultClient_Profile(t *testing.T) {
+	auth, err := auth.TestAccountAuth()
+	require.NoError(t, err)
+	client := testClient()
+
+	profile, err := client.Profile(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, auth.Username+"@mynixplay.com", profile.MyNixplayAddress)
+}
+
+func TestCreatePlaylistLookup(t *testing.T) {
+	ctx := context.Background()
+
+	var requests []string
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		var body string
+		switch {
+		case req.Method == http.MethodPost && req.URL.Path == "/v3/playlists":
+			body = `{"playlistId":56}`
+		case req.Method == http.MethodGet && req.URL.Path == "/v3/playlists":
+			// Nixplay may record a different name than the one we asked for.
+			body = `[{"id":12,"name":"other","picture_count":3},{"id":56,"name":"recorded","picture_count":0}]`
+		default:
+			require.Fail(t, "unexpected request", req.URL.String())
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})
+
+	newClient := func(skipLookup bool) *DefaultClient {
+		c := &DefaultClient{
+			client: client,
+			settings: &clientSettings{
+				metrics:            nopMetrics{},
+				changes:            &changeNotifier{},
+				skipPlaylistLookup: skipLookup,
+			},
+		}
+		c.playlistCache = cache.NewCache(c.playlistsPage)
+		return c
+	}
+
+	t.Run("Lookup", func(t *testing.T) {
+		requests = nil
+		playlist, err := newClient(false).CreateContainer(ctx, types.PlaylistContainerType, "requested")
+		require.NoError(t, err)
+		name, err := playlist.Name(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "recorded", name)
+		assert.Equal(t, []string{"POST /v3/playlists", "GET /v3/playlists"}, requests)
+	})
+
+	t.Run("Skip", func(t *testing.T) {
+		requests = nil
+		playlist, err := newClient(true).CreateContainer(ctx, types.PlaylistContainerType, "requested")
+		require.NoError(t, err)
+		name, err := playlist.Name(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "requested", name)
+		assert.Equal(t, []string{"POST /v3/playlists"}, requests)
+	})
+}
@@ -0,0 +1,160 @@
+// Package diff computes the differences between two snapshots of a Nixplay
+// account as produced by export.Snapshot.
+package diff
+
+import (
+	"github.com/anitschke/go-nixplay/export"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// Result describes how an account changed between two snapshots.
+//
+// Containers and photos are matched between snapshots by ID. Since the ID of a
+// photo is derived from the container it is in and its content, a photo that
+// is changed by uploading new content is reported as a removal of the old
+// photo and an addition of the new photo.
+type Result struct {
+	AddedContainers   []export.Container
+	RemovedContainers []export.Container
+	RenamedContainers []ContainerRename
+
+	// AddedPhotos and RemovedPhotos include the photos in containers that were
+	// added or removed.
+	AddedPhotos   []PhotoChange
+	RemovedPhotos []PhotoChange
+	RenamedPhotos []PhotoRename
+}
+
+// Empty returns true if there are no differences between the snapshots.
+func (r Result) Empty() bool {
+	return len(r.AddedContainers) == 0 &&
+		len(r.RemovedContainers) == 0 &&
+		len(r.RenamedContainers) == 0 &&
+		len(r.AddedPhotos) == 0 &&
+		len(r.RemovedPhotos) == 0 &&
+		len(r.RenamedPhotos) == 0
+}
+
+// ContainerRename describes a container that has a different name in the
+// second snapshot.
+type ContainerRename struct {
+	Old export.Container
+	New export.Container
+}
+
+// PhotoChange describes a photo that was added to or removed from a
+// container.
+//
+// Container is the container as it appears in the snapshot that contains the
+// photo, ie the new snapshot for added photos and the old snapshot for removed
+// photos. The Photos of Container are not populated to keep the Result small.
+type PhotoChange struct {
+	Container export.Container
+	Photo     export.Photo
+}
+
+// PhotoRename describes a photo that has a different name in the second
+// snapshot.
+//
+// Container is the container as it appears in the new snapshot. The Photos of
+// Container are not populated.
+type PhotoRename struct {
+	Container export.Container
+	Old       export.Photo
+	New       export.Photo
+}
+
+type containerKey struct {
+	containerType types.ContainerType
+	id            string
+}
+
+func keyOf(c export.Container) containerKey {
+	return containerKey{containerType: c.Type, id: c.ID}
+}
+
+// Compute computes the differences going from snapshot a to snapshot b.
+// Changes are reported in the order that containers and photos appear in the
+// snapshots.
+func Compute(a, b *export.Manifest) Result {
+	var r Result
+
+	oldContainers := make(map[containerKey]export.Container, len(a.Containers))
+	for _, c := range a.Containers {
+		oldContainers[keyOf(c)] = c
+	}
+	newContainers := make(map[containerKey]bool, len(b.Containers))
+	for _, c := range b.Containers {
+		newContainers[keyOf(c)] = true
+	}
+
+	for _, c := range a.Containers {
+		if newContainers[keyOf(c)] {
+			continue
+		}
+		r.RemovedContainers = append(r.RemovedContainers, c)
+		for _, p := range c.Photos {
+			r.RemovedPhotos = append(r.RemovedPhotos, PhotoChange{Container: withoutPhotos(c), Photo: p})
+		}
+	}
+
+	for _, c := range b.Containers {
+		old, ok := oldContainers[keyOf(c)]
+		if !ok {
+			r.AddedContainers = append(r.AddedContainers, c)
+			for _, p := range c.Photos {
+				r.AddedPhotos = append(r.AddedPhotos, PhotoChange{Container: withoutPhotos(c), Photo: p})
+			}
+			continue
+		}
+		if old.Name != c.Name {
+			r.RenamedContainers = append(r.RenamedContainers, ContainerRename{Old: old, New: c})
+		}
+		r.comparePhotos(old, c)
+	}
+
+	return r
+}
+
+// comparePhotos compares the photos in the old and new versions of the same
+// container.
+//
+// Playlists may contain multiple copies of the same photo which all share the
+// same ID, so photos with the same ID are paired up in order and any unpaired
+// copies are reported as added or removed.
+func (r *Result) comparePhotos(oldC, newC export.Container) {
+	container := withoutPhotos(newC)
+
+	oldByID := make(map[string][]export.Photo, len(oldC.Photos))
+	for _, p := range oldC.Photos {
+		oldByID[p.ID] = append(oldByID[p.ID], p)
+	}
+
+	matched := make(map[string]int, len(newC.Photos))
+	for _, p := range newC.Photos {
+		candidates := oldByID[p.ID]
+		i := matched[p.ID]
+		if i >= len(candidates) {
+			r.AddedPhotos = append(r.AddedPhotos, PhotoChange{Container: container, Photo: p})
+			continue
+		}
+		matched[p.ID] = i + 1
+		if old := candidates[i]; old.Name != p.Name {
+			r.RenamedPhotos = append(r.RenamedPhotos, PhotoRename{Container: container, Old: old, New: p})
+		}
+	}
+
+	oldContainer := withoutPhotos(oldC)
+	seen := make(map[string]int, len(oldC.Photos))
+	for _, p := range oldC.Photos {
+		seen[p.ID]++
+		if seen[p.ID] > matched[p.ID] {
+			r.RemovedPhotos = append(r.RemovedPhotos, PhotoChange{Container: oldContainer, Photo: p})
+		}
+	}
+}
+
+func withoutPhotos(c export.Container) export.Container {
+	c.Photos = nil
+	return c
+}
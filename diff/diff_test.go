@@ -0,0 +1,65 @@
+package diff
+
+import (
+	"testing"
+
+	"github.com/anitschke/go-nixplay/export"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompute(t *testing.T) {
+	photoA := export.Photo{ID: "a", Name: "a.jpg"}
+	photoB := export.Photo{ID: "b", Name: "b.jpg"}
+	photoC := export.Photo{ID: "c", Name: "c.jpg"}
+	photoBRenamed := export.Photo{ID: "b", Name: "renamed.jpg"}
+
+	album := export.Container{Type: types.AlbumContainerType, ID: "1", Name: "album"}
+	playlist := export.Container{Type: types.PlaylistContainerType, ID: "1", Name: "playlist"}
+	removed := export.Container{Type: types.AlbumContainerType, ID: "2", Name: "removed"}
+	added := export.Container{Type: types.AlbumContainerType, ID: "3", Name: "added"}
+
+	withPhotos := func(c export.Container, photos ...export.Photo) export.Container {
+		c.Photos = photos
+		return c
+	}
+	renamedPlaylist := playlist
+	renamedPlaylist.Name = "new name"
+
+	a := &export.Manifest{Containers: []export.Container{
+		withPhotos(album, photoA, photoB),
+		withPhotos(playlist, photoA, photoA, photoB),
+		withPhotos(removed, photoC),
+	}}
+	b := &export.Manifest{Containers: []export.Container{
+		withPhotos(album, photoBRenamed, photoC),
+		withPhotos(renamedPlaylist, photoA, photoB, photoB),
+		withPhotos(added, photoA),
+	}}
+
+	r := Compute(a, b)
+	assert.Equal(t, Result{
+		AddedContainers:   []export.Container{withPhotos(added, photoA)},
+		RemovedContainers: []export.Container{withPhotos(removed, photoC)},
+		RenamedContainers: []ContainerRename{{
+			Old: withPhotos(playlist, photoA, photoA, photoB),
+			New: withPhotos(renamedPlaylist, photoA, photoB, photoB),
+		}},
+		AddedPhotos: []PhotoChange{
+			{Container: album, Photo: photoC},
+			{Container: renamedPlaylist, Photo: photoB},
+			{Container: added, Photo: photoA},
+		},
+		RemovedPhotos: []PhotoChange{
+			{Container: removed, Photo: photoC},
+			{Container: album, Photo: photoA},
+			{Container: playlist, Photo: photoA},
+		},
+		RenamedPhotos: []PhotoRename{
+			{Container: album, Old: photoB, New: photoBRenamed},
+		},
+	}, r)
+	assert.False(t, r.Empty())
+
+	assert.True(t, Compute(a, a).Empty())
+}
@@ -0,0 +1,92 @@
+package nixplay
+
+import (
+	"context"
+
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// DryRunAction describes a change that would have been made to the Nixplay
+// account if the client was not in dry-run mode. See DefaultClientOptions.DryRun
+// and WithDryRun.
+type DryRunAction struct {
+	// Type of change that would have been made.
+	Type types.ChangeType
+
+	// ContainerType is the type of the container that would have been changed,
+	// or the container of the photo that would have been changed.
+	ContainerType types.ContainerType
+
+	// Container is the name of the container that would have been changed, or
+	// the container of the photo that would have been changed.
+	Container string
+
+	// Photo is the name of the photo that would have been changed. Photo is
+	// empty for changes to containers.
+	Photo string
+
+	// Size is the size in bytes of the photo that would have been uploaded for
+	// changes of type types.PhotoAddedChangeType.
+	Size int64
+}
+
+// DryRunLogger is a function that is called with every change that is not made
+// because the client is in dry-run mode.
+type DryRunLogger func(action DryRunAction)
+
+type dryRunContextKey struct{}
+
+// WithDryRun returns a context that puts any operation called with it into
+// dry-run mode. In dry-run mode operations that would change the Nixplay
+// account are validated but not executed. Instead the change that would have
+// been made is passed to log, which may be nil.
+//
+// Delete operations return nil once validated. Operations that would return a
+// new container or photo, such as CreateContainer and AddPhoto, return
+// types.ErrDryRun once validated since there is nothing to return.
+func WithDryRun(ctx context.Context, log DryRunLogger) context.Context {
+	if log == nil {
+		log = func(DryRunAction) {}
+	}
+	return context.WithValue(ctx, dryRunContextKey{}, log)
+}
+
+// isDryRun returns true if changes should not be made, either because the
+// client is in dry-run mode or because of WithDryRun.
+func (s *clientSettings) isDryRun(ctx context.Context) bool {
+	_, ok := ctx.Value(dryRunContextKey{}).(DryRunLogger)
+	return s.dryRun || ok
+}
+
+// isPhotoDryRun returns true if changes to p should not be made. Photos that
+// are not from a DefaultClient only honor WithDryRun.
+func isPhotoDryRun(ctx context.Context, p Photo) bool {
+	if pp, ok := p.(*photo); ok {
+		return pp.settings().isDryRun(ctx)
+	}
+	_, ok := ctx.Value(dryRunContextKey{}).(DryRunLogger)
+	return ok
+}
+
+// logDryRun passes action to the DryRunLoggers of the client and the context.
+func (s *clientSettings) logDryRun(ctx context.Context, action DryRunAction) {
+	if s.dryRunLog != nil {
+		s.dryRunLog(action)
+	}
+	if log, ok := ctx.Value(dryRunContextKey{}).(DryRunLogger); ok {
+		log(action)
+	}
+}
+
+// logContainerDryRun fills in the details of container in action before
+// logging it with logDryRun.
+func (s *clientSettings) logContainerDryRun(ctx context.Context, container Container, action DryRunAction) error {
+	name, err := container.Name(ctx)
+	if err != nil {
+		return err
+	}
+	action.ContainerType = container.ContainerType()
+	action.Container = name
+	s.logDryRun(ctx, action)
+	return nil
+}
@@ -0,0 +1,103 @@
+package nixplay
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/rawapi"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noRequestClient is a httpx.Client that fails the test if any request is
+// made.
+type noRequestClient struct {
+	t *testing.T
+}
+
+func (c noRequestClient) Do(req *http.Request) (*http.Response, error) {
+	c.t.Errorf("unexpected request %s %s", req.Method, req.URL)
+	return nil, errors.New("unexpected request")
+}
+
+func TestDryRun(t *testing.T) {
+	client := noRequestClient{t: t}
+
+	var logged []DryRunAction
+	settings := &clientSettings{
+		metrics:   nopMetrics{},
+		changes:   &changeNotifier{},
+		dryRunLog: func(action DryRunAction) { logged = append(logged, action) },
+	}
+
+	h := types.MD5Hash(md5.Sum([]byte("photo")))
+	pageFunc := func(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error) {
+		if page > 0 {
+			return nil, nil
+		}
+		p, err := newPhoto(container, client, "photo.jpg", &h, 1, "", 5, "")
+		require.NoError(t, err)
+		return []Photo{p}, nil
+	}
+	c := newContainer(client, nil, settings, types.AlbumContainerType, "album", 1234, 1, pageFunc, (*rawapi.Client).DeleteAlbum, albumAddIDName)
+
+	expActions := []DryRunAction{
+		{Type: types.PhotoAddedChangeType, ContainerType: types.AlbumContainerType, Container: "album", Photo: "new.jpg", Size: 3},
+		{Type: types.PhotoDeletedChangeType, ContainerType: types.AlbumContainerType, Container: "album", Photo: "photo.jpg"},
+		{Type: types.PhotoDeletedChangeType, ContainerType: types.AlbumContainerType, Container: "album", Photo: "photo.jpg"}, // from PendingDeletes.Commit
+		{Type: types.ContainerDeletedChangeType, ContainerType: types.AlbumContainerType, Container: "album"},
+	}
+
+	test := func(t *testing.T, ctx context.Context) {
+		logged = nil
+
+		_, err := c.AddPhoto(ctx, "new.jpg", bytes.NewReader([]byte("new")), AddPhotoOptions{})
+		assert.ErrorIs(t, err, types.ErrDryRun)
+
+		// Validation still happens in dry-run mode.
+		_, err = c.AddPhoto(ctx, "new.txt", bytes.NewReader([]byte("new")), AddPhotoOptions{FileSize: 1 << 40, MaxFileSize: 1 << 30})
+		assert.ErrorIs(t, err, types.ErrFileTooLarge)
+		assert.NotErrorIs(t, err, types.ErrDryRun)
+
+		photos, err := c.Photos(ctx)
+		require.NoError(t, err)
+		require.Len(t, photos, 1)
+		require.NoError(t, photos[0].Delete(ctx, DeleteOptions{}))
+
+		// Committing pending deletes does not empty the queue.
+		var pending PendingDeletes
+		pending.Add(photos[0])
+		result, err := pending.Commit(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, BulkResult{DryRun: 1}, result)
+		assert.Equal(t, []Photo{photos[0]}, pending.Photos())
+
+		require.NoError(t, c.Delete(ctx, DeleteOptions{Force: true}))
+
+		assert.Equal(t, expActions, logged)
+		count, err := c.PhotoCount(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), count)
+	}
+
+	t.Run("WithDryRun", func(t *testing.T) {
+		var contextLogged []DryRunAction
+		ctx := WithDryRun(context.Background(), func(action DryRunAction) {
+			contextLogged = append(contextLogged, action)
+		})
+		test(t, ctx)
+		assert.Equal(t, expActions, contextLogged)
+	})
+
+	t.Run("ClientWide", func(t *testing.T) {
+		settings.dryRun = true
+		defer func() { settings.dryRun = false }()
+		test(t, context.Background())
+	})
+}
@@ -140,3 +140,81 @@ func TestEncoding(t *testing.T) {
 	}
 
 }
+
+func TestNameEncoders(t *testing.T) {
+	names := []string{
+		"", "plain", `"quote"`, `a/b\c`, "\x00ctl\x1f\x7f", "漢字", "\U0001f60a",
+		"full／width＼look＂alike␀␡", "‛", "‛‛", "‛/", "‛／", "‛‛／x‛", "invalid utf-8\xfe",
+		Marker, Marker + "plain", Marker + `\n`,
+	}
+	for _, encoder := range []NameEncoder{GoEscape, Rclone, Unicode, PassThrough, Marked(GoEscape), Marked(Unicode), Marked(PassThrough)} {
+		for _, name := range names {
+			encoded := encoder.Encode(name)
+			decoded, err := encoder.Decode(encoded)
+			assert.NoError(t, err)
+			assert.Equal(t, name, decoded, "%T round trip of %q via %q", encoder, name, encoded)
+		}
+	}
+}
+
+func TestRcloneEncoder(t *testing.T) {
+	tests := []struct {
+		decoded string
+		encoded string
+	}{
+		{"plain", "plain"},
+		{`a/b\c"d`, "a／b＼c＂d"},
+		{"\x00\x1f\x7f", "␀␟␡"},
+		{"漢字\U0001f60a", "漢字\U0001f60a"},
+		{"／", "‛／"},
+		{"‛", "‛"},
+		{"‛/", "‛‛／"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.encoded, Rclone.Encode(tt.decoded))
+		decoded, err := Rclone.Decode(tt.encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.decoded, decoded)
+	}
+}
+
+func TestUnicodeEncoder(t *testing.T) {
+	tests := []struct {
+		decoded string
+		encoded string
+	}{
+		{"plain", "plain"},
+		{`"quote" 'single'`, `"quote" 'single'`},
+		{`back\slash`, `back\\slash`},
+		{"\x00\n\x7f\u0085", `\x00\n` + get_x7F_expEncoding() + `\u0085`},
+		{"漢字 Ｆｕｌｌ café", "漢字 Ｆｕｌｌ café"},
+		{"\U0001f60a", `\U0001f60a`},
+		{"invalid utf-8\xfe", `invalid utf-8\xfe`},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.encoded, Unicode.Encode(tt.decoded))
+		decoded, err := Unicode.Decode(tt.encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.decoded, decoded)
+	}
+
+	_, err := Unicode.Decode(`trailing\`)
+	assert.Error(t, err)
+}
+
+func TestMarked(t *testing.T) {
+	encoder := Marked(GoEscape)
+
+	// Names that do not need encoding are not marked.
+	assert.Equal(t, "plain", encoder.Encode("plain"))
+	assert.Equal(t, Marker+`line\nbreak`, encoder.Encode("line\nbreak"))
+
+	// Names without the marker are not decoded, even if they look encoded.
+	decoded, err := encoder.Decode(`literal\n`)
+	assert.NoError(t, err)
+	assert.Equal(t, `literal\n`, decoded)
+
+	decoded, err = encoder.Decode(Marker + `line\nbreak`)
+	assert.NoError(t, err)
+	assert.Equal(t, "line\nbreak", decoded)
+}
@@ -0,0 +1,46 @@
+package encoding
+
+import "strings"
+
+// Marker is put in front of names that were changed by encoding when using an
+// encoder returned by Marked. It is the invisible separator character so it
+// does not change how names look in the Nixplay app.
+const Marker = "\u2063"
+
+// Marked returns a NameEncoder that only decodes names that it encoded.
+//
+// Names that are changed by encoder, or that already start with Marker, are
+// prefixed with Marker when they are encoded. When decoding, names that do not
+// start with Marker are returned as they are. This prevents names that were
+// created outside of this library, such as a name with a literal `\n` created
+// in the Nixplay app, from being changed by decoding.
+func Marked(encoder NameEncoder) NameEncoder {
+	return markedEncoder{encoder: encoder}
+}
+
+type markedEncoder struct {
+	encoder NameEncoder
+}
+
+func (e markedEncoder) Encode(name string) string {
+	encoded := e.encoder.Encode(name)
+	if encoded == name && !strings.HasPrefix(name, Marker) {
+		return name
+	}
+	return Marker + encoded
+}
+
+func (e markedEncoder) Decode(name string) (string, error) {
+	encoded, ok := cutPrefix(name, Marker)
+	if !ok {
+		return name, nil
+	}
+	return e.encoder.Decode(encoded)
+}
+
+func cutPrefix(s string, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
@@ -0,0 +1,47 @@
+package encoding
+
+// NameEncoder encodes the names of containers and photos before they are sent
+// to Nixplay and decodes the names received from Nixplay.
+//
+// Decode(Encode(name)) must return name for every name. Decode may return an
+// error if the provided string could not have been produced by Encode, in
+// which case the string is used as the name without decoding.
+type NameEncoder interface {
+	Encode(name string) string
+	Decode(name string) (string, error)
+}
+
+var (
+	// GoEscape encodes names with Encode and decodes them with Decode. This is
+	// the default NameEncoder.
+	GoEscape NameEncoder = goEscapeEncoder{}
+
+	// Rclone encodes names using the same substitutions as rclone, see
+	// RcloneEncoder.
+	Rclone NameEncoder = RcloneEncoder{}
+
+	// PassThrough does not change names. It is only suitable for names made
+	// up of characters that Nixplay handles correctly, such as printable
+	// ASCII.
+	PassThrough NameEncoder = passThroughEncoder{}
+)
+
+type goEscapeEncoder struct{}
+
+func (goEscapeEncoder) Encode(name string) string {
+	return Encode(name)
+}
+
+func (goEscapeEncoder) Decode(name string) (string, error) {
+	return Decode(name)
+}
+
+type passThroughEncoder struct{}
+
+func (passThroughEncoder) Encode(name string) string {
+	return name
+}
+
+func (passThroughEncoder) Decode(name string) (string, error) {
+	return name, nil
+}
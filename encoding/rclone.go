@@ -0,0 +1,127 @@
+package encoding
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// rcloneQuote is used to mark a character that should not be decoded, it is
+// the same quote character that rclone uses.
+const rcloneQuote = '‛'
+
+// RcloneEncoder replaces control characters, slashes, backslashes and double
+// quotes with look alike Unicode characters in the same way as the encoder of
+// rclone, for example "/" is replaced with the full width "／". Names encoded by
+// rclone can be decoded by RcloneEncoder and the other way around, so a name
+// looks the same in the Nixplay app as in rclone.
+//
+// Characters that are already one of the look alike characters are prefixed
+// with "‛" so they are not changed by Decode. All other characters, including
+// non-ASCII characters, are left as they are. Note that Nixplay does not
+// correctly handle some non-ASCII characters such as emoji.
+type RcloneEncoder struct{}
+
+// rcloneReplacement returns the look alike character for r, or false if r is
+// not replaced.
+func rcloneReplacement(r rune) (rune, bool) {
+	switch {
+	case r <= 0x1F:
+		return '␀' + r, true
+	case r == 0x7F:
+		return '␡', true
+	case r == '/':
+		return '／', true
+	case r == '\\':
+		return '＼', true
+	case r == '"':
+		return '＂', true
+	}
+	return 0, false
+}
+
+// rcloneOriginal returns the character that r is a look alike for, or false if
+// r is not a look alike character.
+func rcloneOriginal(r rune) (rune, bool) {
+	switch {
+	case r >= '␀' && r <= '␀'+0x1F:
+		return r - '␀', true
+	case r == '␡':
+		return 0x7F, true
+	case r == '／':
+		return '/', true
+	case r == '＼':
+		return '\\', true
+	case r == '＂':
+		return '"', true
+	}
+	return 0, false
+}
+
+// rcloneNeedsQuote returns true if a quote character in front of r would be
+// removed by Decode.
+func rcloneNeedsQuote(r rune) bool {
+	_, replaced := rcloneReplacement(r)
+	_, original := rcloneOriginal(r)
+	return replaced || original || r == rcloneQuote
+}
+
+// nextRune returns the rune after the rune at index i of s.
+func nextRune(s string, i int) rune {
+	_, size := utf8.DecodeRuneInString(s[i:])
+	next, _ := utf8.DecodeRuneInString(s[i+size:])
+	return next
+}
+
+func (RcloneEncoder) Encode(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if replacement, ok := rcloneReplacement(r); ok {
+			b.WriteRune(replacement)
+			continue
+		}
+		if _, ok := rcloneOriginal(r); ok {
+			b.WriteRune(rcloneQuote)
+		}
+		if r == rcloneQuote && rcloneNeedsQuote(nextRune(name, i)) {
+			b.WriteRune(rcloneQuote)
+		}
+		if r == utf8.RuneError {
+			// Keep invalid UTF-8 as it is rather than replacing it.
+			_, size := utf8.DecodeRuneInString(name[i:])
+			b.WriteString(name[i : i+size])
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (RcloneEncoder) Decode(name string) (string, error) {
+	var b strings.Builder
+	quoted := false
+	for i, r := range name {
+		if quoted {
+			quoted = false
+			b.WriteRune(r)
+			continue
+		}
+		if r == rcloneQuote {
+			next := nextRune(name, i)
+			if _, ok := rcloneOriginal(next); ok || next == rcloneQuote {
+				quoted = true
+				continue
+			}
+		}
+		if original, ok := rcloneOriginal(r); ok {
+			b.WriteRune(original)
+			continue
+		}
+		if r == utf8.RuneError {
+			_, size := utf8.DecodeRuneInString(name[i:])
+			b.WriteString(name[i : i+size])
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), nil
+}
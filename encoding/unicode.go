@@ -0,0 +1,74 @@
+package encoding
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Unicode encodes names using Go escape sequences like Encode, but only for the
+// characters in NixplayUnsupported and backslashes (\), which start an escape
+// sequence. All other characters, including CJK characters and other
+// non-ASCII characters, are left as they are so names remain readable in the
+// Nixplay app.
+var Unicode NameEncoder = unicodeEncoder{}
+
+// NixplayUnsupported is the table of characters that Nixplay has been
+// observed not to handle correctly in the names of containers and photos.
+// Nixplay does not document which characters it supports so this table may
+// need to be extended in the future.
+var NixplayUnsupported = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		// C0 control characters such as new lines and tabs.
+		{Lo: 0x0000, Hi: 0x001f, Stride: 1},
+		// DEL and the C1 control characters.
+		{Lo: 0x007f, Hi: 0x009f, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		// Characters outside of the Basic Multilingual Plane, which includes
+		// most emoji, are not stored correctly.
+		{Lo: 0x10000, Hi: unicode.MaxRune, Stride: 1},
+	},
+}
+
+type unicodeEncoder struct{}
+
+func (unicodeEncoder) Encode(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if r == utf8.RuneError {
+			if _, size := utf8.DecodeRuneInString(name[i:]); size == 1 {
+				// Invalid UTF-8 is escaped byte by byte.
+				fmt.Fprintf(&b, `\x%02x`, name[i])
+				continue
+			}
+		}
+		if r == '\\' || unicode.Is(NixplayUnsupported, r) {
+			quoted := strconv.QuoteRuneToASCII(r)
+			b.WriteString(quoted[1 : len(quoted)-1])
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (unicodeEncoder) Decode(name string) (string, error) {
+	buf := make([]byte, 0, len(name))
+	for len(name) > 0 {
+		// A quote of 0 means that quote characters are not escaped.
+		r, multibyte, tail, err := strconv.UnquoteChar(name, 0)
+		if err != nil {
+			return "", err
+		}
+		if r < utf8.RuneSelf || !multibyte {
+			buf = append(buf, byte(r))
+		} else {
+			buf = utf8.AppendRune(buf, r)
+		}
+		name = tail
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,56 @@
+package export
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+
+	nixplay "github.com/anitschke/go-nixplay"
+)
+
+// DirectorySink is a nixplay.DownloadSink that writes photos into a directory,
+// for use with nixplay.Container.DownloadAll. Each photo is written to a file
+// named after Photo.NameUnique.
+//
+// DirectorySink implements nixplay.UpToDateSink, if a file already exists with
+// the same size and MD5 hash as the photo then the photo is reported as up to
+// date rather than downloaded again, which makes repeated backups of the same
+// container cheap. Photos are written to a temporary file and renamed once
+// complete so a failed download never leaves a partial file in place of a
+// photo.
+type DirectorySink struct {
+	// Dir is the directory photos are written to. It must already exist.
+	Dir string
+}
+
+var _ nixplay.UpToDateSink = DirectorySink{}
+
+// WritePhoto writes the content of p to a file in the directory.
+func (s DirectorySink) WritePhoto(ctx context.Context, p nixplay.Photo, r io.Reader) error {
+	path, err := s.path(ctx, p)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+		return err
+	})
+}
+
+// UpToDate returns true if the directory already contains a file with the same
+// size and MD5 hash as p. The content of p is not downloaded.
+func (s DirectorySink) UpToDate(ctx context.Context, p nixplay.Photo) (bool, error) {
+	path, err := s.path(ctx, p)
+	if err != nil {
+		return false, err
+	}
+	return alreadyDownloaded(ctx, p, path)
+}
+
+func (s DirectorySink) path(ctx context.Context, p nixplay.Photo) (string, error) {
+	name, err := p.NameUnique(ctx)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(s.Dir, safeFileName(name)), nil
+}
@@ -0,0 +1,55 @@
+package export
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectorySink(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	a := newFakePhoto(1, "a.jpg", "aaa")
+	b := newFakePhoto(2, "b/c.jpg", "bbb")
+	photos := []nixplay.Photo{a, b}
+	sink := DirectorySink{Dir: dir}
+
+	result, err := nixplay.DownloadPhotos(ctx, photos, sink, nixplay.DownloadAllOptions{VerifyMD5: true})
+	require.NoError(t, err)
+	require.Len(t, result.Photos, 2)
+	for _, r := range result.Photos {
+		assert.NoError(t, r.Err)
+		assert.False(t, r.UpToDate)
+		assert.Equal(t, int64(3), r.Bytes)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "a.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "aaa", string(content))
+	content, err = os.ReadFile(filepath.Join(dir, "b_c.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "bbb", string(content))
+
+	// Files that are already up to date are not downloaded again while files
+	// that have changed are.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b_c.jpg"), []byte("xxx"), 0o644))
+	result, err = nixplay.DownloadPhotos(ctx, photos, sink, nixplay.DownloadAllOptions{VerifyMD5: true})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Succeeded)
+	require.Len(t, result.Photos, 2)
+	assert.True(t, result.Photos[0].UpToDate)
+	assert.Equal(t, 0, result.Photos[0].Attempts)
+	assert.False(t, result.Photos[1].UpToDate)
+	assert.Equal(t, int64(1), a.downloads)
+	assert.Equal(t, int64(2), b.downloads)
+
+	content, err = os.ReadFile(filepath.Join(dir, "b_c.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "bbb", string(content))
+}
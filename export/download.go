@@ -0,0 +1,232 @@
+package export
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/internal/errorx"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// defaultDownloadConcurrency is the number of photos downloaded concurrently
+// if DownloadOptions.Concurrency is not specified.
+const defaultDownloadConcurrency = 4
+
+// sidecarExt is the extension added to the name of a photo to form the name of
+// its sidecar metadata file.
+const sidecarExt = ".json"
+
+// DownloadOptions are optional arguments that may be specified when
+// downloading an account with Download.
+type DownloadOptions struct {
+	// Concurrency is the number of photos to download concurrently. If zero a
+	// default of 4 is used.
+	Concurrency int
+
+	// IncludePlaylists specifies that playlists should be downloaded in
+	// addition to albums. Photos in playlists are also contained in an album
+	// so this will usually download the same photos more than once.
+	IncludePlaylists bool
+
+	// Sidecars specifies that a JSON file containing the metadata of each
+	// photo, as it would appear in a Manifest, should be written next to the
+	// photo with the same name plus a ".json" extension.
+	Sidecars bool
+}
+
+// DownloadResult describes the outcome of downloading an account.
+type DownloadResult struct {
+	// Downloaded is the number of photos that were downloaded.
+	Downloaded int64
+
+	// Skipped is the number of photos that were not downloaded because a file
+	// with the same size and MD5 hash already existed.
+	Skipped int64
+}
+
+type downloadJob struct {
+	photo nixplay.Photo
+	dir   string
+}
+
+// Download mirrors the photos in every album of the account to destDir. Each
+// album is downloaded to a directory with the same name as the album.
+//
+// Download can be resumed, if a file already exists with the same size and
+// MD5 hash as the photo then the photo is not downloaded again. Photos are
+// downloaded to a temporary file and renamed once complete so a canceled
+// download never leaves a partial file in place of a photo.
+func Download(ctx context.Context, client nixplay.Client, destDir string, opts DownloadOptions) (retResult DownloadResult, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultDownloadConcurrency
+	}
+
+	containerTypes := []types.ContainerType{types.AlbumContainerType}
+	if opts.IncludePlaylists {
+		containerTypes = append(containerTypes, types.PlaylistContainerType)
+	}
+
+	var jobs []downloadJob
+	for _, containerType := range containerTypes {
+		containers, err := client.Containers(ctx, containerType)
+		if err != nil {
+			return DownloadResult{}, err
+		}
+		for _, c := range containers {
+			name, err := c.NameUnique(ctx)
+			if err != nil {
+				return DownloadResult{}, err
+			}
+			dir := filepath.Join(destDir, string(containerType), safeFileName(name))
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return DownloadResult{}, err
+			}
+
+			photos, err := c.Photos(ctx)
+			if err != nil {
+				return DownloadResult{}, err
+			}
+			for _, p := range photos {
+				jobs = append(jobs, downloadJob{photo: p, dir: dir})
+			}
+		}
+	}
+
+	var downloaded, skipped int64
+	err = forEach(ctx, concurrency, len(jobs), func(ctx context.Context, i int) error {
+		didDownload, err := downloadPhoto(ctx, jobs[i], opts)
+		if err != nil {
+			return err
+		}
+		if didDownload {
+			atomic.AddInt64(&downloaded, 1)
+		} else {
+			atomic.AddInt64(&skipped, 1)
+		}
+		return nil
+	})
+	return DownloadResult{Downloaded: downloaded, Skipped: skipped}, err
+}
+
+// downloadPhoto downloads a single photo into the job's directory. It returns
+// false if the photo was already downloaded.
+func downloadPhoto(ctx context.Context, job downloadJob, opts DownloadOptions) (bool, error) {
+	p := job.photo
+
+	name, err := p.NameUnique(ctx)
+	if err != nil {
+		return false, err
+	}
+	path := filepath.Join(job.dir, safeFileName(name))
+
+	if opts.Sidecars {
+		if err := writeSidecar(ctx, p, path+sidecarExt); err != nil {
+			return false, err
+		}
+	}
+
+	upToDate, err := alreadyDownloaded(ctx, p, path)
+	if err != nil || upToDate {
+		return false, err
+	}
+
+	err = writeFile(path, func(w io.Writer) error {
+		return p.DownloadTo(ctx, w, nixplay.DownloadOptions{VerifyMD5: true})
+	})
+	return err == nil, err
+}
+
+// writeFile writes a file using write. The file is written to a temporary file
+// in the same directory and renamed once complete so that a failure never
+// leaves a partial file at path.
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// alreadyDownloaded returns true if the file at path has the same size and
+// MD5 hash as the photo.
+func alreadyDownloaded(ctx context.Context, p nixplay.Photo, path string) (bool, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	size, err := p.Size(ctx)
+	if err != nil {
+		return false, err
+	}
+	if info.Size() != size {
+		return false, nil
+	}
+
+	expHash, err := p.MD5Hash(ctx)
+	if err != nil {
+		return false, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	hasher := md5.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return false, err
+	}
+	return *(*types.MD5Hash)(hasher.Sum(nil)) == expHash, nil
+}
+
+func writeSidecar(ctx context.Context, p nixplay.Photo, path string) error {
+	mp, err := snapshotPhoto(ctx, p)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(mp, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// safeFileName converts the name of a container or photo into a name that can
+// safely be used as a single element of a file path.
+func safeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', 0:
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == ".." {
+		name = "_" + name
+	}
+	return name
+}
@@ -0,0 +1,71 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownload(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	a := newFakePhoto(1, "a.jpg", "aaa")
+	b := newFakePhoto(2, "b/c.jpg", "bbb")
+	client := &fakeClient{
+		albums: []nixplay.Container{
+			&fakeContainer{containerType: types.AlbumContainerType, id: types.ID{1}, name: "album", photos: []nixplay.Photo{a, b}},
+		},
+		playlists: []nixplay.Container{
+			&fakeContainer{containerType: types.PlaylistContainerType, id: types.ID{2}, name: "playlist", photos: []nixplay.Photo{a}},
+		},
+	}
+
+	result, err := Download(ctx, client, dir, DownloadOptions{Sidecars: true})
+	require.NoError(t, err)
+	assert.Equal(t, DownloadResult{Downloaded: 2}, result)
+
+	albumDir := filepath.Join(dir, string(types.AlbumContainerType), "album")
+	content, err := os.ReadFile(filepath.Join(albumDir, "a.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "aaa", string(content))
+	content, err = os.ReadFile(filepath.Join(albumDir, "b_c.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "bbb", string(content))
+
+	sidecar, err := os.ReadFile(filepath.Join(albumDir, "a.jpg.json"))
+	require.NoError(t, err)
+	var p Photo
+	require.NoError(t, json.Unmarshal(sidecar, &p))
+	assert.Equal(t, "a.jpg", p.Name)
+	assert.Equal(t, int64(3), p.Size)
+
+	// Playlists are skipped unless requested.
+	assert.NoDirExists(t, filepath.Join(dir, string(types.PlaylistContainerType)))
+
+	// Files that are already up to date are not downloaded again while files
+	// that have changed are.
+	require.NoError(t, os.WriteFile(filepath.Join(albumDir, "b_c.jpg"), []byte("xxx"), 0o644))
+	result, err = Download(ctx, client, dir, DownloadOptions{IncludePlaylists: true})
+	require.NoError(t, err)
+	assert.Equal(t, DownloadResult{Downloaded: 2, Skipped: 1}, result)
+	assert.Equal(t, int64(2), a.downloads)
+	assert.Equal(t, int64(2), b.downloads)
+
+	content, err = os.ReadFile(filepath.Join(albumDir, "b_c.jpg"))
+	require.NoError(t, err)
+	assert.Equal(t, "bbb", string(content))
+}
+
+func TestSafeFileName(t *testing.T) {
+	assert.Equal(t, "a_b_c", safeFileName(`a/b\c`))
+	assert.Equal(t, "_..", safeFileName(".."))
+	assert.Equal(t, "_", safeFileName(""))
+}
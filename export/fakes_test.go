@@ -0,0 +1,79 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// fakeClient, fakeContainer and fakePhoto implement just enough of the nixplay
+// interfaces to test this package. Calling any other method panics.
+type fakeClient struct {
+	nixplay.Client
+	albums    []nixplay.Container
+	playlists []nixplay.Container
+}
+
+func (c *fakeClient) Containers(ctx context.Context, containerType types.ContainerType) ([]nixplay.Container, error) {
+	if containerType == types.AlbumContainerType {
+		return c.albums, nil
+	}
+	return c.playlists, nil
+}
+
+type fakeContainer struct {
+	nixplay.Container
+	containerType types.ContainerType
+	id            types.ID
+	name          string
+	photos        []nixplay.Photo
+}
+
+func (c *fakeContainer) ContainerType() types.ContainerType       { return c.containerType }
+func (c *fakeContainer) ID() types.ID                             { return c.id }
+func (c *fakeContainer) Name(ctx context.Context) (string, error) { return c.name, nil }
+func (c *fakeContainer) NameUnique(ctx context.Context) (string, error) {
+	return c.name, nil
+}
+func (c *fakeContainer) Photos(ctx context.Context) ([]nixplay.Photo, error) {
+	return c.photos, nil
+}
+
+type fakePhoto struct {
+	nixplay.Photo
+	id      types.ID
+	name    string
+	content []byte
+	md5Hash types.MD5Hash
+
+	downloads int64
+}
+
+func (p *fakePhoto) ID() types.ID                             { return p.id }
+func (p *fakePhoto) Name(ctx context.Context) (string, error) { return p.name, nil }
+func (p *fakePhoto) NameUnique(ctx context.Context) (string, error) {
+	return p.name, nil
+}
+func (p *fakePhoto) Size(ctx context.Context) (int64, error)            { return int64(len(p.content)), nil }
+func (p *fakePhoto) MD5Hash(ctx context.Context) (types.MD5Hash, error) { return p.md5Hash, nil }
+func (p *fakePhoto) URL(ctx context.Context) (string, error) {
+	return "https://example.com/" + p.name, nil
+}
+func (p *fakePhoto) MediaType(ctx context.Context) (types.MediaType, error) {
+	return types.PhotoMediaType, nil
+}
+func (p *fakePhoto) Duration(ctx context.Context) (time.Duration, error) { return 0, nil }
+func (p *fakePhoto) Open(ctx context.Context) (io.ReadCloser, error) {
+	atomic.AddInt64(&p.downloads, 1)
+	return io.NopCloser(bytes.NewReader(p.content)), nil
+}
+func (p *fakePhoto) DownloadTo(ctx context.Context, w io.Writer, opts nixplay.DownloadOptions) error {
+	atomic.AddInt64(&p.downloads, 1)
+	_, err := w.Write(p.content)
+	return err
+}
@@ -0,0 +1,187 @@
+// Package export provides ways to export the contents of a Nixplay account,
+// such as a manifest of all containers and photos.
+package export
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"time"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/internal/errorx"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// ManifestVersion is the version of the Manifest format produced by Snapshot.
+const ManifestVersion = 1
+
+// snapshotConcurrency is the number of photos that Snapshot gets details for
+// concurrently. Getting some details, such as the size of a photo, may require
+// a request per photo.
+const snapshotConcurrency = 8
+
+// Manifest is a machine readable inventory of all of the containers and
+// photos in a Nixplay account.
+type Manifest struct {
+	Version    int         `json:"version"`
+	CreatedAt  time.Time   `json:"createdAt"`
+	Containers []Container `json:"containers"`
+}
+
+// Container describes an album or playlist in a Manifest.
+type Container struct {
+	Type types.ContainerType `json:"type"`
+
+	// ID is the base64 URL encoding of Container.ID.
+	ID string `json:"id"`
+
+	Name   string  `json:"name"`
+	Photos []Photo `json:"photos"`
+}
+
+// Photo describes a photo in a Manifest.
+type Photo struct {
+	// ID is the base64 URL encoding of Photo.ID.
+	ID string `json:"id"`
+
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+
+	// MD5Hash is the hex encoding of Photo.MD5Hash.
+	MD5Hash string `json:"md5"`
+
+	URL       string          `json:"url"`
+	MediaType types.MediaType `json:"mediaType"`
+	Duration  time.Duration   `json:"duration,omitempty"`
+}
+
+// Snapshot produces a Manifest of all of the albums and playlists in the
+// account and the photos within them.
+//
+// Getting the size of photos, and the names of photos in playlists, may
+// require a request per photo so producing a snapshot of a large account can
+// take some time.
+func Snapshot(ctx context.Context, client nixplay.Client) (retManifest *Manifest, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	var containers []nixplay.Container
+	for _, containerType := range []types.ContainerType{types.AlbumContainerType, types.PlaylistContainerType} {
+		cs, err := client.Containers(ctx, containerType)
+		if err != nil {
+			return nil, err
+		}
+		containers = append(containers, cs...)
+	}
+
+	return SnapshotContainers(ctx, containers)
+}
+
+// SnapshotContainers produces a Manifest of only the specified containers and
+// the photos within them.
+func SnapshotContainers(ctx context.Context, containers []nixplay.Container) (retManifest *Manifest, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	m := &Manifest{
+		Version:    ManifestVersion,
+		CreatedAt:  time.Now().UTC(),
+		Containers: make([]Container, 0, len(containers)),
+	}
+	for _, c := range containers {
+		mc, err := snapshotContainer(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		m.Containers = append(m.Containers, mc)
+	}
+
+	return m, nil
+}
+
+func snapshotContainer(ctx context.Context, c nixplay.Container) (Container, error) {
+	name, err := c.Name(ctx)
+	if err != nil {
+		return Container{}, err
+	}
+	id := c.ID()
+
+	photos, err := c.Photos(ctx)
+	if err != nil {
+		return Container{}, err
+	}
+
+	mc := Container{
+		Type:   c.ContainerType(),
+		ID:     base64.URLEncoding.EncodeToString(id[:]),
+		Name:   name,
+		Photos: make([]Photo, len(photos)),
+	}
+
+	err = forEach(ctx, snapshotConcurrency, len(photos), func(ctx context.Context, i int) error {
+		mp, err := snapshotPhoto(ctx, photos[i])
+		if err != nil {
+			return err
+		}
+		mc.Photos[i] = mp
+		return nil
+	})
+	if err != nil {
+		return Container{}, err
+	}
+	return mc, nil
+}
+
+func snapshotPhoto(ctx context.Context, p nixplay.Photo) (Photo, error) {
+	id := p.ID()
+	name, err := p.Name(ctx)
+	if err != nil {
+		return Photo{}, err
+	}
+	size, err := p.Size(ctx)
+	if err != nil {
+		return Photo{}, err
+	}
+	md5Hash, err := p.MD5Hash(ctx)
+	if err != nil {
+		return Photo{}, err
+	}
+	url, err := p.URL(ctx)
+	if err != nil {
+		return Photo{}, err
+	}
+	mediaType, err := p.MediaType(ctx)
+	if err != nil {
+		return Photo{}, err
+	}
+	duration, err := p.Duration(ctx)
+	if err != nil {
+		return Photo{}, err
+	}
+
+	return Photo{
+		ID:        base64.URLEncoding.EncodeToString(id[:]),
+		Name:      name,
+		Size:      size,
+		MD5Hash:   md5Hash.String(),
+		URL:       url,
+		MediaType: mediaType,
+		Duration:  duration,
+	}, nil
+}
+
+// WriteJSON writes the manifest as indented JSON.
+func (m *Manifest) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// ReadManifest reads a manifest that was written by Manifest.WriteJSON.
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	var m Manifest
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
@@ -0,0 +1,67 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"testing"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakePhoto(id byte, name string, content string) *fakePhoto {
+	return &fakePhoto{
+		id:      types.ID{id},
+		name:    name,
+		content: []byte(content),
+		md5Hash: md5.Sum([]byte(content)),
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+
+	var photos []nixplay.Photo
+	for i := 0; i < 20; i++ {
+		photos = append(photos, newFakePhoto(byte(i), "photo.jpg", string(rune('a'+i))))
+	}
+	client := &fakeClient{
+		albums: []nixplay.Container{
+			&fakeContainer{containerType: types.AlbumContainerType, id: types.ID{1}, name: "album", photos: photos},
+		},
+		playlists: []nixplay.Container{
+			&fakeContainer{containerType: types.PlaylistContainerType, id: types.ID{2}, name: "playlist"},
+		},
+	}
+
+	m, err := Snapshot(ctx, client)
+	require.NoError(t, err)
+	assert.Equal(t, ManifestVersion, m.Version)
+	require.Len(t, m.Containers, 2)
+
+	album := m.Containers[0]
+	assert.Equal(t, types.AlbumContainerType, album.Type)
+	assert.Equal(t, "album", album.Name)
+	require.Len(t, album.Photos, 20)
+	assert.Equal(t, Photo{
+		ID:        "AwAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+		Name:      "photo.jpg",
+		Size:      1,
+		MD5Hash:   "8277e0910d750195b448797616e091ad",
+		URL:       "https://example.com/photo.jpg",
+		MediaType: types.PhotoMediaType,
+	}, album.Photos[3])
+
+	assert.Equal(t, types.PlaylistContainerType, m.Containers[1].Type)
+	assert.Empty(t, m.Containers[1].Photos)
+
+	var buf bytes.Buffer
+	require.NoError(t, m.WriteJSON(&buf))
+	read, err := ReadManifest(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, m.Containers, read.Containers)
+	assert.True(t, m.CreatedAt.Equal(read.CreatedAt))
+}
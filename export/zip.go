@@ -0,0 +1,78 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"path"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/internal/errorx"
+)
+
+// ZipManifestName is the name of the entry in the archive written by Zip that
+// contains the Manifest of the container.
+const ZipManifestName = "manifest.json"
+
+// Zip writes a zip archive of all of the photos in container to w. The photos
+// are placed in a directory with the same name as the container and the
+// archive also contains a Manifest of the container, see ZipManifestName.
+//
+// The archive is streamed to w as each photo is downloaded so nothing is
+// staged on disk, which also means that if Zip fails part way through w will
+// contain an incomplete archive. Photos are stored without compression since
+// photos and videos are already compressed.
+func Zip(ctx context.Context, container nixplay.Container, w io.Writer) (err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	m, err := SnapshotContainers(ctx, []nixplay.Container{container})
+	if err != nil {
+		return err
+	}
+
+	// The photos are listed again rather than using the manifest so that we
+	// can download them, the container caches them so this is cheap.
+	photos, err := container.Photos(ctx)
+	if err != nil {
+		return err
+	}
+	dirName, err := container.NameUnique(ctx)
+	if err != nil {
+		return err
+	}
+	dir := safeFileName(dirName)
+
+	zw := zip.NewWriter(w)
+
+	mw, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     ZipManifestName,
+		Method:   zip.Deflate,
+		Modified: m.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	if err := m.WriteJSON(mw); err != nil {
+		return err
+	}
+
+	for _, p := range photos {
+		name, err := p.NameUnique(ctx)
+		if err != nil {
+			return err
+		}
+		pw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     path.Join(dir, safeFileName(name)),
+			Method:   zip.Store,
+			Modified: m.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if err := p.DownloadTo(ctx, pw, nixplay.DownloadOptions{VerifyMD5: true}); err != nil {
+			return err
+		}
+	}
+
+	return zw.Close()
+}
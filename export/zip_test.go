@@ -0,0 +1,48 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZip(t *testing.T) {
+	ctx := context.Background()
+
+	a := newFakePhoto(1, "a.jpg", "aaa")
+	b := newFakePhoto(2, "b/c.jpg", "bbb")
+	container := &fakeContainer{containerType: types.AlbumContainerType, id: types.ID{1}, name: "album", photos: []nixplay.Photo{a, b}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Zip(ctx, container, &buf))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	entries := map[string]string{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+		entries[f.Name] = string(content)
+	}
+	require.Len(t, entries, 3)
+	assert.Equal(t, "aaa", entries["album/a.jpg"])
+	assert.Equal(t, "bbb", entries["album/b_c.jpg"])
+
+	m, err := ReadManifest(bytes.NewReader([]byte(entries[ZipManifestName])))
+	require.NoError(t, err)
+	require.Len(t, m.Containers, 1)
+	assert.Equal(t, "album", m.Containers[0].Name)
+	require.Len(t, m.Containers[0].Photos, 2)
+	assert.Equal(t, "b/c.jpg", m.Containers[0].Photos[1].Name)
+}
@@ -0,0 +1,91 @@
+package nixplay
+
+import (
+	"context"
+
+	"github.com/anitschke/go-nixplay/internal/errorx"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// IsFavorite reports if the favorites playlist, see Client.Favorites, contains a photo with the same
+// content as p.
+func IsFavorite(ctx context.Context, client Client, p Photo) (retFavorite bool, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	favorites, err := favoritesWithContent(ctx, client, p)
+	if err != nil {
+		return false, err
+	}
+	return len(favorites) > 0, nil
+}
+
+// AddFavorite marks p as a favorite by adding it to the favorites playlist and
+// returns the photo in the favorites playlist. If p is already a favorite then
+// the existing photo in the favorites playlist is returned.
+//
+// The favorites playlist refers to p rather than a copy of it, see
+// Container.AddSlideFromPhoto.
+func AddFavorite(ctx context.Context, client Client, p Photo) (retPhoto Photo, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	existing, err := favoritesWithContent(ctx, client, p)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return existing[0], nil
+	}
+
+	favorites, err := client.Favorites(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return favorites.AddSlideFromPhoto(ctx, p)
+}
+
+// RemoveFavorite unmarks p as a favorite by removing every photo with the same
+// content as p from the favorites playlist. The photo is only removed from the
+// favorites playlist, it is not deleted from the album that contains it.
+func RemoveFavorite(ctx context.Context, client Client, p Photo) (err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	favorites, err := favoritesWithContent(ctx, client, p)
+	if err != nil {
+		return err
+	}
+	for _, f := range favorites {
+		if err := f.Delete(ctx, DeleteOptions{Scope: types.ContainerOnlyDeleteScope}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// favoritesWithContent returns the photos in the favorites playlist that have
+// the same content as p.
+func favoritesWithContent(ctx context.Context, client Client, p Photo) ([]Photo, error) {
+	hash, err := p.MD5Hash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	favorites, err := client.Favorites(ctx)
+	if err != nil {
+		return nil, err
+	}
+	photos, err := favorites.Photos(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Photo
+	for _, f := range photos {
+		fHash, err := f.MD5Hash(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if fHash == hash {
+			matches = append(matches, f)
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,65 @@
+package nixplay_test
+
+import (
+	"context"
+	"testing"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/nixplaytest"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFavorites(t *testing.T) {
+	ctx := context.Background()
+	client := nixplaytest.NewFakeClient()
+
+	_, err := client.Favorites(ctx)
+	assert.ErrorIs(t, err, types.ErrNotFound)
+
+	favorites := client.AddContainer(types.PlaylistContainerType, nixplaytest.FavoritesPlaylistName)
+	album := client.AddContainer(types.AlbumContainerType, "album")
+	photo := album.AddPhotoContent("photo.jpg", []byte("photo"))
+	other := album.AddPhotoContent("other.jpg", []byte("other"))
+
+	found, err := client.Favorites(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, favorites, found)
+	assert.ErrorIs(t, found.Delete(ctx, nixplay.DeleteOptions{}), types.ErrSpecialContainer)
+
+	isFavorite, err := nixplay.IsFavorite(ctx, client, photo)
+	require.NoError(t, err)
+	assert.False(t, isFavorite)
+
+	added, err := nixplay.AddFavorite(ctx, client, photo)
+	require.NoError(t, err)
+	name, err := added.Name(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "photo.jpg", name)
+
+	// Adding a photo that is already a favorite does not add another copy.
+	again, err := nixplay.AddFavorite(ctx, client, photo)
+	require.NoError(t, err)
+	assert.Equal(t, added.ID(), again.ID())
+	count, err := favorites.PhotoCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	isFavorite, err = nixplay.IsFavorite(ctx, client, photo)
+	require.NoError(t, err)
+	assert.True(t, isFavorite)
+	isFavorite, err = nixplay.IsFavorite(ctx, client, other)
+	require.NoError(t, err)
+	assert.False(t, isFavorite)
+
+	require.NoError(t, nixplay.RemoveFavorite(ctx, client, photo))
+	isFavorite, err = nixplay.IsFavorite(ctx, client, photo)
+	require.NoError(t, err)
+	assert.False(t, isFavorite)
+
+	// Only the favorite is removed, not the photo in the album.
+	photos, err := album.Photos(ctx)
+	require.NoError(t, err)
+	assert.Len(t, photos, 2)
+}
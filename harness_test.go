@@ -0,0 +1,131 @@
+package nixplay
+
+import (
+	"context"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The tests in this package run against a real Nixplay account. To allow
+// several runs of the tests to share one account at the same time (for
+// example CI runs for two PRs) every container the tests create is named
+// within a namespace that is unique to the run, and the tests only make
+// assertions about and clean up the things they created.
+
+const testRunIDEnvVar = "GO_NIXPLAY_TEST_RUN_ID"
+
+var (
+	testRunIDOnce sync.Once
+	testRunIDVal  string
+)
+
+// testRunID returns the ID of this run of the tests. It is taken from the
+// "GO_NIXPLAY_TEST_RUN_ID" environment variable if set, otherwise a random ID
+// is used.
+func testRunID() string {
+	testRunIDOnce.Do(func() {
+		testRunIDVal = os.Getenv(testRunIDEnvVar)
+		if testRunIDVal == "" {
+			testRunIDVal = strconv.FormatUint(uint64(rand.Uint32()), 36)
+		}
+	})
+	return testRunIDVal
+}
+
+// testNamespace is the prefix of the names of all containers created by this
+// run of the tests.
+func testNamespace() string {
+	return "go-nixplay-test-" + testRunID() + "-"
+}
+
+func inTestNamespace(name string) bool {
+	return strings.HasPrefix(name, testNamespace())
+}
+
+// randomName returns a random name within the test namespace.
+func randomName() string {
+	return testNamespace() + strconv.FormatUint(rand.Uint64(), 36)
+}
+
+// namespacedContainerNames returns the names of the containers that are
+// within the test namespace.
+func namespacedContainerNames(t *testing.T, containers []Container) []string {
+	names := []string{}
+	for _, c := range containers {
+		name, err := c.Name(context.Background())
+		assert.NoError(t, err)
+		if inTestNamespace(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func containerIDs(containers []Container) []types.ID {
+	ids := make([]types.ID, 0, len(containers))
+	for _, c := range containers {
+		ids = append(ids, c.ID())
+	}
+	return ids
+}
+
+// addMyUploadsCleanup deletes the photos that the test uploads from the "My
+// Uploads" album once the test finishes. Nixplay adds every photo uploaded to
+// a playlist to "My Uploads" so deleting the playlist is not enough to clean
+// up after the test.
+//
+// Only photos uploaded through c during the test are deleted so that photos
+// uploaded by other runs of the tests sharing the account are left alone.
+func addMyUploadsCleanup(t *testing.T, c Client) {
+	var mu sync.Mutex
+	uploaded := make(map[types.MD5Hash]bool)
+	removeListener := c.AddChangeListener(func(event ChangeEvent) {
+		if event.Type != types.PhotoAddedChangeType || event.Refreshed {
+			return
+		}
+		md5Hash, err := event.Photo.MD5Hash(context.Background())
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		uploaded[md5Hash] = true
+		mu.Unlock()
+	})
+
+	t.Cleanup(func() {
+		removeListener()
+		mu.Lock()
+		defer mu.Unlock()
+		deletePhotosFromMyUploads(t, c, uploaded)
+	})
+}
+
+func deletePhotosFromMyUploads(t *testing.T, c Client, md5Hashes map[types.MD5Hash]bool) {
+	if len(md5Hashes) == 0 {
+		return
+	}
+
+	ctx := WithFreshData(context.Background())
+	myUploads, err := c.ContainersWithName(ctx, types.AlbumContainerType, "My Uploads")
+	require.NoError(t, err)
+	require.Len(t, myUploads, 1)
+
+	photos, err := myUploads[0].Photos(ctx)
+	require.NoError(t, err)
+
+	for _, p := range photos {
+		md5Hash, err := p.MD5Hash(ctx)
+		if !assert.NoError(t, err) || !md5Hashes[md5Hash] {
+			continue
+		}
+		assert.NoError(t, p.Delete(ctx, DeleteOptions{}))
+	}
+}
@@ -0,0 +1,127 @@
+package nixplay
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// HashPolicy controls how the hashes returned by Photo.Hash are computed.
+//
+// Nixplay only reports the MD5 hash of photos, any other hash has to be
+// computed from the content of the photo. This is free when the photo is
+// uploaded by this client since the content passes through the client
+// anyway, for other photos the content has to be downloaded once. Computed
+// hashes are cached along with the other data of the photo.
+type HashPolicy struct {
+	// Upload are the hash types that are computed while photos are uploaded,
+	// so that Photo.Hash does not need to download photos uploaded by this
+	// client. The MD5 hash is always computed.
+	Upload []types.HashType
+
+	// Hashers adds support for hash types other than the built in
+	// types.MD5HashType, types.SHA1HashType and types.SHA256HashType, or
+	// replaces the implementation of a built in hash type other than MD5.
+	Hashers map[types.HashType]func() hash.Hash
+
+	// NoDownload specifies that Photo.Hash should not download a photo to
+	// compute a hash that is not already known, types.ErrHashUnavailable is
+	// returned instead.
+	NoDownload bool
+}
+
+var builtinHashers = map[types.HashType]func() hash.Hash{
+	types.MD5HashType:    md5.New,
+	types.SHA1HashType:   sha1.New,
+	types.SHA256HashType: sha256.New,
+}
+
+// New returns a new hash.Hash for the hash type. If the hash type is not
+// supported then an error wrapping types.ErrUnsupportedHashType is returned.
+func (p HashPolicy) New(hashType types.HashType) (hash.Hash, error) {
+	if hashType != types.MD5HashType {
+		if newHash, ok := p.Hashers[hashType]; ok {
+			return newHash(), nil
+		}
+	}
+	if newHash, ok := builtinHashers[hashType]; ok {
+		return newHash(), nil
+	}
+	return nil, fmt.Errorf("%w: %q", types.ErrUnsupportedHashType, hashType)
+}
+
+// types returns all of the hash types that are supported by the policy.
+func (p HashPolicy) types() []types.HashType {
+	hashTypes := make([]types.HashType, 0, len(builtinHashers)+len(p.Hashers))
+	for t := range builtinHashers {
+		hashTypes = append(hashTypes, t)
+	}
+	for t := range p.Hashers {
+		if _, ok := builtinHashers[t]; !ok {
+			hashTypes = append(hashTypes, t)
+		}
+	}
+	return hashTypes
+}
+
+func (p HashPolicy) validate() error {
+	for _, t := range p.Upload {
+		if _, err := p.New(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashSet computes several hashes of the same content at once.
+type hashSet struct {
+	hashes map[types.HashType]hash.Hash
+	w      io.Writer
+}
+
+// newHashSet returns a hashSet that computes MD5 hash as well as the hashes in
+// hashTypes.
+func newHashSet(policy HashPolicy, hashTypes []types.HashType) (*hashSet, error) {
+	s := &hashSet{hashes: map[types.HashType]hash.Hash{types.MD5HashType: md5.New()}}
+	writers := []io.Writer{s.hashes[types.MD5HashType]}
+	for _, t := range hashTypes {
+		if _, ok := s.hashes[t]; ok {
+			continue
+		}
+		h, err := policy.New(t)
+		if err != nil {
+			return nil, err
+		}
+		s.hashes[t] = h
+		writers = append(writers, h)
+	}
+	s.w = io.MultiWriter(writers...)
+	return s, nil
+}
+
+func (s *hashSet) Write(b []byte) (int, error) {
+	return s.w.Write(b)
+}
+
+// md5Hash returns the MD5 hash of the content written so far.
+func (s *hashSet) md5Hash() types.MD5Hash {
+	return *(*types.MD5Hash)(s.hashes[types.MD5HashType].Sum(nil))
+}
+
+// sums returns the hex encoded hashes of the content written so far, other
+// than the MD5 hash.
+func (s *hashSet) sums() map[types.HashType]string {
+	sums := make(map[types.HashType]string, len(s.hashes)-1)
+	for t, h := range s.hashes {
+		if t != types.MD5HashType {
+			sums[t] = hex.EncodeToString(h.Sum(nil))
+		}
+	}
+	return sums
+}
@@ -0,0 +1,126 @@
+package nixplay
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/rawapi"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPhoto_Hash(t *testing.T) {
+	ctx := context.Background()
+
+	content := "photo content"
+	h := types.MD5Hash(md5.Sum([]byte(content)))
+	url := "https://s3.example.com/1/1_" + h.String() + ".jpg"
+	sha1Sum := sha1.Sum([]byte(content))
+	sha256Sum := sha256.Sum256([]byte(content))
+	crc32Type := types.HashType("crc32")
+
+	newTestPhoto := func(t *testing.T, policy HashPolicy, body string) (*photo, *int) {
+		downloads := 0
+		client := clientFunc(func(req *http.Request) (*http.Response, error) {
+			require.Equal(t, url, req.URL.String())
+			downloads++
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}, nil
+		})
+		settings := &clientSettings{
+			metrics: nopMetrics{},
+			changes: &changeNotifier{},
+			hashes:  policy,
+		}
+		pageFunc := func(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error) {
+			return nil, nil
+		}
+		c := newContainer(client, nil, settings, types.AlbumContainerType, "album", 1234, 0, pageFunc, (*rawapi.Client).DeleteAlbum, albumAddIDName)
+		p, err := newPhoto(c, client, "photo.jpg", &h, 7, "", int64(len(content)), url)
+		require.NoError(t, err)
+		return p, &downloads
+	}
+
+	t.Run("MD5", func(t *testing.T) {
+		p, downloads := newTestPhoto(t, HashPolicy{}, content)
+		got, err := p.Hash(ctx, types.MD5HashType)
+		require.NoError(t, err)
+		assert.Equal(t, h.String(), got)
+		assert.Equal(t, 0, *downloads)
+	})
+
+	t.Run("DownloadedOnce", func(t *testing.T) {
+		p, downloads := newTestPhoto(t, HashPolicy{}, content)
+		got, err := p.Hash(ctx, types.SHA256HashType)
+		require.NoError(t, err)
+		assert.Equal(t, hex.EncodeToString(sha256Sum[:]), got)
+
+		// Every hash is computed by the first download.
+		got, err = p.Hash(ctx, types.SHA1HashType)
+		require.NoError(t, err)
+		assert.Equal(t, hex.EncodeToString(sha1Sum[:]), got)
+		assert.Equal(t, 1, *downloads)
+	})
+
+	t.Run("CustomHasher", func(t *testing.T) {
+		policy := HashPolicy{Hashers: map[types.HashType]func() hash.Hash{
+			crc32Type: func() hash.Hash { return crc32.NewIEEE() },
+		}}
+		p, _ := newTestPhoto(t, policy, content)
+		got, err := p.Hash(ctx, crc32Type)
+		require.NoError(t, err)
+		expHasher := crc32.NewIEEE()
+		expHasher.Write([]byte(content))
+		assert.Equal(t, hex.EncodeToString(expHasher.Sum(nil)), got)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		p, downloads := newTestPhoto(t, HashPolicy{}, content)
+		_, err := p.Hash(ctx, crc32Type)
+		assert.ErrorIs(t, err, types.ErrUnsupportedHashType)
+		assert.Equal(t, 0, *downloads)
+	})
+
+	t.Run("NoDownload", func(t *testing.T) {
+		p, downloads := newTestPhoto(t, HashPolicy{NoDownload: true}, content)
+		_, err := p.Hash(ctx, types.SHA256HashType)
+		assert.ErrorIs(t, err, types.ErrHashUnavailable)
+		assert.Equal(t, 0, *downloads)
+	})
+
+	t.Run("MD5Mismatch", func(t *testing.T) {
+		p, downloads := newTestPhoto(t, HashPolicy{}, "corrupt content")
+		_, err := p.Hash(ctx, types.SHA256HashType)
+		assert.ErrorIs(t, err, types.ErrMD5Mismatch)
+
+		// Hashes of corrupt content are not cached.
+		_, err = p.Hash(ctx, types.SHA256HashType)
+		assert.ErrorIs(t, err, types.ErrMD5Mismatch)
+		assert.Equal(t, 2, *downloads)
+	})
+
+	t.Run("Uploaded", func(t *testing.T) {
+		p, downloads := newTestPhoto(t, HashPolicy{NoDownload: true}, content)
+		c := p.container.(*container)
+		c.uploadFinished(p, uploadedPhoto{hashes: map[types.HashType]string{types.SHA256HashType: "computed during upload"}})
+		got, err := p.Hash(ctx, types.SHA256HashType)
+		require.NoError(t, err)
+		assert.Equal(t, "computed during upload", got)
+		assert.Equal(t, 0, *downloads)
+	})
+}
+
+func TestHashPolicy_Validate(t *testing.T) {
+	assert.NoError(t, HashPolicy{Upload: []types.HashType{types.SHA1HashType, types.SHA256HashType}}.validate())
+	assert.ErrorIs(t, HashPolicy{Upload: []types.HashType{"crc32"}}.validate(), types.ErrUnsupportedHashType)
+}
@@ -0,0 +1,122 @@
+package httpx
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// acceptEncoding is the value of the Accept-Encoding header sent by a
+// decompressing client.
+const acceptEncoding = "gzip, deflate"
+
+// decompressingClient is a Client that asks for compressed responses and
+// decompresses them.
+type decompressingClient struct {
+	client Client
+}
+
+// NewDecompressingClient returns a Client that sends requests using the
+// provided client with an Accept-Encoding header asking for gzip or deflate
+// compressed responses, and transparently decompresses the bodies of responses
+// that are compressed.
+//
+// http.Transport already does this for gzip unless DisableCompression is set,
+// but only when it adds the Accept-Encoding header itself, this client makes
+// sure compression is used regardless of the client it wraps.
+//
+// Requests that already have an Accept-Encoding or a Range header are sent
+// unchanged, a range of a compressed body is not useful to the caller.
+// Decompressed responses have their Content-Encoding and Content-Length
+// headers removed and Uncompressed set to true.
+func NewDecompressingClient(client Client) Client {
+	return &decompressingClient{
+		client: client,
+	}
+}
+
+func (c *decompressingClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") != "" || req.Header.Get("Range") != "" {
+		return c.client.Do(req)
+	}
+
+	// A client must not modify the request it is given so the header is set
+	// on a copy.
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return resp, err
+	}
+	decompressResponse(resp)
+	return resp, nil
+}
+
+// decompressResponse replaces the body of resp with a decompressed body if it
+// is gzip or deflate compressed.
+func decompressResponse(resp *http.Response) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if encoding != "gzip" && encoding != "deflate" {
+		return
+	}
+	resp.Body = &decompressingBody{body: resp.Body, encoding: encoding}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// decompressingBody decompresses body as it is read. The decompressor is
+// created on the first read so that empty bodies, such as the body of a
+// response to a HEAD request, do not cause an error.
+type decompressingBody struct {
+	body     io.ReadCloser
+	encoding string
+	r        io.Reader
+	err      error
+}
+
+func (b *decompressingBody) Read(p []byte) (int, error) {
+	if b.r == nil && b.err == nil {
+		r, err := b.newReader()
+		if err != nil {
+			b.err = err
+		} else {
+			b.r = r
+		}
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.r.Read(p)
+}
+
+func (b *decompressingBody) newReader() (io.Reader, error) {
+	if b.encoding == "gzip" {
+		return gzip.NewReader(b.body)
+	}
+
+	// The deflate content coding is meant to be zlib wrapped deflate data but
+	// some servers send raw deflate data, so check for a zlib header.
+	br := bufio.NewReader(b.body)
+	header, err := br.Peek(2)
+	if err != nil && len(header) < 2 {
+		return nil, err
+	}
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+func (b *decompressingBody) Close() error {
+	if c, ok := b.r.(io.Closer); ok {
+		c.Close()
+	}
+	return b.body.Close()
+}
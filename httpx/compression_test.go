@@ -0,0 +1,121 @@
+package httpx
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecompressingClient(t *testing.T) {
+	const content = `{"albums":[{"title":"album"}]}`
+
+	compress := func(newWriter func(w io.Writer) io.WriteCloser) []byte {
+		var buf bytes.Buffer
+		w := newWriter(&buf)
+		_, err := w.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return buf.Bytes()
+	}
+
+	type testData struct {
+		name            string
+		requestHeader   http.Header
+		encoding        string
+		body            []byte
+		expAccept       string
+		expBody         []byte
+		expUncompressed bool
+	}
+
+	testCases := []testData{
+		{
+			name:            "gzip",
+			encoding:        "gzip",
+			body:            compress(func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) }),
+			expAccept:       "gzip, deflate",
+			expBody:         []byte(content),
+			expUncompressed: true,
+		},
+		{
+			name:            "zlibDeflate",
+			encoding:        "deflate",
+			body:            compress(func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) }),
+			expAccept:       "gzip, deflate",
+			expBody:         []byte(content),
+			expUncompressed: true,
+		},
+		{
+			name:     "rawDeflate",
+			encoding: "deflate",
+			body: compress(func(w io.Writer) io.WriteCloser {
+				fw, err := flate.NewWriter(w, flate.DefaultCompression)
+				require.NoError(t, err)
+				return fw
+			}),
+			expAccept:       "gzip, deflate",
+			expBody:         []byte(content),
+			expUncompressed: true,
+		},
+		{
+			name:      "notCompressed",
+			body:      []byte(content),
+			expAccept: "gzip, deflate",
+			expBody:   []byte(content),
+		},
+		{
+			name:            "emptyBody",
+			encoding:        "gzip",
+			body:            []byte{},
+			expAccept:       "gzip, deflate",
+			expBody:         []byte{},
+			expUncompressed: true,
+		},
+		{
+			name:          "rangeRequest",
+			requestHeader: http.Header{"Range": []string{"bytes=0-0"}},
+			body:          []byte("{"),
+			expBody:       []byte("{"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var accept string
+			inner := clientFunc(func(req *http.Request) (*http.Response, error) {
+				accept = req.Header.Get("Accept-Encoding")
+				header := http.Header{}
+				if tc.encoding != "" {
+					header.Set("Content-Encoding", tc.encoding)
+				}
+				return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(bytes.NewReader(tc.body))}, nil
+			})
+			client := NewDecompressingClient(inner)
+
+			req, err := http.NewRequest(http.MethodGet, "https://example.com/albums", http.NoBody)
+			require.NoError(t, err)
+			for k, v := range tc.requestHeader {
+				req.Header[k] = v
+			}
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expAccept, accept)
+			assert.Empty(t, req.Header.Get("Accept-Encoding"), "request passed to client should not be modified")
+			assert.Equal(t, tc.expBody, body)
+			assert.Equal(t, tc.expUncompressed, resp.Uncompressed)
+			assert.Empty(t, resp.Header.Get("Content-Encoding"))
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package httpx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxDebugCaptureBodySize is the maximum number of bytes of each request and
+// response body that is written by a client returned from
+// NewDebugCaptureClient. The rest of the body is still sent or received, it is
+// just left out of the capture.
+const MaxDebugCaptureBodySize = 64 << 10
+
+// redactedFormFields are the (lower case) fields of form encoded request
+// bodies that may contain secrets.
+var redactedFormFields = map[string]bool{
+	"password":            true,
+	"token":               true,
+	"uploadtoken":         true,
+	"csrfmiddlewaretoken": true,
+	"awsaccesskeyid":      true,
+	"policy":              true,
+	"signature":           true,
+}
+
+// redactedRequestHeaders are the request headers that only contain secrets.
+var redactedRequestHeaders = map[string]bool{
+	"Authorization": true,
+	"X-Csrftoken":   true,
+}
+
+// redactedPasswordRegexp matches passwords in JSON request bodies.
+var redactedPasswordRegexp = regexp.MustCompile(`("(?i:password)"\s*:\s*")[^"]*(")`)
+
+// debugCaptureClient is a Client that writes every request and response to a
+// writer.
+type debugCaptureClient struct {
+	client Client
+
+	mu    sync.Mutex
+	w     io.Writer
+	count int
+}
+
+// NewDebugCaptureClient returns a Client that sends requests using client and
+// writes every request and the response received for it to w, in a format
+// similar to the HTTP wire format. This is intended for capturing the traffic
+// with Nixplay to attach to bug reports.
+//
+// Secrets are redacted before anything is written to w: cookie values,
+// the CSRF token, authorization headers, passwords and upload tokens in
+// request bodies, and signatures, tokens and AWS keys in URLs and response
+// bodies. Only text bodies such as JSON and forms are written, the content of
+// photos is left out. Bodies longer than MaxDebugCaptureBodySize are
+// truncated.
+//
+// Requests may be sent concurrently, each request and response pair is
+// numbered so that they can be matched up. Errors writing to w are ignored.
+func NewDebugCaptureClient(client Client, w io.Writer) Client {
+	return &debugCaptureClient{
+		client: client,
+		w:      w,
+	}
+}
+
+func (c *debugCaptureClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.count++
+	n := c.count
+	c.mu.Unlock()
+
+	var capture strings.Builder
+	fmt.Fprintf(&capture, "--- request %d ---\n%s %s\n", n, req.Method, RedactURL(req.URL))
+	writeCapturedHeader(&capture, req.Header, redactRequestHeader)
+	if req.Body != nil && req.Body != http.NoBody && isTextContentType(req.Header.Get("Content-Type")) {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		writeCapturedBody(&capture, redactRequestBody(req.Header.Get("Content-Type"), body))
+	} else if req.Body != nil && req.Body != http.NoBody {
+		fmt.Fprintf(&capture, "\n[%s body not captured]\n", contentTypeOrUnknown(req.Header))
+	}
+	c.write(capture.String())
+
+	start := time.Now()
+	resp, err := c.client.Do(req)
+	capture.Reset()
+	if err != nil {
+		// Errors from http.Client include the URL, which may contain secrets.
+		msg := strings.ReplaceAll(err.Error(), req.URL.String(), RedactURL(req.URL))
+		fmt.Fprintf(&capture, "--- response %d: error after %s ---\n%s\n", n, time.Since(start).Round(time.Millisecond), msg)
+		c.write(capture.String())
+		return resp, err
+	}
+
+	fmt.Fprintf(&capture, "--- response %d: %s after %s ---\n", n, resp.Status, time.Since(start).Round(time.Millisecond))
+	writeCapturedHeader(&capture, resp.Header, redactResponseHeader)
+	if isTextContentType(resp.Header.Get("Content-Type")) {
+		decompressResponse(resp)
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		writeCapturedBody(&capture, redactedBodyRegexp.ReplaceAllString(string(body), "${1}${3}"+redacted+"${2}"))
+	} else {
+		fmt.Fprintf(&capture, "\n[%s body not captured]\n", contentTypeOrUnknown(resp.Header))
+	}
+	c.write(capture.String())
+	return resp, nil
+}
+
+func (c *debugCaptureClient) write(s string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	io.WriteString(c.w, s+"\n")
+}
+
+// isTextContentType returns true for the content types of bodies that are
+// captured.
+func isTextContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == "application/json" ||
+		strings.HasSuffix(mediaType, "+json") ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+func contentTypeOrUnknown(header http.Header) string {
+	if contentType := header.Get("Content-Type"); contentType != "" {
+		return contentType
+	}
+	return "unknown content type"
+}
+
+func writeCapturedHeader(w io.Writer, header http.Header, redact func(key string, value string) string) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			fmt.Fprintf(w, "%s: %s\n", k, redact(http.CanonicalHeaderKey(k), v))
+		}
+	}
+}
+
+func writeCapturedBody(w io.Writer, body string) {
+	if body == "" {
+		return
+	}
+	if len(body) > MaxDebugCaptureBodySize {
+		body = fmt.Sprintf("%s\n[%d more bytes not captured]", body[:MaxDebugCaptureBodySize], len(body)-MaxDebugCaptureBodySize)
+	}
+	fmt.Fprintf(w, "\n%s\n", body)
+}
+
+func redactRequestHeader(key string, value string) string {
+	switch {
+	case redactedRequestHeaders[key]:
+		return redacted
+	case key == "Cookie":
+		return redactCookieHeader(value)
+	}
+	return value
+}
+
+func redactResponseHeader(key string, value string) string {
+	switch {
+	case key == "Authorization":
+		return redacted
+	case key == "Set-Cookie":
+		return redactCookie(value)
+	}
+	return value
+}
+
+// redactCookieHeader replaces the values of all of the cookies in a Cookie
+// header while keeping their names.
+func redactCookieHeader(cookie string) string {
+	parts := strings.Split(cookie, ";")
+	for i, part := range parts {
+		name, _, _ := strings.Cut(strings.TrimSpace(part), "=")
+		parts[i] = name + "=" + redacted
+	}
+	return strings.Join(parts, "; ")
+}
+
+func redactRequestBody(contentType string, body []byte) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType != "application/x-www-form-urlencoded" {
+		s := redactedBodyRegexp.ReplaceAllString(string(body), "${1}${3}"+redacted+"${2}")
+		return redactedPasswordRegexp.ReplaceAllString(s, "${1}"+redacted+"${2}")
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return "[form body could not be parsed so it was not captured]"
+	}
+	for k := range form {
+		if redactedFormFields[strings.ToLower(k)] {
+			form[k] = []string{redacted}
+		}
+	}
+	return form.Encode()
+}
@@ -0,0 +1,103 @@
+package httpx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugCaptureClient(t *testing.T) {
+	ctx := context.Background()
+
+	const respBody = `{"token": "secret-token", "url": "https://s3.example.com/photo.jpg?Expires=1&Signature=secret-signature"}`
+	inner := clientFunc(func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "password=secret-password&username=user", string(body), "the request is sent unchanged")
+
+		header := http.Header{}
+		header.Add("Set-Cookie", "prod.csrftoken=secret-cookie; Domain=.nixplay.com; Path=/")
+		header.Add("Content-Type", "application/json")
+		return &http.Response{Status: "200 OK", StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(respBody))}, nil
+	})
+
+	var capture bytes.Buffer
+	client := NewDebugCaptureClient(inner, &capture)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.nixplay.com/login?token=secret-query", strings.NewReader("password=secret-password&username=user"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-CSRFToken", "secret-csrf")
+	req.Header.Set("Cookie", "prod.csrftoken=secret-cookie; prod.sessionid=secret-session")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, respBody, string(data), "the caller receives the real response")
+
+	captured := capture.String()
+	assert.NotContains(t, captured, "secret")
+	assert.Contains(t, captured, "--- request 1 ---\nPOST https://api.nixplay.com/login?token=REDACTED\n")
+	assert.Contains(t, captured, "Cookie: prod.csrftoken=REDACTED; prod.sessionid=REDACTED\n")
+	assert.Contains(t, captured, "X-Csrftoken: REDACTED\n")
+	assert.Contains(t, captured, "\npassword=REDACTED&username=user\n")
+	assert.Contains(t, captured, "--- response 1: 200 OK after ")
+	assert.Contains(t, captured, "Set-Cookie: prod.csrftoken=REDACTED; Domain=.nixplay.com; Path=/\n")
+	assert.Contains(t, captured, `{"token": "REDACTED", "url": "https://s3.example.com/photo.jpg?Expires=1&Signature=REDACTED"}`)
+}
+
+func TestDebugCaptureClient_BinaryBodiesNotCaptured(t *testing.T) {
+	ctx := context.Background()
+
+	const photo = "binary photo content"
+	inner := clientFunc(func(req *http.Request) (*http.Response, error) {
+		header := http.Header{}
+		header.Set("Content-Type", "image/jpeg")
+		return &http.Response{Status: "200 OK", StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(photo))}, nil
+	})
+
+	var capture bytes.Buffer
+	client := NewDebugCaptureClient(inner, &capture)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://s3.example.com/upload", strings.NewReader(photo))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "multipart/form-data; boundary=x")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, photo, string(data))
+
+	captured := capture.String()
+	assert.NotContains(t, captured, photo)
+	assert.Contains(t, captured, "[multipart/form-data; boundary=x body not captured]")
+	assert.Contains(t, captured, "[image/jpeg body not captured]")
+}
+
+func TestDebugCaptureClient_Error(t *testing.T) {
+	ctx := context.Background()
+
+	inner := clientFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New(`Get "` + req.URL.String() + `": connection reset`)
+	})
+
+	var capture bytes.Buffer
+	client := NewDebugCaptureClient(inner, &capture)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://s3.example.com/photo.jpg?Signature=secret-signature", http.NoBody)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	assert.Error(t, err)
+
+	captured := capture.String()
+	assert.NotContains(t, captured, "secret")
+	assert.Contains(t, captured, "--- response 1: error after ")
+	assert.Contains(t, captured, `Get "https://s3.example.com/photo.jpg?Signature=REDACTED": connection reset`)
+}
@@ -2,11 +2,45 @@ package httpx
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 )
 
+// DefaultMaxResponseSize is the maximum size of a JSON response body that
+// DoUnmarshalJSONResponse will decode.
+const DefaultMaxResponseSize = 64 << 20
+
+// ErrResponseTooLarge is returned when the body of a response is larger than
+// the maximum allowed size.
+var ErrResponseTooLarge = errors.New("response body too large")
+
+// DoUnmarshalJSONResponse makes the request and decodes the JSON body of the
+// response into response. It is DoUnmarshalJSONResponseWithLimit with a limit
+// of DefaultMaxResponseSize.
 func DoUnmarshalJSONResponse(client Client, request *http.Request, response any) error {
+	return DoUnmarshalJSONResponseWithLimit(client, request, response, DefaultMaxResponseSize)
+}
+
+// DoUnmarshalJSONResponseWithLimit makes the request and decodes the JSON body
+// of the response into response as it is read, without first reading the whole
+// body into memory. If the body is larger than maxSize bytes an error wrapping
+// ErrResponseTooLarge is returned. A maxSize of zero or less means that
+// DefaultMaxResponseSize is used.
+//
+// Errors for responses that do not have a 2xx status code or that can not be
+// decoded include the method and redacted URL of the request. If a response
+// with a status code showing that Nixplay is unavailable is an HTML page, such
+// as a maintenance page, then the error wraps a *ServiceUnavailableError. An
+// HTML page received with a 2xx status code, such as the login page that an
+// expired session is redirected to, will not go away by retrying, so the error
+// wraps a *ResponseError that is not retryable instead.
+func DoUnmarshalJSONResponseWithLimit(client Client, request *http.Request, response any, maxSize int64) error {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxResponseSize
+	}
+
 	resp, err := client.Do(request)
 	if err != nil {
 		return err
@@ -14,13 +48,60 @@ func DoUnmarshalJSONResponse(client Client, request *http.Request, response any)
 	defer resp.Body.Close()
 
 	if err := StatusError(resp); err != nil {
-		return err
+		return endpointError(request, err)
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
+	page, r := peekHTML(resp)
+	if page != nil {
+		return endpointError(request, htmlResponseError(resp, page))
+	}
+
+	body := &limitedReader{r: r, n: maxSize}
+	if err := json.NewDecoder(body).Decode(response); err != nil {
+		return endpointError(request, fmt.Errorf("decoding response: %w", err))
 	}
 
-	return json.Unmarshal(body, response)
+	// Drain the rest of the body, normally just trailing white space, so the
+	// connection can be reused.
+	_, _ = io.Copy(io.Discard, body)
+	return nil
+}
+
+// htmlResponseError returns the error for a 2xx response that is an HTML page
+// rather than JSON, page is the start of the page.
+func htmlResponseError(resp *http.Response, page []byte) error {
+	msg := "decoding response: received HTML page instead of JSON"
+	if title := htmlTitle(page); title != "" {
+		msg += fmt.Sprintf(": page title: %q", title)
+	}
+	return fmt.Errorf("%s: %w", msg, newResponseError(resp, page))
+}
+
+// endpointError wraps err with the method and redacted URL of request.
+func endpointError(request *http.Request, err error) error {
+	return fmt.Errorf("%s %s: %w", request.Method, RedactURL(request.URL), err)
+}
+
+// limitedReader reads from r like io.LimitReader but returns
+// ErrResponseTooLarge rather than io.EOF if there is more than n bytes of data.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		var b [1]byte
+		n, err := l.r.Read(b[:])
+		if n > 0 {
+			return 0, ErrResponseTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
 }
@@ -0,0 +1,97 @@
+package httpx
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoUnmarshalJSONResponseWithLimit(t *testing.T) {
+	type response struct {
+		Name string `json:"name"`
+	}
+
+	type testData struct {
+		name       string
+		status     int
+		body       string
+		maxSize    int64
+		expName    string
+		expErr     error
+		expErrText []string
+	}
+
+	testCases := []testData{
+		{
+			name:    "ok",
+			status:  http.StatusOK,
+			body:    `{"name":"album"}` + "\n",
+			maxSize: 100,
+			expName: "album",
+		},
+		{
+			name:    "exactlyMaxSize",
+			status:  http.StatusOK,
+			body:    `{"name":"album"}`,
+			maxSize: int64(len(`{"name":"album"}`)),
+			expName: "album",
+		},
+		{
+			name:       "tooLarge",
+			status:     http.StatusOK,
+			body:       `{"name":"` + strings.Repeat("a", 100) + `"}`,
+			maxSize:    50,
+			expErr:     ErrResponseTooLarge,
+			expErrText: []string{"GET https://example.com/list?Signature=REDACTED"},
+		},
+		{
+			name:       "invalidJSON",
+			status:     http.StatusOK,
+			body:       `{"name":`,
+			maxSize:    100,
+			expErrText: []string{"GET https://example.com/list?Signature=REDACTED", "decoding response"},
+		},
+		{
+			name:       "statusError",
+			status:     http.StatusForbidden,
+			body:       strings.Repeat("b", 2*maxErrorBodySize),
+			maxSize:    100,
+			expErrText: []string{"GET https://example.com/list?Signature=REDACTED", "403 Forbidden", strings.Repeat("b", maxErrorBodySize) + "..."},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := clientFunc(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: tc.status,
+					Status:     fmt.Sprintf("%d %s", tc.status, http.StatusText(tc.status)),
+					Body:       io.NopCloser(strings.NewReader(tc.body)),
+				}, nil
+			})
+			req, err := http.NewRequest(http.MethodGet, "https://example.com/list?Signature=secret", http.NoBody)
+			require.NoError(t, err)
+
+			var r response
+			err = DoUnmarshalJSONResponseWithLimit(client, req, &r, tc.maxSize)
+			if tc.expErr == nil && tc.expErrText == nil {
+				require.NoError(t, err)
+				assert.Equal(t, tc.expName, r.Name)
+				return
+			}
+			require.Error(t, err)
+			if tc.expErr != nil {
+				assert.ErrorIs(t, err, tc.expErr)
+			}
+			for _, text := range tc.expErrText {
+				assert.Contains(t, err.Error(), text)
+			}
+			assert.NotContains(t, err.Error(), "secret")
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package httpx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Fault is a failure that can be injected into requests by a client returned
+// from NewFaultInjectingClient.
+type Fault int
+
+const (
+	// NoFault sends the request as normal.
+	NoFault Fault = iota
+
+	// TimeoutFault fails the request with an error that reports that it timed
+	// out without sending it.
+	TimeoutFault
+
+	// TooManyRequestsFault responds with a 429 Too Many Requests status
+	// without sending the request.
+	TooManyRequestsFault
+
+	// ServerErrorFault responds with a 503 Service Unavailable status without
+	// sending the request.
+	ServerErrorFault
+
+	// TruncatedBodyFault sends the request but only returns the first half of
+	// the response body, after which reading the body fails with
+	// io.ErrUnexpectedEOF.
+	TruncatedBodyFault
+
+	// ConnectionResetFault fails the request with a connection reset error
+	// without sending it.
+	ConnectionResetFault
+)
+
+func (f Fault) String() string {
+	switch f {
+	case NoFault:
+		return "none"
+	case TimeoutFault:
+		return "timeout"
+	case TooManyRequestsFault:
+		return "too many requests"
+	case ServerErrorFault:
+		return "server error"
+	case TruncatedBodyFault:
+		return "truncated body"
+	case ConnectionResetFault:
+		return "connection reset"
+	}
+	return fmt.Sprintf("Fault(%d)", int(f))
+}
+
+// FaultSchedule decides which fault, if any, to inject into a request. n is the
+// number of the request sent through the client, starting at 1.
+type FaultSchedule func(req *http.Request, n int) Fault
+
+// FaultSequence returns a FaultSchedule that injects faults[i] into request
+// i+1. Requests after the end of the sequence are sent as normal.
+func FaultSequence(faults ...Fault) FaultSchedule {
+	return func(req *http.Request, n int) Fault {
+		if n > len(faults) {
+			return NoFault
+		}
+		return faults[n-1]
+	}
+}
+
+// FaultEvery returns a FaultSchedule that injects fault into every n'th
+// request.
+func FaultEvery(n int, fault Fault) FaultSchedule {
+	return func(req *http.Request, i int) Fault {
+		if n > 0 && i%n == 0 {
+			return fault
+		}
+		return NoFault
+	}
+}
+
+// timeoutError is the error returned for a TimeoutFault. Like the errors
+// returned by the net package it implements net.Error.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "injected timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ = (net.Error)(timeoutError{})
+
+// faultInjectingClient is a Client that injects faults into requests.
+type faultInjectingClient struct {
+	client   Client
+	schedule FaultSchedule
+
+	mu sync.Mutex
+	n  int
+}
+
+// NewFaultInjectingClient returns a Client that injects the faults chosen by
+// schedule into requests sent using client. This is intended to be used to
+// test how code copes with Nixplay or the network misbehaving.
+func NewFaultInjectingClient(client Client, schedule FaultSchedule) Client {
+	return &faultInjectingClient{
+		client:   client,
+		schedule: schedule,
+	}
+}
+
+func (c *faultInjectingClient) Do(req *http.Request) (*http.Response, error) {
+	c.mu.Lock()
+	c.n++
+	n := c.n
+	c.mu.Unlock()
+
+	fault := c.schedule(req, n)
+	switch fault {
+	case NoFault:
+		return c.client.Do(req)
+	case TruncatedBodyFault:
+		return c.truncate(req)
+	}
+
+	// The remaining faults don't send the request, but like a real transport
+	// we still need to close the body.
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	switch fault {
+	case TimeoutFault:
+		return nil, &url.Error{Op: req.Method, URL: RedactURL(req.URL), Err: timeoutError{}}
+	case ConnectionResetFault:
+		return nil, &url.Error{Op: req.Method, URL: RedactURL(req.URL), Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}}
+	case TooManyRequestsFault:
+		resp := newFaultResponse(req, http.StatusTooManyRequests)
+		resp.Header.Set("Retry-After", "1")
+		return resp, nil
+	case ServerErrorFault:
+		return newFaultResponse(req, http.StatusServiceUnavailable), nil
+	}
+	return nil, fmt.Errorf("unknown fault: %v", fault)
+}
+
+func (c *faultInjectingClient) truncate(req *http.Request) (*http.Response, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(io.MultiReader(
+		bytes.NewReader(body[:len(body)/2]),
+		errReader{io.ErrUnexpectedEOF},
+	))
+	return resp, nil
+}
+
+func newFaultResponse(req *http.Request, statusCode int) *http.Response {
+	body := http.StatusText(statusCode)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"text/plain"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,77 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultInjectingClient(t *testing.T) {
+	sent := 0
+	inner := clientFunc(func(req *http.Request) (*http.Response, error) {
+		sent++
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("0123456789"))}, nil
+	})
+	client := NewFaultInjectingClient(inner, FaultSequence(
+		TimeoutFault,
+		ConnectionResetFault,
+		TooManyRequestsFault,
+		ServerErrorFault,
+		TruncatedBodyFault,
+	))
+
+	do := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "https://api.nixplay.com/v3/albums/", http.NoBody)
+		require.NoError(t, err)
+		return client.Do(req)
+	}
+
+	_, err := do()
+	var netErr net.Error
+	require.ErrorAs(t, err, &netErr)
+	assert.True(t, netErr.Timeout())
+
+	_, err = do()
+	assert.ErrorIs(t, err, syscall.ECONNRESET)
+
+	resp, err := do()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+
+	resp, err = do()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, 0, sent, "requests with faults that fail early are not sent")
+
+	resp, err = do()
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	assert.True(t, errors.Is(err, io.ErrUnexpectedEOF))
+	assert.Equal(t, "01234", string(body))
+	assert.Equal(t, 1, sent)
+
+	resp, err = do()
+	require.NoError(t, err)
+	body, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(body))
+	assert.Equal(t, 2, sent)
+}
+
+func TestFaultEvery(t *testing.T) {
+	schedule := FaultEvery(3, ServerErrorFault)
+	var faults []Fault
+	for n := 1; n <= 6; n++ {
+		faults = append(faults, schedule(nil, n))
+	}
+	assert.Equal(t, []Fault{NoFault, NoFault, ServerErrorFault, NoFault, NoFault, ServerErrorFault}, faults)
+}
@@ -0,0 +1,50 @@
+package httpx
+
+import "net/http"
+
+// headerClient is a Client that adds default headers to every request.
+type headerClient struct {
+	client Client
+	header http.Header
+}
+
+// NewHeaderClient returns a Client that sends requests using client with the
+// headers in header added. Headers that are already set on a request are left
+// as they are, so the headers the library needs for a request, such as
+// Content-Type, are never replaced.
+func NewHeaderClient(client Client, header http.Header) Client {
+	return &headerClient{
+		client: client,
+		header: header.Clone(),
+	}
+}
+
+func (c *headerClient) Do(req *http.Request) (*http.Response, error) {
+	var cloned bool
+	for k, values := range c.header {
+		if _, ok := req.Header[http.CanonicalHeaderKey(k)]; ok {
+			continue
+		}
+		if !cloned {
+			// The request belongs to the caller so it must not be modified.
+			req = req.Clone(req.Context())
+			cloned = true
+		}
+		req.Header[http.CanonicalHeaderKey(k)] = append([]string(nil), values...)
+	}
+	return c.client.Do(req)
+}
+
+// Header returns a Middleware that adds default headers to every request, see
+// NewHeaderClient.
+func Header(header http.Header) Middleware {
+	return func(client Client) Client {
+		return NewHeaderClient(client, header)
+	}
+}
+
+// UserAgent returns a Middleware that sets the User-Agent header of every
+// request that does not already set one.
+func UserAgent(userAgent string) Middleware {
+	return Header(http.Header{"User-Agent": {userAgent}})
+}
@@ -0,0 +1,37 @@
+package httpx
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderClient(t *testing.T) {
+	var sent http.Header
+	base := ClientFunc(func(req *http.Request) (*http.Response, error) {
+		sent = req.Header
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	header := http.Header{}
+	header.Set("X-Automation", "photo-frame-sync")
+	header.Set("Content-Type", "text/plain")
+	client := Chain(base, UserAgent("photo-frame-sync/1.0"), Header(header))
+
+	req, err := http.NewRequest(http.MethodPost, "https://api.nixplay.com/", http.NoBody)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	_, err = client.Do(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, "photo-frame-sync/1.0", sent.Get("User-Agent"))
+	assert.Equal(t, "photo-frame-sync", sent.Get("X-Automation"))
+	assert.Equal(t, "application/json", sent.Get("Content-Type"), "headers set on the request are kept")
+
+	// The request of the caller is not modified.
+	assert.Equal(t, http.Header{"Content-Type": {"application/json"}}, req.Header)
+}
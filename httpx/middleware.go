@@ -0,0 +1,79 @@
+package httpx
+
+import (
+	"io"
+	"net/http"
+)
+
+// Middleware decorates a Client with additional behavior, such as retrying,
+// rate limiting or logging requests. A Middleware returns a Client that sends
+// requests using the Client it is given.
+type Middleware func(client Client) Client
+
+// Chain returns client decorated by each of the middlewares. The first
+// middleware is the outermost one, it sees each request first and each
+// response last, so
+//
+//	Chain(client, a, b)
+//
+// is the same as a(b(client)).
+func Chain(client Client, middlewares ...Middleware) Client {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		client = middlewares[i](client)
+	}
+	return client
+}
+
+// ClientFunc is an adapter to allow the use of ordinary functions as a Client,
+// which is convenient for writing a Middleware.
+type ClientFunc func(req *http.Request) (*http.Response, error)
+
+func (f ClientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Decompression returns a Middleware that decompresses responses, see
+// NewDecompressingClient.
+func Decompression() Middleware {
+	return NewDecompressingClient
+}
+
+// DebugCapture returns a Middleware that writes requests and responses to w,
+// see NewDebugCaptureClient.
+func DebugCapture(w io.Writer) Middleware {
+	return func(client Client) Client {
+		return NewDebugCaptureClient(client, w)
+	}
+}
+
+// Hook returns a Middleware that invokes hook after every request, see
+// NewHookedClient.
+func Hook(hook RequestHook) Middleware {
+	return func(client Client) Client {
+		return NewHookedClient(client, hook)
+	}
+}
+
+// Tracing returns a Middleware that starts a span for every request, see
+// NewTracedClient.
+func Tracing(tracer Tracer) Middleware {
+	return func(client Client) Client {
+		return NewTracedClient(client, tracer)
+	}
+}
+
+// Recording returns a Middleware that records every request in cassette, see
+// NewRecordingClient.
+func Recording(cassette *Cassette) Middleware {
+	return func(client Client) Client {
+		return NewRecordingClient(client, cassette)
+	}
+}
+
+// FaultInjection returns a Middleware that injects faults according to
+// schedule, see NewFaultInjectingClient.
+func FaultInjection(schedule FaultSchedule) Middleware {
+	return func(client Client) Client {
+		return NewFaultInjectingClient(client, schedule)
+	}
+}
@@ -0,0 +1,41 @@
+package httpx
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	named := func(name string) Middleware {
+		return func(client Client) Client {
+			return ClientFunc(func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name+" request")
+				resp, err := client.Do(req)
+				calls = append(calls, name+" response")
+				return resp, err
+			})
+		}
+	}
+	base := ClientFunc(func(req *http.Request) (*http.Response, error) {
+		calls = append(calls, "client")
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.nixplay.com/", http.NoBody)
+	require.NoError(t, err)
+	_, err = Chain(base, named("outer"), named("inner")).Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"outer request", "inner request", "client", "inner response", "outer response"}, calls)
+
+	// Without any middleware the client is used as it is.
+	calls = nil
+	_, err = Chain(base).Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"client"}, calls)
+}
@@ -0,0 +1,88 @@
+package httpx
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimit limits how often requests are sent.
+//
+// The zero value does not limit requests.
+type RateLimit struct {
+	// RequestsPerSecond is the sustained rate that requests are sent at. If
+	// zero requests are not limited.
+	RequestsPerSecond float64
+
+	// Burst is the number of requests that may be sent at once after the
+	// client has been idle, before requests are spaced out to match
+	// RequestsPerSecond. If less than one then one is used.
+	Burst int
+}
+
+// rateLimitedClient is a Client that delays requests to stay within a
+// RateLimit.
+//
+// The limit is implemented as a token bucket where next is the time at which
+// the bucket will be full again.
+type rateLimitedClient struct {
+	client   Client
+	interval time.Duration
+	burst    int
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// NewRateLimitedClient returns a Client that sends requests using client,
+// delaying requests so that they are sent no faster than limit allows.
+//
+// A request waiting to be sent is abandoned when the context of the request is
+// done, in which case the error of the context is returned.
+func NewRateLimitedClient(client Client, limit RateLimit) Client {
+	if limit.RequestsPerSecond <= 0 {
+		return client
+	}
+	burst := limit.Burst
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimitedClient{
+		client:   client,
+		interval: time.Duration(float64(time.Second) / limit.RequestsPerSecond),
+		burst:    burst,
+	}
+}
+
+func (c *rateLimitedClient) Do(req *http.Request) (*http.Response, error) {
+	if wait := c.reserve(time.Now()); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	return c.client.Do(req)
+}
+
+// reserve takes a token from the bucket and returns how long the request must
+// wait before it is sent.
+func (c *rateLimitedClient) reserve(now time.Time) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.next.Before(now) {
+		c.next = now
+	}
+	c.next = c.next.Add(c.interval)
+	return c.next.Sub(now) - time.Duration(c.burst)*c.interval
+}
+
+// RateLimiting returns a Middleware that limits how often requests are sent,
+// see NewRateLimitedClient.
+func RateLimiting(limit RateLimit) Middleware {
+	return func(client Client) Client {
+		return NewRateLimitedClient(client, limit)
+	}
+}
@@ -0,0 +1,69 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitedClient_Reserve(t *testing.T) {
+	c := NewRateLimitedClient(clientFunc(nil), RateLimit{RequestsPerSecond: 10, Burst: 2}).(*rateLimitedClient)
+	start := time.Now()
+
+	// The burst is sent immediately, after which requests are spaced out.
+	assert.LessOrEqual(t, c.reserve(start), time.Duration(0))
+	assert.LessOrEqual(t, c.reserve(start), time.Duration(0))
+	assert.Equal(t, 100*time.Millisecond, c.reserve(start))
+	assert.Equal(t, 200*time.Millisecond, c.reserve(start))
+
+	// Once the client is idle the bucket fills up again.
+	later := start.Add(time.Second)
+	assert.LessOrEqual(t, c.reserve(later), time.Duration(0))
+	assert.LessOrEqual(t, c.reserve(later), time.Duration(0))
+	assert.Equal(t, 100*time.Millisecond, c.reserve(later))
+}
+
+func TestRateLimitedClient_Do(t *testing.T) {
+	var sent int
+	inner := clientFunc(func(req *http.Request) (*http.Response, error) {
+		sent++
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	client := NewRateLimitedClient(inner, RateLimit{RequestsPerSecond: 50})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequest(http.MethodGet, "https://api.nixplay.com/", http.NoBody)
+		require.NoError(t, err)
+		_, err = client.Do(req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, sent)
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+}
+
+func TestRateLimitedClient_ContextCanceled(t *testing.T) {
+	inner := clientFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	client := NewRateLimitedClient(inner, RateLimit{RequestsPerSecond: 0.1})
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.nixplay.com/", http.NoBody)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = client.Do(req.WithContext(ctx))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRateLimitedClient_NoLimit(t *testing.T) {
+	client := NewRateLimitedClient(clientFunc(nil), RateLimit{})
+	assert.IsType(t, clientFunc(nil), client)
+}
@@ -0,0 +1,239 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Interaction is a single HTTP request and the response that was received
+// for it, as recorded by a client returned from NewRecordingClient.
+//
+// To avoid recording secrets the body of the request is not recorded and
+// secrets in the URL, response headers and response body are redacted.
+type Interaction struct {
+	Method string `json:"method"`
+
+	// URL is the URL of the request with secrets redacted, see RedactURL.
+	URL string `json:"url"`
+
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// Cassette is a list of recorded interactions that can be saved to a file and
+// replayed later with NewReplayClient. This allows tests that talk to Nixplay
+// to be recorded once with a real account and replayed without one.
+//
+// All methods are safe to call concurrently.
+type Cassette struct {
+	// Replacements are strings that are replaced in recorded response bodies
+	// and headers, for example to replace the username of the account that
+	// the cassette was recorded with.
+	Replacements map[string]string
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// LoadCassette loads a cassette saved by Cassette.Save.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var interactions []Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %q: %w", path, err)
+	}
+	return &Cassette{
+		interactions: interactions,
+		used:         make([]bool, len(interactions)),
+	}, nil
+}
+
+// Save writes the recorded interactions to path.
+func (c *Cassette) Save(path string) error {
+	c.mu.Lock()
+	data, err := json.MarshalIndent(c.interactions, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// Interactions returns the interactions in the cassette.
+func (c *Cassette) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Interaction(nil), c.interactions...)
+}
+
+func (c *Cassette) add(i Interaction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.interactions = append(c.interactions, i)
+	c.used = append(c.used, false)
+}
+
+// next returns the first interaction that has not yet been replayed with the
+// same method and URL. Requests may be sent concurrently so the order of
+// requests to different URLs is not required to match the recording.
+func (c *Cassette) next(method string, url string) (Interaction, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, interaction := range c.interactions {
+		if !c.used[i] && interaction.Method == method && interaction.URL == url {
+			c.used[i] = true
+			return interaction, true
+		}
+	}
+	return Interaction{}, false
+}
+
+// redactedBodyRegexp matches JSON string values and URL query parameters in
+// response bodies that may contain secrets.
+var redactedBodyRegexp = regexp.MustCompile(`("(?i:token|csrftoken|AWSAccessKeyId|Signature|Policy)"\s*:\s*")[^"]*(")|([?&](?i:Signature|AWSAccessKeyId|X-Amz-Signature|X-Amz-Credential|X-Amz-Security-Token)=)[^&"\\]*`)
+
+// redactedHeaders are the response headers that are not recorded since they
+// only contain secrets or are not useful when replaying.
+var redactedHeaders = map[string]bool{
+	"Authorization":  true,
+	"Date":           true,
+	"Content-Length": true,
+}
+
+func (c *Cassette) scrub(s string) string {
+	for old, new := range c.Replacements {
+		s = strings.ReplaceAll(s, old, new)
+	}
+	return s
+}
+
+func (c *Cassette) scrubBody(body []byte) string {
+	s := redactedBodyRegexp.ReplaceAllString(string(body), "${1}${3}"+redacted+"${2}")
+	return c.scrub(s)
+}
+
+func (c *Cassette) scrubHeader(header http.Header) http.Header {
+	scrubbed := make(http.Header, len(header))
+	for k, values := range header {
+		if redactedHeaders[http.CanonicalHeaderKey(k)] {
+			continue
+		}
+		for _, v := range values {
+			if http.CanonicalHeaderKey(k) == "Set-Cookie" {
+				v = redactCookie(v)
+			}
+			scrubbed.Add(k, c.scrub(v))
+		}
+	}
+	return scrubbed
+}
+
+// redactCookie replaces the value of the cookie in a Set-Cookie header while
+// keeping its name and attributes.
+func redactCookie(setCookie string) string {
+	nameValue, attrs, _ := strings.Cut(setCookie, ";")
+	name, _, _ := strings.Cut(nameValue, "=")
+	s := name + "=" + redacted
+	if attrs != "" {
+		s += ";" + attrs
+	}
+	return s
+}
+
+// recordingClient is a Client that records every request to a Cassette.
+type recordingClient struct {
+	client   Client
+	cassette *Cassette
+}
+
+// NewRecordingClient returns a Client that sends requests using client and
+// records every request and response in cassette.
+func NewRecordingClient(client Client, cassette *Cassette) Client {
+	return &recordingClient{
+		client:   client,
+		cassette: cassette,
+	}
+}
+
+func (c *recordingClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return resp, err
+	}
+
+	// Bodies are recorded as text so compressed bodies are decompressed first.
+	decompressResponse(resp)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	c.cassette.add(Interaction{
+		Method:     req.Method,
+		URL:        c.cassette.scrub(RedactURL(req.URL)),
+		StatusCode: resp.StatusCode,
+		Header:     c.cassette.scrubHeader(resp.Header),
+		Body:       c.cassette.scrubBody(body),
+	})
+	return resp, nil
+}
+
+// replayClient is a Client that responds to requests using the interactions
+// in a Cassette.
+type replayClient struct {
+	cassette *Cassette
+}
+
+// NewReplayClient returns a Client that responds to requests with the
+// interactions recorded in cassette rather than sending them. Requests are
+// matched to interactions by method and URL, with secrets redacted. If there
+// is no matching interaction then an error is returned.
+func NewReplayClient(cassette *Cassette) Client {
+	return &replayClient{cassette: cassette}
+}
+
+func (c *replayClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		io.Copy(io.Discard, req.Body)
+		req.Body.Close()
+	}
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	url := RedactURL(req.URL)
+	interaction, ok := c.cassette.next(req.Method, url)
+	if !ok {
+		return nil, fmt.Errorf("no recorded interaction for %s %s", req.Method, url)
+	}
+
+	header := interaction.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.StatusCode, http.StatusText(interaction.StatusCode)),
+		StatusCode:    interaction.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(interaction.Body)),
+		ContentLength: int64(len(interaction.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,70 @@
+package httpx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordReplay(t *testing.T) {
+	ctx := context.Background()
+
+	const body = `{"token": "secret-token", "name": "user@example.com", "url": "https://s3.example.com/photo.jpg?Expires=1&Signature=secret-signature"}`
+	inner := clientFunc(func(req *http.Request) (*http.Response, error) {
+		header := http.Header{}
+		header.Add("Set-Cookie", "prod.csrftoken=secret-cookie; Domain=.nixplay.com; Path=/")
+		header.Add("Content-Type", "application/json")
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})
+
+	cassette := &Cassette{Replacements: map[string]string{"user@example.com": "test-user"}}
+	recorder := NewRecordingClient(inner, cassette)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://api.nixplay.com/login?token=secret-query", strings.NewReader("password=secret-password"))
+	require.NoError(t, err)
+	resp, err := recorder.Do(req)
+	require.NoError(t, err)
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, body, string(data), "the caller receives the real response")
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	require.NoError(t, cassette.Save(path))
+
+	loaded, err := LoadCassette(path)
+	require.NoError(t, err)
+	interactions := loaded.Interactions()
+	require.Len(t, interactions, 1)
+	assert.Equal(t, Interaction{
+		Method:     http.MethodPost,
+		URL:        "https://api.nixplay.com/login?token=REDACTED",
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			"Set-Cookie":   {"prod.csrftoken=REDACTED; Domain=.nixplay.com; Path=/"},
+			"Content-Type": {"application/json"},
+		},
+		Body: `{"token": "REDACTED", "name": "test-user", "url": "https://s3.example.com/photo.jpg?Expires=1&Signature=REDACTED"}`,
+	}, interactions[0])
+
+	replay := NewReplayClient(loaded)
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, "https://api.nixplay.com/login?token=other", http.NoBody)
+	require.NoError(t, err)
+	resp, err = replay.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, resp.Cookies(), 1)
+	assert.Equal(t, ".nixplay.com", resp.Cookies()[0].Domain)
+	data, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, interactions[0].Body, string(data))
+
+	// Each interaction is only replayed once.
+	_, err = replay.Do(req)
+	assert.ErrorContains(t, err, "no recorded interaction")
+}
@@ -0,0 +1,138 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RequestInfo describes a single HTTP request that was sent to Nixplay (or S3)
+// and the outcome of that request.
+type RequestInfo struct {
+	// Method is the HTTP method of the request.
+	Method string
+
+	// Operation is the name of the higher level operation the request is part
+	// of as recorded by WithOperation, or an empty string if no operation was
+	// recorded.
+	Operation string
+
+	// URL is the URL of the request with any secrets such as signatures or
+	// tokens redacted. See RedactURL.
+	URL string
+
+	// StatusCode is the HTTP status code of the response. If no response was
+	// received then StatusCode will be 0 and Err will be set.
+	StatusCode int
+
+	// Duration is the amount of time between sending the request and receiving
+	// the response headers.
+	Duration time.Duration
+
+	// Attempt is the attempt number for this request, starting at 1.
+	Attempt int
+
+	// Err is the error returned by the underlying client, if any.
+	Err error
+}
+
+// RequestHook is a function that is invoked after every HTTP request that is
+// made. This can be used to log requests or otherwise observe the behavior of
+// the client.
+//
+// The hook is called synchronously so it should not block for long periods of
+// time.
+type RequestHook func(info RequestInfo)
+
+type attemptKey struct{}
+
+// WithAttempt returns a copy of the context that records the attempt number
+// of the request that the context is used for. This is intended to be used by
+// code that retries requests so that the attempt number can be reported to a
+// RequestHook.
+func WithAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// Attempt returns the attempt number recorded in the context by WithAttempt.
+// If no attempt number has been recorded then 1 is returned.
+func Attempt(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptKey{}).(int); ok {
+		return attempt
+	}
+	return 1
+}
+
+// redactedQueryParams are the (lower case) query parameters that may contain
+// secrets and should not be logged.
+var redactedQueryParams = map[string]bool{
+	"awsaccesskeyid":       true,
+	"signature":            true,
+	"x-amz-signature":      true,
+	"x-amz-credential":     true,
+	"x-amz-security-token": true,
+	"token":                true,
+	"uploadtoken":          true,
+}
+
+const redacted = "REDACTED"
+
+// RedactURL returns a string representation of the URL with any query
+// parameters that may contain secrets replaced with "REDACTED".
+func RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	redactedURL := *u
+	redactedURL.User = nil
+
+	query := redactedURL.Query()
+	changed := false
+	for k := range query {
+		if redactedQueryParams[strings.ToLower(k)] {
+			query[k] = []string{redacted}
+			changed = true
+		}
+	}
+	if changed {
+		redactedURL.RawQuery = query.Encode()
+	}
+	return redactedURL.String()
+}
+
+// hookedClient is a Client that invokes a RequestHook for every request.
+type hookedClient struct {
+	client Client
+	hook   RequestHook
+}
+
+// NewHookedClient returns a Client that sends requests using the provided
+// client and invokes hook after every request.
+func NewHookedClient(client Client, hook RequestHook) Client {
+	return &hookedClient{
+		client: client,
+		hook:   hook,
+	}
+}
+
+func (c *hookedClient) Do(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.client.Do(req)
+
+	info := RequestInfo{
+		Method:    req.Method,
+		Operation: Operation(req.Context()),
+		URL:       RedactURL(req.URL),
+		Duration:  time.Since(start),
+		Attempt:   Attempt(req.Context()),
+		Err:       err,
+	}
+	if resp != nil {
+		info.StatusCode = resp.StatusCode
+	}
+	c.hook(info)
+
+	return resp, err
+}
@@ -0,0 +1,147 @@
+package httpx
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrServiceUnavailable is wrapped by the errors returned when Nixplay responds
+// with an HTML page, such as a maintenance page or a Cloudflare challenge,
+// rather than the response of the API. This means Nixplay is down, not that
+// the request was wrong, so the request may be retried later.
+var ErrServiceUnavailable = errors.New("service unavailable")
+
+// maxHTMLPageSize is the maximum number of bytes of an HTML page that are read
+// to find its title.
+const maxHTMLPageSize = 4 << 10
+
+// htmlTitleRegexp matches the title of an HTML page.
+var htmlTitleRegexp = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// ServiceUnavailableError is the error returned when Nixplay responds with an
+// HTML page, with a status code showing that Nixplay is unavailable, rather
+// than the response of the API. It wraps ErrServiceUnavailable and a
+// *ResponseError.
+type ServiceUnavailableError struct {
+	// StatusCode is the status code of the response, for example 503.
+	StatusCode int
+
+	// Status is the status of the response, for example
+	// "503 Service Unavailable".
+	Status string
+
+	// Title is the title of the HTML page, for example "Down for
+	// maintenance", if it has one.
+	Title string
+
+	// RetryAfter is how long Nixplay asked for clients to wait before trying
+	// again with the Retry-After header. It is zero if Nixplay did not say.
+	RetryAfter time.Duration
+
+	err error
+}
+
+func (e *ServiceUnavailableError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: received HTML page instead of API response: http status: %s", ErrServiceUnavailable, e.Status)
+	if e.Title != "" {
+		fmt.Fprintf(&b, ": page title: %q", e.Title)
+	}
+	if e.RetryAfter > 0 {
+		fmt.Fprintf(&b, ": retry after %s", e.RetryAfter)
+	}
+	return b.String()
+}
+
+func (e *ServiceUnavailableError) Is(target error) bool {
+	return target == ErrServiceUnavailable
+}
+
+func (e *ServiceUnavailableError) Unwrap() error {
+	return e.err
+}
+
+// Retryable always returns true, the page is expected to go away once Nixplay
+// is available again. Wait for at least RetryAfter before retrying.
+func (e *ServiceUnavailableError) Retryable() bool {
+	return true
+}
+
+// newServiceUnavailableError returns the error for an HTML page received in
+// resp, body is the start of the page.
+func newServiceUnavailableError(resp *http.Response, body []byte, err error) *ServiceUnavailableError {
+	return &ServiceUnavailableError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Title:      htmlTitle(body),
+		RetryAfter: retryAfter(resp.Header, time.Now()),
+		err:        err,
+	}
+}
+
+// htmlTitle returns the title of the HTML page that starts with body, or "" if
+// it does not have one.
+func htmlTitle(body []byte) string {
+	m := htmlTitleRegexp.FindSubmatch(body)
+	if m == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(html.UnescapeString(string(m[1]))), " ")
+}
+
+// isUnavailableStatus returns true for the status codes that Nixplay, or
+// Cloudflare in front of it, responds with when showing a maintenance page or
+// a challenge.
+func isUnavailableStatus(resp *http.Response) bool {
+	return resp.StatusCode >= 500 ||
+		resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden && resp.Header.Get("Cf-Mitigated") != "")
+}
+
+// looksLikeHTML returns true if body is the start of an HTML page. The body is
+// checked rather than the Content-Type header since some Nixplay endpoints
+// send JSON with an HTML content type.
+func looksLikeHTML(body []byte) bool {
+	trimmed := bytes.TrimSpace(body)
+	return len(trimmed) > 0 && trimmed[0] == '<'
+}
+
+// peekHTML returns the start of the body of resp if it is an HTML page.
+// Otherwise it returns nil. In both cases the returned reader reads the whole
+// body.
+func peekHTML(resp *http.Response) ([]byte, io.Reader) {
+	r := bufio.NewReaderSize(resp.Body, maxHTMLPageSize)
+	start, _ := r.Peek(maxHTMLPageSize)
+	if !looksLikeHTML(start) {
+		return nil, r
+	}
+	return start, r
+}
+
+// retryAfter parses the Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func retryAfter(header http.Header, now time.Time) time.Duration {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
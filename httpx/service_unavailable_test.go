@@ -0,0 +1,127 @@
+package httpx
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const maintenancePage = `<!DOCTYPE html>
+<html><head><title>
+  Down for maintenance &amp; upgrades
+</title></head><body>We'll be back soon.</body></html>`
+
+func TestStatusError_ServiceUnavailable(t *testing.T) {
+	resp := &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Status:     "503 Service Unavailable",
+		Header:     http.Header{"Content-Type": {"text/html"}, "Retry-After": {"120"}},
+		Body:       io.NopCloser(strings.NewReader(maintenancePage)),
+	}
+	err := StatusError(resp)
+	assert.ErrorIs(t, err, ErrServiceUnavailable)
+
+	var unavailable *ServiceUnavailableError
+	require.True(t, errors.As(err, &unavailable))
+	assert.Equal(t, "Down for maintenance & upgrades", unavailable.Title)
+	assert.Equal(t, 2*time.Minute, unavailable.RetryAfter)
+	assert.True(t, unavailable.Retryable())
+	assert.Equal(t, `service unavailable: received HTML page instead of API response: http status: 503 Service Unavailable: page title: "Down for maintenance & upgrades": retry after 2m0s`, err.Error())
+
+	// The error still reports the status of the response.
+	var respErr *ResponseError
+	require.True(t, errors.As(err, &respErr))
+	assert.Equal(t, http.StatusServiceUnavailable, respErr.StatusCode)
+	assert.True(t, respErr.Retryable())
+
+	// Pages for errors caused by the request are not reported as Nixplay
+	// being unavailable.
+	resp = &http.Response{
+		StatusCode: http.StatusNotFound,
+		Status:     "404 Not Found",
+		Body:       io.NopCloser(strings.NewReader("<html><title>Not Found</title></html>")),
+	}
+	err = StatusError(resp)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrServiceUnavailable)
+
+	// Cloudflare challenges are reported with a 403 status.
+	resp = &http.Response{
+		StatusCode: http.StatusForbidden,
+		Status:     "403 Forbidden",
+		Header:     http.Header{"Cf-Mitigated": {"challenge"}},
+		Body:       io.NopCloser(strings.NewReader("<html><title>Just a moment...</title></html>")),
+	}
+	assert.ErrorIs(t, StatusError(resp), ErrServiceUnavailable)
+}
+
+func TestDoUnmarshalJSONResponse_ServiceUnavailable(t *testing.T) {
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusServiceUnavailable,
+			Status:     "503 Service Unavailable",
+			Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+			Body:       io.NopCloser(strings.NewReader("\n  " + maintenancePage)),
+		}, nil
+	})
+	req, err := http.NewRequest(http.MethodGet, "https://api.nixplay.com/v2/albums/web/json/", http.NoBody)
+	require.NoError(t, err)
+
+	var response map[string]any
+	err = DoUnmarshalJSONResponse(client, req, &response)
+	assert.ErrorIs(t, err, ErrServiceUnavailable)
+	var unavailable *ServiceUnavailableError
+	require.True(t, errors.As(err, &unavailable))
+	assert.Equal(t, http.StatusServiceUnavailable, unavailable.StatusCode)
+	assert.Contains(t, err.Error(), "GET https://api.nixplay.com/v2/albums/web/json/: ")
+
+	// An expired session is redirected to the login page, which is received
+	// with a 200 status. Retrying will not make it go away so it is not
+	// reported as Nixplay being unavailable.
+	client = clientFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+			Body:       io.NopCloser(strings.NewReader(`<!DOCTYPE html><html><head><title>Nixplay | Login</title></head><body><form action="/login/"></form></body></html>`)),
+		}, nil
+	})
+	err = DoUnmarshalJSONResponse(client, req, &response)
+	assert.NotErrorIs(t, err, ErrServiceUnavailable)
+	var respErr *ResponseError
+	require.True(t, errors.As(err, &respErr))
+	assert.Equal(t, http.StatusOK, respErr.StatusCode)
+	assert.False(t, respErr.Retryable())
+	assert.Contains(t, err.Error(), `GET https://api.nixplay.com/v2/albums/web/json/: decoding response: received HTML page instead of JSON: page title: "Nixplay | Login"`)
+
+	// JSON sent with an HTML content type is still decoded.
+	client = clientFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Header:     http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+			Body:       io.NopCloser(strings.NewReader(`{"title": "<b>album</b>"}`)),
+		}, nil
+	})
+	require.NoError(t, DoUnmarshalJSONResponse(client, req, &response))
+	assert.Equal(t, map[string]any{"title": "<b>album</b>"}, response)
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	header := func(v string) http.Header {
+		return http.Header{"Retry-After": {v}}
+	}
+	assert.Equal(t, time.Duration(0), retryAfter(http.Header{}, now))
+	assert.Equal(t, 30*time.Second, retryAfter(header("30"), now))
+	assert.Equal(t, time.Duration(0), retryAfter(header("-1"), now))
+	assert.Equal(t, 90*time.Second, retryAfter(header("Sat, 01 Apr 2023 12:01:30 GMT"), now))
+	assert.Equal(t, time.Duration(0), retryAfter(header("Sat, 01 Apr 2023 11:00:00 GMT"), now))
+	assert.Equal(t, time.Duration(0), retryAfter(header("soon"), now))
+}
@@ -6,10 +6,63 @@ import (
 	"net/http"
 )
 
+// maxErrorBodySize is the maximum number of bytes of the body of a response
+// that are included in the error returned by StatusError.
+const maxErrorBodySize = 512
+
+// ResponseError is the error returned by StatusError for a response that does
+// not have a 2xx status code. It is also wrapped by the error returned by
+// DoUnmarshalJSONResponse when a 2xx response is an HTML page rather than JSON,
+// for example the login page that an expired session is redirected to.
+type ResponseError struct {
+	// StatusCode is the status code of the response, for example 404.
+	StatusCode int
+
+	// Status is the status of the response, for example "404 Not Found".
+	Status string
+
+	// Body is the start of the body of the response.
+	Body string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("http status: %s: body: %s", e.Status, e.Body)
+}
+
+// Retryable returns true if the status code indicates a failure that may go
+// away if the request is made again, such as a server error or throttling.
+func (e *ResponseError) Retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// StatusError returns an error if resp does not have a 2xx status code. The
+// start of the body of the response is included in the error to help with
+// debugging. The returned error is a *ResponseError, or a
+// *ServiceUnavailableError wrapping one if the response is an HTML page
+// showing that Nixplay is unavailable.
 func StatusError(resp *http.Response) error {
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("http status: %s: body: %s", resp.Status, body)
+		page, _ := io.ReadAll(io.LimitReader(resp.Body, maxHTMLPageSize))
+		err := newResponseError(resp, page)
+		if isUnavailableStatus(resp) && looksLikeHTML(page) {
+			return newServiceUnavailableError(resp, page, err)
+		}
+		return err
 	}
 	return nil
 }
+
+// newResponseError returns the error for resp, body is the start of the body
+// of the response.
+func newResponseError(resp *http.Response, body []byte) *ResponseError {
+	truncated := ""
+	if len(body) > maxErrorBodySize {
+		body = body[:maxErrorBodySize]
+		truncated = "..."
+	}
+	return &ResponseError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       string(body) + truncated,
+	}
+}
@@ -0,0 +1,38 @@
+package httpx
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusError(t *testing.T) {
+	newResponse := func(statusCode int, body string) *http.Response {
+		return &http.Response{
+			StatusCode: statusCode,
+			Status:     http.StatusText(statusCode),
+			Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+		}
+	}
+
+	assert.NoError(t, StatusError(newResponse(http.StatusOK, "")))
+
+	err := StatusError(newResponse(http.StatusNotFound, "missing"))
+	var respErr *ResponseError
+	require.True(t, errors.As(err, &respErr))
+	assert.Equal(t, http.StatusNotFound, respErr.StatusCode)
+	assert.Equal(t, "missing", respErr.Body)
+	assert.False(t, respErr.Retryable())
+
+	err = StatusError(newResponse(http.StatusBadGateway, strings.Repeat("x", 2*maxErrorBodySize)))
+	require.True(t, errors.As(err, &respErr))
+	assert.Equal(t, strings.Repeat("x", maxErrorBodySize)+"...", respErr.Body)
+	assert.True(t, respErr.Retryable())
+	assert.True(t, (&ResponseError{StatusCode: http.StatusTooManyRequests}).Retryable())
+}
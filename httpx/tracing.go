@@ -0,0 +1,113 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+)
+
+// Attribute is a key value pair that describes a traced operation.
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Span represents a single traced HTTP request.
+type Span interface {
+	// SetAttributes adds attributes to the span.
+	SetAttributes(attrs ...Attribute)
+
+	// End ends the span. statusCode is the HTTP status code of the response or
+	// 0 if no response was received, in which case err will be set.
+	End(statusCode int, err error)
+}
+
+// Tracer is an interface for starting spans for HTTP requests. It is
+// intentionally small so that it can easily be implemented on top of
+// OpenTelemetry (or any other tracing library) without go-nixplay needing to
+// depend on it. For example an OpenTelemetry based Tracer would call
+// trace.Tracer.Start and return a Span that forwards SetAttributes to
+// trace.Span.SetAttributes and End to trace.Span.SetStatus and trace.Span.End.
+type Tracer interface {
+	// StartSpan starts a new span with the provided name and attributes. The
+	// returned context is used for the traced request so that trace context
+	// can be propagated to any wrapped client.
+	StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+type operationKey struct{}
+type attributesKey struct{}
+
+// WithOperation returns a copy of the context that records the name of the
+// higher level operation (for example "AddPhoto") that requests made with the
+// context are part of. This name is used as the span name by the client
+// returned from NewTracedClient.
+func WithOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationKey{}, operation)
+}
+
+// Operation returns the operation name recorded by WithOperation or an empty
+// string if no operation has been recorded.
+func Operation(ctx context.Context) string {
+	operation, _ := ctx.Value(operationKey{}).(string)
+	return operation
+}
+
+// WithAttributes returns a copy of the context with additional attributes
+// that will be added to spans for any requests made with the context.
+func WithAttributes(ctx context.Context, attrs ...Attribute) context.Context {
+	existing := Attributes(ctx)
+	all := make([]Attribute, 0, len(existing)+len(attrs))
+	all = append(all, existing...)
+	all = append(all, attrs...)
+	return context.WithValue(ctx, attributesKey{}, all)
+}
+
+// Attributes returns the attributes recorded in the context by WithAttributes.
+func Attributes(ctx context.Context) []Attribute {
+	attrs, _ := ctx.Value(attributesKey{}).([]Attribute)
+	return attrs
+}
+
+// tracedClient is a Client that starts a span for every request.
+type tracedClient struct {
+	client Client
+	tracer Tracer
+}
+
+// NewTracedClient returns a Client that sends requests using the provided
+// client and uses tracer to start a span for every request.
+//
+// The span is named after the operation recorded in the request context by
+// WithOperation, or after the HTTP method if no operation has been recorded.
+func NewTracedClient(client Client, tracer Tracer) Client {
+	return &tracedClient{
+		client: client,
+		tracer: tracer,
+	}
+}
+
+func (c *tracedClient) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	name := Operation(ctx)
+	if name == "" {
+		name = req.Method
+	}
+
+	attrs := append([]Attribute{
+		{Key: "http.method", Value: req.Method},
+		{Key: "http.url", Value: RedactURL(req.URL)},
+	}, Attributes(ctx)...)
+
+	ctx, span := c.tracer.StartSpan(ctx, name, attrs...)
+	resp, err := c.client.Do(req.WithContext(ctx))
+
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+		span.SetAttributes(Attribute{Key: "http.status_code", Value: statusCode})
+	}
+	span.End(statusCode, err)
+
+	return resp, err
+}
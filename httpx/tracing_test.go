@@ -0,0 +1,67 @@
+package httpx
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clientFunc func(req *http.Request) (*http.Response, error)
+
+func (f clientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type testSpan struct {
+	name       string
+	attrs      []Attribute
+	statusCode int
+	ended      bool
+}
+
+func (s *testSpan) SetAttributes(attrs ...Attribute) {
+	s.attrs = append(s.attrs, attrs...)
+}
+
+func (s *testSpan) End(statusCode int, err error) {
+	s.statusCode = statusCode
+	s.ended = true
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) StartSpan(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	s := &testSpan{name: name, attrs: attrs}
+	t.spans = append(t.spans, s)
+	return ctx, s
+}
+
+func TestTracedClient(t *testing.T) {
+	inner := clientFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusCreated, Body: http.NoBody}, nil
+	})
+	tracer := &testTracer{}
+	client := NewTracedClient(inner, tracer)
+
+	ctx := WithOperation(context.Background(), "AddPhoto")
+	ctx = WithAttributes(ctx, Attribute{Key: "nixplay.photo.size", Value: int64(42)})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "https://example.com/upload?Signature=secret", http.NoBody)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	require.Len(t, tracer.spans, 1)
+	span := tracer.spans[0]
+	assert.Equal(t, "AddPhoto", span.name)
+	assert.True(t, span.ended)
+	assert.Equal(t, http.StatusCreated, span.statusCode)
+	assert.Contains(t, span.attrs, Attribute{Key: "http.url", Value: "https://example.com/upload?Signature=REDACTED"})
+	assert.Contains(t, span.attrs, Attribute{Key: "nixplay.photo.size", Value: int64(42)})
+}
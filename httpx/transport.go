@@ -0,0 +1,65 @@
+package httpx
+
+import (
+	"crypto/tls"
+	"net/http"
+	"time"
+)
+
+// TransportOptions tune the connections used to send requests. Bulk uploads
+// to S3 and bursts of listing requests benefit from keeping more idle
+// connections open for reuse than the defaults of the standard library.
+//
+// The zero value uses the same settings as http.DefaultTransport.
+type TransportOptions struct {
+	// MaxIdleConns is the maximum number of idle connections kept open across
+	// all hosts. If zero the default of http.DefaultTransport is used.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost is the maximum number of idle connections kept open
+	// to each host. If zero http.DefaultMaxIdleConnsPerHost is used.
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost limits the total number of connections to each host,
+	// including connections that are in use. If zero there is no limit.
+	MaxConnsPerHost int
+
+	// IdleConnTimeout is how long an idle connection is kept open before it
+	// is closed. If zero the default of http.DefaultTransport is used.
+	IdleConnTimeout time.Duration
+
+	// TLSClientConfig is the TLS configuration to use, for example to trust
+	// the certificate of a proxy that intercepts TLS. If nil the default
+	// configuration is used.
+	TLSClientConfig *tls.Config
+
+	// DisableProxyFromEnvironment stops the proxy from being configured by
+	// the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables, see
+	// http.ProxyFromEnvironment. By default the environment is used.
+	DisableProxyFromEnvironment bool
+}
+
+// NewTransport returns a new http.Transport with the settings of
+// http.DefaultTransport modified by opts.
+func NewTransport(opts TransportOptions) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.MaxIdleConns != 0 {
+		transport.MaxIdleConns = opts.MaxIdleConns
+	}
+	if opts.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = opts.MaxIdleConnsPerHost
+	}
+	if opts.MaxConnsPerHost != 0 {
+		transport.MaxConnsPerHost = opts.MaxConnsPerHost
+	}
+	if opts.IdleConnTimeout != 0 {
+		transport.IdleConnTimeout = opts.IdleConnTimeout
+	}
+	if opts.TLSClientConfig != nil {
+		transport.TLSClientConfig = opts.TLSClientConfig.Clone()
+	}
+	if opts.DisableProxyFromEnvironment {
+		transport.Proxy = nil
+	}
+	return transport
+}
@@ -0,0 +1,44 @@
+package httpx
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTransport(t *testing.T) {
+	defaultTransport := http.DefaultTransport.(*http.Transport)
+
+	t.Run("Defaults", func(t *testing.T) {
+		transport := NewTransport(TransportOptions{})
+		assert.Equal(t, defaultTransport.MaxIdleConns, transport.MaxIdleConns)
+		assert.Equal(t, defaultTransport.MaxIdleConnsPerHost, transport.MaxIdleConnsPerHost)
+		assert.Equal(t, defaultTransport.IdleConnTimeout, transport.IdleConnTimeout)
+		assert.NotNil(t, transport.Proxy)
+	})
+
+	t.Run("Tuned", func(t *testing.T) {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS13}
+		transport := NewTransport(TransportOptions{
+			MaxIdleConns:                200,
+			MaxIdleConnsPerHost:         32,
+			MaxConnsPerHost:             64,
+			IdleConnTimeout:             time.Minute,
+			TLSClientConfig:             tlsConfig,
+			DisableProxyFromEnvironment: true,
+		})
+		assert.Equal(t, 200, transport.MaxIdleConns)
+		assert.Equal(t, 32, transport.MaxIdleConnsPerHost)
+		assert.Equal(t, 64, transport.MaxConnsPerHost)
+		assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+		assert.Equal(t, uint16(tls.VersionTLS13), transport.TLSClientConfig.MinVersion)
+		assert.NotSame(t, tlsConfig, transport.TLSClientConfig)
+		assert.Nil(t, transport.Proxy)
+	})
+
+	// The default transport is never modified.
+	assert.NotEqual(t, 32, defaultTransport.MaxIdleConnsPerHost)
+}
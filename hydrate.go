@@ -0,0 +1,99 @@
+package nixplay
+
+import (
+	"context"
+	"sort"
+
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// hydratePhotoNames looks up the names of photos in a playlist from the albums
+// of the account.
+//
+// Nixplay does not include the name of photos when listing the slides in a
+// playlist, so otherwise the name of each photo needs its own request to the
+// picture endpoint. However every photo in a playlist is also a photo in an
+// album, and listing the photos in an album includes their names for a whole
+// page of photos per request. Albums are only listed while doing so takes
+// fewer requests than looking up the remaining names one at a time, starting
+// with "My Uploads" as that is where photos uploaded to playlists end up. Any
+// names that are not found are looked up one at a time as usual.
+func (c *container) hydratePhotoNames(ctx context.Context, photos []Photo) error {
+	if c.nixplayClient == nil {
+		return nil
+	}
+
+	remaining := 0
+	missing := make(map[uint64][]*photo)
+	for _, p := range photos {
+		pp, ok := p.(*photo)
+		if !ok {
+			continue
+		}
+		nixplayID := pp.snapshot().nixplayID
+		if nixplayID == 0 {
+			continue
+		}
+		missing[nixplayID] = append(missing[nixplayID], pp)
+		remaining++
+	}
+	if remaining == 0 {
+		return nil
+	}
+
+	albums, err := c.nixplayClient.Containers(ctx, types.AlbumContainerType)
+	if err != nil {
+		return err
+	}
+	isMyUploads := func(a Container) bool {
+		ac, ok := a.(*container)
+		return ok && ac.special == myUploadsSpecialAlbum
+	}
+	sort.SliceStable(albums, func(i, j int) bool {
+		return isMyUploads(albums[i]) && !isMyUploads(albums[j])
+	})
+
+	for _, album := range albums {
+		if remaining == 0 {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		count, err := album.PhotoCount(ctx)
+		if err != nil {
+			return err
+		}
+		pages := (count + int64(photoPageSize) - 1) / int64(photoPageSize)
+		if pages >= int64(remaining) {
+			continue
+		}
+
+		albumPhotos, err := album.Photos(ctx)
+		if err != nil {
+			return err
+		}
+		for _, ap := range albumPhotos {
+			app, ok := ap.(*photo)
+			if !ok {
+				continue
+			}
+			state := app.snapshot()
+			playlistPhotos, ok := missing[state.nixplayID]
+			if !ok || state.name == "" {
+				continue
+			}
+			for _, p := range playlistPhotos {
+				p.update(func(s *photoState) {
+					if s.name == "" {
+						s.name = state.name
+					}
+				})
+			}
+			delete(missing, state.nixplayID)
+			remaining -= len(playlistPhotos)
+		}
+	}
+	return nil
+}
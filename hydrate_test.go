@@ -0,0 +1,88 @@
+package nixplay
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/rawapi"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// albumsClient is a Client that only implements Containers, for albums.
+type albumsClient struct {
+	Client
+	albums []Container
+}
+
+func (c *albumsClient) Containers(ctx context.Context, containerType types.ContainerType) ([]Container, error) {
+	return c.albums, nil
+}
+
+func TestPlaylist_HydratePhotoNames(t *testing.T) {
+	ctx := context.Background()
+
+	const slideCount = 5
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		require.Fail(t, "unexpected request", req.URL.String())
+		return nil, nil
+	})
+	settings := &clientSettings{
+		metrics: nopMetrics{},
+		changes: &changeNotifier{},
+	}
+	hash := func(i uint64) *types.MD5Hash {
+		h := types.MD5Hash(md5.Sum([]byte(fmt.Sprint(i))))
+		return &h
+	}
+
+	// The photos in the playlist are in "My Uploads", the other album would
+	// take too many requests to list to be worth it.
+	var albumPages []string
+	albumPageFunc := func(name string, ids ...uint64) photoPageFunc {
+		return func(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error) {
+			albumPages = append(albumPages, name)
+			if page > 0 {
+				return nil, nil
+			}
+			var photos []Photo
+			for _, id := range ids {
+				p, err := newPhoto(container, client, fmt.Sprintf("photo%d.jpg", id), hash(id), id, "", -1, "")
+				require.NoError(t, err)
+				photos = append(photos, p)
+			}
+			return photos, nil
+		}
+	}
+	large := newContainer(client, nil, settings, types.AlbumContainerType, "large", 1, 10000, albumPageFunc("large"), (*rawapi.Client).DeleteAlbum, albumAddIDName)
+	myUploads := newContainer(client, nil, settings, types.AlbumContainerType, myUploadsAlbumName, 2, slideCount, albumPageFunc(myUploadsAlbumName, 1, 2, 3, 4, 5), (*rawapi.Client).DeleteAlbum, albumAddIDName)
+	myUploads.special = myUploadsSpecialAlbum
+	nixplayClient := &albumsClient{albums: []Container{large, myUploads}}
+
+	playlistPageFunc := func(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error) {
+		if page > 0 {
+			return nil, nil
+		}
+		var photos []Photo
+		for id := uint64(1); id <= slideCount; id++ {
+			p, err := newPhoto(container, client, "", hash(id), id, fmt.Sprint(id), -1, "")
+			require.NoError(t, err)
+			photos = append(photos, p)
+		}
+		return photos, nil
+	}
+	playlist := newContainer(client, nixplayClient, settings, types.PlaylistContainerType, "playlist", 3, slideCount, playlistPageFunc, (*rawapi.Client).DeletePlaylist, playlistAddIDName)
+
+	photos, err := playlist.PhotosWithName(ctx, "photo3.jpg")
+	require.NoError(t, err)
+	require.Len(t, photos, 1)
+	nixplayID, err := photos[0].(*photo).getNixplayID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), nixplayID)
+	assert.Equal(t, []string{myUploadsAlbumName, myUploadsAlbumName}, albumPages)
+}
@@ -0,0 +1,59 @@
+package nixplay
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// IDVersion is the version of the scheme used to compute the IDs returned by
+// Container.ID and Photo.ID. It is incremented whenever a change to
+// go-nixplay causes the ID of an existing container or photo to change.
+//
+// Code that persists IDs should store IDVersion alongside them, if the stored
+// version does not match IDVersion then the stored IDs must be looked up again
+// rather than being compared to IDs returned by the client.
+const IDVersion = 1
+
+// containerID computes the ID of a container. The container type is included
+// since Nixplay uses separate numbering for albums and playlists so an album
+// and a playlist may have the same Nixplay ID.
+func containerID(containerType types.ContainerType, nixplayID uint64) types.ID {
+	nixplayIDAsBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(nixplayIDAsBytes, nixplayID)
+	hasher := sha256.New()
+	hasher.Write([]byte(containerType)) // shouldn't ever error so we don't need to check for one
+	hasher.Write(nixplayIDAsBytes)
+	return *(*types.ID)(hasher.Sum([]byte{}))
+}
+
+// photoID computes the ID of a photo based on the ID of the container it
+// resides in and the MD5 hash of the photo. See newPhoto for details.
+func photoID(containerID types.ID, md5Hash types.MD5Hash) types.ID {
+	hasher := sha256.New()
+	hasher.Write(containerID[:]) // shouldn't ever error so we don't need to check for one
+	hasher.Write(md5Hash[:])
+	return *(*types.ID)(hasher.Sum([]byte{}))
+}
+
+// playlistItemPhotoID computes the ID of a photo in a playlist based on the ID
+// of the playlist and the ID Nixplay uses for the slide in the playlist, see
+// types.PlaylistItemPhotoIdentity.
+func playlistItemPhotoID(containerID types.ID, playlistItemID string) types.ID {
+	hasher := sha256.New()
+	hasher.Write(containerID[:]) // shouldn't ever error so we don't need to check for one
+	hasher.Write([]byte("playlistItem"))
+	hasher.Write([]byte(playlistItemID))
+	return *(*types.ID)(hasher.Sum([]byte{}))
+}
+
+// photoIDInContainer computes the ID of a photo in a container taking in to
+// account the types.PhotoIdentity the client was configured with.
+// playlistItemID may be empty if it is not known.
+func photoIDInContainer(c Container, md5Hash types.MD5Hash, playlistItemID string) types.ID {
+	if cc, ok := c.(*container); ok && cc.usesPlaylistItemIdentity() && playlistItemID != "" {
+		return playlistItemPhotoID(cc.id, playlistItemID)
+	}
+	return photoID(c.ID(), md5Hash)
+}
@@ -0,0 +1,111 @@
+package nixplay
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/rawapi"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDs(t *testing.T) {
+	albumID := containerID(types.AlbumContainerType, 1234)
+	playlistID := containerID(types.PlaylistContainerType, 1234)
+	assert.NotEqual(t, albumID, playlistID, "albums and playlists with the same Nixplay ID must have different IDs")
+	assert.NotEqual(t, albumID, containerID(types.AlbumContainerType, 1235))
+
+	md5Hash := types.MD5Hash(md5.Sum([]byte("photo")))
+	assert.NotEqual(t, photoID(albumID, md5Hash), photoID(playlistID, md5Hash))
+
+	// IDs are persisted by users of this library so they must not change
+	// without incrementing IDVersion. If this test fails because the way IDs
+	// are computed was changed on purpose then update the expected values
+	// below and increment IDVersion.
+	assert.Equal(t, 1, IDVersion)
+	assert.Equal(t, "b81b95a2a25b5f533da3519cda0da5c566166d837c410ae6cfaec77fbb802259", hex.EncodeToString(albumID[:]))
+	assert.Equal(t, "96a86e660d012795e4147123ec25f5a9c7ab980b3094704082ca833c56b56254", hex.EncodeToString(playlistID[:]))
+	photo := photoID(albumID, md5Hash)
+	assert.Equal(t, "6579eae4f5e9639fdd05491cbe88dfbb53c767a577076a86b423875a92c8bea6", hex.EncodeToString(photo[:]))
+}
+
+func TestPlaylistItemPhotoIdentity(t *testing.T) {
+	ctx := context.Background()
+	h := types.MD5Hash(md5.Sum([]byte("photo")))
+
+	// slides are the playlist item IDs of the slides in the playlist, which
+	// are all copies of the same photo.
+	slides := []string{"item1", "item2"}
+	pageFunc := func(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error) {
+		if page > 0 {
+			return nil, nil
+		}
+		var photos []Photo
+		for i, itemID := range slides {
+			p, err := newPhoto(container, client, "photo.jpg", &h, uint64(i+1), itemID, 5, "")
+			require.NoError(t, err)
+			photos = append(photos, p)
+		}
+		return photos, nil
+	}
+	newTestPlaylist := func(identity types.PhotoIdentity) *container {
+		settings := &clientSettings{
+			metrics:       nopMetrics{},
+			changes:       &changeNotifier{},
+			photoIdentity: identity,
+		}
+		return newContainer(noRequestClient{t: t}, nil, settings, types.PlaylistContainerType, "playlist", 1234, int64(len(slides)), pageFunc, (*rawapi.Client).DeletePlaylist, playlistAddIDName)
+	}
+
+	t.Run("Content", func(t *testing.T) {
+		c := newTestPlaylist(types.ContentPhotoIdentity)
+		photos, err := c.Photos(ctx)
+		require.NoError(t, err)
+		// Copies of the same photo have the same ID so only one is listed.
+		require.Len(t, photos, 1)
+		assert.Equal(t, photoID(c.ID(), h), photos[0].ID())
+	})
+
+	t.Run("PlaylistItem", func(t *testing.T) {
+		c := newTestPlaylist(types.PlaylistItemPhotoIdentity)
+		photos, err := c.Photos(ctx)
+		require.NoError(t, err)
+		require.Len(t, photos, 2)
+		assert.NotEqual(t, photos[0].ID(), photos[1].ID())
+		assert.Equal(t, playlistItemPhotoID(c.ID(), "item1"), photos[0].ID())
+
+		for _, p := range photos {
+			found, err := c.PhotoWithID(ctx, p.ID())
+			require.NoError(t, err)
+			assert.Same(t, p, found)
+		}
+
+		found, err := c.photoWithMD5Hash(ctx, h)
+		require.NoError(t, err)
+		assert.NotNil(t, found)
+
+		// After an upload the new slide is the one whose ID wasn't known
+		// before the upload.
+		knownIDs, err := c.photoIDs(ctx)
+		require.NoError(t, err)
+		slides = append(slides, "item3")
+		defer func() { slides = slides[:2] }()
+		uploaded, err := c.findUploadedSlide(ctx, h, knownIDs)
+		require.NoError(t, err)
+		assert.Equal(t, playlistItemPhotoID(c.ID(), "item3"), uploaded.ID())
+
+		// If no new slide was added the existing slide is returned.
+		knownIDs, err = c.photoIDs(ctx)
+		require.NoError(t, err)
+		uploaded, err = c.findUploadedSlide(ctx, h, knownIDs)
+		require.NoError(t, err)
+		assert.Contains(t, knownIDs, uploaded.ID())
+
+		_, err = c.findUploadedSlide(ctx, types.MD5Hash{}, knownIDs)
+		assert.ErrorIs(t, err, types.ErrMD5Mismatch)
+	})
+}
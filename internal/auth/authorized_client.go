@@ -10,6 +10,7 @@ import (
 	"net/http/cookiejar"
 	"net/url"
 	"strings"
+	"sync"
 
 	"github.com/anitschke/go-nixplay/httpx"
 	"github.com/anitschke/go-nixplay/types"
@@ -18,6 +19,11 @@ import (
 
 const (
 	loginURL = "https://api.nixplay.com/www-login/"
+	apiURL   = "https://api.nixplay.com/"
+
+	// csrfCookieName is the name of the cookie that Nixplay uses to send the
+	// CSRF token, which must be sent back in the X-CSRFToken header.
+	csrfCookieName = "prod.csrftoken"
 )
 
 type loginResponse struct {
@@ -73,7 +79,9 @@ func (r loginResponse) parseErrors() error {
 type auth struct {
 	token     string
 	csrfToken string
-	jar       http.CookieJar
+
+	// jar is a *cookiejar.Jar which is safe for concurrent use on its own.
+	jar http.CookieJar
 }
 
 // AuthorizedClient is a httpx.Client that appends the required headers and
@@ -82,25 +90,45 @@ type auth struct {
 // It is safe to use AuthorizedClient to requests to other domains as well, when
 // this happens the client will do the right thing and will NOT authorize the
 // request.
+//
+// AuthorizedClient is safe for concurrent use. When authorizing with cookies,
+// cookies set by Nixplay in responses are stored for later requests, including
+// a new CSRF token when Nixplay rotates it.
 type AuthorizedClient struct {
 	client httpx.Client
-	auth   auth
+	method types.AuthMethod
+
+	// mu guards the tokens in auth, which are updated when Nixplay rotates
+	// the CSRF token.
+	mu   sync.RWMutex
+	auth auth
 }
 
 var _ = (httpx.Client)((*AuthorizedClient)(nil))
 
-func NewAuthorizedClient(ctx context.Context, client httpx.Client, authIn types.Authorization) (*AuthorizedClient, error) {
-	auth, err := doAuth(ctx, client, authIn)
+func NewAuthorizedClient(ctx context.Context, client httpx.Client, authIn types.Authorization, method types.AuthMethod) (*AuthorizedClient, error) {
+	if method != types.CookieAuthMethod && method != types.BearerAuthMethod {
+		return nil, fmt.Errorf("failed to create authorized http client: unknown auth method %q", method)
+	}
+
+	var auth auth
+	var err error
+	if authIn.Session != nil {
+		auth, err = restoreSession(*authIn.Session, method)
+	} else {
+		auth, err = doAuth(ctx, client, authIn, method)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create authorized http client: %w", err)
 	}
 	return &AuthorizedClient{
 		client: client,
+		method: method,
 		auth:   auth,
 	}, nil
 }
 
-func doAuth(ctx context.Context, client httpx.Client, authIn types.Authorization) (auth, error) {
+func doAuth(ctx context.Context, client httpx.Client, authIn types.Authorization, method types.AuthMethod) (auth, error) {
 	parsedLoginURL, err := url.Parse(loginURL)
 	if err != nil {
 		return auth{}, err
@@ -112,7 +140,7 @@ func doAuth(ctx context.Context, client httpx.Client, authIn types.Authorization
 	}
 	req, err := httpx.NewPostFormRequest(ctx, loginURL, loginForm)
 	if err != nil {
-		return auth{}, nil
+		return auth{}, err
 	}
 
 	resp, err := client.Do(req)
@@ -140,7 +168,7 @@ func doAuth(ctx context.Context, client httpx.Client, authIn types.Authorization
 		}
 		allowedCookies = append(allowedCookies, c)
 		// Keep track of the CSRF token
-		if c.Name == "prod.csrftoken" {
+		if c.Name == csrfCookieName {
 			csrfToken = c.Value
 		}
 	}
@@ -159,7 +187,12 @@ func doAuth(ctx context.Context, client httpx.Client, authIn types.Authorization
 		return auth{}, err
 	}
 
-	if csrfToken == "" {
+	// The token is returned by the legacy log in endpoint but is only used
+	// for bearer authorization, see authorize.
+	if method == types.BearerAuthMethod && response.Token == "" {
+		return auth{}, errors.New("token not set in log in response")
+	}
+	if method == types.CookieAuthMethod && csrfToken == "" {
 		return auth{}, errors.New("CSRF token not set in log in response")
 	}
 	return auth{
@@ -169,25 +202,104 @@ func doAuth(ctx context.Context, client httpx.Client, authIn types.Authorization
 	}, nil
 }
 
+// restoreSession creates the auth for a session previously returned by
+// AuthorizedClient.Session.
+func restoreSession(session types.Session, method types.AuthMethod) (auth, error) {
+	if method == types.BearerAuthMethod && session.Token == "" {
+		return auth{}, errors.New("token not set in session")
+	}
+	if method == types.CookieAuthMethod && session.CSRFToken == "" {
+		return auth{}, errors.New("CSRF token not set in session")
+	}
+
+	parsedAPIURL, err := url.Parse(apiURL)
+	if err != nil {
+		return auth{}, err
+	}
+	jar, err := cookiejar.New(&cookiejar.Options{
+		PublicSuffixList: publicsuffix.List,
+	})
+	if err != nil {
+		return auth{}, err
+	}
+	cookies := make([]*http.Cookie, 0, len(session.Cookies))
+	for name, value := range session.Cookies {
+		cookies = append(cookies, &http.Cookie{Name: name, Value: value})
+	}
+	jar.SetCookies(parsedAPIURL, cookies)
+
+	return auth{
+		token:     session.Token,
+		csrfToken: session.CSRFToken,
+		jar:       jar,
+	}, nil
+}
+
+// Session returns the current state of the session so that it can be restored
+// later by passing it to NewAuthorizedClient.
+func (c *AuthorizedClient) Session() types.Session {
+	c.mu.RLock()
+	session := types.Session{
+		Token:     c.auth.token,
+		CSRFToken: c.auth.csrfToken,
+		Cookies:   make(map[string]string),
+	}
+	c.mu.RUnlock()
+	if parsedAPIURL, err := url.Parse(apiURL); err == nil {
+		for _, cookie := range c.auth.jar.Cookies(parsedAPIURL) {
+			session.Cookies[cookie.Name] = cookie.Value
+		}
+	}
+	return session
+}
+
 func (c *AuthorizedClient) Do(req *http.Request) (*http.Response, error) {
 
 	if req.URL.Host != "api.nixplay.com" {
 		return c.client.Do(req)
 	}
 
-	for _, cookie := range c.auth.jar.Cookies(req.URL) {
-		req.AddCookie(cookie)
-	}
-	req.Header.Set("X-CSRFToken", c.auth.csrfToken)
+	// The request belongs to the caller, who may send it again, so the
+	// authorization is added to a copy.
+	req = req.Clone(req.Context())
+	c.authorize(req)
 	req.Header.Set("Origin", "https://app.nixplay.com")
 	req.Header.Set("Referer", "https://app.nixplay.com/")
 
 	resp, err := c.client.Do(req)
 
-	if err == nil {
+	if err == nil && c.method == types.CookieAuthMethod {
 		if rc := resp.Cookies(); len(rc) > 0 {
-			c.auth.jar.SetCookies(req.URL, rc)
+			c.updateCookies(req.URL, rc)
 		}
 	}
 	return resp, err
 }
+
+// authorize adds the headers and cookies that authorize req according to the
+// auth method of the client.
+func (c *AuthorizedClient) authorize(req *http.Request) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.method == types.BearerAuthMethod {
+		req.Header.Set("Authorization", "Bearer "+c.auth.token)
+		return
+	}
+	for _, cookie := range c.auth.jar.Cookies(req.URL) {
+		req.AddCookie(cookie)
+	}
+	req.Header.Set("X-CSRFToken", c.auth.csrfToken)
+}
+
+// updateCookies stores the cookies set by a response from Nixplay and picks up
+// the new CSRF token if Nixplay has rotated it.
+func (c *AuthorizedClient) updateCookies(u *url.URL, cookies []*http.Cookie) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.auth.jar.SetCookies(u, cookies)
+	for _, cookie := range cookies {
+		if cookie.Name == csrfCookieName && cookie.Value != "" && cookie.MaxAge >= 0 {
+			c.auth.csrfToken = cookie.Value
+		}
+	}
+}
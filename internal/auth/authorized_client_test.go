@@ -5,10 +5,15 @@ import (
 	"crypto/sha512"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"testing"
 
+	"github.com/anitschke/go-nixplay/httpx"
 	"github.com/anitschke/go-nixplay/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -33,7 +38,7 @@ func TestAuthorizedClient_LoginPass(t *testing.T) {
 
 	assert.NoError(t, err)
 	client := http.Client{}
-	authClient, err := NewAuthorizedClient(context.Background(), &client, auth)
+	authClient, err := NewAuthorizedClient(context.Background(), &client, auth, types.CookieAuthMethod)
 	assert.NoError(t, err)
 	assert.NotNil(t, authClient)
 }
@@ -44,7 +49,7 @@ func TestAuthorizedClient_LoginFail_EmptyLogin(t *testing.T) {
 		Password: "",
 	}
 	client := http.Client{}
-	authClient, err := NewAuthorizedClient(context.Background(), &client, invalidAuth)
+	authClient, err := NewAuthorizedClient(context.Background(), &client, invalidAuth, types.CookieAuthMethod)
 	assert.ErrorContains(t, err, "Please enter password")
 	assert.ErrorContains(t, err, "Please enter your email address")
 	assert.ErrorContains(t, err, "Please check your username and password")
@@ -57,7 +62,7 @@ func TestAuthorizedClient_LoginFail_InvalidLogin(t *testing.T) {
 		Password: "ThisIsNotAValidPassword",
 	}
 	client := http.Client{}
-	authClient, err := NewAuthorizedClient(context.Background(), &client, invalidAuth)
+	authClient, err := NewAuthorizedClient(context.Background(), &client, invalidAuth, types.CookieAuthMethod)
 	assert.ErrorContains(t, err, "Please check your username and password")
 	assert.Nil(t, authClient)
 }
@@ -73,7 +78,7 @@ func TestAuthorizedClient_SendRequest(t *testing.T) {
 	auth, err := TestAccountAuth()
 	assert.NoError(t, err)
 	client := http.Client{}
-	authClient, err := NewAuthorizedClient(context.Background(), &client, auth)
+	authClient, err := NewAuthorizedClient(context.Background(), &client, auth, types.CookieAuthMethod)
 	require.NoError(t, err)
 
 	userProfileURL := "https://api.nixplay.com/user/profile/edit/"
@@ -98,3 +103,148 @@ func TestAuthorizedClient_SendRequest(t *testing.T) {
 	expOldUsername := auth.Username + "@mynixplay.com"
 	assert.Equal(t, decodedResponse.OldUsername, expOldUsername)
 }
+
+type recordingClient struct {
+	req *http.Request
+}
+
+func (c *recordingClient) Do(req *http.Request) (*http.Response, error) {
+	c.req = req
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}, nil
+}
+
+func TestAuthorizedClient_Session(t *testing.T) {
+	session := types.Session{
+		Token:     "token",
+		CSRFToken: "csrf",
+		Cookies:   map[string]string{"prod.csrftoken": "csrf", "prod.session.id": "session"},
+	}
+
+	recorder := &recordingClient{}
+	client, err := NewAuthorizedClient(context.Background(), recorder, types.Authorization{Session: &session}, types.CookieAuthMethod)
+	require.NoError(t, err)
+	assert.Equal(t, session, client.Session())
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.nixplay.com/v2/albums/web/json/", http.NoBody)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, "csrf", recorder.req.Header.Get("X-CSRFToken"))
+	cookie, err := recorder.req.Cookie("prod.session.id")
+	require.NoError(t, err)
+	assert.Equal(t, "session", cookie.Value)
+
+	_, err = NewAuthorizedClient(context.Background(), recorder, types.Authorization{Session: &types.Session{}}, types.CookieAuthMethod)
+	assert.Error(t, err)
+}
+
+func TestAuthorizedClient_CSRFTokenRotation(t *testing.T) {
+	session := types.Session{
+		Token:     "token",
+		CSRFToken: "csrf",
+		Cookies:   map[string]string{"prod.csrftoken": "csrf"},
+	}
+
+	var sent []string
+	rotating := httpx.ClientFunc(func(req *http.Request) (*http.Response, error) {
+		sent = append(sent, req.Header.Get("X-CSRFToken"))
+		header := http.Header{}
+		if len(sent) == 1 {
+			header.Add("Set-Cookie", "prod.csrftoken=rotated; Path=/")
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: header}, nil
+	})
+	client, err := NewAuthorizedClient(context.Background(), rotating, types.Authorization{Session: &session}, types.CookieAuthMethod)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.nixplay.com/v2/albums/web/json/", http.NoBody)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = client.Do(req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []string{"csrf", "rotated"}, sent)
+	assert.Empty(t, req.Header.Get("X-CSRFToken"), "the caller's request is not modified")
+
+	got := client.Session()
+	assert.Equal(t, "rotated", got.CSRFToken)
+	assert.Equal(t, "rotated", got.Cookies["prod.csrftoken"])
+}
+
+func TestAuthorizedClient_ConcurrentRequests(t *testing.T) {
+	session := types.Session{
+		Token:     "token",
+		CSRFToken: "csrf-0",
+		Cookies:   map[string]string{"prod.csrftoken": "csrf-0"},
+	}
+
+	var count int64
+	rotating := httpx.ClientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.Header.Get("X-CSRFToken") == "" {
+			return nil, errors.New("missing CSRF token")
+		}
+		n := atomic.AddInt64(&count, 1)
+		header := http.Header{}
+		header.Add("Set-Cookie", fmt.Sprintf("prod.csrftoken=csrf-%d; Path=/", n))
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: header}, nil
+	})
+	client, err := NewAuthorizedClient(context.Background(), rotating, types.Authorization{Session: &session}, types.CookieAuthMethod)
+	require.NoError(t, err)
+
+	// Run with -race to detect unsynchronized access to the auth state.
+	const requests = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, err := http.NewRequest(http.MethodGet, "https://api.nixplay.com/v2/albums/web/json/", http.NoBody)
+			if err == nil {
+				_, err = client.Do(req)
+			}
+			errs <- err
+			client.Session()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	got := client.Session()
+	assert.NotEqual(t, "csrf-0", got.CSRFToken)
+	assert.Equal(t, got.CSRFToken, got.Cookies["prod.csrftoken"])
+}
+
+func TestAuthorizedClient_Bearer(t *testing.T) {
+	session := types.Session{
+		Token:   "token",
+		Cookies: map[string]string{"prod.session.id": "session"},
+	}
+
+	recorder := &recordingClient{}
+	client, err := NewAuthorizedClient(context.Background(), recorder, types.Authorization{Session: &session}, types.BearerAuthMethod)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.nixplay.com/v2/albums/web/json/", http.NoBody)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token", recorder.req.Header.Get("Authorization"))
+	assert.Empty(t, recorder.req.Header.Get("X-CSRFToken"))
+	assert.Empty(t, recorder.req.Cookies())
+
+	// Other hosts, such as S3, never receive the token.
+	req, err = http.NewRequest(http.MethodGet, "https://s3.amazonaws.com/photo.jpg", http.NoBody)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+	require.NoError(t, err)
+	assert.Empty(t, recorder.req.Header.Get("Authorization"))
+
+	_, err = NewAuthorizedClient(context.Background(), recorder, types.Authorization{Session: &types.Session{CSRFToken: "csrf"}}, types.BearerAuthMethod)
+	assert.Error(t, err)
+	_, err = NewAuthorizedClient(context.Background(), recorder, types.Authorization{Session: &session}, types.AuthMethod("unknown"))
+	assert.Error(t, err)
+}
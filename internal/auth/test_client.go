@@ -2,23 +2,40 @@ package auth
 
 import (
 	"fmt"
+	"net/http"
 	"os"
+	"sync"
 
+	"github.com/anitschke/go-nixplay/httpx"
 	"github.com/anitschke/go-nixplay/types"
 )
 
 const (
 	testUsernameEnvVar = "GO_NIXPLAY_TEST_ACCOUNT_USERNAME"
 	testPasswordEnvVar = "GO_NIXPLAY_TEST_ACCOUNT_PASSWORD"
+	testRecordEnvVar   = "GO_NIXPLAY_TEST_RECORD"
+	testReplayEnvVar   = "GO_NIXPLAY_TEST_REPLAY"
+
+	// replayUsername is the username used in place of the real username of
+	// the test account in recorded cassettes.
+	replayUsername = "go-nixplay-test"
 )
 
 // TestAccountAuth gets the test account Authorization
 //
 // Authorization details are obtained from the
 // "GO_NIXPLAY_TEST_ACCOUNT_USERNAME" and "GO_NIXPLAY_TEST_ACCOUNT_PASSWORD"
-// environment variables. For more details see
+// environment variables. If a cassette is being replayed (see TestHTTPClient)
+// placeholder credentials are returned instead. For more details see
 // https://github.com/anitschke/go-nixplay/#testing
 func TestAccountAuth() (types.Authorization, error) {
+	if os.Getenv(testReplayEnvVar) != "" {
+		return types.Authorization{
+			Username: replayUsername,
+			Password: "password",
+		}, nil
+	}
+
 	username := os.Getenv(testUsernameEnvVar)
 	password := os.Getenv(testPasswordEnvVar)
 
@@ -31,3 +48,60 @@ func TestAccountAuth() (types.Authorization, error) {
 		Password: password,
 	}, nil
 }
+
+var (
+	testCassetteOnce sync.Once
+	testCassette     *httpx.Cassette
+	testCassetteErr  error
+)
+
+// TestHTTPClient gets the HTTP client that should be used for testing.
+//
+// If the "GO_NIXPLAY_TEST_RECORD" environment variable is set then all
+// requests are recorded and saved to the cassette file it names when
+// SaveTestCassette is called. If the "GO_NIXPLAY_TEST_REPLAY" environment
+// variable is set then requests are replayed from the cassette file it names
+// rather than being sent to Nixplay.
+func TestHTTPClient() (httpx.Client, error) {
+	testCassetteOnce.Do(func() {
+		if path := os.Getenv(testReplayEnvVar); path != "" {
+			testCassette, testCassetteErr = httpx.LoadCassette(path)
+			return
+		}
+		if os.Getenv(testRecordEnvVar) != "" {
+			testCassette = &httpx.Cassette{}
+			if username := os.Getenv(testUsernameEnvVar); username != "" {
+				testCassette.Replacements = map[string]string{username: replayUsername}
+			}
+		}
+	})
+	if testCassetteErr != nil {
+		return nil, testCassetteErr
+	}
+
+	switch {
+	case os.Getenv(testReplayEnvVar) != "":
+		return httpx.NewReplayClient(testCassette), nil
+	case testCassette != nil:
+		return httpx.NewRecordingClient(&http.Client{}, testCassette), nil
+	default:
+		return &http.Client{}, nil
+	}
+}
+
+// IsRecordingOrReplaying returns true if the tests are being recorded to or
+// replayed from a cassette.
+func IsRecordingOrReplaying() bool {
+	return os.Getenv(testRecordEnvVar) != "" || os.Getenv(testReplayEnvVar) != ""
+}
+
+// SaveTestCassette saves the requests recorded by clients returned from
+// TestHTTPClient to the file named by the "GO_NIXPLAY_TEST_RECORD" environment
+// variable. If requests are not being recorded it does nothing.
+func SaveTestCassette() error {
+	path := os.Getenv(testRecordEnvVar)
+	if path == "" || testCassette == nil || os.Getenv(testReplayEnvVar) != "" {
+		return nil
+	}
+	return testCassette.Save(path)
+}
@@ -2,8 +2,10 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/anitschke/go-nixplay/types"
 )
@@ -13,6 +15,9 @@ type Element interface {
 	Name(ctx context.Context) (string, error)
 }
 
+// ListenableElement is an element that notifies listeners when it is deleted.
+// AddDeletedListener must ignore listeners that were already added, see
+// DeletedListeners.
 type ListenableElement interface {
 	Element
 	AddDeletedListener(l ElementDeletedListener)
@@ -27,6 +32,15 @@ type ElementUniqueNameGenerator interface {
 	GenerateUniqueName(ctx context.Context) (string, error)
 }
 
+// ElementKnownNamer may be implemented by elements that can report their name
+// without making a network request. This allows the cache to keep its name
+// maps up to date when elements are added rather than rebuilding them.
+type ElementKnownNamer interface {
+	// KnownName returns the name of the element and true if the name is
+	// already known, otherwise it returns false.
+	KnownName() (string, bool)
+}
+
 type ElementDeletedListener interface {
 	ElementDeleted(ctx context.Context, e Element) error
 }
@@ -37,6 +51,25 @@ type ElementDeletedListener interface {
 // Page number starts at 0
 type elementPageFunc[T Element] func(ctx context.Context, page uint64) ([]T, error)
 
+// maxConcurrentPages is the maximum number of pages that are requested
+// concurrently when loading elements into the cache.
+const maxConcurrentPages = 4
+
+// maxConcurrentNames is the maximum number of element names that are looked up
+// concurrently when populating the name map.
+const maxConcurrentNames = 8
+
+// Persistence provides functions that can be used to persist the elements of
+// a cache so they can be reused rather than loading them again page by page.
+type Persistence[T Element] struct {
+	// Load loads previously persisted elements. ok is false if there are no
+	// persisted elements or they are no longer valid.
+	Load func(ctx context.Context) (elements []T, ok bool, err error)
+
+	// Save persists all of the elements in the cache.
+	Save func(ctx context.Context, elements []T) error
+}
+
 // Cache provides caching of containers or photos within a container so we do
 // not need to do a HTTP request to lookup info every time we want info on an
 // element.
@@ -45,12 +78,25 @@ type Cache[T Element] struct {
 
 	mu                  sync.Mutex
 	foundAll            bool
+	foundAllTime        time.Time
+	loading             *loadCall
 	elements            []T
 	nameToElements      map[string][]T
 	uniqueNameToElement map[string]T
 	idToElement         map[types.ID]T
 
-	elementDeletedListener []ElementDeletedListener
+	deletedListeners DeletedListeners
+
+	lookupObserver func(hit bool)
+	persistence    Persistence[T]
+
+	ttl                  time.Duration
+	staleWhileRevalidate bool
+	revalidating         bool
+	now                  func() time.Time
+
+	refreshObserver func(added []T, removed []T)
+	nameHydrator    func(ctx context.Context, elements []T) error
 }
 
 func NewCache[T Element](elementPageFunc elementPageFunc[T]) *Cache[T] {
@@ -58,6 +104,7 @@ func NewCache[T Element](elementPageFunc elementPageFunc[T]) *Cache[T] {
 		elementPageFunc: elementPageFunc,
 		nameToElements:  nil,
 		idToElement:     make(map[types.ID]T),
+		now:             time.Now,
 	}
 }
 
@@ -68,12 +115,10 @@ func NewCache[T Element](elementPageFunc elementPageFunc[T]) *Cache[T] {
 // cache by asking for pages until it discovers a page that has no elements and
 // then returns all elements in the cache.
 func (c *Cache[T]) All(ctx context.Context) ([]T, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if err := c.loadAllUnsafe(ctx); err != nil {
+	if err := c.lockLoaded(ctx); err != nil {
 		return nil, err
 	}
+	defer c.mu.Unlock()
 
 	elements := make([]T, len(c.elements))
 	copy(elements, c.elements)
@@ -82,12 +127,10 @@ func (c *Cache[T]) All(ctx context.Context) ([]T, error) {
 
 // ElementCount will return the number of elements
 func (c *Cache[T]) ElementCount(ctx context.Context) (int64, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if err := c.loadAllUnsafe(ctx); err != nil {
+	if err := c.lockLoaded(ctx); err != nil {
 		return 0, err
 	}
+	defer c.mu.Unlock()
 
 	return int64(len(c.elements)), nil
 }
@@ -95,16 +138,10 @@ func (c *Cache[T]) ElementCount(ctx context.Context) (int64, error) {
 // get elements with a specific name. In the event that there are no elements with
 // the specified name nil is returned
 func (c *Cache[T]) ElementsWithName(ctx context.Context, name string) ([]T, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if err := c.loadAllUnsafe(ctx); err != nil {
-		return nil, err
-	}
-
-	if err := c.populateNameMapUnsafe(ctx); err != nil {
+	if err := c.lockNamed(ctx); err != nil {
 		return nil, err
 	}
+	defer c.mu.Unlock()
 
 	elementsWithName := c.nameToElements[name]
 	elements := make([]T, len(elementsWithName))
@@ -113,18 +150,11 @@ func (c *Cache[T]) ElementsWithName(ctx context.Context, name string) ([]T, erro
 }
 
 func (c *Cache[T]) ElementWithUniqueName(ctx context.Context, name string) (T, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if err := c.loadAllUnsafe(ctx); err != nil {
-		var empty T
-		return empty, err
-	}
-
-	if err := c.populateNameMapUnsafe(ctx); err != nil {
+	if err := c.lockNamed(ctx); err != nil {
 		var empty T
 		return empty, err
 	}
+	defer c.mu.Unlock()
 
 	if err := c.populateUniqueNameMapUnsafe(ctx); err != nil {
 		var empty T
@@ -137,36 +167,349 @@ func (c *Cache[T]) ElementWithUniqueName(ctx context.Context, name string) (T, e
 // get the element with the specified ID. In the event that there is no element
 // with the specified ID a nil Photo is returned
 func (c *Cache[T]) ElementWithID(ctx context.Context, id types.ID) (T, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	if err := c.loadAllUnsafe(ctx); err != nil {
+	if err := c.lockLoaded(ctx); err != nil {
 		var empty T
 		return empty, err
 	}
+	defer c.mu.Unlock()
 
 	return c.idToElement[id], nil
 }
 
-// Load all elements into the cache. It assumes the mutex guarding the
-// cache is already locked.
-func (c *Cache[T]) loadAllUnsafe(ctx context.Context) (err error) {
-	for page := uint64(0); !c.foundAll; page++ {
-		elements, err := c.elementPageFunc(ctx, page)
-		if err != nil {
+// Len returns the number of elements currently in the cache. Unlike
+// ElementCount it never loads elements.
+func (c *Cache[T]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.elements)
+}
+
+// Cached returns the elements currently in the cache. Unlike All it never loads
+// elements.
+func (c *Cache[T]) Cached() []T {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elements := make([]T, len(c.elements))
+	copy(elements, c.elements)
+	return elements
+}
+
+// SetLookupObserver sets a function that will be called every time elements
+// are looked up in the cache. hit indicates if all elements were already
+// loaded into the cache.
+func (c *Cache[T]) SetLookupObserver(observer func(hit bool)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lookupObserver = observer
+}
+
+// SetTTL sets how long all elements may be served from the cache once they
+// have been loaded. Once the TTL has elapsed the next lookup resets the cache
+// and loads the elements again, unless stale-while-revalidate is enabled, see
+// SetStaleWhileRevalidate. A TTL of zero means the elements never expire.
+func (c *Cache[T]) SetTTL(ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ttl = ttl
+}
+
+// SetStaleWhileRevalidate sets if elements should continue to be served from
+// the cache once the TTL has elapsed while they are refreshed in the
+// background. See SetTTL.
+func (c *Cache[T]) SetStaleWhileRevalidate(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.staleWhileRevalidate = enabled
+}
+
+// SetRefreshObserver sets a function that is called when refreshing the cache
+// finds elements that were added or removed since the cache was loaded.
+func (c *Cache[T]) SetRefreshObserver(observer func(added []T, removed []T)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshObserver = observer
+}
+
+// SetNameHydrator sets a function that is called with the elements whose name
+// is not known, see ElementKnownNamer, before the name map is populated. This
+// allows the names of many elements to be looked up with fewer requests than
+// calling Name for each element. Name is still called for every element
+// afterwards, so the hydrator does not need to find every name.
+func (c *Cache[T]) SetNameHydrator(hydrator func(ctx context.Context, elements []T) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nameHydrator = hydrator
+}
+
+// SetPersistence sets the functions used to persist the elements of the
+// cache. Persistence is best effort, if loading persisted elements fails then
+// the elements are loaded page by page instead and failures to save elements
+// are ignored.
+func (c *Cache[T]) SetPersistence(p Persistence[T]) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.persistence = p
+}
+
+// Refresh loads all elements again even if they are already in the cache.
+// Elements that are still present keep the same element object so that any
+// data that has already been loaded for them is preserved.
+func (c *Cache[T]) Refresh(ctx context.Context) error {
+	markRefreshed(ctx, c)
+	return c.load(ctx, true)
+}
+
+// lockLoaded makes sure that all elements have been loaded into the cache and
+// then locks the mutex guarding the cache. If an error is returned the mutex is
+// not locked.
+//
+// The mutex is not held while elements are being loaded so that lookups in
+// other caches that are already loaded are not blocked by the network requests.
+func (c *Cache[T]) lockLoaded(ctx context.Context) error {
+	refresh := markRefreshed(ctx, c)
+
+	c.mu.Lock()
+	c.expireUnsafe()
+	if c.lookupObserver != nil {
+		c.lookupObserver(c.foundAll && !refresh)
+	}
+
+	for !c.foundAll || refresh {
+		c.mu.Unlock()
+		if err := c.load(ctx, refresh); err != nil {
 			return err
 		}
-		if len(elements) == 0 {
+		refresh = false
+		c.mu.Lock()
+	}
+	return nil
+}
+
+// expireUnsafe handles the TTL elapsing since all elements were loaded.
+// Normally the cache is reset so the next lookup loads the elements again. In
+// stale-while-revalidate mode the cached elements are kept and a refresh is
+// started in the background instead. It assumes the mutex guarding the cache
+// is already locked.
+func (c *Cache[T]) expireUnsafe() {
+	if !c.foundAll || c.ttl <= 0 || c.now().Sub(c.foundAllTime) < c.ttl {
+		return
+	}
+	if !c.staleWhileRevalidate {
+		c.resetUnsafe()
+		return
+	}
+	if c.revalidating {
+		return
+	}
+
+	c.revalidating = true
+	go func() {
+		// If the refresh fails the stale elements are kept and the next
+		// lookup will try again.
+		_ = c.load(context.Background(), true)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.revalidating = false
+	}()
+}
+
+// loadCall is a load of elements into the cache that is in progress. Anyone
+// else that needs the elements loaded waits for the call to finish rather
+// than starting another load.
+type loadCall struct {
+	done chan struct{}
+	err  error
+}
+
+// load loads all elements into the cache if they are not already loaded, or
+// if refresh is true. It must be called without the mutex guarding the cache
+// locked.
+//
+// If elements are already being loaded then load waits for that load to
+// finish and shares its result rather than loading the elements again.
+func (c *Cache[T]) load(ctx context.Context, refresh bool) error {
+	for {
+		c.mu.Lock()
+		c.expireUnsafe()
+		if c.foundAll && !refresh {
+			c.mu.Unlock()
+			return nil
+		}
+
+		if call := c.loading; call != nil {
+			c.mu.Unlock()
+			select {
+			case <-call.done:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+
+			// A refresh must see data loaded after it was requested, so once
+			// the load that was already in progress finishes we still need
+			// to start our own.
+			if refresh {
+				continue
+			}
+
+			// If the load failed because the context of whoever started it
+			// was done, but our context isn't, then try again ourselves.
+			if call.err != nil && ctx.Err() == nil && (errors.Is(call.err, context.Canceled) || errors.Is(call.err, context.DeadlineExceeded)) {
+				continue
+			}
+			return call.err
+		}
+
+		call := &loadCall{done: make(chan struct{})}
+		c.loading = call
+		foundAll := c.foundAll
+		persistence := c.persistence
+		c.mu.Unlock()
+
+		call.err = c.doLoad(ctx, foundAll, refresh, persistence)
+
+		c.mu.Lock()
+		c.loading = nil
+		c.mu.Unlock()
+		close(call.done)
+		return call.err
+	}
+}
+
+// doLoad does the work of loading elements for load.
+func (c *Cache[T]) doLoad(ctx context.Context, foundAll bool, refresh bool, persistence Persistence[T]) error {
+	if !foundAll && !refresh && persistence.Load != nil {
+		elements, ok, err := persistence.Load(ctx)
+		if err == nil && ok {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			for _, e := range elements {
+				c.addElementUnsafe(e)
+			}
 			c.foundAll = true
+			c.foundAllTime = c.now()
+			return nil
 		}
-		for _, p := range elements {
-			c.addElementUnsafe(p)
+	}
+
+	elements, err := c.loadPages(ctx)
+	if err != nil {
+		return err
+	}
+
+	var added, removed []T
+	c.mu.Lock()
+	if c.foundAll {
+		added, removed = c.replaceElementsUnsafe(elements)
+	} else {
+		for _, e := range elements {
+			c.addElementUnsafe(e)
 		}
 	}
+	c.foundAll = true
+	c.foundAllTime = c.now()
+	all := make([]T, len(c.elements))
+	copy(all, c.elements)
+	refreshObserver := c.refreshObserver
+	c.mu.Unlock()
+
+	if persistence.Save != nil {
+		_ = persistence.Save(ctx, all)
+	}
+	if refreshObserver != nil && (len(added) > 0 || len(removed) > 0) {
+		refreshObserver(added, removed)
+	}
 
 	return nil
 }
 
+// loadPages loads elements page by page until a page with no elements is
+// found.
+//
+// We don't know how many pages there are ahead of time so pages are requested
+// in batches that start with a single page and double in size, up to
+// maxConcurrentPages, for each batch where every page had elements. This
+// keeps the number of wasted requests for pages past the end small while
+// allowing large containers to be loaded with far fewer round trips.
+func (c *Cache[T]) loadPages(ctx context.Context) ([]T, error) {
+	var all []T
+	batchSize := 1
+	for page := uint64(0); ; {
+		// Stop loading pages as soon as the context is done rather than waiting
+		// for the next request to fail.
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		results := make([][]T, batchSize)
+		errs := make([]error, batchSize)
+		var wg sync.WaitGroup
+		for i := 0; i < batchSize; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], errs[i] = c.elementPageFunc(ctx, page+uint64(i))
+			}(i)
+		}
+		wg.Wait()
+
+		for i := range results {
+			if errs[i] != nil {
+				return nil, errs[i]
+			}
+			if len(results[i]) == 0 {
+				return all, nil
+			}
+			all = append(all, results[i]...)
+		}
+
+		page += uint64(batchSize)
+		if page > 1 {
+			batchSize *= 2
+		}
+		if batchSize > maxConcurrentPages {
+			batchSize = maxConcurrentPages
+		}
+	}
+}
+
+// replaceElementsUnsafe replaces all elements in the cache with the provided
+// elements. If an element is already in the cache then the existing element
+// object is kept. It returns the elements that were added to and removed from
+// the cache. It assumes the mutex guarding the cache is already locked.
+func (c *Cache[T]) replaceElementsUnsafe(elements []T) (added []T, removed []T) {
+	existing := c.idToElement
+
+	c.elements = nil
+	c.nameToElements = nil
+	c.uniqueNameToElement = nil
+	c.idToElement = make(map[types.ID]T)
+
+	for _, e := range elements {
+		id := e.ID()
+		if _, ok := c.idToElement[id]; ok {
+			continue
+		}
+		if old, ok := existing[id]; ok {
+			// The existing element is already sending deletes to this cache so
+			// we don't use addElementUnsafe, which would add the cache as a
+			// listener a second time.
+			c.elements = append(c.elements, old)
+			c.idToElement[id] = old
+			continue
+		}
+		c.addElementUnsafe(e)
+		added = append(added, e)
+	}
+
+	for id, old := range existing {
+		if _, ok := c.idToElement[id]; !ok {
+			removed = append(removed, old)
+		}
+	}
+	return added, removed
+}
+
 // Add may be called to add a element to the cache. This can be useful when a
 // element is created
 func (c *Cache[T]) Add(e T) {
@@ -179,11 +522,10 @@ func (c *Cache[T]) Add(e T) {
 // addElementUnsafe adds a element to the cache. It assumes the mutex guarding the
 // cache is already locked.
 //
-// The nameToPhotos map is not populated as part of this because sometimes
-// getting the name of a photo requires a network call (for playlists that were
-// not uploaded) In addition as soon as a new photo is added to the cache the
-// nameToPhotos map is no longer valid because we may not have a name for that
-// photo yet. So we reset the nameToPhotos when adding a new photo to the cache.
+// Getting the name of an element may require a network call (for example
+// playlist photos that were not uploaded by us) so if the name of the element
+// isn't already known the nameToElements map is reset rather than updated.
+// See updateNameMapsUnsafe.
 func (c *Cache[T]) addElementUnsafe(p T) {
 
 	// If the element is already in the cache just early return
@@ -196,8 +538,7 @@ func (c *Cache[T]) addElementUnsafe(p T) {
 	id := p.ID()
 	c.idToElement[id] = p
 
-	c.nameToElements = nil
-	c.uniqueNameToElement = nil
+	c.updateNameMapsUnsafe(p)
 
 	// To aid in not having to transform big slices of interfaces around the
 	// types we store the same interface that we will expose to the eventual API
@@ -220,24 +561,149 @@ func (c *Cache[T]) addElementUnsafe(p T) {
 	le.AddDeletedListener(c)
 }
 
-func (pc *Cache[T]) populateNameMapUnsafe(ctx context.Context) (err error) {
-	if pc.nameToElements != nil {
-		return nil
+// updateNameMapsUnsafe updates the name maps for an element that was just
+// added to the cache. If the name of the element is known without a network
+// request then the maps are updated in place, otherwise they are reset so
+// they will be populated again when they are next needed. It assumes the mutex
+// guarding the cache is already locked.
+func (c *Cache[T]) updateNameMapsUnsafe(e T) {
+	if c.nameToElements == nil {
+		c.uniqueNameToElement = nil
+		return
 	}
 
-	defer func() {
-		if err != nil {
-			pc.nameToElements = nil
+	namer, ok := any(e).(ElementKnownNamer)
+	if !ok {
+		c.nameToElements = nil
+		c.uniqueNameToElement = nil
+		return
+	}
+	name, ok := namer.KnownName()
+	if !ok {
+		c.nameToElements = nil
+		c.uniqueNameToElement = nil
+		return
+	}
+
+	c.nameToElements[name] = append(c.nameToElements[name], e)
+
+	if c.uniqueNameToElement == nil {
+		return
+	}
+	if _, taken := c.uniqueNameToElement[name]; taken || len(c.nameToElements[name]) > 1 {
+		// Other elements share the name so unique names need to be generated
+		// for all of them.
+		c.uniqueNameToElement = nil
+		return
+	}
+	c.uniqueNameToElement[name] = e
+}
+
+// lockNamed makes sure that all elements have been loaded into the cache and
+// that the name map has been populated and then locks the mutex guarding the
+// cache. If an error is returned the mutex is not locked.
+//
+// Looking up the names of elements may require a network request for each
+// element, so the mutex is not held while names are looked up, see
+// lookupNames. If elements are added to the cache while names are being
+// looked up then the names of the new elements are looked up before the name
+// map is populated.
+func (c *Cache[T]) lockNamed(ctx context.Context) error {
+	names := make(map[types.ID]string)
+	for {
+		if err := c.lockLoaded(ctx); err != nil {
+			return err
+		}
+		if c.nameToElements != nil {
+			return nil
 		}
-	}()
 
-	pc.nameToElements = make(map[string][]T)
-	for _, p := range pc.elements {
-		name, err := p.Name(ctx)
-		if err != nil {
+		var unknown []T
+		for _, e := range c.elements {
+			if _, ok := names[e.ID()]; !ok {
+				unknown = append(unknown, e)
+			}
+		}
+		if len(unknown) == 0 {
+			c.nameToElements = make(map[string][]T)
+			for _, e := range c.elements {
+				name := names[e.ID()]
+				c.nameToElements[name] = append(c.nameToElements[name], e)
+			}
+			return nil
+		}
+
+		hydrator := c.nameHydrator
+		c.mu.Unlock()
+		if err := lookupNames(ctx, unknown, hydrator, names); err != nil {
 			return err
 		}
-		pc.nameToElements[name] = append(pc.nameToElements[name], p)
+	}
+}
+
+// lookupNames looks up the names of elements and adds them to names. If
+// hydrator is not nil it is first called with the elements whose name is not
+// already known, see SetNameHydrator. Names are then looked up concurrently
+// with at most maxConcurrentNames lookups at a time.
+func lookupNames[T Element](ctx context.Context, elements []T, hydrator func(ctx context.Context, elements []T) error, names map[types.ID]string) error {
+	if hydrator != nil {
+		var unknown []T
+		for _, e := range elements {
+			if namer, ok := any(e).(ElementKnownNamer); ok {
+				if _, known := namer.KnownName(); known {
+					continue
+				}
+			}
+			unknown = append(unknown, e)
+		}
+		if len(unknown) > 0 {
+			if err := hydrator(ctx, unknown); err != nil {
+				return err
+			}
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]string, len(elements))
+	var errOnce sync.Once
+	var firstErr error
+	sem := make(chan struct{}, maxConcurrentNames)
+	var wg sync.WaitGroup
+	for i, e := range elements {
+		// Don't start looking up any more names once the context is done,
+		// including when a lookup has failed.
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, e T) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			name, err := e.Name(ctx)
+			if err != nil {
+				// There is no point looking up the rest of the names.
+				errOnce.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			results[i] = name
+		}(i, e)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	for i, e := range elements {
+		names[e.ID()] = results[i]
 	}
 	return nil
 }
@@ -280,28 +746,30 @@ func (pc *Cache[T]) populateUniqueNameMapUnsafe(ctx context.Context) (err error)
 	return nil
 }
 
+// ElementDeleted is called by the elements in the cache when they are deleted.
+// The element is removed from the cache before the listeners of the cache are
+// notified, so listeners never observe the deleted element in the cache.
+// Listeners are notified even if removing the element fails, in which case the
+// cache is reset, because the element has been deleted either way.
 func (c *Cache[T]) ElementDeleted(ctx context.Context, e Element) (err error) {
 	et, ok := e.(T)
 	if !ok {
 		return fmt.Errorf("failed to cast element on delete")
 	}
 
-	if err := c.Remove(ctx, et); err != nil {
-		return err
-	}
+	removeErr := c.Remove(ctx, et)
 
 	// Forward on to anyone listening to deletes from the cache
-	for _, l := range c.elementDeletedListener {
-		if err := l.ElementDeleted(ctx, e); err != nil {
-			return err
-		}
+	if err := c.deletedListeners.NotifyDeleted(ctx, e); err != nil {
+		return err
 	}
-
-	return c.Remove(ctx, et)
+	return removeErr
 }
 
+// AddDeletedListener adds a listener that is notified when an element in the
+// cache is deleted, see ElementDeleted.
 func (c *Cache[T]) AddDeletedListener(l ElementDeletedListener) {
-	c.elementDeletedListener = append(c.elementDeletedListener, l)
+	c.deletedListeners.AddDeletedListener(l)
 }
 
 func (c *Cache[T]) Remove(ctx context.Context, e T) (err error) {
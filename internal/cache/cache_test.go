@@ -0,0 +1,577 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testElement struct {
+	DeletedListeners
+
+	id   types.ID
+	name string
+
+	nameCalls int
+
+	// onName is an optional function that is called by Name.
+	onName func()
+}
+
+func (e *testElement) ID() types.ID {
+	return e.id
+}
+
+func (e *testElement) Name(ctx context.Context) (string, error) {
+	e.nameCalls++
+	if e.onName != nil {
+		e.onName()
+	}
+	return e.name, nil
+}
+
+func (e *testElement) KnownName() (string, bool) {
+	return e.name, e.name != ""
+}
+
+func (e *testElement) GenerateUniqueName(ctx context.Context) (string, error) {
+	return e.name + "{" + string(rune('0'+e.id[0])) + "}", nil
+}
+
+func newTestElement(i byte, name string) *testElement {
+	return &testElement{id: types.ID{i}, name: name}
+}
+
+// testPages returns an elementPageFunc that returns each of the provided pages
+// in turn followed by an empty page, and a pointer to the number of times a
+// page has been requested.
+func testPages(pages ...[]*testElement) (elementPageFunc[*testElement], *int) {
+	requests := 0
+	f := func(ctx context.Context, page uint64) ([]*testElement, error) {
+		requests++
+		if page >= uint64(len(pages)) {
+			return nil, nil
+		}
+		return pages[page], nil
+	}
+	return f, &requests
+}
+
+func TestCache_TTL(t *testing.T) {
+	ctx := context.Background()
+
+	pageFunc, requests := testPages([]*testElement{newTestElement(1, "a"), newTestElement(2, "b")})
+	c := NewCache(pageFunc)
+
+	now := time.Unix(0, 0)
+	c.now = func() time.Time { return now }
+	c.SetTTL(time.Minute)
+
+	all, err := c.All(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+	assert.Equal(t, 2, *requests)
+
+	now = now.Add(30 * time.Second)
+	_, err = c.All(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, *requests)
+
+	now = now.Add(30 * time.Second)
+	all, err = c.All(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+	assert.Equal(t, 4, *requests)
+}
+
+func TestCache_Refresh(t *testing.T) {
+	ctx := context.Background()
+
+	a := newTestElement(1, "a")
+	b := newTestElement(2, "b")
+	page := []*testElement{a, b}
+	c := NewCache(func(ctx context.Context, p uint64) ([]*testElement, error) {
+		if p > 0 {
+			return nil, nil
+		}
+		return page, nil
+	})
+
+	all, err := c.All(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*testElement{a, b}, all)
+
+	// b is removed, a is listed as a new but equivalent object and d is added.
+	d := newTestElement(4, "d")
+	page = []*testElement{newTestElement(1, "a"), d}
+	require.NoError(t, c.Refresh(ctx))
+
+	all, err = c.All(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Same(t, a, all[0])
+	assert.Same(t, d, all[1])
+
+	withName, err := c.ElementsWithName(ctx, "b")
+	require.NoError(t, err)
+	assert.Empty(t, withName)
+}
+
+func TestCache_WithFreshData(t *testing.T) {
+	ctx := context.Background()
+
+	pageFunc, requests := testPages([]*testElement{newTestElement(1, "a")})
+	c := NewCache(pageFunc)
+
+	_, err := c.All(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, *requests)
+
+	freshCtx := WithFreshData(ctx)
+	_, err = c.All(freshCtx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, *requests)
+
+	// The cache is only refreshed once per context.
+	_, err = c.ElementWithID(freshCtx, types.ID{1})
+	require.NoError(t, err)
+	assert.Equal(t, 4, *requests)
+
+	_, err = c.All(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 4, *requests)
+}
+
+func TestCache_ConcurrentPages(t *testing.T) {
+	ctx := context.Background()
+
+	const nPages = 10
+	var pages [][]*testElement
+	var expected []*testElement
+	for i := 0; i < nPages; i++ {
+		e := newTestElement(byte(i+1), "element")
+		pages = append(pages, []*testElement{e})
+		expected = append(expected, e)
+	}
+
+	var mu sync.Mutex
+	inFlight, maxInFlight, requests := 0, 0, 0
+	c := NewCache(func(ctx context.Context, page uint64) ([]*testElement, error) {
+		mu.Lock()
+		inFlight++
+		requests++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+
+		if page >= nPages {
+			return nil, nil
+		}
+		return pages[page], nil
+	})
+
+	all, err := c.All(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, expected, all)
+
+	assert.Greater(t, maxInFlight, 1)
+	assert.LessOrEqual(t, maxInFlight, maxConcurrentPages)
+	assert.Less(t, requests, nPages+maxConcurrentPages)
+}
+
+func TestCache_PageError(t *testing.T) {
+	ctx := context.Background()
+
+	c := NewCache(func(ctx context.Context, page uint64) ([]*testElement, error) {
+		if page == 3 {
+			return nil, assert.AnError
+		}
+		return []*testElement{newTestElement(byte(page+1), "element")}, nil
+	})
+
+	_, err := c.All(ctx)
+	assert.ErrorIs(t, err, assert.AnError)
+}
+
+func TestCache_SingleFlight(t *testing.T) {
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	var mu sync.Mutex
+	requests := map[uint64]int{}
+	c := NewCache(func(ctx context.Context, page uint64) ([]*testElement, error) {
+		mu.Lock()
+		requests[page]++
+		mu.Unlock()
+		<-release
+		if page > 0 {
+			return nil, nil
+		}
+		return []*testElement{newTestElement(1, "a")}, nil
+	})
+
+	const nCallers = 10
+	var wg sync.WaitGroup
+	results := make([][]*testElement, nCallers)
+	errs := make([]error, nCallers)
+	for i := 0; i < nCallers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = c.All(ctx)
+		}(i)
+	}
+
+	// Give all of the callers a chance to start waiting on the load.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i := 0; i < nCallers; i++ {
+		require.NoError(t, errs[i])
+		assert.Len(t, results[i], 1)
+	}
+	assert.Equal(t, map[uint64]int{0: 1, 1: 1}, requests)
+}
+
+func TestCache_SingleFlightCancel(t *testing.T) {
+	ctx := context.Background()
+
+	started := make(chan struct{}, 10)
+	c := NewCache(func(ctx context.Context, page uint64) ([]*testElement, error) {
+		started <- struct{}{}
+		if page > 0 {
+			return nil, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+		}
+		return []*testElement{newTestElement(1, "a")}, nil
+	})
+
+	// The first caller starts the load and then gives up.
+	leaderCtx, cancelLeader := context.WithCancel(ctx)
+	leaderErr := make(chan error)
+	go func() {
+		_, err := c.All(leaderCtx)
+		leaderErr <- err
+	}()
+	<-started
+
+	// A waiting caller whose context is done returns right away.
+	waiterCtx, cancelWaiter := context.WithCancel(ctx)
+	cancelWaiter()
+	_, err := c.All(waiterCtx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// A waiting caller with a live context loads the elements itself if the
+	// load it was waiting on is canceled.
+	followerResult := make(chan []*testElement)
+	go func() {
+		all, err := c.All(ctx)
+		assert.NoError(t, err)
+		followerResult <- all
+	}()
+	time.Sleep(5 * time.Millisecond)
+	cancelLeader()
+
+	assert.ErrorIs(t, <-leaderErr, context.Canceled)
+	assert.Len(t, <-followerResult, 1)
+}
+
+func TestCache_AddUpdatesNameMaps(t *testing.T) {
+	ctx := context.Background()
+
+	a := newTestElement(1, "a")
+	pageFunc, _ := testPages([]*testElement{a})
+	c := NewCache(pageFunc)
+
+	unique, err := c.ElementWithUniqueName(ctx, "a")
+	require.NoError(t, err)
+	assert.Same(t, a, unique)
+	assert.Equal(t, 1, a.nameCalls)
+
+	b := newTestElement(2, "b")
+	c.Add(b)
+	withName, err := c.ElementsWithName(ctx, "b")
+	require.NoError(t, err)
+	assert.Equal(t, []*testElement{b}, withName)
+	unique, err = c.ElementWithUniqueName(ctx, "b")
+	require.NoError(t, err)
+	assert.Same(t, b, unique)
+
+	// The names of elements already in the cache are not requested again.
+	assert.Equal(t, 1, a.nameCalls)
+	assert.Equal(t, 0, b.nameCalls)
+
+	// Adding an element with a name that is already used means unique names
+	// are needed.
+	a2 := newTestElement(3, "a")
+	c.Add(a2)
+	withName, err = c.ElementsWithName(ctx, "a")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []*testElement{a, a2}, withName)
+	unique, err = c.ElementWithUniqueName(ctx, "a")
+	require.NoError(t, err)
+	assert.Nil(t, unique)
+	unique, err = c.ElementWithUniqueName(ctx, "a{3}")
+	require.NoError(t, err)
+	assert.Same(t, a2, unique)
+}
+
+func TestCache_StaleWhileRevalidate(t *testing.T) {
+	ctx := context.Background()
+
+	a := newTestElement(1, "a")
+	b := newTestElement(2, "b")
+	var mu sync.Mutex
+	page := []*testElement{a}
+	c := NewCache(func(ctx context.Context, p uint64) ([]*testElement, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if p > 0 {
+			return nil, nil
+		}
+		return page, nil
+	})
+
+	now := time.Unix(0, 0)
+	c.now = func() time.Time { return now }
+	c.SetTTL(time.Minute)
+	c.SetStaleWhileRevalidate(true)
+
+	type diff struct {
+		added, removed []*testElement
+	}
+	diffs := make(chan diff, 1)
+	c.SetRefreshObserver(func(added, removed []*testElement) {
+		diffs <- diff{added, removed}
+	})
+
+	all, err := c.All(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*testElement{a}, all)
+
+	mu.Lock()
+	page = []*testElement{b}
+	mu.Unlock()
+	c.mu.Lock()
+	now = now.Add(time.Minute)
+	c.mu.Unlock()
+
+	// The stale elements are returned right away while they are refreshed in
+	// the background.
+	all, err = c.All(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*testElement{a}, all)
+
+	select {
+	case d := <-diffs:
+		assert.Equal(t, []*testElement{b}, d.added)
+		assert.Equal(t, []*testElement{a}, d.removed)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for background refresh")
+	}
+
+	all, err = c.All(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*testElement{b}, all)
+}
+
+type recordingListener struct {
+	calls *[]string
+	name  string
+	f     func() error
+}
+
+func (l *recordingListener) ElementDeleted(ctx context.Context, e Element) error {
+	*l.calls = append(*l.calls, l.name)
+	if l.f != nil {
+		return l.f()
+	}
+	return nil
+}
+
+func TestCache_ElementDeleted(t *testing.T) {
+	ctx := context.Background()
+
+	a := newTestElement(1, "a")
+	b := newTestElement(2, "b")
+	pageFunc, _ := testPages([]*testElement{a, b})
+	c := NewCache(pageFunc)
+
+	_, err := c.All(ctx)
+	require.NoError(t, err)
+
+	// Resetting and loading the cache again adds the same elements to the
+	// cache a second time, the cache must still only be notified once.
+	c.Reset()
+	_, err = c.All(ctx)
+	require.NoError(t, err)
+
+	var calls []string
+	first := &recordingListener{calls: &calls, name: "first", f: func() error {
+		// The element is already removed when listeners are notified.
+		all, err := c.All(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []*testElement{b}, all)
+		return errors.New("first failed")
+	}}
+	second := &recordingListener{calls: &calls, name: "second"}
+	c.AddDeletedListener(first)
+	c.AddDeletedListener(second)
+	c.AddDeletedListener(first)
+
+	err = a.NotifyDeleted(ctx, a)
+	assert.EqualError(t, err, "first failed")
+
+	// Every listener is notified once, in the order they were added, even
+	// though the first one failed.
+	assert.Equal(t, []string{"first", "second"}, calls)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestCache_NameHydrator(t *testing.T) {
+	ctx := context.Background()
+
+	a := newTestElement(1, "a")
+	b := newTestElement(2, "")
+	c := newTestElement(3, "")
+	pageFunc, _ := testPages([]*testElement{a, b, c})
+	cache := NewCache(pageFunc)
+
+	var hydrated []*testElement
+	cache.SetNameHydrator(func(ctx context.Context, elements []*testElement) error {
+		hydrated = append(hydrated, elements...)
+		for _, e := range elements {
+			e.name = "hydrated" + string(rune('0'+e.id[0]))
+		}
+		return nil
+	})
+
+	found, err := cache.ElementsWithName(ctx, "hydrated2")
+	require.NoError(t, err)
+	assert.Equal(t, []*testElement{b}, found)
+	assert.Equal(t, []*testElement{b, c}, hydrated, "only elements with unknown names should be hydrated")
+
+	// Once the name map is populated the hydrator is not needed again.
+	_, err = cache.ElementsWithName(ctx, "a")
+	require.NoError(t, err)
+	assert.Len(t, hydrated, 2)
+
+	hydrateErr := errors.New("hydrate failed")
+	cache.Reset()
+	b.name, c.name = "", ""
+	cache.SetNameHydrator(func(ctx context.Context, elements []*testElement) error {
+		return hydrateErr
+	})
+	_, err = cache.ElementsWithName(ctx, "a")
+	assert.ErrorIs(t, err, hydrateErr)
+}
+
+func TestCache_NamesLookedUpConcurrentlyWithoutLock(t *testing.T) {
+	ctx := context.Background()
+
+	const count = 3 * maxConcurrentNames
+	release := make(chan struct{})
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	var elements []*testElement
+	for i := 0; i < count; i++ {
+		e := newTestElement(byte(i), string(rune('a'+i)))
+		e.onName = func() {
+			mu.Lock()
+			inFlight++
+			if inFlight > maxInFlight {
+				maxInFlight = inFlight
+			}
+			mu.Unlock()
+
+			<-release
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}
+		elements = append(elements, e)
+	}
+	pageFunc, _ := testPages(elements)
+	c := NewCache(pageFunc)
+
+	_, err := c.All(ctx)
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() {
+		_, err := c.ElementsWithName(ctx, "a")
+		done <- err
+	}()
+
+	// Wait for the names to start being looked up, other users of the cache
+	// must not be blocked while they are.
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return inFlight == maxConcurrentNames
+	}, 5*time.Second, time.Millisecond)
+	all, err := c.All(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, count)
+
+	close(release)
+	require.NoError(t, <-done)
+	assert.Equal(t, maxConcurrentNames, maxInFlight)
+}
+
+func TestCache_NamesNotLookedUpOnceCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var elements []*testElement
+	for i := 0; i < 3*maxConcurrentNames; i++ {
+		elements = append(elements, newTestElement(byte(i), ""))
+	}
+	pageFunc, _ := testPages(elements)
+	c := NewCache(pageFunc)
+	c.SetNameHydrator(func(ctx context.Context, elements []*testElement) error {
+		cancel()
+		return nil
+	})
+
+	_, err := c.ElementsWithName(ctx, "a")
+	assert.ErrorIs(t, err, context.Canceled)
+	for _, e := range elements {
+		assert.Zero(t, e.nameCalls)
+	}
+}
+
+func TestCache_PagesNotLoadedOnceCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	requests := 0
+	c := NewCache(func(ctx context.Context, page uint64) ([]*testElement, error) {
+		requests++
+		cancel()
+		return []*testElement{newTestElement(byte(page), "e")}, nil
+	})
+
+	_, err := c.All(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, requests)
+}
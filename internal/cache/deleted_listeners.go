@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+// DeletedListeners is a set of ElementDeletedListeners that is safe for
+// concurrent use. It can be embedded in an element to implement
+// ListenableElement.
+//
+// Listeners are notified in the order they were added. Adding a listener that
+// was already added has no effect, so an element that is added to the same
+// cache several times, for example after the cache is reset, only notifies
+// that cache once.
+type DeletedListeners struct {
+	mu        sync.Mutex
+	listeners []ElementDeletedListener
+}
+
+func (d *DeletedListeners) AddDeletedListener(l ElementDeletedListener) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, existing := range d.listeners {
+		if existing == l {
+			return
+		}
+	}
+	d.listeners = append(d.listeners, l)
+}
+
+// NotifyDeleted notifies all listeners that e was deleted. Every listener is
+// notified even if an earlier listener returns an error, the first error is
+// returned.
+func (d *DeletedListeners) NotifyDeleted(ctx context.Context, e Element) error {
+	d.mu.Lock()
+	listeners := append([]ElementDeletedListener(nil), d.listeners...)
+	d.mu.Unlock()
+
+	var firstErr error
+	for _, l := range listeners {
+		if err := l.ElementDeleted(ctx, e); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"sync"
+)
+
+type freshDataKey struct{}
+
+// freshData records which caches have already been refreshed for a context
+// returned by WithFreshData. This ensures each cache is only refreshed once
+// even if the context is used for many lookups.
+type freshData struct {
+	mu        sync.Mutex
+	refreshed map[any]struct{}
+}
+
+// WithFreshData returns a copy of the context that causes the first lookup in
+// each cache that uses the context to load its elements again rather than
+// using the elements already in the cache.
+func WithFreshData(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshDataKey{}, &freshData{
+		refreshed: make(map[any]struct{}),
+	})
+}
+
+// markRefreshed returns true if the cache should be refreshed because the
+// context was created by WithFreshData and the cache has not been refreshed
+// for the context yet. It records that the cache has been refreshed.
+func markRefreshed(ctx context.Context, cache any) bool {
+	fd, ok := ctx.Value(freshDataKey{}).(*freshData)
+	if !ok {
+		return false
+	}
+
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	if _, done := fd.refreshed[cache]; done {
+		return false
+	}
+	fd.refreshed[cache] = struct{}{}
+	return true
+}
@@ -1,7 +1,23 @@
 package mime
 
-// cSpell:ignore stdmime
-import stdmime "mime"
+import (
+	// cSpell:ignore stdmime
+	stdmime "mime"
+	"path/filepath"
+	"strings"
+)
+
+// supportedTypes are the MIME types that Nixplay supports.
+//
+// see https://web.archive.org/web/20230328184513/https://support.nixplay.com/hc/en-us/articles/900002393886-What-photo-and-video-formats-does-Nixplay-support-
+var supportedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/tiff": true,
+	"image/heic": true,
+	"image/heif": true,
+	"video/mp4":  true,
+}
 
 func init() {
 	// Add all supported file types that nixplay supports into the go mime type
@@ -19,3 +35,27 @@ func init() {
 	stdmime.AddExtensionType(".heif", "image/heif")
 	stdmime.AddExtensionType(".mp4", "video/mp4")
 }
+
+// IsVideo returns true if the provided MIME type is for a video.
+func IsVideo(mimeType string) bool {
+	mediaType, _, err := stdmime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "video/")
+}
+
+// TypeByFileName returns the MIME type based on the extension of the file
+// name, or an empty string if the MIME type could not be determined.
+func TypeByFileName(name string) string {
+	return stdmime.TypeByExtension(filepath.Ext(name))
+}
+
+// IsSupported returns true if the provided MIME type is supported by Nixplay.
+func IsSupported(mimeType string) bool {
+	mediaType, _, err := stdmime.ParseMediaType(mimeType)
+	if err != nil {
+		return false
+	}
+	return supportedTypes[mediaType]
+}
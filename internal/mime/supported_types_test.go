@@ -0,0 +1,23 @@
+package mime
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsVideo(t *testing.T) {
+	assert.True(t, IsVideo(TypeByFileName("clip.mp4")))
+	assert.True(t, IsVideo(TypeByFileName("clip.MP4")))
+	assert.False(t, IsVideo(TypeByFileName("photo.jpg")))
+	assert.False(t, IsVideo(TypeByFileName("photo.heic")))
+	assert.False(t, IsVideo(TypeByFileName("noExtension")))
+}
+
+func TestIsSupported(t *testing.T) {
+	assert.True(t, IsSupported(TypeByFileName("photo.jpg")))
+	assert.True(t, IsSupported(TypeByFileName("photo.HEIC")))
+	assert.True(t, IsSupported(TypeByFileName("clip.mp4")))
+	assert.False(t, IsSupported(TypeByFileName("notes.txt")))
+	assert.False(t, IsSupported(TypeByFileName("noExtension")))
+}
@@ -0,0 +1,28 @@
+package nixplay
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/internal/auth"
+)
+
+func TestMain(m *testing.M) {
+	// Random names must be the same when recording and replaying so that the
+	// replayed requests match the recorded ones.
+	if auth.IsRecordingOrReplaying() {
+		rand.Seed(1)
+	}
+
+	code := m.Run()
+
+	if err := auth.SaveTestCassette(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to save test cassette: %v\n", err)
+		if code == 0 {
+			code = 1
+		}
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,199 @@
+package nixplay
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// Account describes a Nixplay account to sign in to and add to a Manager.
+type Account struct {
+	// Name identifies the account within the Manager, for example "mine" or
+	// "parents". It does not need to match the username of the account.
+	Name string
+
+	// Authorization is used to sign in to the account.
+	Authorization types.Authorization
+
+	// Options are the options used to create the DefaultClient for the
+	// account.
+	Options DefaultClientOptions
+
+	// RateLimit limits how often requests are sent for this account,
+	// independently of the other accounts in the Manager. It applies to all
+	// requests made by the client, including uploads and downloads of photos.
+	// By default requests are not limited.
+	RateLimit httpx.RateLimit
+}
+
+// Manager holds the clients for several Nixplay accounts, for example when
+// administering the frames of several family members from one program.
+//
+// Manager is safe for concurrent use.
+type Manager struct {
+	mu      sync.RWMutex
+	names   []string
+	clients map[string]Client
+
+	// adding are the names of the accounts that Add is signing in to. They
+	// are reserved so that a concurrent Add with the same name fails without
+	// signing in.
+	adding map[string]bool
+}
+
+// NewManager returns a Manager without any accounts.
+func NewManager() *Manager {
+	return &Manager{
+		clients: make(map[string]Client),
+		adding:  make(map[string]bool),
+	}
+}
+
+// Add signs in to account and adds the resulting client to the Manager. An
+// error wrapping types.ErrDuplicateAccount is returned if the Manager already
+// has an account with the same name, including one that is still being signed
+// in to by a concurrent call to Add.
+func (m *Manager) Add(ctx context.Context, account Account) (*DefaultClient, error) {
+	if err := m.reserve(account.Name); err != nil {
+		return nil, err
+	}
+	defer m.release(account.Name)
+
+	opts := account.Options
+	if account.RateLimit.RequestsPerSecond > 0 {
+		// The rate limit is the outermost middleware so that every request
+		// sent for the account is counted, including retries made by other
+		// middleware.
+		opts.Middleware = append([]httpx.Middleware{httpx.RateLimiting(account.RateLimit)}, opts.Middleware...)
+	}
+	client, err := NewDefaultClient(ctx, account.Authorization, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add account %q: %w", account.Name, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.addLocked(account.Name, client)
+	return client, nil
+}
+
+// reserve reserves name for an account that is being added, an error wrapping
+// types.ErrDuplicateAccount is returned if the name is already in use or
+// reserved.
+func (m *Manager) reserve(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkNameLocked(name); err != nil {
+		return err
+	}
+	m.adding[name] = true
+	return nil
+}
+
+// release releases a name reserved by reserve.
+func (m *Manager) release(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.adding, name)
+}
+
+func (m *Manager) checkNameLocked(name string) error {
+	if _, ok := m.clients[name]; ok || m.adding[name] {
+		return fmt.Errorf("failed to add account %q: %w", name, types.ErrDuplicateAccount)
+	}
+	return nil
+}
+
+func (m *Manager) addLocked(name string, client Client) {
+	m.names = append(m.names, name)
+	m.clients[name] = client
+}
+
+// AddClient adds an existing client to the Manager under name, for example a
+// client with custom options or a fake client for testing. An error wrapping
+// types.ErrDuplicateAccount is returned if the Manager already has an account
+// with the same name.
+func (m *Manager) AddClient(name string, client Client) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.checkNameLocked(name); err != nil {
+		return err
+	}
+	m.addLocked(name, client)
+	return nil
+}
+
+// Remove removes the account with the given name from the Manager. It returns
+// false if the Manager does not have an account with that name.
+func (m *Manager) Remove(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.clients[name]; !ok {
+		return false
+	}
+	delete(m.clients, name)
+	for i, n := range m.names {
+		if n == name {
+			m.names = append(m.names[:i:i], m.names[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Has returns true if the Manager has an account with the given name.
+func (m *Manager) Has(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.clients[name]
+	return ok
+}
+
+// Client returns the client of the account with the given name. An error
+// wrapping types.ErrNotFound is returned if the Manager does not have an
+// account with that name.
+func (m *Manager) Client(name string) (Client, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	client, ok := m.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("account %q: %w", name, types.ErrNotFound)
+	}
+	return client, nil
+}
+
+// Names returns the names of the accounts in the order they were added.
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]string(nil), m.names...)
+}
+
+// ForEach calls f with the client of each account in the order they were
+// added. Iteration stops at the first error returned by f, which is returned
+// wrapped with the name of the account, or when ctx is done.
+//
+// Accounts added or removed while iterating do not affect the accounts that
+// are visited.
+func (m *Manager) ForEach(ctx context.Context, f func(ctx context.Context, name string, client Client) error) error {
+	m.mu.RLock()
+	names := append([]string(nil), m.names...)
+	clients := make([]Client, len(names))
+	for i, name := range names {
+		clients[i] = m.clients[name]
+	}
+	m.mu.RUnlock()
+
+	for i, name := range names {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := f(ctx, name, clients[i]); err != nil {
+			return fmt.Errorf("account %q: %w", name, err)
+		}
+	}
+	return nil
+}
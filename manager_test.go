@@ -0,0 +1,138 @@
+package nixplay_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/nixplaytest"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	m := nixplay.NewManager()
+
+	mine := nixplaytest.NewFakeClient()
+	parents := nixplaytest.NewFakeClient()
+	require.NoError(t, m.AddClient("mine", mine))
+	require.NoError(t, m.AddClient("parents", parents))
+	assert.ErrorIs(t, m.AddClient("mine", nixplaytest.NewFakeClient()), types.ErrDuplicateAccount)
+
+	assert.Equal(t, []string{"mine", "parents"}, m.Names())
+	assert.True(t, m.Has("parents"))
+	client, err := m.Client("parents")
+	require.NoError(t, err)
+	assert.Same(t, parents, client)
+	_, err = m.Client("unknown")
+	assert.ErrorIs(t, err, types.ErrNotFound)
+
+	var visited []string
+	err = m.ForEach(ctx, func(ctx context.Context, name string, client nixplay.Client) error {
+		visited = append(visited, name)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mine", "parents"}, visited)
+
+	errStop := errors.New("stop")
+	visited = nil
+	err = m.ForEach(ctx, func(ctx context.Context, name string, client nixplay.Client) error {
+		visited = append(visited, name)
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	assert.Contains(t, err.Error(), `"mine"`)
+	assert.Equal(t, []string{"mine"}, visited)
+
+	assert.True(t, m.Remove("mine"))
+	assert.False(t, m.Remove("mine"))
+	assert.Equal(t, []string{"parents"}, m.Names())
+}
+
+func TestManager_AddRateLimit(t *testing.T) {
+	ctx := context.Background()
+	m := nixplay.NewManager()
+
+	httpClient := httpx.ClientFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Header: http.Header{}}, nil
+	})
+	add := func(name string, limit httpx.RateLimit) *nixplay.DefaultClient {
+		client, err := m.Add(ctx, nixplay.Account{
+			Name:          name,
+			Authorization: types.Authorization{Session: &types.Session{Token: "token", CSRFToken: "csrf"}},
+			Options:       nixplay.DefaultClientOptions{HTTPClient: httpClient},
+			RateLimit:     limit,
+		})
+		require.NoError(t, err)
+		return client
+	}
+	limited := add("limited", httpx.RateLimit{RequestsPerSecond: 0.01})
+	unlimited := add("unlimited", httpx.RateLimit{})
+
+	_, err := m.Add(ctx, nixplay.Account{Name: "limited"})
+	assert.ErrorIs(t, err, types.ErrDuplicateAccount)
+
+	send := func(client *nixplay.DefaultClient) error {
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		req, err := client.RawAPI().NewRequest(ctx, http.MethodGet, "v3/profile/", nil)
+		require.NoError(t, err)
+		return client.RawAPI().Do(req)
+	}
+
+	// The first request is sent immediately but the next one must wait for
+	// longer than the timeout, without affecting the other account.
+	assert.NoError(t, send(limited))
+	assert.ErrorIs(t, send(limited), context.DeadlineExceeded)
+	assert.NoError(t, send(unlimited))
+	assert.NoError(t, send(unlimited))
+}
+
+func TestManager_AddConcurrentDuplicate(t *testing.T) {
+	ctx := context.Background()
+	m := nixplay.NewManager()
+
+	var logins int32
+	loggingIn := make(chan struct{})
+	finishLogin := make(chan struct{})
+	httpClient := httpx.ClientFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&logins, 1)
+		close(loggingIn)
+		<-finishLogin
+		return &http.Response{StatusCode: http.StatusUnauthorized, Status: "401 Unauthorized", Body: http.NoBody, Header: http.Header{}}, nil
+	})
+	account := nixplay.Account{
+		Name:          "mine",
+		Authorization: types.Authorization{Username: "user", Password: "password"},
+		Options:       nixplay.DefaultClientOptions{HTTPClient: httpClient},
+	}
+
+	firstErr := make(chan error)
+	go func() {
+		_, err := m.Add(ctx, account)
+		firstErr <- err
+	}()
+	<-loggingIn
+
+	// The name is reserved while the first Add signs in, so the second Add
+	// fails without signing in again.
+	_, err := m.Add(ctx, account)
+	assert.ErrorIs(t, err, types.ErrDuplicateAccount)
+	assert.ErrorIs(t, m.AddClient("mine", nixplaytest.NewFakeClient()), types.ErrDuplicateAccount)
+	assert.False(t, m.Has("mine"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&logins))
+
+	// Once the first Add fails the name can be used again.
+	close(finishLogin)
+	assert.Error(t, <-firstErr)
+	assert.False(t, m.Has("mine"))
+	require.NoError(t, m.AddClient("mine", nixplaytest.NewFakeClient()))
+}
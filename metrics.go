@@ -0,0 +1,92 @@
+package nixplay
+
+import (
+	"io"
+	"time"
+
+	"github.com/anitschke/go-nixplay/httpx"
+)
+
+// Names of the caches reported to Metrics.CacheLookup.
+const (
+	AlbumCacheName    = "albums"
+	PlaylistCacheName = "playlists"
+	PhotoCacheName    = "photos"
+)
+
+// Metrics is an interface that may be implemented by the caller in order to
+// collect metrics about the behavior of the client. It is intended to be small
+// enough that it can easily be backed by Prometheus counters and histograms
+// (or any other metrics library) without go-nixplay needing to depend on it.
+//
+// All methods may be called concurrently from multiple goroutines.
+type Metrics interface {
+	// RequestCompleted is called after every HTTP request. operation is the
+	// name of the operation the request was part of (for example "AddPhoto").
+	// statusCode is 0 if no response was received.
+	RequestCompleted(operation string, statusCode int, duration time.Duration)
+
+	// BytesUploaded is called with the number of bytes of photo content that
+	// were uploaded.
+	BytesUploaded(n int64)
+
+	// BytesDownloaded is called with the number of bytes of photo content that
+	// were downloaded.
+	BytesDownloaded(n int64)
+
+	// CacheLookup is called every time data is requested from one of the
+	// internal caches, hit indicates if the data could be served from the cache
+	// without making any requests to Nixplay. cache is one of AlbumCacheName,
+	// PlaylistCacheName or PhotoCacheName.
+	CacheLookup(cache string, hit bool)
+}
+
+// nopMetrics is the Metrics that is used when the caller does not provide
+// one.
+type nopMetrics struct{}
+
+var _ = (Metrics)(nopMetrics{})
+
+func (nopMetrics) RequestCompleted(operation string, statusCode int, duration time.Duration) {}
+func (nopMetrics) BytesUploaded(n int64)                                                     {}
+func (nopMetrics) BytesDownloaded(n int64)                                                   {}
+func (nopMetrics) CacheLookup(cache string, hit bool)                                        {}
+
+// metricsRequestHook returns a RequestHook that reports requests to m.
+func metricsRequestHook(m Metrics) httpx.RequestHook {
+	return func(info httpx.RequestInfo) {
+		operation := info.Operation
+		if operation == "" {
+			operation = info.Method
+		}
+		m.RequestCompleted(operation, info.StatusCode, info.Duration)
+	}
+}
+
+// cacheLookupObserver returns a function that can be passed to
+// cache.Cache.SetLookupObserver to report cache lookups to m.
+func cacheLookupObserver(m Metrics, cache string) func(hit bool) {
+	return func(hit bool) {
+		m.CacheLookup(cache, hit)
+	}
+}
+
+// downloadCounter is an io.ReadCloser that reports the number of bytes read
+// to Metrics.BytesDownloaded when it is closed.
+type downloadCounter struct {
+	io.ReadCloser
+	metrics Metrics
+	n       int64
+}
+
+func (d *downloadCounter) Read(p []byte) (int, error) {
+	n, err := d.ReadCloser.Read(p)
+	d.n += int64(n)
+	return n, err
+}
+
+func (d *downloadCounter) Close() error {
+	d.metrics.BytesDownloaded(d.n)
+	d.n = 0
+	return d.ReadCloser.Close()
+}
@@ -0,0 +1,134 @@
+package nixplay
+
+import (
+	"context"
+	"crypto/md5"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/rawapi"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedRequest struct {
+	operation  string
+	statusCode int
+}
+
+type recordedLookup struct {
+	cache string
+	hit   bool
+}
+
+// recordingMetrics is a Metrics that records everything reported to it.
+type recordingMetrics struct {
+	mu         sync.Mutex
+	requests   []recordedRequest
+	lookups    []recordedLookup
+	uploaded   int64
+	downloaded int64
+}
+
+var _ = (Metrics)((*recordingMetrics)(nil))
+
+func (m *recordingMetrics) RequestCompleted(operation string, statusCode int, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, recordedRequest{operation: operation, statusCode: statusCode})
+}
+
+func (m *recordingMetrics) BytesUploaded(n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.uploaded += n
+}
+
+func (m *recordingMetrics) BytesDownloaded(n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.downloaded += n
+}
+
+func (m *recordingMetrics) CacheLookup(cache string, hit bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lookups = append(m.lookups, recordedLookup{cache: cache, hit: hit})
+}
+
+func TestMetrics_RequestsAndCacheLookups(t *testing.T) {
+	ctx := context.Background()
+
+	httpClient := clientFunc(func(req *http.Request) (*http.Response, error) {
+		if strings.HasPrefix(req.URL.Path, "/v2/albums/") {
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("[]"))}, nil
+		}
+		return newTestResponse(http.StatusNotFound), nil
+	})
+	metrics := &recordingMetrics{}
+	client, err := NewDefaultClient(ctx, types.Authorization{Session: &types.Session{Token: "token", CSRFToken: "csrf"}}, DefaultClientOptions{
+		HTTPClient: httpClient,
+		Metrics:    metrics,
+	})
+	require.NoError(t, err)
+
+	_, err = client.Containers(ctx, types.AlbumContainerType)
+	require.NoError(t, err)
+	_, err = client.Containers(ctx, types.AlbumContainerType)
+	require.NoError(t, err)
+
+	// Only the first lookup needs to list the albums.
+	assert.Equal(t, []recordedRequest{
+		{operation: "ListAlbums", statusCode: http.StatusOK},
+		{operation: "ListAlbums", statusCode: http.StatusOK},
+	}, metrics.requests)
+	assert.Equal(t, []recordedLookup{
+		{cache: AlbumCacheName, hit: false},
+		{cache: AlbumCacheName, hit: true},
+	}, metrics.lookups)
+
+	// Requests that are not part of an operation are reported by method.
+	req, err := http.NewRequest(http.MethodGet, "https://api.nixplay.com/unknown/", http.NoBody)
+	require.NoError(t, err)
+	assert.Error(t, client.RawAPI().Do(req))
+	assert.Equal(t, recordedRequest{operation: http.MethodGet, statusCode: http.StatusNotFound}, metrics.requests[2])
+}
+
+func TestMetrics_BytesDownloaded(t *testing.T) {
+	ctx := context.Background()
+
+	content := "photo content"
+	h := types.MD5Hash(md5.Sum([]byte(content)))
+	url := "https://s3.example.com/1/1_" + h.String() + ".jpg"
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(content))}, nil
+	})
+	metrics := &recordingMetrics{}
+	settings := &clientSettings{
+		metrics: metrics,
+		changes: &changeNotifier{},
+	}
+	pageFunc := func(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error) {
+		return nil, nil
+	}
+	c := newContainer(client, nil, settings, types.AlbumContainerType, "album", 1234, 0, pageFunc, (*rawapi.Client).DeleteAlbum, albumAddIDName)
+	p, err := newPhoto(c, client, "photo.jpg", &h, 7, "", int64(len(content)), url)
+	require.NoError(t, err)
+
+	r, err := p.Open(ctx)
+	require.NoError(t, err)
+	_, err = io.CopyN(io.Discard, r, 5)
+	require.NoError(t, err)
+
+	// The bytes are reported once the download is closed, and only the bytes
+	// that were actually read are counted.
+	assert.Equal(t, int64(0), metrics.downloaded)
+	require.NoError(t, r.Close())
+	assert.Equal(t, int64(5), metrics.downloaded)
+}
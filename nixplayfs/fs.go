@@ -0,0 +1,347 @@
+// Package nixplayfs provides a read only io/fs.FS view of a Nixplay account so
+// that it can be used with anything that accepts an fs.FS, such as
+// http.FileServer or fs.WalkDir.
+//
+// The root of the file system contains an "album" and a "playlist" directory,
+// these contain a directory for each album or playlist named using
+// Container.NameUnique, which in turn contain a file for each photo named using
+// Photo.NameUnique. For example:
+//
+//	album/My Uploads/photo.jpg
+//	playlist/Favorites/photo.jpg
+//
+// Albums, playlists and photos with names that are not valid path elements,
+// for example names containing "/", are left out of the file system.
+//
+// Nixplay does not report when photos were taken or uploaded so the
+// modification time of all files and directories is the zero time.
+package nixplayfs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"sort"
+	"strings"
+	"time"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+var containerTypes = []types.ContainerType{types.AlbumContainerType, types.PlaylistContainerType}
+
+// FS is a read only fs.FS view of a Nixplay account, see the package
+// documentation for its layout.
+type FS struct {
+	client nixplay.Client
+	ctx    context.Context
+}
+
+var (
+	_ = (fs.FS)((*FS)(nil))
+	_ = (fs.ReadDirFS)((*FS)(nil))
+	_ = (fs.StatFS)((*FS)(nil))
+)
+
+// New returns a file system for the Nixplay account that client is connected
+// to. Requests are made with context.Background(), use WithContext to use a
+// different context.
+func New(client nixplay.Client) *FS {
+	return &FS{
+		client: client,
+		ctx:    context.Background(),
+	}
+}
+
+// WithContext returns a copy of the file system that makes requests to
+// Nixplay using ctx.
+func (fsys *FS) WithContext(ctx context.Context) *FS {
+	return &FS{
+		client: fsys.client,
+		ctx:    ctx,
+	}
+}
+
+// Open opens the named file or directory.
+func (fsys *FS) Open(name string) (fs.File, error) {
+	n, err := fsys.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.photo != nil {
+		return &file{ctx: fsys.ctx, info: n.info, photo: n.photo}, nil
+	}
+	entries, err := fsys.entries(n)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &dir{info: n.info, entries: entries}, nil
+}
+
+// ReadDir reads the named directory and returns its entries sorted by name.
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	n, err := fsys.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if n.photo != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	entries, err := fsys.entries(n)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	return entries, nil
+}
+
+// Stat returns the fs.FileInfo of the named file or directory.
+func (fsys *FS) Stat(name string) (fs.FileInfo, error) {
+	n, err := fsys.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return n.info, nil
+}
+
+// node is a file or directory in the file system. containerType is set for
+// everything below the root, container for everything below a container
+// type directory and photo for files.
+type node struct {
+	info          fileInfo
+	containerType types.ContainerType
+	container     nixplay.Container
+	photo         nixplay.Photo
+}
+
+func (fsys *FS) lookup(op string, name string) (node, error) {
+	if !fs.ValidPath(name) {
+		return node{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	n := node{info: fileInfo{name: ".", mode: fs.ModeDir | 0o555}}
+	if name == "." {
+		return n, nil
+	}
+
+	elems := strings.Split(name, "/")
+	if len(elems) > 3 {
+		return node{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	for _, ct := range containerTypes {
+		if elems[0] == string(ct) {
+			n.containerType = ct
+		}
+	}
+	if n.containerType == "" {
+		return node{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	n.info.name = elems[0]
+	if len(elems) == 1 {
+		return n, nil
+	}
+
+	container, err := fsys.client.ContainerWithUniqueName(fsys.ctx, n.containerType, elems[1])
+	if err != nil {
+		return node{}, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	if container == nil {
+		return node{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	n.container = container
+	n.info.name = elems[1]
+	if len(elems) == 2 {
+		return n, nil
+	}
+
+	photo, err := container.PhotoWithUniqueName(fsys.ctx, elems[2])
+	if err != nil {
+		return node{}, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	if photo == nil {
+		return node{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	info, err := photoInfo(fsys.ctx, elems[2], photo)
+	if err != nil {
+		return node{}, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	n.photo = photo
+	n.info = info
+	return n, nil
+}
+
+// entries lists the entries of a directory sorted by name.
+func (fsys *FS) entries(n node) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	switch {
+	case n.containerType == "":
+		for _, ct := range containerTypes {
+			entries = append(entries, fileInfo{name: string(ct), mode: fs.ModeDir | 0o555})
+		}
+	case n.container == nil:
+		containers, err := fsys.client.Containers(fsys.ctx, n.containerType)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range containers {
+			name, err := c.NameUnique(fsys.ctx)
+			if err != nil {
+				return nil, err
+			}
+			if validElem(name) {
+				entries = append(entries, fileInfo{name: name, mode: fs.ModeDir | 0o555})
+			}
+		}
+	default:
+		photos, err := n.container.Photos(fsys.ctx)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool, len(photos))
+		for _, p := range photos {
+			name, err := p.NameUnique(fsys.ctx)
+			if err != nil {
+				return nil, err
+			}
+			// Copies of the same photo in a playlist have the same unique
+			// name, only list them once.
+			if !validElem(name) || seen[name] {
+				continue
+			}
+			seen[name] = true
+			info, err := photoInfo(fsys.ctx, name, p)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, info)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+// validElem returns true if name may be used as a single element of a path.
+func validElem(name string) bool {
+	return name != "." && !strings.Contains(name, "/") && fs.ValidPath(name)
+}
+
+func photoInfo(ctx context.Context, name string, p nixplay.Photo) (fileInfo, error) {
+	size, err := p.Size(ctx)
+	if err != nil {
+		return fileInfo{}, err
+	}
+	return fileInfo{name: name, size: size, mode: 0o444, sys: p}, nil
+}
+
+// fileInfo implements both fs.FileInfo and fs.DirEntry.
+type fileInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+	sys  any
+}
+
+func (i fileInfo) Name() string               { return i.name }
+func (i fileInfo) Size() int64                { return i.size }
+func (i fileInfo) Mode() fs.FileMode          { return i.mode }
+func (i fileInfo) ModTime() time.Time         { return time.Time{} }
+func (i fileInfo) IsDir() bool                { return i.mode.IsDir() }
+func (i fileInfo) Type() fs.FileMode          { return i.mode.Type() }
+func (i fileInfo) Info() (fs.FileInfo, error) { return i, nil }
+
+// Sys returns the nixplay.Photo for files and nil for directories.
+func (i fileInfo) Sys() any { return i.sys }
+
+// dir is an open directory.
+type dir struct {
+	info    fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+var _ = (fs.ReadDirFile)((*dir)(nil))
+
+func (d *dir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dir) Close() error               { return nil }
+
+func (d *dir) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
+
+// file is an open photo. The photo is downloaded lazily when it is first read
+// and Seek is supported by downloading the photo again from the new offset.
+type file struct {
+	ctx    context.Context
+	info   fileInfo
+	photo  nixplay.Photo
+	r      io.ReadCloser
+	offset int64
+}
+
+var _ = (io.ReadSeeker)((*file)(nil))
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *file) Read(p []byte) (int, error) {
+	if f.offset >= f.info.size {
+		return 0, io.EOF
+	}
+	if f.r == nil {
+		r, err := f.photo.OpenRange(f.ctx, f.offset, -1)
+		if err != nil {
+			return 0, &fs.PathError{Op: "read", Path: f.info.name, Err: err}
+		}
+		f.r = r
+	}
+	n, err := f.r.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.size
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.info.name, Err: fs.ErrInvalid}
+	}
+	if offset != f.offset && f.r != nil {
+		f.r.Close()
+		f.r = nil
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *file) Close() error {
+	if f.r == nil {
+		return nil
+	}
+	err := f.r.Close()
+	f.r = nil
+	return err
+}
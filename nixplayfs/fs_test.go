@@ -0,0 +1,75 @@
+package nixplayfs
+
+import (
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/anitschke/go-nixplay/nixplaytest"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFS(t *testing.T) {
+	client := nixplaytest.NewFakeClient()
+	album := client.AddContainer(types.AlbumContainerType, "album")
+	album.AddPhotoContent("a.jpg", []byte("aaaa"))
+	album.AddPhotoContent("b.jpg", []byte("bb"))
+	album.AddPhotoContent("b.jpg", []byte("b2"))
+	client.AddContainer(types.AlbumContainerType, "slash/name")
+	playlist := client.AddContainer(types.PlaylistContainerType, "playlist")
+	playlist.AddPhotoContent("c.jpg", []byte("c"))
+	playlist.AddPhotoContent("c.jpg", []byte("c"))
+	client.AddContainer(types.PlaylistContainerType, "empty")
+
+	fsys := New(client)
+
+	albumPhotos, err := fs.ReadDir(fsys, "album/album")
+	require.NoError(t, err)
+	require.Len(t, albumPhotos, 3)
+	assert.Equal(t, "a.jpg", albumPhotos[0].Name())
+	uniqueB := albumPhotos[1].Name()
+	assert.Regexp(t, `^b\{.*\}\.jpg$`, uniqueB)
+
+	// Copies of the same photo in a playlist are only listed once.
+	playlistPhotos, err := fs.ReadDir(fsys, "playlist/playlist")
+	require.NoError(t, err)
+	require.Len(t, playlistPhotos, 1)
+
+	require.NoError(t, fstest.TestFS(fsys,
+		"album/album/a.jpg",
+		"album/album/"+uniqueB,
+		"playlist/playlist/"+playlistPhotos[0].Name(),
+		"playlist/empty",
+	))
+
+	containers, err := fs.ReadDir(fsys, "album")
+	require.NoError(t, err)
+	require.Len(t, containers, 1, "containers with names that aren't valid paths are left out")
+
+	data, err := fs.ReadFile(fsys, "album/album/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, "aaaa", string(data))
+
+	info, err := fs.Stat(fsys, "album/album/a.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), info.Size())
+	assert.False(t, info.IsDir())
+
+	f, err := fsys.Open("album/album/a.jpg")
+	require.NoError(t, err)
+	defer f.Close()
+	seeker := f.(io.ReadSeeker)
+	_, err = seeker.Seek(2, io.SeekStart)
+	require.NoError(t, err)
+	data, err = io.ReadAll(seeker)
+	require.NoError(t, err)
+	assert.Equal(t, "aa", string(data))
+
+	_, err = fsys.Open("album/missing")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = fsys.Open("/album")
+	assert.ErrorIs(t, err, fs.ErrInvalid)
+}
@@ -0,0 +1,564 @@
+package nixplaytest
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math/rand"
+	"strconv"
+	"sync"
+	"testing"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ClientFactory creates the client that the contract tests are run against.
+// It is called once for each test so that tests do not share cached state.
+// The factory may use t to register cleanup, for example to remove photos
+// that Nixplay adds to the "My Uploads" album when uploading to a playlist.
+type ClientFactory func(t *testing.T) nixplay.Client
+
+// RunClientContract runs a suite of tests that check that a nixplay.Client,
+// along with the Containers and Photos it returns, behaves the way that the
+// nixplay interfaces are documented to behave. It can be run against the
+// real client, FakeClient or any other implementation.
+//
+// The tests only look at containers that they create themselves, which are
+// given random names and deleted when each test finishes, so the client may
+// be connected to an account that has other content.
+func RunClientContract(t *testing.T, newClient ClientFactory) {
+	containerTypes := []types.ContainerType{types.AlbumContainerType, types.PlaylistContainerType}
+	for _, containerType := range containerTypes {
+		containerType := containerType
+		t.Run(string(containerType), func(t *testing.T) {
+			t.Run("Containers", func(t *testing.T) { testContainers(t, newClient(t), containerType) })
+			t.Run("DuplicateContainerNames", func(t *testing.T) { testDuplicateContainerNames(t, newClient(t), containerType) })
+			t.Run("Photos", func(t *testing.T) { testPhotos(t, newClient(t), containerType) })
+			t.Run("DuplicatePhotoNames", func(t *testing.T) { testDuplicatePhotoNames(t, newClient(t), containerType) })
+			t.Run("ChangeToken", func(t *testing.T) { testChangeToken(t, newClient(t), containerType) })
+			t.Run("SortedPhotos", func(t *testing.T) { testSortedPhotos(t, newClient(t), containerType) })
+			t.Run("DuplicatePhotoContent", func(t *testing.T) { testDuplicatePhotoContent(t, newClient(t), containerType) })
+			t.Run("AddPhotoAsync", func(t *testing.T) { testAddPhotoAsync(t, newClient(t), containerType) })
+			t.Run("AddSlideFromPhoto", func(t *testing.T) { testAddSlideFromPhoto(t, newClient(t), containerType) })
+			t.Run("ChangeListener", func(t *testing.T) { testChangeListener(t, newClient(t), containerType) })
+		})
+	}
+}
+
+// RunDuplicateSlideContract runs tests that check that copies of the same photo
+// within a playlist can be told apart. newClient must return clients that are
+// configured with types.PlaylistItemPhotoIdentity, see
+// nixplay.DefaultClientOptions.PhotoIdentity and FakeClient.PhotoIdentity.
+func RunDuplicateSlideContract(t *testing.T, newClient ClientFactory) {
+	t.Run("DuplicateSlides", func(t *testing.T) { testDuplicateSlides(t, newClient(t)) })
+}
+
+func contractName() string {
+	return "nixplaytest-contract-" + strconv.FormatUint(rand.Uint64(), 36)
+}
+
+// contractPhoto returns the content of a small PNG that is different every
+// time it is called, so that tests don't collide with photos uploaded by
+// other tests.
+func contractPhoto(t *testing.T) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	c := rand.Uint32()
+	img.Set(0, 0, color.RGBA{R: uint8(c), G: uint8(c >> 8), B: uint8(c >> 16), A: 255})
+	img.Set(1, 1, color.RGBA{R: uint8(c >> 24), A: 255})
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func createContainer(t *testing.T, client nixplay.Client, containerType types.ContainerType, name string) nixplay.Container {
+	container, err := client.CreateContainer(context.Background(), containerType, name)
+	require.NoError(t, err)
+	require.NotNil(t, container)
+	t.Cleanup(func() {
+		// The test may have already deleted the container so ignore errors.
+		container.Delete(context.Background(), nixplay.DeleteOptions{Force: true})
+	})
+	return container
+}
+
+func addPhoto(t *testing.T, container nixplay.Container, name string, content []byte) nixplay.Photo {
+	p, err := container.AddPhoto(context.Background(), name, bytes.NewReader(content), nixplay.AddPhotoOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	return p
+}
+
+func containerIDs(containers []nixplay.Container) []types.ID {
+	ids := make([]types.ID, 0, len(containers))
+	for _, c := range containers {
+		ids = append(ids, c.ID())
+	}
+	return ids
+}
+
+func photoIDs(photos []nixplay.Photo) []types.ID {
+	ids := make([]types.ID, 0, len(photos))
+	for _, p := range photos {
+		ids = append(ids, p.ID())
+	}
+	return ids
+}
+
+func testContainers(t *testing.T, client nixplay.Client, containerType types.ContainerType) {
+	ctx := context.Background()
+	name := contractName()
+
+	containers, err := client.ContainersWithName(ctx, containerType, name)
+	require.NoError(t, err)
+	assert.Empty(t, containers)
+
+	container := createContainer(t, client, containerType, name)
+	assert.Equal(t, containerType, container.ContainerType())
+	actName, err := container.Name(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, name, actName)
+	uniqueName, err := container.NameUnique(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, name, uniqueName)
+	count, err := container.PhotoCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+	photos, err := container.Photos(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, photos)
+
+	containers, err = client.Containers(ctx, containerType)
+	require.NoError(t, err)
+	assert.Contains(t, containerIDs(containers), container.ID())
+	for _, c := range containers {
+		assert.Equal(t, containerType, c.ContainerType())
+	}
+
+	containers, err = client.ContainersWithName(ctx, containerType, name)
+	require.NoError(t, err)
+	assert.Equal(t, []types.ID{container.ID()}, containerIDs(containers))
+
+	found, err := client.ContainerWithUniqueName(ctx, containerType, name)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, container.ID(), found.ID())
+
+	// The ID is stable after the cache is reset.
+	client.ResetCache()
+	containers, err = client.ContainersWithName(ctx, containerType, name)
+	require.NoError(t, err)
+	assert.Equal(t, []types.ID{container.ID()}, containerIDs(containers))
+
+	require.NoError(t, container.Delete(ctx, nixplay.DeleteOptions{}))
+	containers, err = client.ContainersWithName(ctx, containerType, name)
+	require.NoError(t, err)
+	assert.Empty(t, containers)
+	found, err = client.ContainerWithUniqueName(ctx, containerType, name)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+	containers, err = client.Containers(ctx, containerType)
+	require.NoError(t, err)
+	assert.NotContains(t, containerIDs(containers), container.ID())
+}
+
+func testDuplicateContainerNames(t *testing.T, client nixplay.Client, containerType types.ContainerType) {
+	ctx := context.Background()
+	name := contractName()
+
+	container1 := createContainer(t, client, containerType, name)
+	container2 := createContainer(t, client, containerType, name)
+	assert.NotEqual(t, container1.ID(), container2.ID())
+
+	containers, err := client.ContainersWithName(ctx, containerType, name)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []types.ID{container1.ID(), container2.ID()}, containerIDs(containers))
+
+	uniqueName1, err := container1.NameUnique(ctx)
+	require.NoError(t, err)
+	uniqueName2, err := container2.NameUnique(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, name, uniqueName1)
+	assert.NotEqual(t, name, uniqueName2)
+	assert.NotEqual(t, uniqueName1, uniqueName2)
+
+	found, err := client.ContainerWithUniqueName(ctx, containerType, name)
+	require.NoError(t, err)
+	assert.Nil(t, found)
+	found, err = client.ContainerWithUniqueName(ctx, containerType, uniqueName1)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, container1.ID(), found.ID())
+	found, err = client.ContainerWithUniqueName(ctx, containerType, uniqueName2)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, container2.ID(), found.ID())
+
+	// Once the duplicate is deleted the unique name is the name again.
+	require.NoError(t, container2.Delete(ctx, nixplay.DeleteOptions{}))
+	uniqueName1, err = container1.NameUnique(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, name, uniqueName1)
+}
+
+func testPhotos(t *testing.T, client nixplay.Client, containerType types.ContainerType) {
+	ctx := context.Background()
+	container := createContainer(t, client, containerType, contractName())
+	content := contractPhoto(t)
+
+	p := addPhoto(t, container, "photo.png", content)
+	name, err := p.Name(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "photo.png", name)
+	uniqueName, err := p.NameUnique(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "photo.png", uniqueName)
+	size, err := p.Size(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), size)
+	md5Hash, err := p.MD5Hash(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, types.MD5Hash(md5.Sum(content)), md5Hash)
+	mediaType, err := p.MediaType(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, types.PhotoMediaType, mediaType)
+	url, err := p.URL(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, url)
+
+	r, err := p.Open(ctx)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	r.Close()
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+
+	r, err = p.OpenRange(ctx, 1, 4)
+	require.NoError(t, err)
+	data, err = io.ReadAll(r)
+	r.Close()
+	require.NoError(t, err)
+	assert.Equal(t, content[1:5], data)
+
+	var buf bytes.Buffer
+	require.NoError(t, p.DownloadTo(ctx, &buf, nixplay.DownloadOptions{VerifyMD5: true}))
+	assert.Equal(t, content, buf.Bytes())
+
+	count, err := container.PhotoCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// A container that is not empty is only deleted when forced.
+	var nonEmpty *nixplay.NonEmptyContainerError
+	require.ErrorAs(t, container.Delete(ctx, nixplay.DeleteOptions{}), &nonEmpty)
+	assert.Equal(t, int64(1), nonEmpty.PhotoCount)
+	assert.ErrorIs(t, nonEmpty, nixplay.ErrNonEmptyContainer)
+
+	photos, err := container.Photos(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []types.ID{p.ID()}, photoIDs(photos))
+	photos, err = container.PhotosWithName(ctx, "photo.png")
+	require.NoError(t, err)
+	assert.Equal(t, []types.ID{p.ID()}, photoIDs(photos))
+	found, err := container.PhotoWithUniqueName(ctx, "photo.png")
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, p.ID(), found.ID())
+	found, err = container.PhotoWithID(ctx, p.ID())
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, p.ID(), found.ID())
+
+	// The ID is stable after the cache is reset.
+	container.ResetCache()
+	photos, err = container.Photos(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []types.ID{p.ID()}, photoIDs(photos))
+
+	require.NoError(t, p.Refresh(ctx))
+	exists, err := p.Exists(ctx)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, p.Delete(ctx, nixplay.DeleteOptions{}))
+	photos, err = container.Photos(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, photos)
+	exists, err = p.Exists(ctx)
+	require.NoError(t, err)
+	assert.False(t, exists)
+	assert.ErrorIs(t, p.Refresh(ctx), types.ErrNotFound)
+	found, err = container.PhotoWithID(ctx, p.ID())
+	require.NoError(t, err)
+	assert.Nil(t, found)
+	found, err = container.PhotoWithUniqueName(ctx, "photo.png")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+}
+
+func testChangeToken(t *testing.T, client nixplay.Client, containerType types.ContainerType) {
+	ctx := context.Background()
+	container := createContainer(t, client, containerType, contractName())
+
+	empty, err := container.ChangeToken(ctx)
+	require.NoError(t, err)
+	token, err := container.ChangeToken(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, empty, token, "token should not change if the container does not change")
+
+	p := addPhoto(t, container, "photo.png", contractPhoto(t))
+	added, err := container.ChangeToken(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, empty, added)
+
+	// Replacing the photo keeps the number of photos the same but must still
+	// change the token.
+	require.NoError(t, p.Delete(ctx, nixplay.DeleteOptions{}))
+	addPhoto(t, container, "photo.png", contractPhoto(t))
+	replaced, err := container.ChangeToken(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, added, replaced)
+}
+
+func testSortedPhotos(t *testing.T, client nixplay.Client, containerType types.ContainerType) {
+	ctx := context.Background()
+	container := createContainer(t, client, containerType, contractName())
+
+	b := addPhoto(t, container, "b.png", contractPhoto(t))
+	c := addPhoto(t, container, "c.png", contractPhoto(t))
+	a := addPhoto(t, container, "a.png", contractPhoto(t))
+
+	photos, err := container.PhotosWithOptions(ctx, nixplay.PhotosOptions{SortBy: types.NamePhotoSortBy})
+	require.NoError(t, err)
+	assert.Equal(t, []types.ID{a.ID(), b.ID(), c.ID()}, photoIDs(photos))
+
+	photos, err = container.PhotosWithOptions(ctx, nixplay.PhotosOptions{SortBy: types.NamePhotoSortBy, Order: types.DescendingSortOrder})
+	require.NoError(t, err)
+	assert.Equal(t, []types.ID{c.ID(), b.ID(), a.ID()}, photoIDs(photos))
+
+	_, err = container.PhotosWithOptions(ctx, nixplay.PhotosOptions{SortBy: "takenAt"})
+	assert.ErrorIs(t, err, types.ErrInvalidPhotoSort)
+}
+
+func testDuplicatePhotoNames(t *testing.T, client nixplay.Client, containerType types.ContainerType) {
+	ctx := context.Background()
+	container := createContainer(t, client, containerType, contractName())
+
+	p1 := addPhoto(t, container, "photo.png", contractPhoto(t))
+	p2 := addPhoto(t, container, "photo.png", contractPhoto(t))
+	assert.NotEqual(t, p1.ID(), p2.ID())
+
+	photos, err := container.PhotosWithName(ctx, "photo.png")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []types.ID{p1.ID(), p2.ID()}, photoIDs(photos))
+
+	uniqueName1, err := p1.NameUnique(ctx)
+	require.NoError(t, err)
+	uniqueName2, err := p2.NameUnique(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, "photo.png", uniqueName1)
+	assert.NotEqual(t, uniqueName1, uniqueName2)
+
+	found, err := container.PhotoWithUniqueName(ctx, "photo.png")
+	require.NoError(t, err)
+	assert.Nil(t, found)
+	found, err = container.PhotoWithUniqueName(ctx, uniqueName1)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, p1.ID(), found.ID())
+	found, err = container.PhotoWithUniqueName(ctx, uniqueName2)
+	require.NoError(t, err)
+	require.NotNil(t, found)
+	assert.Equal(t, p2.ID(), found.ID())
+}
+
+func testDuplicatePhotoContent(t *testing.T, client nixplay.Client, containerType types.ContainerType) {
+	ctx := context.Background()
+	container := createContainer(t, client, containerType, contractName())
+	content := contractPhoto(t)
+
+	p := addPhoto(t, container, "photo.png", content)
+
+	// SkipExisting returns the existing photo rather than uploading again.
+	skipped, err := container.AddPhoto(ctx, "copy.png", bytes.NewReader(content), nixplay.AddPhotoOptions{SkipExisting: true})
+	require.NoError(t, err)
+	require.NotNil(t, skipped)
+	assert.Equal(t, p.ID(), skipped.ID())
+
+	_, err = container.AddPhoto(ctx, "copy.png", bytes.NewReader(content), nixplay.AddPhotoOptions{})
+	if containerType == types.AlbumContainerType {
+		// Albums may not contain the same content twice.
+		var dupErr *nixplay.DuplicateImageError
+		require.ErrorAs(t, err, &dupErr)
+		assert.ErrorIs(t, err, nixplay.ErrDuplicateImage)
+		if dupErr.Existing != nil {
+			assert.Equal(t, p.ID(), dupErr.Existing.ID())
+		}
+		return
+	}
+
+	// Playlists may, and both copies have the same ID.
+	require.NoError(t, err)
+	photos, err := container.Photos(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []types.ID{p.ID(), p.ID()}, photoIDs(photos))
+}
+
+func testAddPhotoAsync(t *testing.T, client nixplay.Client, containerType types.ContainerType) {
+	ctx := context.Background()
+	container := createContainer(t, client, containerType, contractName())
+	content := contractPhoto(t)
+
+	handle, err := container.AddPhotoAsync(ctx, "photo.png", bytes.NewReader(content), nixplay.AddPhotoOptions{})
+	require.NoError(t, err)
+	p, err := handle.Wait(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, p)
+	assert.Equal(t, types.UploadCompleteStatus, handle.Status())
+
+	md5Hash, err := p.MD5Hash(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, types.MD5Hash(md5.Sum(content)), md5Hash)
+	found, err := container.PhotoWithID(ctx, p.ID())
+	require.NoError(t, err)
+	assert.NotNil(t, found)
+}
+
+func testAddSlideFromPhoto(t *testing.T, client nixplay.Client, containerType types.ContainerType) {
+	ctx := context.Background()
+	album := createContainer(t, client, types.AlbumContainerType, contractName())
+	content := contractPhoto(t)
+	source := addPhoto(t, album, "photo.png", content)
+
+	container := createContainer(t, client, containerType, contractName())
+	slide, err := container.AddSlideFromPhoto(ctx, source)
+	if containerType != types.PlaylistContainerType {
+		assert.ErrorIs(t, err, types.ErrInvalidContainerType)
+		return
+	}
+	require.NoError(t, err)
+	require.NotNil(t, slide)
+
+	name, err := slide.Name(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "photo.png", name)
+	md5Hash, err := slide.MD5Hash(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, types.MD5Hash(md5.Sum(content)), md5Hash)
+	photos, err := container.Photos(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []types.ID{slide.ID()}, photoIDs(photos))
+
+	// The slide refers to the photo in the album rather than a copy of it.
+	count, err := album.PhotoCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	// Photos that are not slides of the playlist can't be removed from it.
+	assert.ErrorIs(t, container.RemoveSlide(ctx, source), types.ErrNotFound)
+
+	// Removing the slide leaves the photo in the album alone.
+	require.NoError(t, container.RemoveSlide(ctx, slide))
+	photos, err = container.Photos(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, photos)
+	photos, err = album.Photos(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []types.ID{source.ID()}, photoIDs(photos))
+
+	// Photos can not be deleted from an album without deleting them from
+	// playlists.
+	assert.ErrorIs(t, source.Delete(ctx, nixplay.DeleteOptions{Scope: types.ContainerOnlyDeleteScope}), nixplay.ErrUnsupportedDeleteScope)
+
+	// Deleting a slide globally deletes the photo it shows from the album.
+	slide, err = container.AddSlideFromPhoto(ctx, source)
+	require.NoError(t, err)
+	require.NoError(t, slide.Delete(ctx, nixplay.DeleteOptions{Scope: types.GlobalDeleteScope}))
+	exists, err := source.Exists(ctx)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func testDuplicateSlides(t *testing.T, client nixplay.Client) {
+	ctx := context.Background()
+	album := createContainer(t, client, types.AlbumContainerType, contractName())
+	source := addPhoto(t, album, "photo.png", contractPhoto(t))
+	playlist := createContainer(t, client, types.PlaylistContainerType, contractName())
+
+	first, err := playlist.AddSlideFromPhoto(ctx, source)
+	require.NoError(t, err)
+	second, err := playlist.AddSlideFromPhoto(ctx, source)
+	require.NoError(t, err)
+	assert.NotEqual(t, first.ID(), second.ID())
+
+	photos, err := playlist.Photos(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []types.ID{first.ID(), second.ID()}, photoIDs(photos))
+	for _, p := range []nixplay.Photo{first, second} {
+		found, err := playlist.PhotoWithID(ctx, p.ID())
+		require.NoError(t, err)
+		require.NotNil(t, found)
+		assert.Equal(t, p.ID(), found.ID())
+	}
+
+	// Copies have the same name so their unique names tell them apart.
+	firstUnique, err := first.NameUnique(ctx)
+	require.NoError(t, err)
+	secondUnique, err := second.NameUnique(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, firstUnique, secondUnique)
+
+	// Removing one copy leaves the other copy and the photo in the album.
+	require.NoError(t, playlist.RemoveSlide(ctx, first))
+	photos, err = playlist.Photos(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []types.ID{second.ID()}, photoIDs(photos))
+	exists, err := second.Exists(ctx)
+	require.NoError(t, err)
+	assert.True(t, exists)
+	exists, err = source.Exists(ctx)
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func testChangeListener(t *testing.T, client nixplay.Client, containerType types.ContainerType) {
+	ctx := context.Background()
+
+	name := contractName()
+	var mu sync.Mutex
+	var events []types.ChangeType
+	remove := client.AddChangeListener(func(event nixplay.ChangeEvent) {
+		// Only look at changes to our container, uploading to a playlist may
+		// also add the photo to "My Uploads".
+		containerName, err := event.Container.Name(ctx)
+		if err != nil || containerName != name || event.Refreshed {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event.Type)
+	})
+
+	container := createContainer(t, client, containerType, name)
+	p := addPhoto(t, container, "photo.png", contractPhoto(t))
+	require.NoError(t, p.Delete(ctx, nixplay.DeleteOptions{}))
+	require.NoError(t, container.Delete(ctx, nixplay.DeleteOptions{}))
+
+	remove()
+	_ = createContainer(t, client, containerType, name)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []types.ChangeType{
+		types.ContainerCreatedChangeType,
+		types.PhotoAddedChangeType,
+		types.PhotoDeletedChangeType,
+		types.ContainerDeletedChangeType,
+	}, events)
+}
@@ -0,0 +1,24 @@
+package nixplaytest
+
+import (
+	"testing"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+func TestFakeClient_Contract(t *testing.T) {
+	RunClientContract(t, func(t *testing.T) nixplay.Client {
+		client := NewFakeClient()
+		client.AddContainer(types.AlbumContainerType, MyUploadsAlbumName)
+		return client
+	})
+}
+
+func TestFakeClient_DuplicateSlideContract(t *testing.T) {
+	RunDuplicateSlideContract(t, func(t *testing.T) nixplay.Client {
+		client := NewFakeClient()
+		client.PhotoIdentity = types.PlaylistItemPhotoIdentity
+		return client
+	})
+}
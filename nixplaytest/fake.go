@@ -0,0 +1,879 @@
+// Package nixplaytest provides an in-memory implementation of the nixplay
+// Client, Container and Photo interfaces for use in tests of code that uses
+// go-nixplay, without needing a Nixplay account or network access.
+package nixplaytest
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/internal/mime"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// MyUploadsAlbumName is the name of the album that Nixplay stores photos in
+// when they are uploaded to a playlist.
+const MyUploadsAlbumName = "My Uploads"
+
+// FavoritesPlaylistName is the name of the playlist that Nixplay creates to
+// hold the photos that are marked as favorites.
+const FavoritesPlaylistName = "Favorites"
+
+// Call describes a call to a method of a fake, see FakeClient.OnCall.
+type Call struct {
+	// Method is the name of the method that was called prefixed with the name
+	// of the interface, for example "Client.CreateContainer",
+	// "Container.AddPhoto" or "Photo.Delete".
+	Method string
+
+	// Container is the container the method was called on, or the container
+	// of the photo the method was called on. Container is nil for methods of
+	// Client.
+	Container *FakeContainer
+
+	// Photo is the photo the method was called on. Photo is nil for methods of
+	// Client and Container.
+	Photo *FakePhoto
+}
+
+// FakeClient is an in-memory implementation of nixplay.Client.
+//
+// The contents of the fake can be set up with AddContainer and
+// FakeContainer.AddPhotoContent or through the nixplay interfaces. Like
+// Nixplay an album may not contain more than one photo with the same content
+// while a playlist may. Photos uploaded to a playlist are also added to the
+// "My Uploads" album if it exists. The first album named MyUploadsAlbumName is
+// the "My Uploads" album and the first playlist named FavoritesPlaylistName is
+// the favorites playlist, they are returned by MyUploads and Favorites and can
+// not be deleted. The fake does not have an email album. Unlike Nixplay deleting a photo from
+// an album does not delete it from playlists.
+//
+// All methods are safe to call concurrently.
+type FakeClient struct {
+	// OnCall is an optional function that is called at the start of every
+	// method of the client and the containers and photos it contains. If it
+	// returns an error the method returns that error without doing anything
+	// else. This can be used to inject errors. OnCall must be set before the
+	// client is used.
+	OnCall func(call Call) error
+
+	// PhotoIdentity controls how the IDs of photos in playlists are computed,
+	// see nixplay.DefaultClientOptions.PhotoIdentity. With
+	// types.PlaylistItemPhotoIdentity every slide added to a playlist gets its
+	// own ID, even if the playlist already contains the same content.
+	// PhotoIdentity must be set before the client is used.
+	PhotoIdentity types.PhotoIdentity
+
+	mu         sync.Mutex
+	nextID     uint64
+	containers []*FakeContainer
+
+	listenersMu    sync.Mutex
+	nextListenerID uint64
+	listeners      map[uint64]nixplay.ChangeListener
+}
+
+var _ = (nixplay.Client)((*FakeClient)(nil))
+
+// NewFakeClient creates an empty FakeClient.
+func NewFakeClient() *FakeClient {
+	return &FakeClient{}
+}
+
+func (c *FakeClient) call(call Call) error {
+	if c.OnCall == nil {
+		return nil
+	}
+	return c.OnCall(call)
+}
+
+// AddContainer adds a container to the fake.
+func (c *FakeClient) AddContainer(containerType types.ContainerType, name string) *FakeContainer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.addContainerLocked(containerType, name)
+}
+
+func (c *FakeClient) addContainerLocked(containerType types.ContainerType, name string) *FakeContainer {
+	c.nextID++
+	idBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(idBytes, c.nextID)
+	hasher := sha256.New()
+	hasher.Write([]byte(containerType))
+	hasher.Write(idBytes)
+
+	container := &FakeContainer{
+		client:        c,
+		containerType: containerType,
+		id:            *(*types.ID)(hasher.Sum(nil)),
+		name:          name,
+	}
+	c.containers = append(c.containers, container)
+	return container
+}
+
+func (c *FakeClient) containersLocked(containerType types.ContainerType) []nixplay.Container {
+	containers := []nixplay.Container{}
+	for _, container := range c.containers {
+		if container.containerType == containerType {
+			containers = append(containers, container)
+		}
+	}
+	return containers
+}
+
+func (c *FakeClient) Containers(ctx context.Context, containerType types.ContainerType) ([]nixplay.Container, error) {
+	if err := c.call(Call{Method: "Client.Containers"}); err != nil {
+		return nil, err
+	}
+	if containerType != types.AlbumContainerType && containerType != types.PlaylistContainerType {
+		return nil, types.ErrInvalidContainerType
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.containersLocked(containerType), nil
+}
+
+func (c *FakeClient) ContainersWithName(ctx context.Context, containerType types.ContainerType, name string) ([]nixplay.Container, error) {
+	if err := c.call(Call{Method: "Client.ContainersWithName"}); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	containers := []nixplay.Container{}
+	for _, container := range c.containers {
+		if container.containerType == containerType && container.name == name {
+			containers = append(containers, container)
+		}
+	}
+	return containers, nil
+}
+
+func (c *FakeClient) ContainerWithUniqueName(ctx context.Context, containerType types.ContainerType, name string) (nixplay.Container, error) {
+	if err := c.call(Call{Method: "Client.ContainerWithUniqueName"}); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, container := range c.containers {
+		if container.containerType == containerType && c.containerUniqueNameLocked(container) == name {
+			return container, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *FakeClient) CreateContainer(ctx context.Context, containerType types.ContainerType, name string) (nixplay.Container, error) {
+	if err := c.call(Call{Method: "Client.CreateContainer"}); err != nil {
+		return nil, err
+	}
+	if containerType != types.AlbumContainerType && containerType != types.PlaylistContainerType {
+		return nil, types.ErrInvalidContainerType
+	}
+	container := c.AddContainer(containerType, name)
+	c.notify(nixplay.ChangeEvent{Type: types.ContainerCreatedChangeType, Container: container})
+	return container, nil
+}
+
+func (c *FakeClient) MyUploads(ctx context.Context) (nixplay.Container, error) {
+	if err := c.call(Call{Method: "Client.MyUploads"}); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if myUploads := c.myUploadsLocked(); myUploads != nil {
+		return myUploads, nil
+	}
+	return nil, fmt.Errorf("My Uploads album: %w", types.ErrNotFound)
+}
+
+// EmailAlbum always returns an error wrapping types.ErrNotFound since the fake
+// does not have an email album.
+func (c *FakeClient) EmailAlbum(ctx context.Context) (nixplay.Container, error) {
+	if err := c.call(Call{Method: "Client.EmailAlbum"}); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("email album: %w", types.ErrNotFound)
+}
+
+func (c *FakeClient) Favorites(ctx context.Context) (nixplay.Container, error) {
+	if err := c.call(Call{Method: "Client.Favorites"}); err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if favorites := c.favoritesLocked(); favorites != nil {
+		return favorites, nil
+	}
+	return nil, fmt.Errorf("Favorites playlist: %w", types.ErrNotFound)
+}
+
+// myUploadsLocked returns the "My Uploads" album or nil if there is not one.
+func (c *FakeClient) myUploadsLocked() *FakeContainer {
+	return c.firstContainerLocked(types.AlbumContainerType, MyUploadsAlbumName)
+}
+
+// favoritesLocked returns the favorites playlist or nil if there is not one.
+func (c *FakeClient) favoritesLocked() *FakeContainer {
+	return c.firstContainerLocked(types.PlaylistContainerType, FavoritesPlaylistName)
+}
+
+func (c *FakeClient) firstContainerLocked(containerType types.ContainerType, name string) *FakeContainer {
+	for _, container := range c.containers {
+		if container.containerType == containerType && container.name == name {
+			return container
+		}
+	}
+	return nil
+}
+
+// ResetCache does nothing since the fake does not cache anything.
+func (c *FakeClient) ResetCache() {}
+
+func (c *FakeClient) AddChangeListener(l nixplay.ChangeListener) (remove func()) {
+	c.listenersMu.Lock()
+	defer c.listenersMu.Unlock()
+	if c.listeners == nil {
+		c.listeners = make(map[uint64]nixplay.ChangeListener)
+	}
+	id := c.nextListenerID
+	c.nextListenerID++
+	c.listeners[id] = l
+	return func() {
+		c.listenersMu.Lock()
+		defer c.listenersMu.Unlock()
+		delete(c.listeners, id)
+	}
+}
+
+func (c *FakeClient) notify(event nixplay.ChangeEvent) {
+	c.listenersMu.Lock()
+	listeners := make([]nixplay.ChangeListener, 0, len(c.listeners))
+	for _, l := range c.listeners {
+		listeners = append(listeners, l)
+	}
+	c.listenersMu.Unlock()
+	for _, l := range listeners {
+		l(event)
+	}
+}
+
+// Refresh does nothing since the fake does not cache anything.
+func (c *FakeClient) Refresh(ctx context.Context) error {
+	return c.call(Call{Method: "Client.Refresh"})
+}
+
+func (c *FakeClient) containerUniqueNameLocked(container *FakeContainer) string {
+	for _, other := range c.containers {
+		if other != container && other.containerType == container.containerType && other.name == container.name {
+			return container.name + "{" + base64.URLEncoding.EncodeToString(container.id[:]) + "}"
+		}
+	}
+	return container.name
+}
+
+// FakeContainer is an in-memory implementation of nixplay.Container. See
+// FakeClient.
+type FakeContainer struct {
+	client        *FakeClient
+	containerType types.ContainerType
+	id            types.ID
+	name          string
+	photos        []*FakePhoto
+	deleted       bool
+}
+
+var _ = (nixplay.Container)((*FakeContainer)(nil))
+
+func (c *FakeContainer) call(method string) error {
+	return c.client.call(Call{Method: method, Container: c})
+}
+
+// AddPhotoContent adds a photo to the container without any of the checks
+// done by AddPhoto, which allows setting up states such as an album that
+// contains duplicate photos.
+func (c *FakeContainer) AddPhotoContent(name string, content []byte) *FakePhoto {
+	c.client.mu.Lock()
+	defer c.client.mu.Unlock()
+	return c.addPhotoLocked(name, content)
+}
+
+func (c *FakeContainer) addPhotoLocked(name string, content []byte) *FakePhoto {
+	md5Hash := types.MD5Hash(md5.Sum(content))
+	hasher := sha256.New()
+	hasher.Write(c.id[:])
+	if c.containerType == types.PlaylistContainerType && c.client.PhotoIdentity == types.PlaylistItemPhotoIdentity {
+		c.client.nextID++
+		hasher.Write([]byte("playlistItem"))
+		hasher.Write([]byte(strconv.FormatUint(c.client.nextID, 10)))
+	} else {
+		hasher.Write(md5Hash[:])
+	}
+	p := &FakePhoto{
+		container: c,
+		id:        *(*types.ID)(hasher.Sum(nil)),
+		name:      name,
+		content:   append([]byte(nil), content...),
+		md5Hash:   md5Hash,
+	}
+	c.photos = append(c.photos, p)
+	return p
+}
+
+func (c *FakeContainer) ID() types.ID {
+	return c.id
+}
+
+func (c *FakeContainer) ContainerType() types.ContainerType {
+	return c.containerType
+}
+
+func (c *FakeContainer) Name(ctx context.Context) (string, error) {
+	if err := c.call("Container.Name"); err != nil {
+		return "", err
+	}
+	c.client.mu.Lock()
+	defer c.client.mu.Unlock()
+	return c.name, nil
+}
+
+func (c *FakeContainer) NameUnique(ctx context.Context) (string, error) {
+	if err := c.call("Container.NameUnique"); err != nil {
+		return "", err
+	}
+	c.client.mu.Lock()
+	defer c.client.mu.Unlock()
+	return c.client.containerUniqueNameLocked(c), nil
+}
+
+func (c *FakeContainer) PhotoCount(ctx context.Context) (int64, error) {
+	if err := c.call("Container.PhotoCount"); err != nil {
+		return 0, err
+	}
+	c.client.mu.Lock()
+	defer c.client.mu.Unlock()
+	return int64(len(c.photos)), nil
+}
+
+func (c *FakeContainer) ChangeToken(ctx context.Context) (string, error) {
+	if err := c.call("Container.ChangeToken"); err != nil {
+		return "", err
+	}
+	c.client.mu.Lock()
+	defer c.client.mu.Unlock()
+	if c.deleted {
+		return "", types.ErrNotFound
+	}
+	hasher := sha256.New()
+	for _, p := range c.photos {
+		hasher.Write(p.id[:])
+	}
+	return fmt.Sprintf("%d-%x", len(c.photos), hasher.Sum(nil)[:16]), nil
+}
+
+func (c *FakeContainer) Photos(ctx context.Context) ([]nixplay.Photo, error) {
+	if err := c.call("Container.Photos"); err != nil {
+		return nil, err
+	}
+	c.client.mu.Lock()
+	defer c.client.mu.Unlock()
+	photos := make([]nixplay.Photo, 0, len(c.photos))
+	for _, p := range c.photos {
+		photos = append(photos, p)
+	}
+	return photos, nil
+}
+
+func (c *FakeContainer) PhotosWithOptions(ctx context.Context, opts nixplay.PhotosOptions) ([]nixplay.Photo, error) {
+	if err := c.call("Container.PhotosWithOptions"); err != nil {
+		return nil, err
+	}
+	c.client.mu.Lock()
+	photos := make([]nixplay.Photo, 0, len(c.photos))
+	for _, p := range c.photos {
+		photos = append(photos, p)
+	}
+	c.client.mu.Unlock()
+
+	if err := nixplay.SortPhotos(ctx, photos, opts); err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+func (c *FakeContainer) PhotosWithName(ctx context.Context, name string) ([]nixplay.Photo, error) {
+	if err := c.call("Container.PhotosWithName"); err != nil {
+		return nil, err
+	}
+	c.client.mu.Lock()
+	defer c.client.mu.Unlock()
+	photos := []nixplay.Photo{}
+	for _, p := range c.photos {
+		if p.name == name {
+			photos = append(photos, p)
+		}
+	}
+	return photos, nil
+}
+
+func (c *FakeContainer) PhotoWithUniqueName(ctx context.Context, name string) (nixplay.Photo, error) {
+	if err := c.call("Container.PhotoWithUniqueName"); err != nil {
+		return nil, err
+	}
+	c.client.mu.Lock()
+	defer c.client.mu.Unlock()
+	for _, p := range c.photos {
+		if c.photoUniqueNameLocked(p) == name {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *FakeContainer) PhotoWithID(ctx context.Context, id types.ID) (nixplay.Photo, error) {
+	if err := c.call("Container.PhotoWithID"); err != nil {
+		return nil, err
+	}
+	c.client.mu.Lock()
+	defer c.client.mu.Unlock()
+	for _, p := range c.photos {
+		if p.id == id {
+			return p, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *FakeContainer) DownloadAll(ctx context.Context, sink nixplay.DownloadSink, opts nixplay.DownloadAllOptions) (nixplay.DownloadAllResult, error) {
+	if err := c.call("Container.DownloadAll"); err != nil {
+		return nixplay.DownloadAllResult{}, err
+	}
+	c.client.mu.Lock()
+	photos := make([]nixplay.Photo, 0, len(c.photos))
+	for _, p := range c.photos {
+		photos = append(photos, p)
+	}
+	c.client.mu.Unlock()
+
+	return nixplay.DownloadPhotos(ctx, photos, sink, opts)
+}
+
+func (c *FakeContainer) Delete(ctx context.Context, opts nixplay.DeleteOptions) error {
+	if err := c.call("Container.Delete"); err != nil {
+		return err
+	}
+	c.client.mu.Lock()
+	if c == c.client.myUploadsLocked() || c == c.client.favoritesLocked() {
+		c.client.mu.Unlock()
+		return fmt.Errorf("can not delete the %s: %w", c.name, types.ErrSpecialContainer)
+	}
+	if count := len(c.photos); count > 0 && !opts.Force {
+		c.client.mu.Unlock()
+		return &nixplay.NonEmptyContainerError{PhotoCount: int64(count)}
+	}
+	for i, other := range c.client.containers {
+		if other == c {
+			c.client.containers = append(c.client.containers[:i:i], c.client.containers[i+1:]...)
+			break
+		}
+	}
+	c.deleted = true
+	c.client.mu.Unlock()
+
+	c.client.notify(nixplay.ChangeEvent{Type: types.ContainerDeletedChangeType, Container: c})
+	return nil
+}
+
+func (c *FakeContainer) AddPhoto(ctx context.Context, name string, r io.Reader, opts nixplay.AddPhotoOptions) (nixplay.Photo, error) {
+	if err := c.call("Container.AddPhoto"); err != nil {
+		return nil, err
+	}
+	return c.addPhoto(name, r, opts)
+}
+
+func (c *FakeContainer) AddPhotoAsync(ctx context.Context, name string, r io.Reader, opts nixplay.AddPhotoOptions) (nixplay.UploadHandle, error) {
+	if err := c.call("Container.AddPhotoAsync"); err != nil {
+		return nil, err
+	}
+	p, err := c.addPhoto(name, r, opts)
+	if err != nil && !errors.Is(err, nixplay.ErrDuplicateImage) {
+		return nil, err
+	}
+	return &uploadHandle{photo: p, err: err}, nil
+}
+
+func (c *FakeContainer) addPhoto(name string, r io.Reader, opts nixplay.AddPhotoOptions) (nixplay.Photo, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if opts.FileSize != 0 && opts.FileSize != int64(len(content)) {
+		return nil, fmt.Errorf("file size %d does not match size of content %d", opts.FileSize, len(content))
+	}
+	mimeType := opts.MIMEType
+	if mimeType == "" {
+		mimeType = mime.TypeByFileName(name)
+	}
+	if !mime.IsSupported(mimeType) {
+		return nil, fmt.Errorf("unsupported MIME type %q", mimeType)
+	}
+	md5Hash := types.MD5Hash(md5.Sum(content))
+
+	c.client.mu.Lock()
+	if c.deleted {
+		c.client.mu.Unlock()
+		return nil, errors.New("container has been deleted")
+	}
+	var existing *FakePhoto
+	for _, p := range c.photos {
+		if p.md5Hash == md5Hash {
+			existing = p
+			break
+		}
+	}
+	if existing != nil && (c.containerType == types.AlbumContainerType || opts.SkipExisting) {
+		c.client.mu.Unlock()
+		if opts.SkipExisting {
+			return existing, nil
+		}
+		return nil, &nixplay.DuplicateImageError{Existing: existing}
+	}
+	p := c.addPhotoLocked(name, content)
+	if c.containerType == types.PlaylistContainerType {
+		c.addToMyUploadsLocked(name, content, md5Hash)
+	}
+	c.client.mu.Unlock()
+
+	c.client.notify(nixplay.ChangeEvent{Type: types.PhotoAddedChangeType, Container: c, Photo: p})
+	return p, nil
+}
+
+func (c *FakeContainer) AddSlideFromPhoto(ctx context.Context, p nixplay.Photo) (nixplay.Photo, error) {
+	if err := c.call("Container.AddSlideFromPhoto"); err != nil {
+		return nil, err
+	}
+	if c.containerType != types.PlaylistContainerType {
+		return nil, types.ErrInvalidContainerType
+	}
+	source, ok := p.(*FakePhoto)
+	if !ok || source.container.client != c.client {
+		return nil, errors.New("photo was not obtained from this client")
+	}
+
+	c.client.mu.Lock()
+	if c.deleted {
+		c.client.mu.Unlock()
+		return nil, errors.New("container has been deleted")
+	}
+	slide := c.addPhotoLocked(source.name, source.content)
+	c.client.mu.Unlock()
+
+	c.client.notify(nixplay.ChangeEvent{Type: types.PhotoAddedChangeType, Container: c, Photo: slide})
+	return slide, nil
+}
+
+func (c *FakeContainer) RemoveSlide(ctx context.Context, slide nixplay.Photo) error {
+	if err := c.call("Container.RemoveSlide"); err != nil {
+		return err
+	}
+	if c.containerType != types.PlaylistContainerType {
+		return types.ErrInvalidContainerType
+	}
+	p, ok := slide.(*FakePhoto)
+	if !ok || p.container != c {
+		return types.ErrNotFound
+	}
+	return p.Delete(ctx, nixplay.DeleteOptions{Scope: types.ContainerOnlyDeleteScope})
+}
+
+// addToMyUploadsLocked adds a photo uploaded to a playlist to the "My
+// Uploads" album if it exists and does not already contain the photo.
+func (c *FakeContainer) addToMyUploadsLocked(name string, content []byte, md5Hash types.MD5Hash) {
+	album := c.client.myUploadsLocked()
+	if album == nil {
+		return
+	}
+	for _, p := range album.photos {
+		if p.md5Hash == md5Hash {
+			return
+		}
+	}
+	album.addPhotoLocked(name, content)
+}
+
+// ResetCache does nothing since the fake does not cache anything.
+func (c *FakeContainer) ResetCache() {}
+
+// Refresh does nothing since the fake does not cache anything.
+func (c *FakeContainer) Refresh(ctx context.Context) error {
+	return c.call("Container.Refresh")
+}
+
+// RawMetadata returns a JSON object with the fields Nixplay uses to describe
+// the name and photo count of albums and playlists.
+func (c *FakeContainer) RawMetadata(ctx context.Context) (json.RawMessage, error) {
+	if err := c.call("Container.RawMetadata"); err != nil {
+		return nil, err
+	}
+	c.client.mu.Lock()
+	defer c.client.mu.Unlock()
+	if c.containerType == types.PlaylistContainerType {
+		return json.Marshal(map[string]any{"name": c.name, "picture_count": len(c.photos)})
+	}
+	return json.Marshal(map[string]any{"title": c.name, "photo_count": len(c.photos)})
+}
+
+func (c *FakeContainer) photoUniqueNameLocked(p *FakePhoto) string {
+	for _, other := range c.photos {
+		if other != p && other.name == p.name {
+			ext := filepath.Ext(p.name)
+			return p.name[:len(p.name)-len(ext)] + "{" + base64.URLEncoding.EncodeToString(p.id[:]) + "}" + ext
+		}
+	}
+	return p.name
+}
+
+// FakePhoto is an in-memory implementation of nixplay.Photo. See FakeClient.
+type FakePhoto struct {
+	container *FakeContainer
+	id        types.ID
+	name      string
+	content   []byte
+	md5Hash   types.MD5Hash
+}
+
+var _ = (nixplay.Photo)((*FakePhoto)(nil))
+
+func (p *FakePhoto) call(method string) error {
+	return p.container.client.call(Call{Method: method, Container: p.container, Photo: p})
+}
+
+// Content returns the content of the photo.
+func (p *FakePhoto) Content() []byte {
+	return p.content
+}
+
+func (p *FakePhoto) ID() types.ID {
+	return p.id
+}
+
+func (p *FakePhoto) Name(ctx context.Context) (string, error) {
+	if err := p.call("Photo.Name"); err != nil {
+		return "", err
+	}
+	return p.name, nil
+}
+
+func (p *FakePhoto) NameUnique(ctx context.Context) (string, error) {
+	if err := p.call("Photo.NameUnique"); err != nil {
+		return "", err
+	}
+	p.container.client.mu.Lock()
+	defer p.container.client.mu.Unlock()
+	return p.container.photoUniqueNameLocked(p), nil
+}
+
+func (p *FakePhoto) Size(ctx context.Context) (int64, error) {
+	if err := p.call("Photo.Size"); err != nil {
+		return 0, err
+	}
+	return int64(len(p.content)), nil
+}
+
+func (p *FakePhoto) MD5Hash(ctx context.Context) (types.MD5Hash, error) {
+	if err := p.call("Photo.MD5Hash"); err != nil {
+		return types.MD5Hash{}, err
+	}
+	return p.md5Hash, nil
+}
+
+// Hash computes the hash from the content of the photo using the built in hash
+// types of nixplay.HashPolicy.
+func (p *FakePhoto) Hash(ctx context.Context, hashType types.HashType) (string, error) {
+	if err := p.call("Photo.Hash"); err != nil {
+		return "", err
+	}
+	h, err := nixplay.HashPolicy{}.New(hashType)
+	if err != nil {
+		return "", err
+	}
+	h.Write(p.content)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (p *FakePhoto) URL(ctx context.Context) (string, error) {
+	if err := p.call("Photo.URL"); err != nil {
+		return "", err
+	}
+	return "https://nixplay.invalid/photos/" + p.md5Hash.String(), nil
+}
+
+func (p *FakePhoto) MediaType(ctx context.Context) (types.MediaType, error) {
+	if err := p.call("Photo.MediaType"); err != nil {
+		return "", err
+	}
+	if mime.IsVideo(mime.TypeByFileName(p.name)) {
+		return types.VideoMediaType, nil
+	}
+	return types.PhotoMediaType, nil
+}
+
+// Duration always returns 0 since the fake does not know the length of
+// videos.
+func (p *FakePhoto) Duration(ctx context.Context) (time.Duration, error) {
+	if err := p.call("Photo.Duration"); err != nil {
+		return 0, err
+	}
+	return 0, nil
+}
+
+func (p *FakePhoto) Open(ctx context.Context) (io.ReadCloser, error) {
+	if err := p.call("Photo.Open"); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(p.content)), nil
+}
+
+func (p *FakePhoto) OpenRange(ctx context.Context, offset int64, length int64) (io.ReadCloser, error) {
+	if err := p.call("Photo.OpenRange"); err != nil {
+		return nil, err
+	}
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid offset %d", offset)
+	}
+	content := p.content
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+	content = content[offset:]
+	if length >= 0 && length < int64(len(content)) {
+		content = content[:length]
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (p *FakePhoto) DownloadTo(ctx context.Context, w io.Writer, opts nixplay.DownloadOptions) error {
+	if err := p.call("Photo.DownloadTo"); err != nil {
+		return err
+	}
+	n, err := w.Write(p.content)
+	if opts.Progress != nil {
+		opts.Progress(int64(n), int64(len(p.content)))
+	}
+	return err
+}
+
+// Delete deletes the photo. With types.GlobalDeleteScope every photo with the
+// same content is deleted from every container, since the fake does not track
+// which album the photos in a playlist refer to.
+func (p *FakePhoto) Delete(ctx context.Context, opts nixplay.DeleteOptions) error {
+	if err := p.call("Photo.Delete"); err != nil {
+		return err
+	}
+	c := p.container
+	switch opts.Scope {
+	case types.DefaultDeleteScope, types.GlobalDeleteScope:
+	case types.ContainerOnlyDeleteScope:
+		if c.containerType != types.PlaylistContainerType {
+			return &nixplay.UnsupportedDeleteScopeError{Scope: opts.Scope, ContainerType: c.containerType}
+		}
+	default:
+		return &nixplay.UnsupportedDeleteScopeError{Scope: opts.Scope, ContainerType: c.containerType}
+	}
+
+	var events []nixplay.ChangeEvent
+	c.client.mu.Lock()
+	for _, container := range c.client.containers {
+		photos := container.photos[:0:0]
+		for _, other := range container.photos {
+			deleted := other == p || (opts.Scope == types.GlobalDeleteScope && other.md5Hash == p.md5Hash)
+			if deleted {
+				events = append(events, nixplay.ChangeEvent{Type: types.PhotoDeletedChangeType, Container: container, Photo: other})
+			} else {
+				photos = append(photos, other)
+			}
+		}
+		container.photos = photos
+	}
+	c.client.mu.Unlock()
+
+	for _, event := range events {
+		c.client.notify(event)
+	}
+	return nil
+}
+
+// RawMetadata returns a JSON object with the fields Nixplay uses to describe
+// the name, MD5 hash and size of photos.
+func (p *FakePhoto) RawMetadata(ctx context.Context) (json.RawMessage, error) {
+	if err := p.call("Photo.RawMetadata"); err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[string]any{"filename": p.name, "md5": p.md5Hash, "size": len(p.content)})
+}
+
+func (p *FakePhoto) Refresh(ctx context.Context) error {
+	if err := p.call("Photo.Refresh"); err != nil {
+		return err
+	}
+	c := p.container
+	c.client.mu.Lock()
+	defer c.client.mu.Unlock()
+	for _, other := range c.photos {
+		if other == p {
+			return nil
+		}
+	}
+	return types.ErrNotFound
+}
+
+func (p *FakePhoto) Exists(ctx context.Context) (bool, error) {
+	if err := p.call("Photo.Exists"); err != nil {
+		return false, err
+	}
+	c := p.container
+	c.client.mu.Lock()
+	defer c.client.mu.Unlock()
+	for _, other := range c.photos {
+		if other == p {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// uploadHandle is the nixplay.UploadHandle returned by
+// FakeContainer.AddPhotoAsync. The fake processes uploads immediately so the
+// upload is always complete.
+type uploadHandle struct {
+	photo nixplay.Photo
+	err   error
+}
+
+func (h *uploadHandle) Wait(ctx context.Context) (nixplay.Photo, error) {
+	return h.photo, h.err
+}
+
+func (h *uploadHandle) Status() types.UploadStatus {
+	if h.err != nil {
+		return types.UploadFailedStatus
+	}
+	return types.UploadCompleteStatus
+}
+
+// Cancel does nothing since the upload is always complete.
+func (h *uploadHandle) Cancel() {}
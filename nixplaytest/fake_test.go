@@ -0,0 +1,123 @@
+package nixplaytest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClient(t *testing.T) {
+	ctx := context.Background()
+	client := NewFakeClient()
+	myUploads := client.AddContainer(types.AlbumContainerType, MyUploadsAlbumName)
+
+	var events []types.ChangeType
+	client.AddChangeListener(func(event nixplay.ChangeEvent) {
+		events = append(events, event.Type)
+	})
+
+	album, err := client.CreateContainer(ctx, types.AlbumContainerType, "album")
+	require.NoError(t, err)
+	playlist, err := client.CreateContainer(ctx, types.PlaylistContainerType, "album")
+	require.NoError(t, err)
+
+	albums, err := client.Containers(ctx, types.AlbumContainerType)
+	require.NoError(t, err)
+	assert.Equal(t, []nixplay.Container{myUploads, album}, albums)
+
+	// Containers of different types may have the same name without needing a
+	// unique name.
+	name, err := playlist.NameUnique(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "album", name)
+
+	p, err := album.AddPhoto(ctx, "photo.jpg", bytes.NewReader([]byte("content")), nixplay.AddPhotoOptions{})
+	require.NoError(t, err)
+	got, err := album.PhotoWithID(ctx, p.ID())
+	require.NoError(t, err)
+	assert.Equal(t, p, got)
+
+	var downloaded bytes.Buffer
+	require.NoError(t, p.DownloadTo(ctx, &downloaded, nixplay.DownloadOptions{VerifyMD5: true}))
+	assert.Equal(t, "content", downloaded.String())
+
+	r, err := p.OpenRange(ctx, 2, 3)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "nte", string(data))
+
+	// Albums do not allow duplicate content.
+	_, err = album.AddPhoto(ctx, "copy.jpg", bytes.NewReader([]byte("content")), nixplay.AddPhotoOptions{})
+	var dupErr *nixplay.DuplicateImageError
+	require.ErrorAs(t, err, &dupErr)
+	assert.Equal(t, p, dupErr.Existing)
+
+	// Playlists do, and photos uploaded to them are added to My Uploads.
+	for i := 0; i < 2; i++ {
+		_, err = playlist.AddPhoto(ctx, "photo.jpg", bytes.NewReader([]byte("content")), nixplay.AddPhotoOptions{})
+		require.NoError(t, err)
+	}
+	photos, err := playlist.Photos(ctx)
+	require.NoError(t, err)
+	require.Len(t, photos, 2)
+	assert.Equal(t, photos[0].ID(), photos[1].ID())
+	count, err := myUploads.PhotoCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	unique, err := photos[0].NameUnique(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, "photo.jpg", unique)
+	assert.Regexp(t, `^photo\{.*\}\.jpg$`, unique)
+
+	require.NoError(t, photos[0].Delete(ctx, nixplay.DeleteOptions{}))
+	require.NoError(t, album.Delete(ctx, nixplay.DeleteOptions{Force: true}))
+	albums, err = client.Containers(ctx, types.AlbumContainerType)
+	require.NoError(t, err)
+	assert.Equal(t, []nixplay.Container{myUploads}, albums)
+
+	assert.Equal(t, []types.ChangeType{
+		types.ContainerCreatedChangeType,
+		types.ContainerCreatedChangeType,
+		types.PhotoAddedChangeType,
+		types.PhotoAddedChangeType,
+		types.PhotoAddedChangeType,
+		types.PhotoDeletedChangeType,
+		types.ContainerDeletedChangeType,
+	}, events)
+}
+
+func TestFakeClient_OnCall(t *testing.T) {
+	ctx := context.Background()
+	client := NewFakeClient()
+	album := client.AddContainer(types.AlbumContainerType, "album")
+	p := album.AddPhotoContent("photo.jpg", []byte("content"))
+
+	expErr := errors.New("injected")
+	var calls []Call
+	client.OnCall = func(call Call) error {
+		calls = append(calls, call)
+		if call.Method == "Photo.Delete" {
+			return expErr
+		}
+		return nil
+	}
+
+	assert.ErrorIs(t, p.Delete(ctx, nixplay.DeleteOptions{}), expErr)
+	count, err := album.PhotoCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	assert.Equal(t, []Call{
+		{Method: "Photo.Delete", Container: album, Photo: p},
+		{Method: "Container.PhotoCount", Container: album},
+	}, calls)
+}
@@ -0,0 +1,111 @@
+package nixsync
+
+import (
+	"context"
+	"fmt"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/internal/errorx"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// MirrorAlbumToPlaylist makes the playlist contain exactly one copy of each
+// photo in the album. Photos in the playlist that are not in the album, and
+// additional copies of photos that are in the playlist more than once, are
+// removed from the playlist. Photos are matched by their MD5 hash.
+//
+// Photos missing from the playlist are added in the order that they are listed
+// in the album. They are not sorted by date since the photo data returned by
+// Nixplay does not include one. They are linked into the playlist with
+// Container.AddSlideFromPhoto, so nothing is downloaded or uploaded again.
+//
+// If an error occurs part way through then the Result describes the actions
+// that were completed before the error.
+func MirrorAlbumToPlaylist(ctx context.Context, album nixplay.Container, playlist nixplay.Container) (retResult Result, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	if album.ContainerType() != types.AlbumContainerType {
+		return Result{}, fmt.Errorf("%w: source must be an album", types.ErrInvalidContainerType)
+	}
+	if playlist.ContainerType() != types.PlaylistContainerType {
+		return Result{}, fmt.Errorf("%w: destination must be a playlist", types.ErrInvalidContainerType)
+	}
+
+	albumPhotos, err := album.Photos(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	inAlbum := make(map[types.MD5Hash]bool, len(albumPhotos))
+	for _, p := range albumPhotos {
+		md5Hash, err := p.MD5Hash(ctx)
+		if err != nil {
+			return Result{}, err
+		}
+		inAlbum[md5Hash] = true
+	}
+
+	playlistPhotos, err := playlist.Photos(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+	inPlaylist := make(map[types.MD5Hash]bool, len(playlistPhotos))
+	var toDelete []nixplay.Photo
+	for _, p := range playlistPhotos {
+		md5Hash, err := p.MD5Hash(ctx)
+		if err != nil {
+			return Result{}, err
+		}
+		if !inAlbum[md5Hash] || inPlaylist[md5Hash] {
+			toDelete = append(toDelete, p)
+			continue
+		}
+		inPlaylist[md5Hash] = true
+	}
+
+	var result Result
+	for _, p := range toDelete {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		action, err := photoAction(ctx, DeleteAction, p)
+		if err != nil {
+			return result, err
+		}
+		if err := p.Delete(ctx, nixplay.DeleteOptions{}); err != nil {
+			return result, err
+		}
+		result.Actions = append(result.Actions, action)
+	}
+
+	for _, p := range albumPhotos {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		action, err := photoAction(ctx, LinkAction, p)
+		if err != nil {
+			return result, err
+		}
+		if inPlaylist[action.MD5Hash] {
+			result.Unchanged++
+			continue
+		}
+		if _, err := playlist.AddSlideFromPhoto(ctx, p); err != nil {
+			return result, err
+		}
+		result.Actions = append(result.Actions, action)
+	}
+
+	return result, nil
+}
+
+func photoAction(ctx context.Context, actionType ActionType, p nixplay.Photo) (Action, error) {
+	name, err := p.Name(ctx)
+	if err != nil {
+		return Action{}, err
+	}
+	md5Hash, err := p.MD5Hash(ctx)
+	if err != nil {
+		return Action{}, err
+	}
+	return Action{Type: actionType, Name: name, MD5Hash: md5Hash}, nil
+}
@@ -0,0 +1,55 @@
+package nixsync
+
+import (
+	"context"
+	"crypto/md5"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirrorAlbumToPlaylist(t *testing.T) {
+	ctx := context.Background()
+
+	album := &fakeContainer{containerType: types.AlbumContainerType}
+	album.photos = []*fakePhoto{
+		newFakePhoto(album, "a.jpg", "a"),
+		newFakePhoto(album, "b.jpg", "b"),
+		newFakePhoto(album, "c.jpg", "c"),
+	}
+	playlist := &fakeContainer{containerType: types.PlaylistContainerType}
+	playlist.photos = []*fakePhoto{
+		newFakePhoto(playlist, "b.jpg", "b"),
+		newFakePhoto(playlist, "other.jpg", "other"),
+		newFakePhoto(playlist, "b copy.jpg", "b"),
+	}
+
+	result, err := MirrorAlbumToPlaylist(ctx, album, playlist)
+	require.NoError(t, err)
+	assert.Equal(t, []Action{
+		{Type: DeleteAction, Name: "other.jpg", MD5Hash: md5.Sum([]byte("other"))},
+		{Type: DeleteAction, Name: "b copy.jpg", MD5Hash: md5.Sum([]byte("b"))},
+		{Type: LinkAction, Name: "a.jpg", MD5Hash: md5.Sum([]byte("a"))},
+		{Type: LinkAction, Name: "c.jpg", MD5Hash: md5.Sum([]byte("c"))},
+	}, result.Actions)
+	assert.Equal(t, 1, result.Unchanged)
+
+	// The photos are linked into the playlist, not uploaded again.
+	assert.Zero(t, playlist.uploads)
+
+	var names []string
+	for _, p := range playlist.photos {
+		names = append(names, p.name)
+	}
+	assert.Equal(t, []string{"b.jpg", "a.jpg", "c.jpg"}, names)
+
+	result, err = MirrorAlbumToPlaylist(ctx, album, playlist)
+	require.NoError(t, err)
+	assert.Empty(t, result.Actions)
+	assert.Equal(t, 3, result.Unchanged)
+
+	_, err = MirrorAlbumToPlaylist(ctx, playlist, album)
+	assert.ErrorIs(t, err, types.ErrInvalidContainerType)
+}
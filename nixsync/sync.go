@@ -0,0 +1,259 @@
+// Package nixsync provides ways to make the photos in a Nixplay album or playlist
+// match the photos in a local directory or in another album.
+package nixsync
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/internal/errorx"
+	"github.com/anitschke/go-nixplay/internal/mime"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// ActionType is the enum that describes an action taken to sync a container.
+type ActionType string
+
+const (
+	// UploadAction means a local file was uploaded to the container.
+	UploadAction = ActionType("upload")
+
+	// DeleteAction means a photo was deleted from the container because there
+	// is no local file with the same content.
+	DeleteAction = ActionType("delete")
+
+	// LinkAction means a photo that is already stored in Nixplay was added to
+	// the container without uploading it again.
+	LinkAction = ActionType("link")
+)
+
+// Plan describes how to sync a local directory to a container.
+type Plan struct {
+	// LocalDir is the directory that contains the photos to sync. Only the
+	// files directly in LocalDir with a photo or video file extension
+	// supported by Nixplay are synced, subdirectories are ignored.
+	LocalDir string
+
+	// ContainerType is the type of container to sync to.
+	ContainerType types.ContainerType
+
+	// Container is the unique name of the container to sync to, as returned
+	// by Container.NameUnique. If the container does not exist it is created.
+	Container string
+
+	// DeleteExtraneous specifies that photos in the container that do not
+	// have the same content as any of the local files should be deleted.
+	DeleteExtraneous bool
+
+	// DryRun specifies that the actions needed to sync the container should
+	// be computed and returned without making any changes.
+	DryRun bool
+
+	// OnAction is an optional function that is called after each action is
+	// taken, or would be taken for a dry run, so that progress can be
+	// reported. done is the number of actions that have been completed,
+	// including action, out of total.
+	OnAction func(action Action, done int, total int)
+}
+
+// Action is a single change made, or that would be made for a dry run, to
+// sync the container.
+type Action struct {
+	Type ActionType
+
+	// Name of the photo that was uploaded or deleted.
+	Name string
+
+	// MD5Hash of the content of the photo.
+	MD5Hash types.MD5Hash
+}
+
+// Result describes the outcome of syncing a container.
+type Result struct {
+	// Actions that were taken, or would be taken for a dry run.
+	Actions []Action
+
+	// Unchanged is the number of local files that already had a photo with the
+	// same content in the container.
+	Unchanged int
+}
+
+type localFile struct {
+	name    string
+	path    string
+	md5Hash types.MD5Hash
+}
+
+// Run syncs the photos in the local directory to the container described by
+// the plan. Local files and photos in the container are matched by the MD5
+// hash of their content, a local file is uploaded if the container does not
+// have a photo with the same content.
+//
+// If an error occurs part way through the sync then the Result describes the
+// actions that were completed before the error.
+func Run(ctx context.Context, client nixplay.Client, plan Plan) (retResult Result, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	local, err := hashLocalDir(plan.LocalDir)
+	if err != nil {
+		return Result{}, err
+	}
+
+	container, err := client.ContainerWithUniqueName(ctx, plan.ContainerType, plan.Container)
+	if err != nil {
+		return Result{}, err
+	}
+
+	remote := make(map[types.MD5Hash][]nixplay.Photo)
+	if container != nil {
+		photos, err := container.Photos(ctx)
+		if err != nil {
+			return Result{}, err
+		}
+		for _, p := range photos {
+			md5Hash, err := p.MD5Hash(ctx)
+			if err != nil {
+				return Result{}, err
+			}
+			remote[md5Hash] = append(remote[md5Hash], p)
+		}
+	}
+
+	var result Result
+	localHashes := make(map[types.MD5Hash]struct{}, len(local))
+	var toUpload []localFile
+	for _, f := range local {
+		if _, seen := localHashes[f.md5Hash]; seen {
+			// Nixplay does not allow the same content to be uploaded to an
+			// album more than once so only the first copy is synced.
+			continue
+		}
+		localHashes[f.md5Hash] = struct{}{}
+		if _, ok := remote[f.md5Hash]; ok {
+			result.Unchanged++
+			continue
+		}
+		toUpload = append(toUpload, f)
+	}
+
+	var toDelete []nixplay.Photo
+	if plan.DeleteExtraneous {
+		var extraneous []types.MD5Hash
+		for md5Hash := range remote {
+			if _, ok := localHashes[md5Hash]; !ok {
+				extraneous = append(extraneous, md5Hash)
+			}
+		}
+		sort.Slice(extraneous, func(i, j int) bool {
+			return bytes.Compare(extraneous[i][:], extraneous[j][:]) < 0
+		})
+		for _, md5Hash := range extraneous {
+			toDelete = append(toDelete, remote[md5Hash]...)
+		}
+	}
+
+	if container == nil && !plan.DryRun && len(toUpload) > 0 {
+		container, err = client.CreateContainer(ctx, plan.ContainerType, plan.Container)
+		if err != nil {
+			return result, err
+		}
+	}
+
+	total := len(toUpload) + len(toDelete)
+	addAction := func(action Action) {
+		result.Actions = append(result.Actions, action)
+		if plan.OnAction != nil {
+			plan.OnAction(action, len(result.Actions), total)
+		}
+	}
+
+	for _, f := range toUpload {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if !plan.DryRun {
+			if err := uploadFile(ctx, container, f); err != nil {
+				return result, err
+			}
+		}
+		addAction(Action{Type: UploadAction, Name: f.name, MD5Hash: f.md5Hash})
+	}
+
+	for _, p := range toDelete {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		name, err := p.Name(ctx)
+		if err != nil {
+			return result, err
+		}
+		md5Hash, err := p.MD5Hash(ctx)
+		if err != nil {
+			return result, err
+		}
+		if !plan.DryRun {
+			if err := p.Delete(ctx, nixplay.DeleteOptions{}); err != nil {
+				return result, err
+			}
+		}
+		addAction(Action{Type: DeleteAction, Name: name, MD5Hash: md5Hash})
+	}
+
+	return result, nil
+}
+
+// hashLocalDir returns the files in dir that can be uploaded to Nixplay along
+// with the MD5 hash of their content, sorted by name.
+func hashLocalDir(dir string) ([]localFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []localFile
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !mime.IsSupported(mime.TypeByFileName(e.Name())) {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		md5Hash, err := hashFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, localFile{name: e.Name(), path: path, md5Hash: md5Hash})
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+	return files, nil
+}
+
+func hashFile(path string) (types.MD5Hash, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return types.MD5Hash{}, err
+	}
+	defer f.Close()
+
+	hasher := md5.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return types.MD5Hash{}, err
+	}
+	return *(*types.MD5Hash)(hasher.Sum(nil)), nil
+}
+
+func uploadFile(ctx context.Context, container nixplay.Container, f localFile) error {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = container.AddPhoto(ctx, f.name, file, nixplay.AddPhotoOptions{})
+	return err
+}
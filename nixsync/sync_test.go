@@ -0,0 +1,205 @@
+package nixsync
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient, fakeContainer and fakePhoto implement just enough of the nixplay
+// interfaces to test this package. Calling any other method panics.
+type fakeClient struct {
+	nixplay.Client
+	containers map[string]*fakeContainer
+}
+
+func (c *fakeClient) ContainerWithUniqueName(ctx context.Context, containerType types.ContainerType, name string) (nixplay.Container, error) {
+	if container, ok := c.containers[name]; ok {
+		return container, nil
+	}
+	return nil, nil
+}
+
+func (c *fakeClient) CreateContainer(ctx context.Context, containerType types.ContainerType, name string) (nixplay.Container, error) {
+	container := &fakeContainer{}
+	c.containers[name] = container
+	return container, nil
+}
+
+type fakeContainer struct {
+	nixplay.Container
+	containerType types.ContainerType
+	photos        []*fakePhoto
+	uploads       int
+}
+
+func (c *fakeContainer) ContainerType() types.ContainerType {
+	return c.containerType
+}
+
+func (c *fakeContainer) Photos(ctx context.Context) ([]nixplay.Photo, error) {
+	photos := make([]nixplay.Photo, 0, len(c.photos))
+	for _, p := range c.photos {
+		photos = append(photos, p)
+	}
+	return photos, nil
+}
+
+func (c *fakeContainer) AddPhoto(ctx context.Context, name string, r io.Reader, opts nixplay.AddPhotoOptions) (nixplay.Photo, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	p := &fakePhoto{container: c, name: name, content: data, md5Hash: md5.Sum(data)}
+	c.photos = append(c.photos, p)
+	c.uploads++
+	return p, nil
+}
+
+func (c *fakeContainer) AddSlideFromPhoto(ctx context.Context, p nixplay.Photo) (nixplay.Photo, error) {
+	source := p.(*fakePhoto)
+	slide := &fakePhoto{container: c, name: source.name, content: source.content, md5Hash: source.md5Hash}
+	c.photos = append(c.photos, slide)
+	return slide, nil
+}
+
+type fakePhoto struct {
+	nixplay.Photo
+	container *fakeContainer
+	name      string
+	content   []byte
+	md5Hash   types.MD5Hash
+}
+
+func newFakePhoto(container *fakeContainer, name string, content string) *fakePhoto {
+	return &fakePhoto{container: container, name: name, content: []byte(content), md5Hash: md5.Sum([]byte(content))}
+}
+
+func (p *fakePhoto) Size(ctx context.Context) (int64, error) {
+	return int64(len(p.content)), nil
+}
+
+func (p *fakePhoto) Open(ctx context.Context) (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(p.content)), nil
+}
+
+func (p *fakePhoto) Name(ctx context.Context) (string, error) {
+	return p.name, nil
+}
+
+func (p *fakePhoto) MD5Hash(ctx context.Context) (types.MD5Hash, error) {
+	return p.md5Hash, nil
+}
+
+func (p *fakePhoto) Delete(ctx context.Context, opts nixplay.DeleteOptions) error {
+	for i, other := range p.container.photos {
+		if other == p {
+			p.container.photos = append(p.container.photos[:i], p.container.photos[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func writeFile(t *testing.T, dir string, name string, content string) {
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+}
+
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "a.jpg", "a")
+	writeFile(t, dir, "b.jpg", "b")
+	writeFile(t, dir, "notes.txt", "not a photo")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "subdir"), 0o700))
+
+	existing := &fakeContainer{}
+	existing.photos = []*fakePhoto{
+		{container: existing, name: "renamed-a.jpg", md5Hash: md5.Sum([]byte("a"))},
+		{container: existing, name: "old.jpg", md5Hash: md5.Sum([]byte("old"))},
+	}
+	client := &fakeClient{containers: map[string]*fakeContainer{"album": existing}}
+
+	plan := Plan{
+		LocalDir:         dir,
+		ContainerType:    types.AlbumContainerType,
+		Container:        "album",
+		DeleteExtraneous: true,
+	}
+	expActions := []Action{
+		{Type: UploadAction, Name: "b.jpg", MD5Hash: md5.Sum([]byte("b"))},
+		{Type: DeleteAction, Name: "old.jpg", MD5Hash: md5.Sum([]byte("old"))},
+	}
+
+	t.Run("DryRun", func(t *testing.T) {
+		dryPlan := plan
+		dryPlan.DryRun = true
+		result, err := Run(ctx, client, dryPlan)
+		require.NoError(t, err)
+		assert.Equal(t, expActions, result.Actions)
+		assert.Equal(t, 1, result.Unchanged)
+		assert.Len(t, existing.photos, 2)
+	})
+
+	t.Run("Sync", func(t *testing.T) {
+		var progress []int
+		progressPlan := plan
+		progressPlan.OnAction = func(action Action, done int, total int) {
+			assert.Equal(t, 2, total)
+			progress = append(progress, done)
+		}
+		result, err := Run(ctx, client, progressPlan)
+		require.NoError(t, err)
+		assert.Equal(t, expActions, result.Actions)
+		assert.Equal(t, []int{1, 2}, progress)
+		assert.Equal(t, 1, result.Unchanged)
+
+		var names []string
+		for _, p := range existing.photos {
+			names = append(names, p.name)
+		}
+		assert.Equal(t, []string{"renamed-a.jpg", "b.jpg"}, names)
+
+		// Syncing again does nothing.
+		result, err = Run(ctx, client, plan)
+		require.NoError(t, err)
+		assert.Empty(t, result.Actions)
+		assert.Equal(t, 2, result.Unchanged)
+	})
+
+	t.Run("CreateContainer", func(t *testing.T) {
+		newPlan := plan
+		newPlan.Container = "new album"
+		result, err := Run(ctx, client, newPlan)
+		require.NoError(t, err)
+		assert.Len(t, result.Actions, 2)
+		require.Contains(t, client.containers, "new album")
+		assert.Len(t, client.containers["new album"].photos, 2)
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		canceledPlan := plan
+		canceledPlan.Container = "canceled album"
+		canceledPlan.OnAction = func(action Action, done int, total int) {
+			cancel()
+		}
+		result, err := Run(ctx, client, canceledPlan)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Len(t, result.Actions, 1)
+		require.Contains(t, client.containers, "canceled album")
+		assert.Len(t, client.containers["canceled album"].photos, 1)
+	})
+}
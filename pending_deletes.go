@@ -0,0 +1,105 @@
+package nixplay
+
+import (
+	"context"
+	"sync"
+
+	"github.com/anitschke/go-nixplay/internal/errorx"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// PendingDeletes is a queue of photos that are going to be deleted. Photos are
+// only deleted from Nixplay once Commit is called, until then they can be
+// reviewed and removed from the queue. This allows tools that delete photos in
+// bulk to confirm the deletions before they are made, since Nixplay does not
+// provide a way to restore deleted photos.
+//
+// A PendingDeletes is safe for concurrent use. The zero value is an empty
+// queue ready to use.
+type PendingDeletes struct {
+	mu     sync.Mutex
+	photos []Photo
+}
+
+// Add adds p to the queue. Adding a photo that is already in the queue has no
+// effect.
+func (d *PendingDeletes) Add(p Photo) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.indexUnsafe(p.ID()) >= 0 {
+		return
+	}
+	d.photos = append(d.photos, p)
+}
+
+// Remove removes p from the queue so that it will not be deleted. It returns
+// false if p was not in the queue.
+func (d *PendingDeletes) Remove(p Photo) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	i := d.indexUnsafe(p.ID())
+	if i < 0 {
+		return false
+	}
+	d.photos = append(d.photos[:i:i], d.photos[i+1:]...)
+	return true
+}
+
+// Photos returns the photos in the queue in the order they were added.
+func (d *PendingDeletes) Photos() []Photo {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Photo(nil), d.photos...)
+}
+
+// Discard removes all photos from the queue without deleting them.
+func (d *PendingDeletes) Discard() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.photos = nil
+}
+
+// Commit deletes the photos in the queue in the order they were added. Photos
+// are removed from the queue as they are deleted. If deleting a photo fails
+// Commit carries on deleting the remaining photos, the photos that failed stay
+// in the queue so Commit can be called again and a *BulkError describing the
+// failures is returned along with the result.
+//
+// In dry-run mode, see WithDryRun, the deletes are validated and logged but
+// the queue is left unchanged. The photos that would have been deleted are
+// counted in BulkResult.DryRun.
+func (d *PendingDeletes) Commit(ctx context.Context) (retResult BulkResult, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	var result BulkResult
+	for _, p := range d.Photos() {
+		if err := ctx.Err(); err != nil {
+			result.add(p, err)
+			continue
+		}
+		err := p.Delete(ctx, DeleteOptions{})
+		if err == nil && isPhotoDryRun(ctx, p) {
+			result.DryRun++
+			continue
+		}
+		if err == nil {
+			d.Remove(p)
+		}
+		result.add(p, err)
+	}
+	return result, result.Err()
+}
+
+// indexUnsafe returns the index of the photo with the specified ID in the
+// queue or -1 if it is not in the queue. It assumes the mutex is already
+// locked.
+func (d *PendingDeletes) indexUnsafe(id types.ID) int {
+	for i, p := range d.photos {
+		if p.ID() == id {
+			return i
+		}
+	}
+	return -1
+}
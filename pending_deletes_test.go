@@ -0,0 +1,67 @@
+package nixplay_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/nixplaytest"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingDeletes(t *testing.T) {
+	ctx := context.Background()
+	client := nixplaytest.NewFakeClient()
+	album := client.AddContainer(types.AlbumContainerType, "album")
+	a := album.AddPhotoContent("a.jpg", []byte("a"))
+	b := album.AddPhotoContent("b.jpg", []byte("b"))
+	c := album.AddPhotoContent("c.jpg", []byte("c"))
+
+	var pending nixplay.PendingDeletes
+	pending.Add(a)
+	pending.Add(b)
+	pending.Add(a)
+	pending.Add(c)
+	assert.Equal(t, []nixplay.Photo{a, b, c}, pending.Photos())
+
+	// Nothing is deleted until the deletes are committed.
+	count, err := album.PhotoCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	assert.True(t, pending.Remove(a))
+	assert.False(t, pending.Remove(a))
+	assert.Equal(t, []nixplay.Photo{b, c}, pending.Photos())
+
+	// If a delete fails the photos that were not deleted stay pending.
+	deleteErr := errors.New("delete failed")
+	client.OnCall = func(call nixplaytest.Call) error {
+		if call.Method == "Photo.Delete" && call.Photo == c {
+			return deleteErr
+		}
+		return nil
+	}
+	result, err := pending.Commit(ctx)
+	assert.ErrorIs(t, err, deleteErr)
+	assert.Equal(t, 1, result.Succeeded)
+	require.Len(t, result.Failures, 1)
+	assert.Equal(t, c, result.Failures[0].Photo)
+	assert.Equal(t, []nixplay.Photo{c}, pending.Photos())
+
+	client.OnCall = nil
+	result, err = pending.Commit(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, nixplay.BulkResult{Succeeded: 1}, result)
+	assert.Empty(t, pending.Photos())
+
+	photos, err := album.Photos(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []nixplay.Photo{a}, photos)
+
+	pending.Add(a)
+	pending.Discard()
+	assert.Empty(t, pending.Photos())
+}
@@ -3,8 +3,9 @@ package nixplay
 import (
 	"bytes"
 	"context"
-	"crypto/sha256"
+	"crypto/md5"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -14,11 +15,12 @@ import (
 	"regexp"
 	"strconv"
 	"sync"
+	"time"
 
-	"github.com/anitschke/go-nixplay/encoding"
 	"github.com/anitschke/go-nixplay/httpx"
 	"github.com/anitschke/go-nixplay/internal/cache"
 	"github.com/anitschke/go-nixplay/internal/errorx"
+	"github.com/anitschke/go-nixplay/internal/mime"
 	"github.com/anitschke/go-nixplay/types"
 )
 
@@ -51,18 +53,52 @@ type photo struct {
 	container Container
 	client    httpx.Client
 
-	elementDeletedListener []cache.ElementDeletedListener
+	cache.DeletedListeners
 
-	// All of the following data may not be known when the photo object is
-	// initially created and as a result may need to be looked up and cached
-	// when needed. As a result all of this data must be guarded by a mutex
-	// because it may change over time.
-	mu                    sync.Mutex
+	// mu guards state, which may change over time. It is only held while
+	// reading or updating state and never while making requests, because
+	// looking up missing data may need to access other photos in the
+	// container, including this one.
+	mu    sync.Mutex
+	state photoState
+}
+
+// photoState is the data about a photo that may not be known when the photo
+// object is initially created and as a result may need to be looked up and
+// cached when needed.
+type photoState struct {
 	name                  string
 	nixplayID             uint64
 	nixplayPlaylistItemID string
 	size                  int64
 	url                   string
+
+	// duration is the length of a video, it is 0 until the video has been
+	// read to find it.
+	duration time.Duration
+
+	// raw is the JSON object Nixplay listed the photo with, or nil if the
+	// photo has not been listed, for example because it was just uploaded.
+	raw json.RawMessage
+
+	// hashes are the hex encoded hashes of the content other than MD5 that
+	// are known, see Photo.Hash. The map is replaced rather than modified so
+	// that it can be shared by snapshots.
+	hashes map[types.HashType]string
+}
+
+// snapshot returns a copy of the current state of the photo.
+func (p *photo) snapshot() photoState {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.state
+}
+
+// update modifies the state of the photo.
+func (p *photo) update(f func(s *photoState)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	f(&p.state)
 }
 
 func newPhoto(container Container, client httpx.Client, name string, md5Hash *types.MD5Hash, nixplayID uint64, nixplayPlaylistItemID string, size int64, url string) (retPhoto *photo, err error) {
@@ -72,7 +108,7 @@ func newPhoto(container Container, client httpx.Client, name string, md5Hash *ty
 	// just use the raw un-decoded string. This should be fine since we are safe
 	// to duplicate photos with the same name that could come about as a result
 	// of using the raw un-decoded string.
-	if decodedName, err := encoding.Decode(name); err == nil {
+	if decodedName, err := settingsOf(container).encoder().Decode(name); err == nil {
 		name = decodedName
 	}
 
@@ -124,25 +160,27 @@ func newPhoto(container Container, client httpx.Client, name string, md5Hash *ty
 	// So with all that being said we will hash the container id together with
 	// the MD5 hash of the photo and that should give us a unique
 	// enough ID with the exception of the above mentioned issue.
+	//
+	// Users that need to tell copies apart can opt in to
+	// types.PlaylistItemPhotoIdentity, in which case photos in playlists are
+	// identified by the ID Nixplay uses for the slide instead.
 
-	containerID := container.ID()
-	hasher := sha256.New()
-	hasher.Write(containerID[:]) // shouldn't ever error so we don't need to check for one
-	hasher.Write(md5Hash[:])
-	id := *(*types.ID)(hasher.Sum([]byte{}))
+	id := photoIDInContainer(container, *md5Hash, nixplayPlaylistItemID)
 
 	return &photo{
-		name:    name,
 		id:      id,
 		md5Hash: *md5Hash,
 
 		container: container,
 		client:    client,
 
-		nixplayID:             nixplayID,
-		nixplayPlaylistItemID: nixplayPlaylistItemID,
-		size:                  size,
-		url:                   url,
+		state: photoState{
+			name:                  name,
+			nixplayID:             nixplayID,
+			nixplayPlaylistItemID: nixplayPlaylistItemID,
+			size:                  size,
+			url:                   url,
+		},
 	}, nil
 }
 
@@ -170,19 +208,22 @@ func md5HashFromPhotoURL(photoURL string) (returnHash types.MD5Hash, err error)
 }
 
 func (p *photo) Name(ctx context.Context) (string, error) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	if p.name == "" {
-		if err := p.populatePhotoDataFromPictureEndpoint(ctx); err != nil {
-			return "", fmt.Errorf("failed to get image name: %w", err)
-		}
+	if name := p.snapshot().name; name != "" {
+		return name, nil
 	}
-	if p.name == "" {
+	if err := p.populatePhotoDataFromPictureEndpoint(ctx); err != nil {
+		return "", fmt.Errorf("failed to get image name: %w", err)
+	}
+	name := p.snapshot().name
+	if name == "" {
 		return "", errors.New("failed to determine photo name")
 	}
+	return name, nil
+}
 
-	return p.name, nil
+func (p *photo) KnownName() (string, bool) {
+	name := p.snapshot().name
+	return name, name != ""
 }
 
 func (p *photo) NameUnique(ctx context.Context) (string, error) {
@@ -240,168 +281,518 @@ func (p *photo) ID() types.ID {
 }
 
 func (p *photo) Size(ctx context.Context) (int64, error) {
-	if p.size == -1 {
-		err := p.populatePhotoDataFromHead(ctx)
-		if err != nil {
-			return 0, fmt.Errorf("failed to get image size: %w", err)
-		}
+	if size := p.snapshot().size; size != -1 {
+		return size, nil
+	}
+	if err := p.populatePhotoDataFromHead(ctx); err != nil {
+		return 0, fmt.Errorf("failed to get image size: %w", err)
 	}
-	if p.size == -1 {
+	size := p.snapshot().size
+	if size == -1 {
 		return 0, errors.New("unable to determine photo size")
 	}
-
-	return p.size, nil
+	return size, nil
 }
 
 func (p *photo) MD5Hash(ctx context.Context) (types.MD5Hash, error) {
 	return p.md5Hash, nil
 }
 
-func (p *photo) URL(ctx context.Context) (string, error) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
+func (p *photo) Hash(ctx context.Context, hashType types.HashType) (retHash string, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
 
-	if p.url == "" {
-		if err := p.populatePhotoDataFromListSearch(ctx); err != nil {
-			return "", fmt.Errorf("failed to get image url: %w", err)
-		}
+	if hashType == types.MD5HashType {
+		return p.md5Hash.String(), nil
+	}
+	if h, ok := p.snapshot().hashes[hashType]; ok {
+		return h, nil
+	}
+
+	policy := p.settings().hashes
+	if _, err := policy.New(hashType); err != nil {
+		return "", err
+	}
+	if policy.NoDownload {
+		return "", types.ErrHashUnavailable
+	}
+
+	// Since the whole photo has to be downloaded anyway compute every hash we
+	// know of so that later calls for other hash types are free.
+	hashes, err := newHashSet(policy, policy.types())
+	if err != nil {
+		return "", err
+	}
+	r, err := p.Open(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	if _, err := io.Copy(hashes, r); err != nil {
+		return "", err
+	}
+	if hashes.md5Hash() != p.md5Hash {
+		return "", types.ErrMD5Mismatch
 	}
-	if p.url == "" {
+
+	sums := hashes.sums()
+	p.setHashes(sums)
+	return sums[hashType], nil
+}
+
+func (p *photo) URL(ctx context.Context) (string, error) {
+	if url := p.snapshot().url; url != "" {
+		return url, nil
+	}
+	if err := p.populatePhotoDataFromListSearch(ctx); err != nil {
+		return "", fmt.Errorf("failed to get image url: %w", err)
+	}
+	url := p.snapshot().url
+	if url == "" {
 		return "", errors.New("unable to determine photo URL")
 	}
-	return p.url, nil
+	return url, nil
+}
+
+func (p *photo) MediaType(ctx context.Context) (types.MediaType, error) {
+	name, err := p.Name(ctx)
+	if err != nil {
+		return "", err
+	}
+	if mime.IsVideo(mime.TypeByFileName(name)) {
+		return types.VideoMediaType, nil
+	}
+	return types.PhotoMediaType, nil
+}
+
+func (p *photo) Duration(ctx context.Context) (time.Duration, error) {
+	mediaType, err := p.MediaType(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if mediaType != types.VideoMediaType {
+		return 0, nil
+	}
+
+	if duration := p.snapshot().duration; duration != 0 {
+		return duration, nil
+	}
+	size, err := p.Size(ctx)
+	if err != nil {
+		return 0, err
+	}
+	duration, err := mp4Duration(ctx, p.readRange, size)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get video duration: %w", err)
+	}
+	p.update(func(s *photoState) {
+		s.duration = duration
+	})
+	return duration, nil
+}
+
+func (p *photo) RawMetadata(ctx context.Context) (retRaw json.RawMessage, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	if raw := p.snapshot().raw; raw != nil {
+		return append(json.RawMessage(nil), raw...), nil
+	}
+	if err := p.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	raw := p.snapshot().raw
+	if raw == nil {
+		return nil, errors.New("unable to determine raw metadata of photo")
+	}
+	return append(json.RawMessage(nil), raw...), nil
 }
 
 func (p *photo) Open(ctx context.Context) (retReadCloser io.ReadCloser, err error) {
 	defer errorx.WrapWithFuncNameIfError(&err)
+	return p.openRange(ctx, 0, -1)
+}
+
+func (p *photo) OpenRange(ctx context.Context, offset int64, length int64) (retReadCloser io.ReadCloser, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	if offset < 0 {
+		return nil, fmt.Errorf("invalid offset %d", offset)
+	}
+	if length == 0 {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+	return p.openRange(ctx, offset, length)
+}
+
+// openRange opens the photo for reading starting at offset for length bytes.
+// If length is negative then the photo is read until the end.
+func (p *photo) openRange(ctx context.Context, offset int64, length int64) (retReadCloser io.ReadCloser, err error) {
+	ctx = p.withOperation(ctx, "OpenPhoto")
 
 	photoURL, err := p.URL(ctx)
 	if err != nil {
 		return nil, err
 	}
+
+	// The download timeout needs to cover reading the body, so rather than
+	// canceling the context when we return it is canceled when the caller
+	// closes the returned io.ReadCloser.
+	ctx, cancel := withTimeout(ctx, p.settings().timeouts.Download)
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, photoURL, http.NoBody)
 	if err != nil {
 		return nil, err
 	}
+	isRange := offset != 0 || length >= 0
+	if isRange {
+		// S3 honors range requests so we only need to download the portion of
+		// the photo that was asked for. See populatePhotoDataFromHead.
+		if length < 0 {
+			req.Header.Add("Range", fmt.Sprintf("bytes=%d-", offset))
+		} else {
+			req.Header.Add("Range", fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+		}
+	}
 
 	resp, err := p.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
 		defer resp.Body.Close()
 		defer io.Copy(io.Discard, resp.Body)
 
 		return nil, errors.New(resp.Status)
 	}
 
-	if p.size == -1 {
-		sizeStr := resp.Header.Get("Content-Length")
-		size, err := strconv.ParseInt(sizeStr, 10, 64)
-		if err != nil {
-			return nil, err
+	var body io.ReadCloser = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+
+	if resp.StatusCode == http.StatusPartialContent {
+		if p.snapshot().size == -1 {
+			contentRange := resp.Header.Get("Content-Range")
+			if matches := sizeFromContentRangeRegexp.FindStringSubmatch(contentRange); len(matches) == 2 {
+				if size, err := strconv.ParseInt(matches[1], 10, 64); err == nil {
+					p.setSize(size)
+				}
+			}
+		}
+	} else {
+		if p.snapshot().size == -1 {
+			sizeStr := resp.Header.Get("Content-Length")
+			size, err := strconv.ParseInt(sizeStr, 10, 64)
+			if err != nil {
+				body.Close()
+				return nil, err
+			}
+			p.setSize(size)
+		}
+
+		// If we asked for a range but the server sent back the full photo
+		// then skip over the part we don't want and stop reading once we have
+		// the requested length.
+		if isRange {
+			if _, err := io.CopyN(io.Discard, body, offset); err != nil {
+				body.Close()
+				return nil, err
+			}
+			if length >= 0 {
+				body = &limitedReadCloser{Reader: io.LimitReader(body, length), Closer: body}
+			}
 		}
-		p.size = size
 	}
 
-	return resp.Body, nil
+	return &downloadCounter{ReadCloser: body, metrics: p.settings().metrics}, nil
 }
 
-func (p *photo) Delete(ctx context.Context) (err error) {
+// limitedReadCloser combines a separate io.Reader and io.Closer into a single
+// io.ReadCloser.
+type limitedReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func (p *photo) DownloadTo(ctx context.Context, w io.Writer, opts DownloadOptions) (err error) {
 	defer errorx.WrapWithFuncNameIfError(&err)
 
-	req, err := p.deleteRequest(ctx)
+	r, err := p.Open(ctx)
 	if err != nil {
 		return err
 	}
+	defer r.Close()
 
-	resp, err := p.client.Do(req)
+	hasher := md5.New()
+	if opts.VerifyMD5 {
+		w = io.MultiWriter(w, hasher)
+	}
+	if opts.Progress != nil {
+		total, err := p.Size(ctx)
+		if err != nil {
+			return err
+		}
+		w = &progressWriter{w: w, total: total, progress: opts.Progress}
+	}
+
+	if _, err := io.Copy(w, r); err != nil {
+		return err
+	}
+
+	if opts.VerifyMD5 {
+		expHash, err := p.MD5Hash(ctx)
+		if err != nil {
+			return err
+		}
+		if *(*types.MD5Hash)(hasher.Sum(nil)) != expHash {
+			return types.ErrMD5Mismatch
+		}
+	}
+
+	return nil
+}
+
+// progressWriter is an io.Writer that reports the number of bytes written to a
+// progress callback.
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	progress func(written int64, total int64)
+}
+
+func (pw *progressWriter) Write(b []byte) (int, error) {
+	n, err := pw.w.Write(b)
+	pw.written += int64(n)
+	pw.progress(pw.written, pw.total)
+	return n, err
+}
+
+// ErrUnsupportedDeleteScope is the error that is returned when deleting a photo
+// with a DeleteOptions.Scope that is not supported for the type of container
+// the photo was obtained from. The actual error returned by Photo.Delete will be
+// a *UnsupportedDeleteScopeError.
+var ErrUnsupportedDeleteScope = errors.New("delete scope is not supported")
+
+// UnsupportedDeleteScopeError is the error returned by Photo.Delete when the
+// scope of the delete is not supported. errors.Is(err,
+// ErrUnsupportedDeleteScope) will return true for a
+// UnsupportedDeleteScopeError.
+type UnsupportedDeleteScopeError struct {
+	Scope         types.DeleteScope
+	ContainerType types.ContainerType
+}
+
+func (e *UnsupportedDeleteScopeError) Error() string {
+	return fmt.Sprintf("%s: can not delete a photo in a %s with scope %q", ErrUnsupportedDeleteScope, e.ContainerType, e.Scope)
+}
+
+func (e *UnsupportedDeleteScopeError) Unwrap() error {
+	return ErrUnsupportedDeleteScope
+}
+
+func (p *photo) Delete(ctx context.Context, opts DeleteOptions) (err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	ctx = p.withOperation(ctx, "DeletePhoto")
+	ctx, cancel := withTimeout(ctx, p.settings().timeouts.Metadata)
+	defer cancel()
+
+	deleteFunc, err := p.deleteFunc(opts.Scope)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	defer io.Copy(io.Discard, resp.Body)
 
-	if err := httpx.StatusError(resp); err != nil {
+	if p.settings().isDryRun(ctx) {
+		name, err := p.Name(ctx)
+		if err != nil {
+			return err
+		}
+		return p.settings().logContainerDryRun(ctx, p.container, DryRunAction{Type: types.PhotoDeletedChangeType, Photo: name})
+	}
+
+	if err := deleteFunc(ctx); err != nil {
+		return err
+	}
+
+	if err := p.NotifyDeleted(ctx, p); err != nil {
 		return err
 	}
 
-	for _, l := range p.elementDeletedListener {
-		if err := l.ElementDeleted(ctx, p); err != nil {
+	p.settings().changes.notify(ChangeEvent{
+		Type:      types.PhotoDeletedChangeType,
+		Container: p.container,
+		Photo:     p,
+	})
+
+	return nil
+}
+
+func (p *photo) Refresh(ctx context.Context) (err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	c, ok := p.container.(*container)
+	if !ok {
+		return fmt.Errorf("failed to cast container")
+	}
+
+	found, err := c.loadPhotoWithID(ctx, p.ID())
+	if err != nil {
+		return err
+	}
+	if found == nil {
+		// The photo was deleted outside of this client so make sure it is no
+		// longer returned from the cache of the container.
+		if err := p.NotifyDeleted(ctx, p); err != nil {
 			return err
 		}
+		return types.ErrNotFound
 	}
 
+	// The content of a photo can not change so the size, duration and hashes
+	// are kept.
+	latest := found.snapshot()
+	p.update(func(s *photoState) {
+		if latest.name != "" {
+			s.name = latest.name
+		}
+		s.nixplayID = latest.nixplayID
+		s.nixplayPlaylistItemID = latest.nixplayPlaylistItemID
+		s.url = latest.url
+		s.raw = latest.raw
+	})
 	return nil
 }
 
-func (p *photo) deleteRequest(ctx context.Context) (*http.Request, error) {
-	switch p.container.ContainerType() {
+func (p *photo) Exists(ctx context.Context) (exists bool, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	err = p.Refresh(ctx)
+	if errors.Is(err, types.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// deleteFunc returns the function that deletes the photo with the specified
+// scope.
+func (p *photo) deleteFunc(scope types.DeleteScope) (func(ctx context.Context) error, error) {
+	containerType := p.container.ContainerType()
+	switch containerType {
 	case types.AlbumContainerType:
-		return p.albumDeleteRequest(ctx)
+		switch scope {
+		case types.DefaultDeleteScope, types.GlobalDeleteScope:
+			return p.albumDelete, nil
+		}
 	case types.PlaylistContainerType:
-		return p.playlistDeleteRequest(ctx)
+		switch scope {
+		case types.DefaultDeleteScope, types.ContainerOnlyDeleteScope:
+			return p.playlistDelete, nil
+		case types.GlobalDeleteScope:
+			// Deleting the photo the slide shows removes it from the album
+			// that owns it and so from every playlist, including this one.
+			return p.albumDelete, nil
+		}
+	default:
+		return nil, types.ErrInvalidContainerType
 	}
-	return nil, types.ErrInvalidContainerType
+	return nil, &UnsupportedDeleteScopeError{Scope: scope, ContainerType: containerType}
 }
 
-func (p *photo) albumDeleteRequest(ctx context.Context) (*http.Request, error) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
+func (p *photo) albumDelete(ctx context.Context) error {
 	nixplayID, err := p.getNixplayID(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	url := fmt.Sprintf("https://api.nixplay.com/picture/%d/delete/json/", nixplayID)
-	return http.NewRequestWithContext(ctx, http.MethodPost, url, http.NoBody)
+	return p.settings().rawAPI(p.client).DeletePicture(ctx, nixplayID)
 }
 
-func (p *photo) playlistDeleteRequest(ctx context.Context) (*http.Request, error) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
+func (p *photo) playlistDelete(ctx context.Context) error {
 	playlist, ok := p.container.(*container)
 	if !ok {
-		return nil, fmt.Errorf("failed to cast container")
+		return fmt.Errorf("failed to cast container")
 	}
 
 	nixplayPlaylistItemID, err := p.getNixplayPlaylistItemID(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	url := fmt.Sprintf("https://api.nixplay.com/v3/playlists/%d/items?id=%s", playlist.nixplayID, nixplayPlaylistItemID)
-	return http.NewRequestWithContext(ctx, http.MethodDelete, url, bytes.NewReader([]byte{}))
+	return p.settings().rawAPI(p.client).DeletePlaylistItem(ctx, playlist.nixplayID, nixplayPlaylistItemID)
 }
 
-func (p *photo) AddDeletedListener(l cache.ElementDeletedListener) {
-	p.elementDeletedListener = append(p.elementDeletedListener, l)
+// settings returns the settings of the client that created the container the
+// photo resides in.
+func (p *photo) settings() *clientSettings {
+	return settingsOf(p.container)
+}
+
+// settingsOf returns the settings of the client that created the container.
+func settingsOf(c Container) *clientSettings {
+	if c, ok := c.(*container); ok && c.settings != nil {
+		return c.settings
+	}
+	return &clientSettings{metrics: nopMetrics{}}
 }
 
 func (p *photo) getNixplayID(ctx context.Context) (uint64, error) {
-	if p.nixplayID == 0 {
-		if err := p.populatePhotoDataFromListSearch(ctx); err != nil {
-			return 0, fmt.Errorf("failed to get internal Nixplay ID: %w", err)
-		}
+	if nixplayID := p.snapshot().nixplayID; nixplayID != 0 {
+		return nixplayID, nil
+	}
+	if err := p.populatePhotoDataFromListSearch(ctx); err != nil {
+		return 0, fmt.Errorf("failed to get internal Nixplay ID: %w", err)
 	}
-	if p.nixplayID == 0 {
+	nixplayID := p.snapshot().nixplayID
+	if nixplayID == 0 {
 		return 0, errors.New("unable to determine internal Nixplay ID")
 	}
-	return p.nixplayID, nil
+	return nixplayID, nil
 }
 
 func (p *photo) getNixplayPlaylistItemID(ctx context.Context) (string, error) {
-	if p.nixplayPlaylistItemID == "" {
-		if err := p.populatePhotoDataFromListSearch(ctx); err != nil {
-			return "", fmt.Errorf("failed to get internal Nixplay ID: %w", err)
-		}
+	if itemID := p.snapshot().nixplayPlaylistItemID; itemID != "" {
+		return itemID, nil
 	}
-	if p.nixplayPlaylistItemID == "" {
+	if err := p.populatePhotoDataFromListSearch(ctx); err != nil {
+		return "", fmt.Errorf("failed to get internal Nixplay ID: %w", err)
+	}
+	itemID := p.snapshot().nixplayPlaylistItemID
+	if itemID == "" {
 		return "", errors.New("unable to determine internal Nixplay ID")
 	}
-	return p.nixplayPlaylistItemID, nil
+	return itemID, nil
+}
+
+// setSize records the size of the photo if it is not already known.
+func (p *photo) setSize(size int64) {
+	p.update(func(s *photoState) {
+		if s.size == -1 {
+			s.size = size
+		}
+	})
+}
+
+// setHashes records hashes of the content of the photo, in addition to those
+// that are already known.
+func (p *photo) setHashes(hashes map[types.HashType]string) {
+	if len(hashes) == 0 {
+		return
+	}
+	p.update(func(s *photoState) {
+		merged := make(map[types.HashType]string, len(s.hashes)+len(hashes))
+		for t, h := range s.hashes {
+			merged[t] = h
+		}
+		for t, h := range hashes {
+			merged[t] = h
+		}
+		s.hashes = merged
+	})
 }
 
 func (p *photo) populatePhotoDataFromListSearch(ctx context.Context) (err error) {
@@ -455,10 +846,15 @@ func (p *photo) attemptPopulatePhotoDataFromListSearch(ctx context.Context) (boo
 			return false, errors.New("failed to cast to *photo in populatePhotoDataFromListSearch")
 		}
 
-		if ppFromContainer.nixplayID != 0 && ppFromContainer.url != "" {
-			p.nixplayID = ppFromContainer.nixplayID
-			p.nixplayPlaylistItemID = ppFromContainer.nixplayPlaylistItemID // we don't check this in the if condition because it is not set for album photos
-			p.url = ppFromContainer.url
+		// ppFromContainer may be p itself so take a snapshot rather than
+		// holding both locks.
+		found := ppFromContainer.snapshot()
+		if found.nixplayID != 0 && found.url != "" {
+			p.update(func(s *photoState) {
+				s.nixplayID = found.nixplayID
+				s.nixplayPlaylistItemID = found.nixplayPlaylistItemID // we don't check this in the if condition because it is not set for album photos
+				s.url = found.url
+			})
 			return true, nil
 		}
 	}
@@ -470,29 +866,34 @@ func (p *photo) attemptPopulatePhotoDataFromListSearch(ctx context.Context) (boo
 func (p *photo) populatePhotoDataFromPictureEndpoint(ctx context.Context) (err error) {
 	defer errorx.WrapWithFuncNameIfError(&err)
 
+	ctx = p.withOperation(ctx, "PhotoName")
+
 	id, err := p.getNixplayID(ctx)
 	if err != nil {
 		return err
 	}
 
-	url := fmt.Sprintf("https://api.nixplay.com/picture/%d/", id)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	ctx, cancel := withTimeout(ctx, p.settings().timeouts.Metadata)
+	defer cancel()
+
+	picture, err := p.settings().rawAPI(p.client).Picture(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	var nixplayPhoto nixplayAlbumPhoto
-	if err := httpx.DoUnmarshalJSONResponse(p.client, req, &nixplayPhoto); err != nil {
+	photoFromPicEndpoint, err := pictureToPhoto(picture, p.container, p.client)
+	if err != nil {
 		return err
 	}
 
-	photoFromPicEndpoint, err := nixplayPhoto.ToPhoto(p.container, p.client)
+	name, err := photoFromPicEndpoint.Name(ctx)
 	if err != nil {
 		return err
 	}
-
-	p.name, err = photoFromPicEndpoint.Name(ctx)
-	return err
+	p.update(func(s *photoState) {
+		s.name = name
+	})
+	return nil
 }
 
 func (p *photo) populatePhotoDataFromHead(ctx context.Context) (err error) {
@@ -520,6 +921,10 @@ func (p *photo) populatePhotoDataFromHead(ctx context.Context) (err error) {
 	if err != nil {
 		return err
 	}
+	ctx = p.withOperation(ctx, "PhotoSize")
+	ctx, cancel := withTimeout(ctx, p.settings().timeouts.Metadata)
+	defer cancel()
+
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, photoURL, http.NoBody)
 	if err != nil {
 		return err
@@ -564,6 +969,6 @@ func (p *photo) populatePhotoDataFromHead(ctx context.Context) (err error) {
 		return err
 	}
 
-	p.size = size
+	p.setSize(size)
 	return nil
 }
@@ -0,0 +1,45 @@
+package nixplay
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// SortPhotos sorts photos in place as specified by opts.
+//
+// Sorting by name requires the name of every photo. For photos in playlists
+// the name is not included in the list of photos so this may require a
+// request to Nixplay for each photo whose name has not been loaded yet.
+func SortPhotos(ctx context.Context, photos []Photo, opts PhotosOptions) error {
+	switch opts.SortBy {
+	case types.NixplayPhotoSortBy:
+	case types.NamePhotoSortBy:
+		names := make(map[Photo]string, len(photos))
+		for _, p := range photos {
+			name, err := p.Name(ctx)
+			if err != nil {
+				return err
+			}
+			names[p] = name
+		}
+		sort.SliceStable(photos, func(i, j int) bool {
+			return names[photos[i]] < names[photos[j]]
+		})
+	default:
+		return fmt.Errorf("%w: sort by %q", types.ErrInvalidPhotoSort, opts.SortBy)
+	}
+
+	switch opts.Order {
+	case types.AscendingSortOrder:
+	case types.DescendingSortOrder:
+		for i, j := 0, len(photos)-1; i < j; i, j = i+1, j-1 {
+			photos[i], photos[j] = photos[j], photos[i]
+		}
+	default:
+		return fmt.Errorf("%w: order %q", types.ErrInvalidPhotoSort, opts.Order)
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package nixplay
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/rawapi"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPhoto_ConcurrentAccess looks up the data of a photo from many goroutines
+// at the same time. It is most useful when run with the -race flag.
+func TestPhoto_ConcurrentAccess(t *testing.T) {
+	const goroutines = 20
+
+	content := []byte("photo content")
+	h := types.MD5Hash(md5.Sum(content))
+	photoURL := fmt.Sprintf("https://s3.example.com/1/1_%s.jpg", h)
+
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		header := http.Header{}
+		switch {
+		case req.URL.Path == "/picture/7/":
+			body := fmt.Sprintf(`{"filename":"photo.jpg","id":7,"md5":"%s","url":"%s"}`, h, photoURL)
+			return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(body))}, nil
+		case req.URL.String() == photoURL && req.Header.Get("Range") == "bytes=0-0":
+			header.Set("Content-Range", fmt.Sprintf("bytes 0-0/%d", len(content)))
+			return &http.Response{StatusCode: http.StatusPartialContent, Header: header, Body: io.NopCloser(strings.NewReader(string(content[:1])))}, nil
+		case req.URL.String() == photoURL:
+			header.Set("Content-Length", strconv.Itoa(len(content)))
+			return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(string(content)))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Header: header, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+
+	settings := &clientSettings{
+		metrics: nopMetrics{},
+		changes: &changeNotifier{},
+	}
+	pageFunc := func(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error) {
+		if page > 0 {
+			return nil, nil
+		}
+		p, err := newPhoto(container, client, "", &h, 7, "", -1, photoURL)
+		require.NoError(t, err)
+		return []Photo{p}, nil
+	}
+	c := newContainer(client, nil, settings, types.AlbumContainerType, "album", 1234, 1, pageFunc, (*rawapi.Client).DeleteAlbum, albumAddIDName)
+
+	// This is what a photo looks like right after it is uploaded, everything
+	// other than the MD5 hash needs to be looked up.
+	p, err := newPhoto(c, client, "", &h, 0, "", -1, "")
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			switch i % 5 {
+			case 0:
+				name, err := p.Name(ctx)
+				assert.NoError(t, err)
+				assert.Equal(t, "photo.jpg", name)
+			case 1:
+				size, err := p.Size(ctx)
+				assert.NoError(t, err)
+				assert.Equal(t, int64(len(content)), size)
+			case 2:
+				r, err := p.Open(ctx)
+				if assert.NoError(t, err) {
+					data, err := io.ReadAll(r)
+					assert.NoError(t, err)
+					assert.Equal(t, content, data)
+					r.Close()
+				}
+			case 3:
+				url, err := p.URL(ctx)
+				assert.NoError(t, err)
+				assert.Equal(t, photoURL, url)
+			case 4:
+				p.persisted()
+				c.ResetCache()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	persisted := p.persisted()
+	assert.Equal(t, "photo.jpg", persisted.Name)
+	assert.Equal(t, uint64(7), persisted.NixplayID)
+	assert.Equal(t, int64(len(content)), persisted.Size)
+	assert.Equal(t, photoURL, persisted.URL)
+}
+
+func TestPhoto_DeleteUpdatesPhotoCount(t *testing.T) {
+	ctx := context.Background()
+
+	h := types.MD5Hash(md5.Sum([]byte("photo")))
+	var deletes int
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		require.Equal(t, "/picture/7/delete/json/", req.URL.Path)
+		deletes++
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})
+
+	settings := &clientSettings{
+		metrics: nopMetrics{},
+		changes: &changeNotifier{},
+	}
+	var listed []*photo
+	pageFunc := func(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error) {
+		if page > 0 {
+			return nil, nil
+		}
+		if listed == nil {
+			p, err := newPhoto(container, client, "photo.jpg", &h, 7, "", 5, "")
+			require.NoError(t, err)
+			listed = []*photo{p}
+		}
+		return []Photo{listed[0]}, nil
+	}
+	c := newContainer(client, nil, settings, types.AlbumContainerType, "album", 1234, 1, pageFunc, (*rawapi.Client).DeleteAlbum, albumAddIDName)
+
+	// Load the same photo object into the cache twice.
+	_, err := c.Photos(ctx)
+	require.NoError(t, err)
+	c.ResetCache()
+	photos, err := c.Photos(ctx)
+	require.NoError(t, err)
+	require.Len(t, photos, 1)
+
+	require.NoError(t, photos[0].Delete(ctx, DeleteOptions{}))
+	assert.Equal(t, 1, deletes)
+
+	count, err := c.PhotoCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+	assert.Equal(t, 0, c.photoCache.Len())
+}
+
+func TestPhoto_Refresh(t *testing.T) {
+	ctx := context.Background()
+
+	h := types.MD5Hash(md5.Sum([]byte("photo")))
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		require.Fail(t, "unexpected request", req.URL.String())
+		return nil, nil
+	})
+
+	settings := &clientSettings{
+		metrics: nopMetrics{},
+		changes: &changeNotifier{},
+	}
+	url := "https://s3.example.com/1/1_" + h.String() + ".jpg?Signature=1"
+	deleted := false
+	pageFunc := func(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error) {
+		if page > 0 || deleted {
+			return nil, nil
+		}
+		p, err := newPhoto(container, client, "photo.jpg", &h, 7, "", 5, url)
+		require.NoError(t, err)
+		return []Photo{p}, nil
+	}
+	c := newContainer(client, nil, settings, types.AlbumContainerType, "album", 1234, 1, pageFunc, (*rawapi.Client).DeleteAlbum, albumAddIDName)
+
+	photos, err := c.Photos(ctx)
+	require.NoError(t, err)
+	require.Len(t, photos, 1)
+	p := photos[0]
+
+	// The signed URL changes over time, refreshing picks up the new one.
+	url = "https://s3.example.com/1/1_" + h.String() + ".jpg?Signature=2"
+	require.NoError(t, p.Refresh(ctx))
+	gotURL, err := p.URL(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, url, gotURL)
+
+	exists, err := p.Exists(ctx)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// Delete the photo outside of the client.
+	deleted = true
+	assert.ErrorIs(t, p.Refresh(ctx), types.ErrNotFound)
+	exists, err = p.Exists(ctx)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	count, err := c.PhotoCount(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), count)
+	found, err := c.PhotoWithID(ctx, p.ID())
+	require.NoError(t, err)
+	assert.Nil(t, found)
+}
+
+func TestPhoto_Duration(t *testing.T) {
+	ctx := context.Background()
+
+	video := concat(
+		mp4Box("ftyp", []byte("isom")),
+		mp4Box("mdat", make([]byte, 10000)),
+		mp4Box("moov", mvhdBox(1000, 2500)),
+	)
+	h := types.MD5Hash(md5.Sum(video))
+	videoURL := fmt.Sprintf("https://s3.example.com/1/1_%s.mp4", h)
+
+	// The client only supports range requests so that reading the whole
+	// video fails the test.
+	var requests int
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		requests++
+		require.Equal(t, videoURL, req.URL.String())
+		var first, last int
+		_, err := fmt.Sscanf(req.Header.Get("Range"), "bytes=%d-%d", &first, &last)
+		require.NoError(t, err)
+		header := http.Header{}
+		header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", first, last, len(video)))
+		return &http.Response{StatusCode: http.StatusPartialContent, Header: header, Body: io.NopCloser(bytes.NewReader(video[first : last+1]))}, nil
+	})
+	settings := &clientSettings{
+		metrics: nopMetrics{},
+		changes: &changeNotifier{},
+	}
+	c := newContainer(client, nil, settings, types.AlbumContainerType, "album", 1234, 1, nil, (*rawapi.Client).DeleteAlbum, albumAddIDName)
+
+	t.Run("Video", func(t *testing.T) {
+		requests = 0
+		p, err := newPhoto(c, client, "video.mp4", &h, 7, "", -1, videoURL)
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			duration, err := p.Duration(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, 2500*time.Millisecond, duration)
+		}
+
+		// One request for the size, and one for each of the headers of the
+		// ftyp, mdat, moov and mvhd boxes and the content of the mvhd box.
+		// The duration is only read once.
+		assert.Equal(t, 6, requests)
+	})
+
+	t.Run("Photo", func(t *testing.T) {
+		requests = 0
+		p, err := newPhoto(c, client, "photo.jpg", &h, 7, "", -1, videoURL)
+		require.NoError(t, err)
+		duration, err := p.Duration(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), duration)
+		assert.Equal(t, 0, requests)
+	})
+}
@@ -2,37 +2,123 @@ package nixplay
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net/http"
 
 	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/internal/errorx"
+	"github.com/anitschke/go-nixplay/rawapi"
 	"github.com/anitschke/go-nixplay/types"
 )
 
-const playlistAddIDName = "playlistId"
+const playlistAddIDName = rawapi.PlaylistIDField
 
-func newPlaylist(client httpx.Client, nixplayClient Client, name string, nixplayID uint64, photoCount int64) *container {
-	return newContainer(client, nixplayClient, types.PlaylistContainerType, name, nixplayID, photoCount, playlistPhotosPage, playlistDeleteRequest, playlistAddIDName)
-}
-
-func playlistDeleteRequest(ctx context.Context, nixplayID uint64) (*http.Request, error) {
-	url := fmt.Sprintf("https://api.nixplay.com/v3/playlists/%d", nixplayID)
-	return http.NewRequestWithContext(context.Background(), http.MethodDelete, url, http.NoBody)
+func newPlaylist(client httpx.Client, nixplayClient Client, settings *clientSettings, name string, nixplayID uint64, photoCount int64) *container {
+	return newContainer(client, nixplayClient, settings, types.PlaylistContainerType, name, nixplayID, photoCount, playlistPhotosPage, (*rawapi.Client).DeletePlaylist, playlistAddIDName)
 }
 
 func playlistPhotosPage(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error) {
 	limit := pageSize
 	offset := page * limit
-	url := fmt.Sprintf("https://api.nixplay.com/v3/playlists/%d/slides?size=%d&offset=%d", nixplayID, limit, offset)
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, http.NoBody)
+	slides, err := settingsOf(container).rawAPI(client).PlaylistSlides(ctx, nixplayID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return slidesToPhotos(slides, container, client)
+}
+
+func (c *container) AddSlideFromPhoto(ctx context.Context, p Photo) (retPhoto Photo, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	if c.containerType != types.PlaylistContainerType {
+		return nil, fmt.Errorf("can only add slides to a playlist: %w", types.ErrInvalidContainerType)
+	}
+	source, ok := p.(*photo)
+	if !ok {
+		return nil, errors.New("photo was not obtained from a DefaultClient")
+	}
+
+	ctx = withContainerOperation(ctx, "AddSlide", c.containerType, c.nixplayID)
+	ctx, cancel := withTimeout(ctx, c.settings.timeouts.Metadata)
+	defer cancel()
+
+	name, err := source.Name(ctx)
+	if err != nil {
+		return nil, err
+	}
+	md5Hash, err := source.MD5Hash(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pictureID, err := source.getNixplayID(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	var playlistPhotos playlistPhotosResponse
-	if err := httpx.DoUnmarshalJSONResponse(client, req, &playlistPhotos); err != nil {
+	if c.settings.isDryRun(ctx) {
+		if err := c.settings.logContainerDryRun(ctx, c, DryRunAction{Type: types.PhotoAddedChangeType, Photo: name}); err != nil {
+			return nil, err
+		}
+		return nil, types.ErrDryRun
+	}
+
+	// Nixplay does not tell us the ID of the new slide, see AddPhotoAsync.
+	var knownIDs map[types.ID]bool
+	if c.usesPlaylistItemIdentity() {
+		knownIDs, err = c.photoIDs(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := c.settings.rawAPI(c.client).AddPlaylistItems(ctx, c.nixplayID, []uint64{pictureID}); err != nil {
 		return nil, err
 	}
 
-	return playlistPhotos.ToPhotos(container, client)
+	var slide Photo
+	if c.usesPlaylistItemIdentity() {
+		slide, err = c.findUploadedSlide(ctx, md5Hash, knownIDs)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// The name is already decoded so it is set after creating the photo
+		// rather than being decoded again by newPhoto.
+		nixplayPlaylistItemID := ""
+		size := int64(-1)
+		url := ""
+		newP, err := newPhoto(c, c.client, "", &md5Hash, pictureID, nixplayPlaylistItemID, size, url)
+		if err != nil {
+			return nil, err
+		}
+		newP.state.name = name
+		c.photoCache.Add(newP)
+		slide = newP
+	}
+
+	c.incrementPhotoCount()
+
+	c.settings.changes.notify(ChangeEvent{
+		Type:      types.PhotoAddedChangeType,
+		Container: c,
+		Photo:     slide,
+	})
+
+	return slide, nil
+}
+
+func (c *container) RemoveSlide(ctx context.Context, slide Photo) (err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	if c.containerType != types.PlaylistContainerType {
+		return fmt.Errorf("can only remove slides from a playlist: %w", types.ErrInvalidContainerType)
+	}
+	p, ok := slide.(*photo)
+	if !ok || p.container != Container(c) {
+		return fmt.Errorf("slide is not in the playlist: %w", types.ErrNotFound)
+	}
+
+	// Deleting a photo from a playlist only removes the playlist item, see
+	// photo.playlistDelete.
+	return p.Delete(ctx, DeleteOptions{Scope: types.ContainerOnlyDeleteScope})
 }
@@ -0,0 +1,86 @@
+package nixplay
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/rawapi"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainer_RawMetadata(t *testing.T) {
+	ctx := context.Background()
+	settings := &clientSettings{
+		metrics: nopMetrics{},
+		changes: &changeNotifier{},
+	}
+
+	raw := json.RawMessage(`{"photo_count":0,"title":"album","id":12,"unknown":true}`)
+	containers := albumsToContainers([]rawapi.Album{{Title: "album", ID: 12, Raw: raw}}, noRequestClient{t: t}, nil, settings)
+	require.Len(t, containers, 1)
+	got, err := containers[0].RawMetadata(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, raw, got)
+
+	// The returned JSON is a copy.
+	got[0] = 'x'
+	got, err = containers[0].RawMetadata(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, raw, got)
+
+	// Playlists that were created without being listed have no metadata.
+	notListed := playlistToContainer(rawapi.Playlist{Name: "playlist", ID: 34}, noRequestClient{t: t}, nil, settings)
+	_, err = notListed.RawMetadata(ctx)
+	assert.Error(t, err)
+}
+
+func TestPhoto_RawMetadata(t *testing.T) {
+	ctx := context.Background()
+
+	h := types.MD5Hash(md5.Sum([]byte("photo")))
+	raw := json.RawMessage(`{"filename":"photo.jpg","id":7,"md5":"` + h.String() + `","caption":"not modeled"}`)
+	settings := &clientSettings{
+		metrics: nopMetrics{},
+		changes: &changeNotifier{},
+	}
+	listed := 0
+	pageFunc := func(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error) {
+		if page > 0 {
+			return nil, nil
+		}
+		listed++
+		p, err := pictureToPhoto(rawapi.Picture{FileName: "photo.jpg", ID: 7, MD5: h, Raw: raw}, container, client)
+		return []Photo{p}, err
+	}
+	c := newContainer(noRequestClient{t: t}, nil, settings, types.AlbumContainerType, "album", 1234, 1, pageFunc, (*rawapi.Client).DeleteAlbum, albumAddIDName)
+
+	t.Run("Listed", func(t *testing.T) {
+		photos, err := c.Photos(ctx)
+		require.NoError(t, err)
+		require.Len(t, photos, 1)
+		listed = 0
+
+		got, err := photos[0].RawMetadata(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, raw, got)
+		assert.Equal(t, 0, listed)
+	})
+
+	t.Run("NotListed", func(t *testing.T) {
+		// Photos that were just uploaded have not been listed so the
+		// container is listed to find them.
+		uploaded, err := newPhoto(c, c.client, "photo.jpg", &h, 0, "", 5, "")
+		require.NoError(t, err)
+		listed = 0
+
+		got, err := uploaded.RawMetadata(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, raw, got)
+		assert.Equal(t, 1, listed)
+	})
+}
@@ -0,0 +1,118 @@
+// Package rawapi provides thin typed wrappers around the individual Nixplay
+// REST endpoints that the high level nixplay.Client is built on.
+//
+// This package performs no caching, name decoding or dry-run handling, it
+// simply makes the request and decodes the response. It is intended as an
+// escape hatch for callers that need data or endpoints that the high level API
+// does not expose yet. Use nixplay.DefaultClient.RawAPI to get a Client that
+// shares the signed in session of a DefaultClient.
+//
+// Nixplay does not document its REST API, the endpoints and types in this
+// package were discovered by observing the Nixplay web app and may change
+// without notice.
+package rawapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// BaseURL is the URL that the paths of Nixplay REST endpoints are relative to.
+const BaseURL = "https://api.nixplay.com"
+
+// Options are optional inputs that may be specified for creating a Client.
+type Options struct {
+	// DecodingMode controls how strictly responses are checked against the
+	// types they are decoded into. See types.DecodingMode.
+	DecodingMode types.DecodingMode
+
+	// MaxResponseSize is the maximum size in bytes of a JSON response body.
+	// If it is zero then httpx.DefaultMaxResponseSize is used.
+	MaxResponseSize int64
+}
+
+// Client makes requests to the Nixplay REST API.
+type Client struct {
+	client          httpx.Client
+	decodingMode    types.DecodingMode
+	maxResponseSize int64
+}
+
+// New returns a Client that makes requests using client. client is
+// responsible for authorizing requests, normally it is obtained from
+// nixplay.DefaultClient.RawAPI rather than by calling New directly.
+func New(client httpx.Client, opts Options) *Client {
+	return &Client{
+		client:          client,
+		decodingMode:    opts.DecodingMode,
+		maxResponseSize: opts.MaxResponseSize,
+	}
+}
+
+// HTTPClient returns the client used to make requests.
+func (c *Client) HTTPClient() httpx.Client {
+	return c.client
+}
+
+// NewRequest creates a request for the endpoint at path, which is relative to
+// BaseURL. It can be used with Do or DoJSON to make requests to endpoints that
+// this package does not have a method for.
+func (c *Client) NewRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Request, error) {
+	if body == nil {
+		body = http.NoBody
+	}
+	return http.NewRequestWithContext(ctx, method, BaseURL+"/"+strings.TrimPrefix(path, "/"), body)
+}
+
+// DoJSON makes the request and decodes the JSON body of the response into
+// response. An error is returned if the response does not have a 2xx status
+// code or if the body is larger than the maximum response size, see
+// Options.MaxResponseSize.
+//
+// In types.StrictDecodingMode a *SchemaError is returned if the response is
+// missing any of the fields of response, response is still populated with the
+// fields that were present.
+func (c *Client) DoJSON(req *http.Request, response any) error {
+	if c.decodingMode != types.StrictDecodingMode {
+		return httpx.DoUnmarshalJSONResponseWithLimit(c.client, req, response, c.maxResponseSize)
+	}
+
+	// The body is needed twice in strict mode, once to populate response and
+	// once to look for missing fields.
+	var body json.RawMessage
+	if err := httpx.DoUnmarshalJSONResponseWithLimit(c.client, req, &body, c.maxResponseSize); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, response); err != nil {
+		return err
+	}
+
+	missing, err := missingFields(body, reflect.TypeOf(response))
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return &SchemaError{URL: httpx.RedactURL(req.URL), Missing: missing}
+	}
+	return nil
+}
+
+// Do makes a request for which the body of the response is not needed. An
+// error is returned if the response does not have a 2xx status code.
+func (c *Client) Do(req *http.Request) error {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	defer io.Copy(io.Discard, resp.Body)
+
+	return httpx.StatusError(resp)
+}
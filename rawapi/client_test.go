@@ -0,0 +1,146 @@
+package rawapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clientFunc func(req *http.Request) (*http.Response, error)
+
+func (f clientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func respond(statusCode int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Status:     http.StatusText(statusCode),
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+
+	var requests []string
+	c := New(clientFunc(func(req *http.Request) (*http.Response, error) {
+		requests = append(requests, req.Method+" "+req.URL.String())
+		switch req.URL.Path {
+		case "/v2/albums/web/json/":
+			return respond(http.StatusOK, `[{"photo_count":2,"title":"Trip","id":12,"unknown":true}]`), nil
+		case "/album/12/pictures/json/":
+			return respond(http.StatusOK, `{"photos":[{"filename":"a.jpg","id":34,"md5":"0123456789abcdef0123456789abcdef"}]}`), nil
+		case "/v3/playlists":
+			if req.Method == http.MethodPost {
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"name":"New"}`, string(body))
+				return respond(http.StatusOK, `{"playlistId":56}`), nil
+			}
+		case "/v3/playlists/56/items":
+			if req.Method == http.MethodPost {
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"items":[{"pictureId":34},{"pictureId":35}]}`, string(body))
+			}
+			return respond(http.StatusOK, ``), nil
+		case "/user/profile/edit/":
+			return respond(http.StatusForbidden, `{}`), nil
+		}
+		return respond(http.StatusNotFound, ``), nil
+	}), Options{})
+
+	albums, err := c.WebAlbums(ctx)
+	require.NoError(t, err)
+	// Fields that are not declared are still available in Raw.
+	assert.Equal(t, []Album{{PhotoCount: 2, Title: "Trip", ID: 12, Raw: json.RawMessage(`{"photo_count":2,"title":"Trip","id":12,"unknown":true}`)}}, albums)
+
+	pictures, err := c.AlbumPhotos(ctx, 12, 1, 100)
+	require.NoError(t, err)
+	require.Len(t, pictures, 1)
+	assert.Equal(t, "a.jpg", pictures[0].FileName)
+	assert.Equal(t, uint64(34), pictures[0].ID)
+	assert.Equal(t, types.MD5Hash{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef, 0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef}, pictures[0].MD5)
+	assert.JSONEq(t, `{"filename":"a.jpg","id":34,"md5":"0123456789abcdef0123456789abcdef"}`, string(pictures[0].Raw))
+
+	playlistID, err := c.CreatePlaylist(ctx, "New")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(56), playlistID)
+
+	assert.NoError(t, c.AddPlaylistItems(ctx, 56, []uint64{34, 35}))
+	assert.NoError(t, c.DeletePlaylistItem(ctx, 56, "item"))
+
+	_, err = c.Profile(ctx)
+	assert.ErrorContains(t, err, "Forbidden")
+
+	// Endpoints without a method can be reached with NewRequest and DoJSON.
+	req, err := c.NewRequest(ctx, http.MethodGet, "/v2/albums/web/json/", nil)
+	require.NoError(t, err)
+	var raw []map[string]any
+	require.NoError(t, c.DoJSON(req, &raw))
+	assert.Equal(t, true, raw[0]["unknown"])
+
+	assert.Equal(t, []string{
+		"GET https://api.nixplay.com/v2/albums/web/json/",
+		"GET https://api.nixplay.com/album/12/pictures/json/?page=1&limit=100",
+		"POST https://api.nixplay.com/v3/playlists",
+		"POST https://api.nixplay.com/v3/playlists/56/items",
+		"DELETE https://api.nixplay.com/v3/playlists/56/items?id=item",
+		"GET https://api.nixplay.com/user/profile/edit/",
+		"GET https://api.nixplay.com/v2/albums/web/json/",
+	}, requests)
+}
+
+func TestClient_DecodingMode(t *testing.T) {
+	ctx := context.Background()
+
+	// The second photo is missing its md5 and the url of both photos has been
+	// renamed.
+	body := `{"photos":[
+		{"filename":"a.jpg","id":1,"md5":"0123456789abcdef0123456789abcdef","photo_url":"u","extra":1},
+		{"filename":"b.jpg","id":2,"photo_url":"u"}
+	]}`
+	httpClient := clientFunc(func(req *http.Request) (*http.Response, error) {
+		return respond(http.StatusOK, body), nil
+	})
+
+	t.Run("Tolerant", func(t *testing.T) {
+		pictures, err := New(httpClient, Options{}).AlbumPhotos(ctx, 1, 1, 100)
+		require.NoError(t, err)
+		assert.Len(t, pictures, 2)
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, BaseURL+"/album/1/pictures/json/?page=1&limit=100", http.NoBody)
+		require.NoError(t, err)
+
+		var response albumPhotosResponse
+		err = New(httpClient, Options{DecodingMode: types.StrictDecodingMode}).DoJSON(req, &response)
+
+		var schemaErr *SchemaError
+		require.ErrorAs(t, err, &schemaErr)
+		assert.Equal(t, []string{"photos[].md5", "photos[].url"}, schemaErr.Missing)
+		assert.Equal(t, "https://api.nixplay.com/album/1/pictures/json/?page=1&limit=100", schemaErr.URL)
+
+		// The fields that were present are still decoded.
+		require.Len(t, response.Photos, 2)
+		assert.Equal(t, "b.jpg", response.Photos[1].FileName)
+	})
+
+	t.Run("StrictComplete", func(t *testing.T) {
+		httpClient := clientFunc(func(req *http.Request) (*http.Response, error) {
+			return respond(http.StatusOK, `[{"photo_count":2,"title":"Trip","id":12,"unknown":true}]`), nil
+		})
+		_, err := New(httpClient, Options{DecodingMode: types.StrictDecodingMode}).WebAlbums(ctx)
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,161 @@
+package rawapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaError is returned when decoding a response in types.StrictDecodingMode
+// and the response is missing fields that the type it is decoded into
+// expects. This usually means that Nixplay has changed its API.
+//
+// Fields that are present in the response but unknown to this package are
+// not an error, the types in this package intentionally only declare the
+// subset of fields that are used.
+type SchemaError struct {
+	// URL is the redacted URL of the request, see httpx.RedactURL.
+	URL string
+
+	// Missing are the paths of the fields that were expected but not present
+	// in the response, for example "photos[].md5".
+	Missing []string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("response from %s is missing fields: %s", e.URL, strings.Join(e.Missing, ", "))
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	rawFieldType        = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// unmarshalWithRaw decodes data into v, which must be a type without its own
+// UnmarshalJSON method, and keeps a copy of data in raw. It is used to
+// implement UnmarshalJSON for types with a Raw field.
+func unmarshalWithRaw(data []byte, v any, raw *json.RawMessage) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	*raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// hasRawField returns true if t is a struct with a Raw field that is filled in
+// by unmarshalWithRaw. The fields of these types are still checked even though
+// they implement json.Unmarshaler.
+func hasRawField(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	field, ok := t.FieldByName("Raw")
+	return ok && field.Type == rawFieldType
+}
+
+// missingFields returns the sorted paths of the fields of t that are not
+// present in the JSON body.
+func missingFields(body []byte, t reflect.Type) ([]string, error) {
+	var generic any
+	if err := json.Unmarshal(body, &generic); err != nil {
+		return nil, err
+	}
+
+	missing := make(map[string]bool)
+	collectMissingFields("", generic, t, missing)
+
+	paths := make([]string, 0, len(missing))
+	for p := range missing {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func collectMissingFields(path string, value any, t reflect.Type, missing map[string]bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if value == nil {
+		return
+	}
+	if !hasRawField(t) && (reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType)) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, ok := jsonFieldName(field)
+			if !ok {
+				continue
+			}
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			fieldValue, ok := lookupField(object, name)
+			if !ok {
+				missing[fieldPath] = true
+				continue
+			}
+			collectMissingFields(fieldPath, fieldValue, field.Type, missing)
+		}
+	case reflect.Slice, reflect.Array:
+		array, ok := value.([]any)
+		if !ok {
+			return
+		}
+		for _, element := range array {
+			collectMissingFields(path+"[]", element, t.Elem(), missing)
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return
+		}
+		for _, element := range object {
+			collectMissingFields(path+".*", element, t.Elem(), missing)
+		}
+	}
+}
+
+// jsonFieldName returns the name of the field in JSON, or false if the field
+// is not marshalled.
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// lookupField looks up the field in the same way as encoding/json, preferring
+// an exact match but falling back to a case insensitive match.
+func lookupField(object map[string]any, name string) (any, bool) {
+	if v, ok := object[name]; ok {
+		return v, true
+	}
+	for k, v := range object {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,264 @@
+package rawapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/anitschke/go-nixplay/httpx"
+)
+
+// Names of the form fields used to identify the container a photo is uploaded
+// to, see PhotoUploadRequest.
+const (
+	AlbumIDField    = "albumId"
+	PlaylistIDField = "playlistId"
+)
+
+// WebAlbums lists the albums that were created through the web app or API.
+func (c *Client) WebAlbums(ctx context.Context) ([]Album, error) {
+	return c.albums(ctx, "v2/albums/web/json/")
+}
+
+// EmailAlbums lists the albums that hold photos emailed to the account.
+func (c *Client) EmailAlbums(ctx context.Context) ([]Album, error) {
+	return c.albums(ctx, "v2/albums/email/json/")
+}
+
+func (c *Client) albums(ctx context.Context, path string) ([]Album, error) {
+	req, err := c.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var albums []Album
+	if err := c.DoJSON(req, &albums); err != nil {
+		return nil, err
+	}
+	return albums, nil
+}
+
+// CreateAlbum creates an album with the specified name. Nixplay does not
+// modify or validate the name so it should already be encoded, see the
+// encoding package.
+func (c *Client) CreateAlbum(ctx context.Context, name string) (Album, error) {
+	formData := url.Values{
+		"name": {name},
+	}
+	req, err := httpx.NewPostFormRequest(ctx, BaseURL+"/album/create/json/", formData)
+	if err != nil {
+		return Album{}, err
+	}
+
+	var albums []Album
+	if err := c.DoJSON(req, &albums); err != nil {
+		return Album{}, err
+	}
+	if len(albums) != 1 {
+		return Album{}, errors.New("incorrect number of created containers returned")
+	}
+	return albums[0], nil
+}
+
+// DeleteAlbum deletes the album and all of the photos in it.
+func (c *Client) DeleteAlbum(ctx context.Context, albumID uint64) error {
+	req, err := c.NewRequest(ctx, http.MethodPost, fmt.Sprintf("album/%d/delete/json/", albumID), nil)
+	if err != nil {
+		return err
+	}
+	return c.Do(req)
+}
+
+// AlbumPhotos lists a page of the photos in an album. Pages are numbered from 1
+// and limit is the maximum number of photos per page.
+func (c *Client) AlbumPhotos(ctx context.Context, albumID uint64, page uint64, limit uint64) ([]Picture, error) {
+	path := fmt.Sprintf("album/%d/pictures/json/?page=%d&limit=%d", albumID, page, limit)
+	req, err := c.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response albumPhotosResponse
+	if err := c.DoJSON(req, &response); err != nil {
+		return nil, err
+	}
+	return response.Photos, nil
+}
+
+// Picture gets a single photo by the ID Nixplay assigned to it.
+func (c *Client) Picture(ctx context.Context, pictureID uint64) (Picture, error) {
+	req, err := c.NewRequest(ctx, http.MethodGet, fmt.Sprintf("picture/%d/", pictureID), nil)
+	if err != nil {
+		return Picture{}, err
+	}
+
+	var picture Picture
+	if err := c.DoJSON(req, &picture); err != nil {
+		return Picture{}, err
+	}
+	return picture, nil
+}
+
+// DeletePicture deletes a photo from the album it resides in.
+func (c *Client) DeletePicture(ctx context.Context, pictureID uint64) error {
+	req, err := c.NewRequest(ctx, http.MethodPost, fmt.Sprintf("picture/%d/delete/json/", pictureID), nil)
+	if err != nil {
+		return err
+	}
+	return c.Do(req)
+}
+
+// Playlists lists all playlists.
+func (c *Client) Playlists(ctx context.Context) ([]Playlist, error) {
+	req, err := c.NewRequest(ctx, http.MethodGet, "v3/playlists", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var playlists []Playlist
+	if err := c.DoJSON(req, &playlists); err != nil {
+		return nil, err
+	}
+	return playlists, nil
+}
+
+// CreatePlaylist creates a playlist with the specified name and returns its ID.
+// Nixplay does not modify or validate the name so it should already be
+// encoded, see the encoding package.
+func (c *Client) CreatePlaylist(ctx context.Context, name string) (uint64, error) {
+	createBytes, err := json.Marshal(createPlaylistRequest{Name: name})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := c.NewRequest(ctx, http.MethodPost, "v3/playlists", bytes.NewReader(createBytes))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var response createPlaylistResponse
+	if err := c.DoJSON(req, &response); err != nil {
+		return 0, err
+	}
+	return response.PlaylistID, nil
+}
+
+// DeletePlaylist deletes the playlist. The photos in the playlist are not
+// deleted from the albums they reside in.
+func (c *Client) DeletePlaylist(ctx context.Context, playlistID uint64) error {
+	req, err := c.NewRequest(ctx, http.MethodDelete, fmt.Sprintf("v3/playlists/%d", playlistID), nil)
+	if err != nil {
+		return err
+	}
+	return c.Do(req)
+}
+
+// PlaylistSlides lists the slides in a playlist, starting at offset and
+// returning at most size slides.
+func (c *Client) PlaylistSlides(ctx context.Context, playlistID uint64, size uint64, offset uint64) ([]Slide, error) {
+	path := fmt.Sprintf("v3/playlists/%d/slides?size=%d&offset=%d", playlistID, size, offset)
+	req, err := c.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response playlistSlidesResponse
+	if err := c.DoJSON(req, &response); err != nil {
+		return nil, err
+	}
+	return response.Slides, nil
+}
+
+// AddPlaylistItems adds a slide to the end of a playlist for each of the
+// pictures with the specified IDs. The pictures are not copied, the slides
+// refer to the pictures in the albums they reside in.
+func (c *Client) AddPlaylistItems(ctx context.Context, playlistID uint64, pictureIDs []uint64) error {
+	items := make([]playlistItem, 0, len(pictureIDs))
+	for _, id := range pictureIDs {
+		items = append(items, playlistItem{PictureID: id})
+	}
+	addBytes, err := json.Marshal(addPlaylistItemsRequest{Items: items})
+	if err != nil {
+		return err
+	}
+
+	req, err := c.NewRequest(ctx, http.MethodPost, fmt.Sprintf("v3/playlists/%d/items", playlistID), bytes.NewReader(addBytes))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.Do(req)
+}
+
+// DeletePlaylistItem removes a slide from a playlist.
+func (c *Client) DeletePlaylistItem(ctx context.Context, playlistID uint64, playlistItemID string) error {
+	path := fmt.Sprintf("v3/playlists/%d/items?id=%s", playlistID, url.QueryEscape(playlistItemID))
+	req, err := c.NewRequest(ctx, http.MethodDelete, path, bytes.NewReader([]byte{}))
+	if err != nil {
+		return err
+	}
+	return c.Do(req)
+}
+
+// UploadToken gets a token that is needed to upload a photo to the container
+// identified by idField and id, see PhotoUploadRequest.
+func (c *Client) UploadToken(ctx context.Context, idField string, id string) (string, error) {
+	form := url.Values{
+		idField: {id},
+		"total": {"1"},
+	}
+
+	req, err := httpx.NewPostFormRequest(ctx, BaseURL+"/v3/upload/receivers/", form)
+	if err != nil {
+		return "", err
+	}
+
+	var response uploadTokenResponse
+	if err := c.DoJSON(req, &response); err != nil {
+		return "", err
+	}
+	return response.Token, nil
+}
+
+// PhotoUpload tells Nixplay about a photo that is about to be uploaded and
+// returns the details needed to upload its content to S3.
+func (c *Client) PhotoUpload(ctx context.Context, photo PhotoUploadRequest) (PhotoUploadResponse, error) {
+	form := url.Values{
+		photo.IDField: {photo.ID},
+		"uploadToken": {photo.UploadToken},
+		"fileName":    {photo.FileName},
+		"fileType":    {photo.FileType},
+		"fileSize":    {strconv.FormatInt(photo.FileSize, 10)},
+	}
+
+	req, err := httpx.NewPostFormRequest(ctx, BaseURL+"/v3/photo/upload/", form)
+	if err != nil {
+		return PhotoUploadResponse{}, err
+	}
+
+	var response photoUploadResponseContainer
+	if err := c.DoJSON(req, &response); err != nil {
+		return PhotoUploadResponse{}, err
+	}
+	return response.Data, nil
+}
+
+// Profile gets the profile of the signed in account.
+func (c *Client) Profile(ctx context.Context) (Profile, error) {
+	req, err := c.NewRequest(ctx, http.MethodGet, "user/profile/edit/", nil)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	var profile Profile
+	if err := c.DoJSON(req, &profile); err != nil {
+		return Profile{}, err
+	}
+	return profile, nil
+}
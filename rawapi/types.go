@@ -0,0 +1,151 @@
+package rawapi
+
+import (
+	"encoding/json"
+
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// This file contains types to support marshalling requests to and unmarshalling
+// responses from Nixplay. Only the fields that are known to be useful are
+// included, Nixplay returns many more.
+
+// Album is an album as returned by the album endpoints.
+type Album struct {
+	PhotoCount int64  `json:"photo_count"`
+	Title      string `json:"title"`
+	ID         uint64 `json:"id"`
+
+	// Raw is the JSON object the album was decoded from, including the fields
+	// that are not declared above.
+	Raw json.RawMessage `json:"-"`
+}
+
+func (a *Album) UnmarshalJSON(data []byte) error {
+	type plain Album
+	return unmarshalWithRaw(data, (*plain)(a), &a.Raw)
+}
+
+// Playlist is a playlist as returned by the playlist endpoints.
+type Playlist struct {
+	PictureCount int64  `json:"picture_count"`
+	Name         string `json:"name"`
+	ID           uint64 `json:"id"`
+
+	// Raw is the JSON object the playlist was decoded from, including the
+	// fields that are not declared above.
+	Raw json.RawMessage `json:"-"`
+}
+
+func (p *Playlist) UnmarshalJSON(data []byte) error {
+	type plain Playlist
+	return unmarshalWithRaw(data, (*plain)(p), &p.Raw)
+}
+
+type createPlaylistRequest struct {
+	Name string `json:"name"`
+}
+
+type createPlaylistResponse struct {
+	PlaylistID uint64 `json:"playlistId"`
+}
+
+type albumPhotosResponse struct {
+	Photos []Picture `json:"photos"`
+}
+
+// Picture is a photo in an album as returned by the album photos and picture
+// endpoints.
+type Picture struct {
+	FileName string        `json:"filename"`
+	ID       uint64        `json:"id"`
+	MD5      types.MD5Hash `json:"md5"`
+	URL      string        `json:"url"`
+
+	// Raw is the JSON object the picture was decoded from, including the
+	// fields that are not declared above.
+	Raw json.RawMessage `json:"-"`
+}
+
+func (p *Picture) UnmarshalJSON(data []byte) error {
+	type plain Picture
+	return unmarshalWithRaw(data, (*plain)(p), &p.Raw)
+}
+
+type playlistSlidesResponse struct {
+	Slides []Slide `json:"slides"`
+}
+
+// Slide is a photo in a playlist as returned by the playlist slides endpoint.
+type Slide struct {
+	// ID is the ID of the picture that the slide shows.
+	ID uint64 `json:"dbId"`
+
+	// PlaylistItemID is the ID of the slide within the playlist.
+	PlaylistItemID string `json:"playlistItemId"`
+
+	URL string `json:"originalUrl"`
+
+	// Raw is the JSON object the slide was decoded from, including the fields
+	// that are not declared above.
+	Raw json.RawMessage `json:"-"`
+}
+
+func (s *Slide) UnmarshalJSON(data []byte) error {
+	type plain Slide
+	return unmarshalWithRaw(data, (*plain)(s), &s.Raw)
+}
+
+type addPlaylistItemsRequest struct {
+	Items []playlistItem `json:"items"`
+}
+
+type playlistItem struct {
+	PictureID uint64 `json:"pictureId"`
+}
+
+type uploadTokenResponse struct {
+	Token string `json:"token"`
+}
+
+// PhotoUploadRequest describes a photo that is about to be uploaded.
+type PhotoUploadRequest struct {
+	// IDField is the name of the form field that identifies the container the
+	// photo is added to, either AlbumIDField or PlaylistIDField.
+	IDField string
+
+	// ID is the Nixplay ID of the container the photo is added to.
+	ID string
+
+	// UploadToken is the token returned by UploadToken.
+	UploadToken string
+
+	FileName string
+	FileType string
+	FileSize int64
+}
+
+type photoUploadResponseContainer struct {
+	Data PhotoUploadResponse `json:"data"`
+}
+
+// PhotoUploadResponse contains the details needed to upload the content of a
+// photo to S3.
+type PhotoUploadResponse struct {
+	ACL            string   `json:"acl"`
+	Key            string   `json:"key"`
+	AWSAccessKeyID string   `json:"AWSAccessKeyId"`
+	Policy         string   `json:"Policy"`
+	Signature      string   `json:"Signature"`
+	BatchUploadID  string   `json:"batchUploadId"`
+	UserUploadIDs  []string `json:"userUploadIds"`
+	FileType       string   `json:"fileType"`
+	S3UploadURL    string   `json:"s3UploadUrl"`
+}
+
+// Profile is the profile of the signed in account.
+type Profile struct {
+	// OldUsername is the ${username}@mynixplay.com email address of the
+	// account.
+	OldUsername string `json:"old_username"`
+}
@@ -2,68 +2,45 @@ package nixplay
 
 import (
 	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/rawapi"
 	"github.com/anitschke/go-nixplay/types"
 )
 
-// This file contains types to support unmarshalling all of the responses we get
-// back from Nixplay
+// This file contains functions to convert the responses we get back from
+// Nixplay through the rawapi package into the types of this package.
 
-type albumsResponse []nixplayAlbum
-
-func (albums albumsResponse) ToContainers(client httpx.Client, nixplayClient Client) []Container {
+func albumsToContainers(albums []rawapi.Album, client httpx.Client, nixplayClient Client, settings *clientSettings) []Container {
 	containers := make([]Container, 0, len(albums))
 	for _, a := range albums {
-		containers = append(containers, a.ToContainer(client, nixplayClient))
+		containers = append(containers, albumToContainer(a, client, nixplayClient, settings))
 	}
 	return containers
 }
 
-type nixplayAlbum struct {
-	PhotoCount int64  `json:"photo_count"`
-	Title      string `json:"title"`
-	ID         uint64 `json:"id"`
-}
-
-func (a nixplayAlbum) ToContainer(client httpx.Client, nixplayClient Client) Container {
-	return newAlbum(client, nixplayClient, a.Title, a.ID, a.PhotoCount)
+func albumToContainer(a rawapi.Album, client httpx.Client, nixplayClient Client, settings *clientSettings) Container {
+	c := newAlbum(client, nixplayClient, settings, a.Title, a.ID, a.PhotoCount)
+	c.raw = a.Raw
+	return c
 }
 
-type playlistsResponse []playlistResponse
-
-func (playlists playlistsResponse) ToContainers(client httpx.Client, nixplayClient Client) []Container {
+func playlistsToContainers(playlists []rawapi.Playlist, client httpx.Client, nixplayClient Client, settings *clientSettings) []Container {
 	containers := make([]Container, 0, len(playlists))
 	for _, p := range playlists {
-		containers = append(containers, p.ToContainer(client, nixplayClient))
+		containers = append(containers, playlistToContainer(p, client, nixplayClient, settings))
 	}
 	return containers
 }
 
-type playlistResponse struct {
-	PictureCount int64  `json:"picture_count"`
-	Name         string `json:"name"`
-	ID           uint64 `json:"id"`
+func playlistToContainer(p rawapi.Playlist, client httpx.Client, nixplayClient Client, settings *clientSettings) Container {
+	c := newPlaylist(client, nixplayClient, settings, p.Name, p.ID, p.PictureCount)
+	c.raw = p.Raw
+	return c
 }
 
-func (p playlistResponse) ToContainer(client httpx.Client, nixplayClient Client) Container {
-	return newPlaylist(client, nixplayClient, p.Name, p.ID, p.PictureCount)
-}
-
-type createPlaylistRequest struct {
-	Name string `json:"name"`
-}
-
-type createPlaylistResponse struct {
-	PlaylistId uint64 `json:"playlistId"`
-}
-
-type albumPhotosResponse struct {
-	Photos []nixplayAlbumPhoto `json:"photos"`
-}
-
-func (resp albumPhotosResponse) ToPhotos(album Container, client httpx.Client) ([]Photo, error) {
-	photos := make([]Photo, 0, len(resp.Photos))
-	for _, p := range resp.Photos {
-		asPhoto, err := p.ToPhoto(album, client)
+func picturesToPhotos(pictures []rawapi.Picture, album Container, client httpx.Client) ([]Photo, error) {
+	photos := make([]Photo, 0, len(pictures))
+	for _, p := range pictures {
+		asPhoto, err := pictureToPhoto(p, album, client)
 		if err != nil {
 			return nil, err
 		}
@@ -72,27 +49,21 @@ func (resp albumPhotosResponse) ToPhotos(album Container, client httpx.Client) (
 	return photos, nil
 }
 
-type nixplayAlbumPhoto struct {
-	FileName string        `json:"filename"`
-	ID       uint64        `json:"id"`
-	MD5      types.MD5Hash `json:"md5"`
-	URL      string        `json:"url"`
-}
-
-func (p nixplayAlbumPhoto) ToPhoto(album Container, client httpx.Client) (Photo, error) {
+func pictureToPhoto(p rawapi.Picture, album Container, client httpx.Client) (*photo, error) {
 	size := int64(-1)
 	nixplayPlaylistItemID := ""
-	return newPhoto(album, client, p.FileName, &p.MD5, p.ID, nixplayPlaylistItemID, size, p.URL)
-}
-
-type playlistPhotosResponse struct {
-	Photos []nixplayPlaylistPhoto `json:"slides"`
+	photo, err := newPhoto(album, client, p.FileName, &p.MD5, p.ID, nixplayPlaylistItemID, size, p.URL)
+	if err != nil {
+		return nil, err
+	}
+	photo.state.raw = p.Raw
+	return photo, nil
 }
 
-func (resp playlistPhotosResponse) ToPhotos(album Container, client httpx.Client) ([]Photo, error) {
-	photos := make([]Photo, 0, len(resp.Photos))
-	for _, p := range resp.Photos {
-		asPhoto, err := p.ToPhoto(album, client)
+func slidesToPhotos(slides []rawapi.Slide, playlist Container, client httpx.Client) ([]Photo, error) {
+	photos := make([]Photo, 0, len(slides))
+	for _, s := range slides {
+		asPhoto, err := slideToPhoto(s, playlist, client)
 		if err != nil {
 			return nil, err
 		}
@@ -101,35 +72,20 @@ func (resp playlistPhotosResponse) ToPhotos(album Container, client httpx.Client
 	return photos, nil
 }
 
-type nixplayPlaylistPhoto struct {
-	ID             uint64 `json:"dbId"`
-	PlaylistItemID string `json:"playlistItemId"`
-	URL            string `json:"originalUrl"`
-}
-
-func (p nixplayPlaylistPhoto) ToPhoto(playlist Container, client httpx.Client) (Photo, error) {
+func slideToPhoto(s rawapi.Slide, playlist Container, client httpx.Client) (*photo, error) {
 	name := ""
 	var md5Hash *types.MD5Hash
 	size := int64(-1)
-	return newPhoto(playlist, client, name, md5Hash, p.ID, p.PlaylistItemID, size, p.URL)
-}
-
-type uploadTokenResponse struct {
-	Token string `json:"token"`
-}
-
-type uploadNixplayResponseContainer struct {
-	Data uploadNixplayResponse `json:"data"`
+	photo, err := newPhoto(playlist, client, name, md5Hash, s.ID, s.PlaylistItemID, size, s.URL)
+	if err != nil {
+		return nil, err
+	}
+	photo.state.raw = s.Raw
+	return photo, nil
 }
 
-type uploadNixplayResponse struct {
-	ACL            string   `json:"acl"`
-	Key            string   `json:"key"`
-	AWSAccessKeyID string   `json:"AWSAccessKeyId"`
-	Policy         string   `json:"Policy"`
-	Signature      string   `json:"Signature"`
-	BatchUploadID  string   `json:"batchUploadId"`
-	UserUploadIDs  []string `json:"userUploadIds"`
-	FileType       string   `json:"fileType"`
-	S3UploadURL    string   `json:"s3UploadUrl"`
+func profileToProfile(p rawapi.Profile) types.Profile {
+	return types.Profile{
+		MyNixplayAddress: p.OldUsername,
+	}
 }
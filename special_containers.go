@@ -0,0 +1,140 @@
+package nixplay
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// specialContainer identifies the albums and playlists that Nixplay creates in
+// every account.
+type specialContainer int
+
+const (
+	notSpecialContainer specialContainer = iota
+
+	// emailSpecialAlbum is the album that holds photos that are emailed to
+	// the ${username}@mynixplay.com address of the account.
+	emailSpecialAlbum
+
+	// myUploadsSpecialAlbum is the album that photos uploaded to playlists
+	// are added to.
+	myUploadsSpecialAlbum
+
+	// emailSpecialPlaylist is the playlist that photos emailed to the
+	// ${username}@mynixplay.com address of the account are shown in. It has
+	// the same name as the email album.
+	emailSpecialPlaylist
+
+	// favoritesSpecialPlaylist is the playlist that holds the photos that are
+	// marked as favorites.
+	favoritesSpecialPlaylist
+)
+
+func (s specialContainer) String() string {
+	switch s {
+	case emailSpecialAlbum:
+		return "email album"
+	case myUploadsSpecialAlbum:
+		return "My Uploads album"
+	case emailSpecialPlaylist:
+		return "email playlist"
+	case favoritesSpecialPlaylist:
+		return "Favorites playlist"
+	}
+	return "container"
+}
+
+// myUploadsAlbumName and favoritesPlaylistName are the names that Nixplay
+// gives the "My Uploads" album and the favorites playlist when it creates them
+// with the account. See the README for why these names are needed.
+const (
+	myUploadsAlbumName    = "My Uploads"
+	favoritesPlaylistName = "Favorites"
+)
+
+// markSpecialAlbums marks the special albums among the albums of the account.
+//
+// Every album returned by the email albums endpoint is the email album. The
+// "My Uploads" album is created along with the account, so it is the web album
+// with the lowest ID. It is only marked if it also has the name Nixplay gives
+// it, so that an album created by the user is never mistaken for it.
+func markSpecialAlbums(webAlbums []Container, emailAlbums []Container) {
+	for _, a := range emailAlbums {
+		if c, ok := a.(*container); ok {
+			c.special = emailSpecialAlbum
+		}
+	}
+	markFirstCreated(webAlbums, myUploadsAlbumName, myUploadsSpecialAlbum)
+}
+
+// markSpecialPlaylists marks the special playlists among the playlists of the
+// account, emailAlbums are the albums returned by the email albums endpoint.
+//
+// The email playlist is the playlist with the same name as an email album.
+// Like "My Uploads" the favorites playlist is created along with the account,
+// so it is the playlist other than the email playlist with the lowest ID and
+// is only marked if it also has the name Nixplay gives it.
+func markSpecialPlaylists(playlists []Container, emailAlbums []Container) {
+	emailNames := make(map[string]bool, len(emailAlbums))
+	for _, a := range emailAlbums {
+		if c, ok := a.(*container); ok {
+			emailNames[c.name] = true
+		}
+	}
+
+	var others []Container
+	for _, p := range playlists {
+		c, ok := p.(*container)
+		if ok && emailNames[c.name] {
+			c.special = emailSpecialPlaylist
+			continue
+		}
+		others = append(others, p)
+	}
+	markFirstCreated(others, favoritesPlaylistName, favoritesSpecialPlaylist)
+}
+
+// markFirstCreated marks the container with the lowest ID in containers as
+// special if it has the given name.
+func markFirstCreated(containers []Container, name string, special specialContainer) {
+	var first *container
+	for _, ct := range containers {
+		c, ok := ct.(*container)
+		if !ok {
+			continue
+		}
+		if first == nil || c.nixplayID < first.nixplayID {
+			first = c
+		}
+	}
+	if first != nil && first.name == name {
+		first.special = special
+	}
+}
+
+func (c *DefaultClient) MyUploads(ctx context.Context) (Container, error) {
+	return c.specialContainer(ctx, types.AlbumContainerType, myUploadsSpecialAlbum)
+}
+
+func (c *DefaultClient) EmailAlbum(ctx context.Context) (Container, error) {
+	return c.specialContainer(ctx, types.AlbumContainerType, emailSpecialAlbum)
+}
+
+func (c *DefaultClient) Favorites(ctx context.Context) (Container, error) {
+	return c.specialContainer(ctx, types.PlaylistContainerType, favoritesSpecialPlaylist)
+}
+
+func (c *DefaultClient) specialContainer(ctx context.Context, containerType types.ContainerType, special specialContainer) (Container, error) {
+	containers, err := c.Containers(ctx, containerType)
+	if err != nil {
+		return nil, err
+	}
+	for _, ct := range containers {
+		if sc, ok := ct.(*container); ok && sc.special == special {
+			return ct, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", special, types.ErrNotFound)
+}
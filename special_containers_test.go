@@ -0,0 +1,155 @@
+package nixplay
+
+import (
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/internal/cache"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecialContainers(t *testing.T) {
+	ctx := context.Background()
+
+	newClient := func(webAlbums string, playlists string) *DefaultClient {
+		client := clientFunc(func(req *http.Request) (*http.Response, error) {
+			var body string
+			switch req.URL.Path {
+			case "/v2/albums/web/json/":
+				body = webAlbums
+			case "/v2/albums/email/json/":
+				// The email album is found by the endpoint, not by its name.
+				body = `[{"id":5,"title":"user@mynixplay.com","photo_count":0}]`
+			case "/v3/playlists":
+				body = playlists
+			default:
+				require.Fail(t, "unexpected request", req.URL.String())
+			}
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}, nil
+		})
+		c := &DefaultClient{
+			client: client,
+			settings: &clientSettings{
+				metrics: nopMetrics{},
+				changes: &changeNotifier{},
+			},
+		}
+		c.albumCache = cache.NewCache(c.albumsPage)
+		c.playlistCache = cache.NewCache(c.playlistsPage)
+		return c
+	}
+	special := func(t *testing.T, c *DefaultClient, containerType types.ContainerType) map[uint64]specialContainer {
+		containers, err := c.Containers(ctx, containerType)
+		require.NoError(t, err)
+		specials := make(map[uint64]specialContainer)
+		for _, ct := range containers {
+			if sc := ct.(*container).special; sc != notSpecialContainer {
+				specials[ct.(*container).nixplayID] = sc
+			}
+		}
+		return specials
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		// A user created album or playlist can share the name of the special
+		// one, but it is created later so it has a higher ID.
+		c := newClient(
+			`[{"id":30,"title":"My Uploads","photo_count":0},{"id":10,"title":"My Uploads","photo_count":0},{"id":20,"title":"album","photo_count":0}]`,
+			`[{"id":60,"name":"Favorites","picture_count":0},{"id":50,"name":"user@mynixplay.com","picture_count":0},{"id":51,"name":"Favorites","picture_count":0},{"id":70,"name":"playlist","picture_count":0}]`,
+		)
+
+		myUploads, err := c.MyUploads(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(10), myUploads.(*container).nixplayID)
+		assert.ErrorIs(t, myUploads.Delete(ctx, DeleteOptions{}), types.ErrSpecialContainer)
+
+		email, err := c.EmailAlbum(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(5), email.(*container).nixplayID)
+		assert.ErrorIs(t, email.Delete(ctx, DeleteOptions{}), types.ErrSpecialContainer)
+
+		assert.Equal(t, map[uint64]specialContainer{10: myUploadsSpecialAlbum, 5: emailSpecialAlbum}, special(t, c, types.AlbumContainerType))
+		assert.Equal(t, map[uint64]specialContainer{50: emailSpecialPlaylist, 51: favoritesSpecialPlaylist}, special(t, c, types.PlaylistContainerType))
+
+		playlists, err := c.Containers(ctx, types.PlaylistContainerType)
+		require.NoError(t, err)
+		for _, p := range playlists {
+			if p.(*container).special != notSpecialContainer {
+				assert.ErrorIs(t, p.Delete(ctx, DeleteOptions{}), types.ErrSpecialContainer)
+			}
+		}
+	})
+
+	t.Run("Localized", func(t *testing.T) {
+		// The special album and playlist have other names, so the user
+		// created ones with the English names must not be mistaken for them.
+		c := newClient(
+			`[{"id":10,"title":"Mes envois","photo_count":0},{"id":20,"title":"My Uploads","photo_count":0}]`,
+			`[{"id":50,"name":"user@mynixplay.com","picture_count":0},{"id":51,"name":"Favoris","picture_count":0},{"id":60,"name":"Favorites","picture_count":0}]`,
+		)
+
+		_, err := c.MyUploads(ctx)
+		assert.ErrorIs(t, err, types.ErrNotFound)
+		assert.Equal(t, map[uint64]specialContainer{5: emailSpecialAlbum}, special(t, c, types.AlbumContainerType))
+		assert.Equal(t, map[uint64]specialContainer{50: emailSpecialPlaylist}, special(t, c, types.PlaylistContainerType))
+	})
+}
+
+func TestSkipExisting_MyUploads(t *testing.T) {
+	ctx := context.Background()
+
+	content := "photo content"
+	h := types.MD5Hash(md5.Sum([]byte(content)))
+	var added []string
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		var body string
+		switch {
+		case req.URL.Path == "/v2/albums/web/json/":
+			body = `[{"id":10,"title":"My Uploads","photo_count":1}]`
+		case req.URL.Path == "/v2/albums/email/json/":
+			body = `[]`
+		case req.URL.Path == "/album/10/pictures/json/" && req.URL.Query().Get("page") == "1":
+			body = fmt.Sprintf(`{"photos":[{"filename":"photo.jpg","id":7,"md5":"%s","url":"u"}]}`, h)
+		case req.URL.Path == "/album/10/pictures/json/":
+			body = `{"photos":[]}`
+		case req.URL.Path == "/v3/playlists/50/slides":
+			body = `{"slides":[]}`
+		case req.Method == http.MethodPost && req.URL.Path == "/v3/playlists/50/items":
+			data, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			added = append(added, string(data))
+		default:
+			require.Fail(t, "unexpected request", req.URL.String())
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})
+
+	c := &DefaultClient{
+		client: client,
+		settings: &clientSettings{
+			metrics: nopMetrics{},
+			changes: &changeNotifier{},
+		},
+	}
+	c.albumCache = cache.NewCache(c.albumsPage)
+	playlist := newPlaylist(client, c, c.settings, "playlist", 50, 0)
+
+	// The photo is not in the playlist but it is in "My Uploads", so it is
+	// linked into the playlist rather than uploaded again.
+	p, err := playlist.AddPhoto(ctx, "photo.jpg", strings.NewReader(content), AddPhotoOptions{SkipExisting: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{`{"items":[{"pictureId":7}]}`}, added)
+	md5Hash, err := p.MD5Hash(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, h, md5Hash)
+	name, err := p.Name(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "photo.jpg", name)
+}
@@ -0,0 +1,53 @@
+package nixplay
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Timeouts are timeouts that are applied to requests made to Nixplay. A zero
+// value for any timeout means that no timeout is applied beyond whatever
+// deadline the caller's context may already have.
+type Timeouts struct {
+	// Metadata is the timeout applied to each request that reads or modifies
+	// metadata, such as listing, creating or deleting containers and photos.
+	Metadata time.Duration
+
+	// Upload is the timeout applied to uploading the content of a single photo.
+	Upload time.Duration
+
+	// Download is the timeout applied to downloading the content of a single
+	// photo. This includes the time spent reading from the io.ReadCloser
+	// returned by Photo.Open.
+	Download time.Duration
+
+	// UploadMonitor is the timeout applied to each request made when waiting
+	// for Nixplay to finish processing a photo after it has been uploaded. See
+	// UploadMonitorOptions for how long to keep waiting in total.
+	UploadMonitor time.Duration
+}
+
+// withTimeout returns a copy of the context with the provided timeout applied.
+// If timeout is zero then the context is returned without an additional
+// deadline. Like context.WithTimeout the returned cancel function must always
+// be called.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// cancelOnClose is an io.ReadCloser that cancels a context when it is closed.
+// This is used to keep the context of a request alive until the caller is done
+// reading the response body.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
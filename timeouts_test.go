@@ -0,0 +1,150 @@
+package nixplay
+
+import (
+	"context"
+	"crypto/md5"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/rawapi"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingClient is a httpx.Client that never responds, it blocks until the
+// context of the request is done. The time remaining until the deadline of the
+// last request is recorded in remaining.
+type blockingClient struct {
+	remaining time.Duration
+}
+
+func (c *blockingClient) Do(req *http.Request) (*http.Response, error) {
+	if deadline, ok := req.Context().Deadline(); ok {
+		c.remaining = time.Until(deadline)
+	}
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+func TestTimeouts(t *testing.T) {
+	ctx := context.Background()
+
+	const short = 20 * time.Millisecond
+	content := "photo content"
+	h := types.MD5Hash(md5.Sum([]byte(content)))
+	url := "https://s3.example.com/1/1_" + h.String() + ".jpg"
+
+	// Each operation is only limited by its own timeout, the other timeouts
+	// are long enough that the test would time out if they were used.
+	onlyTimeout := func(timeouts Timeouts) Timeouts {
+		if timeouts.Metadata == 0 {
+			timeouts.Metadata = time.Hour
+		}
+		if timeouts.Upload == 0 {
+			timeouts.Upload = time.Hour
+		}
+		if timeouts.Download == 0 {
+			timeouts.Download = time.Hour
+		}
+		return timeouts
+	}
+	testPhoto := func(t *testing.T, client httpx.Client, timeouts Timeouts) *photo {
+		settings := &clientSettings{
+			metrics:  nopMetrics{},
+			changes:  &changeNotifier{},
+			timeouts: timeouts,
+		}
+		pageFunc := func(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error) {
+			return nil, nil
+		}
+		c := newContainer(client, nil, settings, types.AlbumContainerType, "album", 1234, 0, pageFunc, (*rawapi.Client).DeleteAlbum, albumAddIDName)
+		p, err := newPhoto(c, client, "photo.jpg", &h, 7, "", int64(len(content)), url)
+		require.NoError(t, err)
+		return p
+	}
+
+	t.Run("Metadata", func(t *testing.T) {
+		blocking := &blockingClient{}
+		client, err := NewDefaultClient(ctx, types.Authorization{Session: &types.Session{Token: "token", CSRFToken: "csrf"}}, DefaultClientOptions{
+			HTTPClient: blocking,
+			Timeouts:   onlyTimeout(Timeouts{Metadata: short}),
+		})
+		require.NoError(t, err)
+
+		_, err = client.Containers(ctx, types.AlbumContainerType)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.LessOrEqual(t, blocking.remaining, short)
+	})
+
+	t.Run("Upload", func(t *testing.T) {
+		blocking := &blockingClient{}
+		u := rawapi.PhotoUploadResponse{S3UploadURL: "https://s3.example.com/upload"}
+		_, err := uploadS3(ctx, blocking, onlyTimeout(Timeouts{Upload: short}), u, "photo.jpg", strings.NewReader(content), int64(len(content)))
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.LessOrEqual(t, blocking.remaining, short)
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		blocking := &blockingClient{}
+		p := testPhoto(t, blocking, onlyTimeout(Timeouts{Download: short}))
+		_, err := p.Open(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.LessOrEqual(t, blocking.remaining, short)
+	})
+
+	t.Run("DownloadIncludesReadingBody", func(t *testing.T) {
+		// The body never ends, so reading it must be stopped by the download
+		// timeout.
+		client := clientFunc(func(req *http.Request) (*http.Response, error) {
+			body := &blockingBody{ctx: req.Context()}
+			return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: body}, nil
+		})
+		p := testPhoto(t, client, onlyTimeout(Timeouts{Download: short}))
+		r, err := p.Open(ctx)
+		require.NoError(t, err)
+		defer r.Close()
+		_, err = io.ReadAll(r)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("CloseReleasesContext", func(t *testing.T) {
+		for _, download := range []time.Duration{0, time.Hour} {
+			var reqCtx context.Context
+			client := clientFunc(func(req *http.Request) (*http.Response, error) {
+				reqCtx = req.Context()
+				return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(content))}, nil
+			})
+			p := testPhoto(t, client, Timeouts{Download: download})
+			r, err := p.Open(ctx)
+			require.NoError(t, err)
+
+			// The context must stay alive while the body is read.
+			require.NoError(t, reqCtx.Err())
+			_, err = io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, reqCtx.Err())
+
+			require.NoError(t, r.Close())
+			assert.ErrorIs(t, reqCtx.Err(), context.Canceled)
+		}
+	})
+}
+
+// blockingBody is a response body that blocks until ctx is done.
+type blockingBody struct {
+	ctx context.Context
+}
+
+func (b *blockingBody) Read(p []byte) (int, error) {
+	<-b.ctx.Done()
+	return 0, b.ctx.Err()
+}
+
+func (b *blockingBody) Close() error {
+	return nil
+}
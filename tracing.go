@@ -0,0 +1,36 @@
+package nixplay
+
+import (
+	"context"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// Names of the attributes that are attached to traced requests. See
+// DefaultClientOptions.Tracer.
+const (
+	attrContainerType = "nixplay.container.type"
+	attrContainerID   = "nixplay.container.id"
+	attrPhotoSize     = "nixplay.photo.size"
+)
+
+// withContainerOperation records the operation name along with the identity
+// of the container the operation is acting on so it can be attached to any
+// traced requests.
+func withContainerOperation(ctx context.Context, operation string, containerType types.ContainerType, nixplayID uint64) context.Context {
+	ctx = httpx.WithOperation(ctx, operation)
+	return httpx.WithAttributes(ctx,
+		httpx.Attribute{Key: attrContainerType, Value: string(containerType)},
+		httpx.Attribute{Key: attrContainerID, Value: nixplayID},
+	)
+}
+
+// withOperation records the operation name along with the identity of the
+// container the photo resides in so it can be attached to any traced requests.
+func (p *photo) withOperation(ctx context.Context, operation string) context.Context {
+	if c, ok := p.container.(*container); ok {
+		return withContainerOperation(ctx, operation, c.containerType, c.nixplayID)
+	}
+	return httpx.WithOperation(ctx, operation)
+}
@@ -0,0 +1,206 @@
+package nixplay
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/anitschke/go-nixplay/internal/errorx"
+	"github.com/anitschke/go-nixplay/internal/mime"
+)
+
+// resizeJPEGQuality is the quality used when re-encoding JPEG photos that have
+// been resized by ResizeTransform.
+const resizeJPEGQuality = 90
+
+// UploadContent is the content of a photo that is about to be uploaded to
+// Nixplay. It is the input and output of a PhotoTransform.
+type UploadContent struct {
+	// Name of the photo.
+	Name string
+
+	// MIMEType of the photo.
+	MIMEType string
+
+	// Reader that provides the content of the photo.
+	Reader io.Reader
+
+	// FileSize in bytes of the photo, or 0 if the size is not known. A
+	// PhotoTransform that changes the content of the photo must also update
+	// FileSize.
+	FileSize int64
+}
+
+// PhotoTransform is a function that transforms a photo before it is uploaded
+// to Nixplay, for example to downscale large photos to the resolution of the
+// frame or to convert the photo to a different format. A PhotoTransform that
+// does not need to change the photo should return the provided UploadContent
+// unchanged.
+//
+// For example HEIC photos can be converted to JPEG by a PhotoTransform that
+// uses a third party HEIC decoder, and that updates Name, MIMEType, Reader and
+// FileSize.
+type PhotoTransform func(ctx context.Context, content UploadContent) (UploadContent, error)
+
+// applyTransforms applies AddPhotoOptions.Transforms to the photo and returns
+// the name, reader and options that should be used to upload the transformed
+// photo.
+func applyTransforms(ctx context.Context, name string, r io.Reader, opts AddPhotoOptions) (retName string, retR io.Reader, retOpts AddPhotoOptions, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	if len(opts.Transforms) == 0 {
+		return name, r, opts, nil
+	}
+
+	content := UploadContent{
+		Name:     name,
+		MIMEType: opts.MIMEType,
+		Reader:   r,
+		FileSize: opts.FileSize,
+	}
+	if content.MIMEType == "" {
+		content.MIMEType = mime.TypeByFileName(name)
+	}
+
+	for _, t := range opts.Transforms {
+		content, err = t(ctx, content)
+		if err != nil {
+			return "", nil, AddPhotoOptions{}, err
+		}
+	}
+
+	opts.MIMEType = content.MIMEType
+	opts.FileSize = content.FileSize
+	return content.Name, content.Reader, opts, nil
+}
+
+// ResizeTransform returns a PhotoTransform that downscales JPEG and PNG photos
+// so that they fit within maxWidth by maxHeight pixels while preserving the
+// aspect ratio. Photos that already fit, and photos of other types, are left
+// unchanged.
+//
+// Nixplay frames display photos at roughly 2K resolution so downscaling large
+// photos before uploading saves both upload time and account storage. Note
+// that resized JPEG photos are re-encoded without their original metadata,
+// such as EXIF orientation.
+func ResizeTransform(maxWidth, maxHeight int) PhotoTransform {
+	return func(ctx context.Context, content UploadContent) (retContent UploadContent, err error) {
+		defer errorx.WrapWithFuncNameIfError(&err)
+
+		var encode func(w io.Writer, img image.Image) error
+		switch content.MIMEType {
+		case "image/jpeg":
+			encode = func(w io.Writer, img image.Image) error {
+				return jpeg.Encode(w, img, &jpeg.Options{Quality: resizeJPEGQuality})
+			}
+		case "image/png":
+			encode = png.Encode
+		default:
+			return content, nil
+		}
+
+		data, err := io.ReadAll(content.Reader)
+		if err != nil {
+			return UploadContent{}, err
+		}
+		content.Reader = bytes.NewReader(data)
+		content.FileSize = int64(len(data))
+
+		config, _, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return UploadContent{}, err
+		}
+		width, height := fitWithin(config.Width, config.Height, maxWidth, maxHeight)
+		if width == config.Width && height == config.Height {
+			return content, nil
+		}
+
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return UploadContent{}, err
+		}
+
+		var buf bytes.Buffer
+		if err := encode(&buf, downscale(img, width, height)); err != nil {
+			return UploadContent{}, err
+		}
+		content.Reader = bytes.NewReader(buf.Bytes())
+		content.FileSize = int64(buf.Len())
+		return content, nil
+	}
+}
+
+// fitWithin returns the largest size with the same aspect ratio as width by
+// height that fits within maxWidth by maxHeight. If the size already fits then
+// it is returned unchanged. A maxWidth or maxHeight that is not positive means
+// there is no limit in that dimension.
+func fitWithin(width, height, maxWidth, maxHeight int) (int, int) {
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && height > maxHeight {
+		if s := float64(maxHeight) / float64(height); s < scale {
+			scale = s
+		}
+	}
+	if scale == 1.0 {
+		return width, height
+	}
+
+	newWidth := int(float64(width)*scale + 0.5)
+	newHeight := int(float64(height)*scale + 0.5)
+	if newWidth < 1 {
+		newWidth = 1
+	}
+	if newHeight < 1 {
+		newHeight = 1
+	}
+	return newWidth, newHeight
+}
+
+// downscale resizes img to width by height using a box filter, where each
+// destination pixel is the average of the source pixels it covers.
+func downscale(img image.Image, width, height int) image.Image {
+	src := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := src.Min.Y + y*src.Dy()/height
+		y1 := src.Min.Y + (y+1)*src.Dy()/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := src.Min.X + x*src.Dx()/width
+			x1 := src.Min.X + (x+1)*src.Dx()/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.NRGBA64Model.Convert(img.At(sx, sy)).(color.NRGBA64)
+					r += uint64(c.R)
+					g += uint64(c.G)
+					b += uint64(c.B)
+					a += uint64(c.A)
+					n++
+				}
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+
+	return dst
+}
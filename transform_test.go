@@ -0,0 +1,91 @@
+package nixplay
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFitWithin(t *testing.T) {
+	type testData struct {
+		name                string
+		width, height       int
+		maxWidth, maxHeight int
+		expWidth, expHeight int
+	}
+
+	tests := []testData{
+		{name: "AlreadyFits", width: 100, height: 50, maxWidth: 200, maxHeight: 200, expWidth: 100, expHeight: 50},
+		{name: "WidthLimited", width: 400, height: 100, maxWidth: 200, maxHeight: 200, expWidth: 200, expHeight: 50},
+		{name: "HeightLimited", width: 100, height: 400, maxWidth: 200, maxHeight: 200, expWidth: 50, expHeight: 200},
+		{name: "NoHeightLimit", width: 400, height: 1000, maxWidth: 200, maxHeight: 0, expWidth: 200, expHeight: 500},
+		{name: "NeverZero", width: 10000, height: 1, maxWidth: 100, maxHeight: 100, expWidth: 100, expHeight: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w, h := fitWithin(tc.width, tc.height, tc.maxWidth, tc.maxHeight)
+			assert.Equal(t, tc.expWidth, w)
+			assert.Equal(t, tc.expHeight, h)
+		})
+	}
+}
+
+func TestResizeTransform(t *testing.T) {
+	ctx := context.Background()
+
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	original := buf.Bytes()
+
+	newContent := func(mimeType string) UploadContent {
+		return UploadContent{
+			Name:     "photo.png",
+			MIMEType: mimeType,
+			Reader:   bytes.NewReader(original),
+			FileSize: int64(len(original)),
+		}
+	}
+
+	t.Run("Downscale", func(t *testing.T) {
+		out, err := ResizeTransform(10, 10)(ctx, newContent("image/png"))
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(out.Reader)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(data)), out.FileSize)
+
+		resized, err := png.Decode(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 10, 5), resized.Bounds())
+		assert.Equal(t, color.NRGBA{R: 200, G: 100, B: 50, A: 255}, color.NRGBAModel.Convert(resized.At(3, 3)))
+	})
+
+	t.Run("AlreadyFits", func(t *testing.T) {
+		out, err := ResizeTransform(100, 100)(ctx, newContent("image/png"))
+		require.NoError(t, err)
+		data, err := io.ReadAll(out.Reader)
+		require.NoError(t, err)
+		assert.Equal(t, original, data)
+	})
+
+	t.Run("UnsupportedType", func(t *testing.T) {
+		in := newContent("video/mp4")
+		out, err := ResizeTransform(10, 10)(ctx, in)
+		require.NoError(t, err)
+		assert.Equal(t, in, out)
+	})
+}
@@ -11,10 +11,35 @@ import (
 // Authorization is a struct representing authorization details needed to sign
 // in to use this API.
 //
-// Currently both Username and Password are required.
+// Either both Username and Password are required, or a Session that was
+// previously obtained from a client that signed in with a username and
+// password.
 type Authorization struct {
 	Username string
 	Password string
+
+	// Session is an existing session to use instead of signing in with
+	// Username and Password. See DefaultClient.Session.
+	Session *Session
+}
+
+// Session is the state of a signed in session with Nixplay. It can be saved
+// and used to create a new client later without needing the password of the
+// account.
+//
+// A Session grants full access to the account so it should be stored as
+// securely as the password.
+type Session struct {
+	Token     string            `json:"token"`
+	CSRFToken string            `json:"csrfToken"`
+	Cookies   map[string]string `json:"cookies"`
+}
+
+// Profile is the profile of the signed in Nixplay account.
+type Profile struct {
+	// MyNixplayAddress is the ${username}@mynixplay.com email address of the
+	// account. Photos emailed to this address are added to the account.
+	MyNixplayAddress string `json:"myNixplayAddress"`
 }
 
 // ContainerType is the enum that describes the Nixplay container type that
@@ -26,8 +51,193 @@ const (
 	PlaylistContainerType = ContainerType("playlist")
 )
 
+// MediaType is the enum that describes the kind of media stored in Nixplay,
+// either a still photo or a video.
+type MediaType string
+
+const (
+	PhotoMediaType = MediaType("photo")
+	VideoMediaType = MediaType("video")
+)
+
+// UploadStatus is the enum that describes the status of a photo that is being
+// uploaded to Nixplay.
+type UploadStatus string
+
+const (
+	// UploadProcessingStatus means the photo has been uploaded but Nixplay is
+	// still processing it.
+	UploadProcessingStatus = UploadStatus("processing")
+
+	// UploadCompleteStatus means Nixplay has finished processing the photo.
+	UploadCompleteStatus = UploadStatus("complete")
+
+	// UploadFailedStatus means Nixplay failed to process the photo.
+	UploadFailedStatus = UploadStatus("failed")
+)
+
+// UploadTimeoutBehavior is the enum that describes what happens when Nixplay
+// does not finish processing an uploaded photo in time.
+type UploadTimeoutBehavior string
+
+const (
+	// FailUploadTimeoutBehavior means the upload fails with an error. This is
+	// the default behavior.
+	FailUploadTimeoutBehavior = UploadTimeoutBehavior("")
+
+	// SucceedUploadTimeoutBehavior means the upload is treated as if Nixplay
+	// finished processing the photo successfully.
+	SucceedUploadTimeoutBehavior = UploadTimeoutBehavior("succeed")
+)
+
+// PhotoIdentity is the enum that describes how the IDs of photos in playlists
+// are computed.
+type PhotoIdentity string
+
+const (
+	// ContentPhotoIdentity means the ID of a photo is computed from the
+	// container it resides in and the MD5 hash of its content. All copies of
+	// the same photo within a playlist have the same ID. This is the default.
+	ContentPhotoIdentity = PhotoIdentity("")
+
+	// PlaylistItemPhotoIdentity means the ID of a photo in a playlist is
+	// computed from the ID Nixplay uses for the slide in the playlist, so
+	// copies of the same photo within a playlist have different IDs. Photos in
+	// albums still use ContentPhotoIdentity.
+	PlaylistItemPhotoIdentity = PhotoIdentity("playlistItem")
+)
+
+// DecodingMode is the enum that describes how strictly the responses from
+// Nixplay are checked against the fields this library expects.
+type DecodingMode string
+
+const (
+	// TolerantDecodingMode means fields that are missing from a response are
+	// left as their zero value and fields that are not known are ignored. This
+	// is the default.
+	TolerantDecodingMode = DecodingMode("")
+
+	// StrictDecodingMode means a response that is missing any of the fields
+	// this library expects is an error. This is intended for detecting changes
+	// to the Nixplay API, for example in tests against the live API, and
+	// should not be used by applications.
+	StrictDecodingMode = DecodingMode("strict")
+)
+
+// AuthMethod is the enum that describes how requests to Nixplay are
+// authorized.
+type AuthMethod string
+
+const (
+	// CookieAuthMethod means requests are authorized by the session cookies
+	// and CSRF token set by Nixplay when signing in, the same as the Nixplay
+	// web app. This is the default.
+	CookieAuthMethod = AuthMethod("")
+
+	// BearerAuthMethod means requests are authorized by sending the token
+	// returned when signing in as a bearer token in the Authorization header,
+	// as used by newer Nixplay endpoints. Cookies and the CSRF token are not
+	// sent.
+	BearerAuthMethod = AuthMethod("bearer")
+)
+
+// NameDecoding is the enum that describes which names of containers and photos
+// received from Nixplay are decoded.
+type NameDecoding string
+
+const (
+	// AllNameDecoding means that every name is decoded, even names that were
+	// not created by this library. This is the default.
+	AllNameDecoding = NameDecoding("")
+
+	// MarkedNameDecoding means that names which are changed by encoding are
+	// marked with encoding.Marker and only marked names are decoded. Names
+	// created elsewhere, such as in the Nixplay app, are left as they are even
+	// if they look like they were encoded.
+	MarkedNameDecoding = NameDecoding("marked")
+)
+
+// PhotoSortBy is the enum that describes what photos are sorted by when they
+// are listed.
+type PhotoSortBy string
+
+const (
+	// NixplayPhotoSortBy means photos are listed in the order Nixplay returns
+	// them, for playlists this is the order the slides are shown in. This is
+	// the default.
+	NixplayPhotoSortBy = PhotoSortBy("")
+
+	// NamePhotoSortBy means photos are sorted by their name. Photos with the
+	// same name keep the order Nixplay returns them in.
+	NamePhotoSortBy = PhotoSortBy("name")
+)
+
+// SortOrder is the enum that describes the direction of a sort.
+type SortOrder string
+
+const (
+	// AscendingSortOrder sorts from smallest to largest. This is the default.
+	AscendingSortOrder = SortOrder("")
+
+	// DescendingSortOrder sorts from largest to smallest.
+	DescendingSortOrder = SortOrder("desc")
+)
+
+// DeleteScope is the enum that describes what a photo is deleted from.
+type DeleteScope string
+
+const (
+	// DefaultDeleteScope means photos in albums are deleted, which also
+	// removes them from every playlist, while photos in playlists only have
+	// their slide removed. This is the default.
+	DefaultDeleteScope = DeleteScope("")
+
+	// ContainerOnlyDeleteScope means the photo is only removed from the
+	// container it was obtained from. Nixplay removes a photo that is deleted
+	// from an album from every playlist too, so this is only supported for
+	// photos in playlists.
+	ContainerOnlyDeleteScope = DeleteScope("containerOnly")
+
+	// GlobalDeleteScope means the photo is deleted from the album that owns
+	// it, which also removes it from every playlist. For photos in playlists
+	// the photo the slide shows is deleted.
+	GlobalDeleteScope = DeleteScope("global")
+)
+
+// ChangeType is the enum that describes the type of change reported by a
+// ChangeEvent.
+type ChangeType string
+
+const (
+	ContainerCreatedChangeType = ChangeType("containerCreated")
+	ContainerDeletedChangeType = ChangeType("containerDeleted")
+	PhotoAddedChangeType       = ChangeType("photoAdded")
+	PhotoDeletedChangeType     = ChangeType("photoDeleted")
+)
+
+// HashType is the enum that describes the hash functions that can be used to
+// compute digests of the content of photos, see Photo.Hash.
+type HashType string
+
+const (
+	// MD5HashType is the MD5 hash that Nixplay reports for every photo.
+	MD5HashType = HashType("md5")
+
+	SHA1HashType   = HashType("sha1")
+	SHA256HashType = HashType("sha256")
+)
+
 var (
+	ErrFileTooLarge         = errors.New("file is too large to upload to Nixplay")
 	ErrInvalidContainerType = errors.New("invalid container type")
+	ErrInvalidPhotoSort     = errors.New("invalid photo sort")
+	ErrNotFound             = errors.New("not found")
+	ErrSpecialContainer     = errors.New("container was created by Nixplay and can not be deleted")
+	ErrMD5Mismatch          = errors.New("MD5 hash of content does not match expected MD5 hash")
+	ErrDryRun               = errors.New("change was not made because of dry-run mode")
+	ErrUnsupportedHashType  = errors.New("unsupported hash type")
+	ErrHashUnavailable      = errors.New("hash is not known and computing it requires downloading the photo")
+	ErrDuplicateAccount     = errors.New("an account with the same name already exists")
 )
 
 // ID is a unique identifier for objects in this library.
@@ -38,8 +248,42 @@ type ID [IDSize]byte
 
 const IDSize = sha256.Size
 
+// String returns the ID encoded as hex.
+func (id ID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// MarshalText encodes the ID as hex. This allows IDs to be used in JSON and
+// other text based formats.
+func (id ID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText decodes an ID encoded by MarshalText.
+func (id *ID) UnmarshalText(data []byte) error {
+	if hex.DecodedLen(len(data)) != IDSize {
+		return fmt.Errorf("invalid ID length")
+	}
+	_, err := hex.Decode(id[:], data)
+	if err != nil {
+		return fmt.Errorf("failed to decode ID: %w", err)
+	}
+	return nil
+}
+
 type MD5Hash [md5.Size]byte
 
+// String returns the MD5 hash encoded as hex.
+func (hash MD5Hash) String() string {
+	return hex.EncodeToString(hash[:])
+}
+
+// MarshalText encodes the MD5 hash as hex. This allows hashes to be used in
+// JSON and other text based formats.
+func (hash MD5Hash) MarshalText() ([]byte, error) {
+	return []byte(hash.String()), nil
+}
+
 func (hash *MD5Hash) UnmarshalText(data []byte) error {
 	if hex.DecodedLen(len(data)) != md5.Size {
 		return fmt.Errorf("invalid md5 hash length")
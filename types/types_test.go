@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -65,3 +66,43 @@ func TestParseMD5_Error(t *testing.T) {
 		})
 	}
 }
+
+func TestIDText(t *testing.T) {
+	id := ID{0x01, 0xab, 0xff}
+	text, err := id.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "01abff0000000000000000000000000000000000000000000000000000000000", string(text))
+	assert.Equal(t, string(text), id.String())
+
+	var decoded ID
+	assert.NoError(t, decoded.UnmarshalText(text))
+	assert.Equal(t, id, decoded)
+
+	assert.Error(t, decoded.UnmarshalText([]byte("01ab")))
+	assert.Error(t, decoded.UnmarshalText([]byte("zz"+string(text[2:]))))
+}
+
+func TestJSON(t *testing.T) {
+	type data struct {
+		ID      ID             `json:"id"`
+		MD5Hash MD5Hash        `json:"md5"`
+		ByID    map[ID]MD5Hash `json:"byId"`
+	}
+	in := data{
+		ID:      ID{1},
+		MD5Hash: MD5Hash{2},
+		ByID:    map[ID]MD5Hash{{3}: {4}},
+	}
+
+	encoded, err := json.Marshal(in)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"id": "0100000000000000000000000000000000000000000000000000000000000000",
+		"md5": "02000000000000000000000000000000",
+		"byId": {"0300000000000000000000000000000000000000000000000000000000000000": "04000000000000000000000000000000"}
+	}`, string(encoded))
+
+	var out data
+	assert.NoError(t, json.Unmarshal(encoded, &out))
+	assert.Equal(t, in, out)
+}
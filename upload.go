@@ -7,20 +7,49 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"mime"
 	"mime/multipart"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
-	"strconv"
 
 	"github.com/anitschke/go-nixplay/httpx"
 	"github.com/anitschke/go-nixplay/internal/errorx"
+	"github.com/anitschke/go-nixplay/internal/mime"
+	"github.com/anitschke/go-nixplay/rawapi"
 	"github.com/anitschke/go-nixplay/types"
 )
 
-var errDuplicateImage = errors.New("failed to upload image as duplicate image with the same content already exists in this album")
+// defaultMaxMemoryBuffer is the default for AddPhotoOptions.MaxMemoryBuffer.
+const defaultMaxMemoryBuffer = int64(32 * 1024 * 1024)
+
+// maxS3UploadAttempts is the default maximum number of times we will attempt to
+// upload the content of a photo to S3. See UploadRetryPolicy.S3Attempts.
+const maxS3UploadAttempts = 3
+
+// ErrDuplicateImage is the error returned when uploading a photo to an album
+// that already contains a photo with the same content. Nixplay does not allow
+// an album to contain multiple photos with the same content. The error
+// returned by Container.AddPhoto will be a *DuplicateImageError which can be
+// used to get the existing photo.
+var ErrDuplicateImage = errors.New("failed to upload image as duplicate image with the same content already exists in this album")
+
+// DuplicateImageError is the error returned by Container.AddPhoto when the
+// container already has a photo with the same content. errors.Is(err,
+// ErrDuplicateImage) will return true for a DuplicateImageError.
+type DuplicateImageError struct {
+	// Existing is the photo that already exists in the container with the same
+	// content as the photo that was uploaded. Existing may be nil if the
+	// existing photo could not be found.
+	Existing Photo
+}
+
+func (e *DuplicateImageError) Error() string {
+	return ErrDuplicateImage.Error()
+}
+
+func (e *DuplicateImageError) Unwrap() error {
+	return ErrDuplicateImage
+}
 
 type uploadContainerID struct {
 	idName string
@@ -28,53 +57,48 @@ type uploadContainerID struct {
 }
 
 type uploadedPhoto struct {
-	name    string
-	md5Hash types.MD5Hash
-	size    int64
+	name      string
+	md5Hash   types.MD5Hash
+	hashes    map[types.HashType]string
+	size      int64
+	monitorID string
 }
 
-func addPhoto(ctx context.Context, client httpx.Client, containerID uploadContainerID, name string, r io.Reader, opts AddPhotoOptions) (retData uploadedPhoto, err error) {
+// startUpload uploads the content of the photo to Nixplay. Once startUpload
+// returns the provided io.Reader is no longer needed, however Nixplay may still
+// be processing the photo. Use monitorUpload with the returned monitorID to
+// wait for Nixplay to finish processing the photo.
+func startUpload(ctx context.Context, raw *rawapi.Client, timeouts Timeouts, policy UploadRetryPolicy, hashPolicy HashPolicy, containerID uploadContainerID, name string, r io.Reader, opts AddPhotoOptions) (retData uploadedPhoto, err error) {
 	defer errorx.WrapWithFuncNameIfError(&err)
 
-	photoData, r, err := getUploadPhotoData(name, r, opts)
+	photoData, r, cleanup, err := getUploadPhotoData(name, r, opts)
 	if err != nil {
 		return uploadedPhoto{}, err
 	}
+	defer cleanup()
+	ctx = httpx.WithAttributes(ctx, httpx.Attribute{Key: attrPhotoSize, Value: photoData.FileSize})
 
-	uploadToken, err := getUploadToken(ctx, client, containerID)
+	uploadNixplayResponse, err := registerUpload(ctx, raw, timeouts, policy, containerID, photoData)
 	if err != nil {
 		return uploadedPhoto{}, err
 	}
 
-	uploadNixplayResponse, err := uploadNixplay(ctx, client, containerID, photoData, uploadToken)
+	hashes, err := uploadS3WithRetry(ctx, raw.HTTPClient(), timeouts, policy.s3Attempts(), hashPolicy, uploadNixplayResponse, name, r, photoData.FileSize)
 	if err != nil {
 		return uploadedPhoto{}, err
 	}
 
-	hasher := md5.New()
-	readAndHash := io.TeeReader(r, hasher)
-
-	if err := uploadS3(ctx, client, uploadNixplayResponse, name, readAndHash); err != nil {
-		return uploadedPhoto{}, err
-	}
-
-	md5Hash := *(*types.MD5Hash)(hasher.Sum(nil))
-
 	if len(uploadNixplayResponse.UserUploadIDs) != 1 {
 		return uploadedPhoto{}, errors.New("unable to wait for photo to be uploaded")
 	}
-	monitorId := uploadNixplayResponse.UserUploadIDs[0]
-
-	// We still need to return uploadedPhoto even if monitorUpload errors out because
-	// sometimes monitorUpload returns an error but we can still recover from when uploading
-	// to a playlist. See comments in container.AddPhoto for details
-	err = monitorUpload(ctx, client, monitorId)
 
 	return uploadedPhoto{
-		name:    name,
-		md5Hash: md5Hash,
-		size:    int64(photoData.FileSize),
-	}, err
+		name:      name,
+		md5Hash:   hashes.md5Hash(),
+		hashes:    hashes.sums(),
+		size:      int64(photoData.FileSize),
+		monitorID: uploadNixplayResponse.UserUploadIDs[0],
+	}, nil
 }
 
 type uploadPhotoData struct {
@@ -82,9 +106,11 @@ type uploadPhotoData struct {
 	Name string
 }
 
-func getUploadPhotoData(name string, r io.Reader, opts AddPhotoOptions) (retData uploadPhotoData, retR io.Reader, err error) {
+func getUploadPhotoData(name string, r io.Reader, opts AddPhotoOptions) (retData uploadPhotoData, retR io.Reader, cleanup func(), err error) {
 	defer errorx.WrapWithFuncNameIfError(&err)
 
+	cleanup = func() {}
+
 	data := uploadPhotoData{
 		AddPhotoOptions: opts,
 		Name:            name,
@@ -93,24 +119,24 @@ func getUploadPhotoData(name string, r io.Reader, opts AddPhotoOptions) (retData
 	if data.MIMEType == "" {
 		ext := filepath.Ext(name)
 		if ext == "" {
-			return uploadPhotoData{}, nil, fmt.Errorf("could not determine file extension for file %q", name)
+			return uploadPhotoData{}, nil, nil, fmt.Errorf("could not determine file extension for file %q", name)
 		}
-		data.MIMEType = mime.TypeByExtension(ext)
+		data.MIMEType = mime.TypeByFileName(name)
 		if data.MIMEType == "" {
-			return uploadPhotoData{}, nil, fmt.Errorf("could not determine mime type for file %q", name)
+			return uploadPhotoData{}, nil, nil, fmt.Errorf("could not determine mime type for file %q", name)
 		}
 	}
 
 	// If we don't know the file size we will try a few different APIs to try to
 	// determine the size of the photo efficiently. If that doesn't work we will
-	// resort to reading into a buffer which requires us to buffer the entire
-	// file into memory, not ideal.
+	// resort to reading into a buffer, spilling over to a temporary file if the
+	// photo is too large to reasonably hold in memory.
 	if data.FileSize == 0 {
 		switch photo := r.(type) {
 		case *os.File:
 			fileInfo, err := photo.Stat()
 			if err != nil {
-				return uploadPhotoData{}, nil, err
+				return uploadPhotoData{}, nil, nil, err
 			}
 			data.FileSize = fileInfo.Size()
 		case *bytes.Buffer:
@@ -121,77 +147,206 @@ func getUploadPhotoData(name string, r io.Reader, opts AddPhotoOptions) (retData
 			var err error
 			data.FileSize, err = photo.Seek(0, io.SeekEnd)
 			if err != nil {
-				return uploadPhotoData{}, nil, err
+				return uploadPhotoData{}, nil, nil, err
 			}
 			// seek back to the start of file so that it can be read again properly
 			if _, err := photo.Seek(0, io.SeekStart); err != nil {
-				return uploadPhotoData{}, nil, err
+				return uploadPhotoData{}, nil, nil, err
 			}
 		default:
 			var err error
-			buf := new(bytes.Buffer)
-			data.FileSize, err = buf.ReadFrom(r)
+			r, data.FileSize, cleanup, err = bufferUpload(r, opts.MaxMemoryBuffer)
 			if err != nil {
-				return uploadPhotoData{}, nil, err
+				return uploadPhotoData{}, nil, nil, err
 			}
-			r = buf
+		}
+	}
 
+	// Nixplay does not document the limits enforced by the upload API so
+	// there is only a limit if the caller asks for one.
+	if opts.MaxFileSize > 0 && data.FileSize > opts.MaxFileSize {
+		mediaType := types.PhotoMediaType
+		if mime.IsVideo(data.MIMEType) {
+			mediaType = types.VideoMediaType
 		}
+		cleanup()
+		return uploadPhotoData{}, nil, nil, fmt.Errorf("%w: %s %q is %d bytes but the maximum size is %d bytes", types.ErrFileTooLarge, mediaType, name, data.FileSize, opts.MaxFileSize)
 	}
 
-	return data, r, nil
+	return data, r, cleanup, nil
 }
 
-func getUploadToken(ctx context.Context, client httpx.Client, containerID uploadContainerID) (returnedToken string, err error) {
+// hashUpload computes the MD5 hash of the photo before it is uploaded. The
+// returned reader must be used to read the photo for the upload, and the
+// returned cleanup function must be called once the returned reader is no
+// longer needed.
+//
+// If r can seek then it is rewound after computing the hash, otherwise it is
+// buffered using bufferUpload.
+func hashUpload(r io.Reader, maxMemory int64) (retHash types.MD5Hash, retR io.Reader, cleanup func(), err error) {
 	defer errorx.WrapWithFuncNameIfError(&err)
 
-	form := url.Values{
-		containerID.idName: {containerID.id},
-		"total":            {"1"},
+	cleanup = func() {}
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		rs, _, cleanup, err = bufferUpload(r, maxMemory)
+		if err != nil {
+			return types.MD5Hash{}, nil, nil, err
+		}
 	}
+	defer func() {
+		if err != nil {
+			cleanup()
+		}
+	}()
 
-	req, err := httpx.NewPostFormRequest(ctx, "https://api.nixplay.com/v3/upload/receivers/", form)
+	start, err := rs.Seek(0, io.SeekCurrent)
 	if err != nil {
-		return "", err
+		return types.MD5Hash{}, nil, nil, err
 	}
-
-	var response uploadTokenResponse
-	if err := httpx.DoUnmarshalJSONResponse(client, req, &response); err != nil {
-		return "", err
+	hasher := md5.New()
+	if _, err := io.Copy(hasher, rs); err != nil {
+		return types.MD5Hash{}, nil, nil, err
+	}
+	if _, err := rs.Seek(start, io.SeekStart); err != nil {
+		return types.MD5Hash{}, nil, nil, err
 	}
 
-	return response.Token, nil
+	return *(*types.MD5Hash)(hasher.Sum(nil)), rs, cleanup, nil
 }
 
-func uploadNixplay(ctx context.Context, client httpx.Client, containerID uploadContainerID, photo uploadPhotoData, token string) (returnedResponse uploadNixplayResponse, err error) {
-	defer errorx.WrapWithFuncNameIfError(&err)
+// bufferUpload reads all of r so that the size of the photo can be determined.
+// Up to maxMemory bytes are buffered in memory, if the photo is larger than
+// that then it is written to a temporary file instead. The returned cleanup
+// function must be called once the returned reader is no longer needed in order
+// to remove the temporary file.
+func bufferUpload(r io.Reader, maxMemory int64) (retR io.ReadSeeker, retSize int64, cleanup func(), err error) {
+	if maxMemory == 0 {
+		maxMemory = defaultMaxMemoryBuffer
+	}
 
-	form := url.Values{
-		containerID.idName: {containerID.id},
-		"uploadToken":      {token},
-		"fileName":         {photo.Name},
-		"fileType":         {photo.MIMEType},
-		"fileSize":         {strconv.FormatInt(photo.FileSize, 10)},
+	buf := new(bytes.Buffer)
+	if maxMemory > 0 {
+		// Read one byte more than the limit so we can tell if we hit the end of
+		// the photo exactly at the limit.
+		n, err := io.CopyN(buf, r, maxMemory+1)
+		if err == io.EOF {
+			// Use a bytes.Reader rather than the buffer directly so the upload
+			// can be rewound and retried if needed.
+			return bytes.NewReader(buf.Bytes()), n, func() {}, nil
+		}
+		if err != nil {
+			return nil, 0, nil, err
+		}
 	}
 
-	req, err := httpx.NewPostFormRequest(ctx, "https://api.nixplay.com/v3/photo/upload/", form)
+	f, err := os.CreateTemp("", "go-nixplay-upload-*")
 	if err != nil {
-		return uploadNixplayResponse{}, err
+		return nil, 0, nil, err
 	}
+	cleanup = func() {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	defer func() {
+		if err != nil {
+			cleanup()
+		}
+	}()
+
+	size, err := io.Copy(f, io.MultiReader(buf, r))
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, nil, err
+	}
+	return f, size, cleanup, nil
+}
+
+func getUploadToken(ctx context.Context, raw *rawapi.Client, timeouts Timeouts, containerID uploadContainerID) (returnedToken string, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	ctx, cancel := withTimeout(ctx, timeouts.Metadata)
+	defer cancel()
 
-	var response uploadNixplayResponseContainer
-	if err := httpx.DoUnmarshalJSONResponse(client, req, &response); err != nil {
-		return uploadNixplayResponse{}, err
+	return raw.UploadToken(ctx, containerID.idName, containerID.id)
+}
+
+func uploadNixplay(ctx context.Context, raw *rawapi.Client, timeouts Timeouts, containerID uploadContainerID, photo uploadPhotoData, token string) (returnedResponse rawapi.PhotoUploadResponse, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	ctx, cancel := withTimeout(ctx, timeouts.Metadata)
+	defer cancel()
+
+	return raw.PhotoUpload(ctx, rawapi.PhotoUploadRequest{
+		IDField:     containerID.idName,
+		ID:          containerID.id,
+		UploadToken: token,
+		FileName:    photo.Name,
+		FileType:    photo.MIMEType,
+		FileSize:    photo.FileSize,
+	})
+}
+
+// uploadS3WithRetry uploads the photo to S3 and returns the hashes of the
+// uploaded content, the MD5 hash and the hashes in hashPolicy.Upload.
+//
+// Nixplay hands us a presigned S3 POST policy for the upload. Unfortunately
+// POST policies do not support S3 multipart uploads so there is no way to
+// upload the photo in chunks and resume a failed upload part way through. The
+// best we can do is retry the entire upload when it fails with what looks like
+// a transient error. This is only possible if we can rewind the reader back to
+// the start of the photo, if we can't then we fall back to a single attempt.
+func uploadS3WithRetry(ctx context.Context, client httpx.Client, timeouts Timeouts, attempts int, hashPolicy HashPolicy, u rawapi.PhotoUploadResponse, filename string, r io.Reader, size int64) (retHashes *hashSet, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	maxAttempts := 1
+	seeker, canRewind := r.(io.Seeker)
+	var start int64
+	if canRewind {
+		start, err = seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		maxAttempts = attempts
 	}
 
-	return response.Data, nil
+	for attempt := 1; ; attempt++ {
+		hashes, err := newHashSet(hashPolicy, hashPolicy.Upload)
+		if err != nil {
+			return nil, err
+		}
+		readAndHash := io.TeeReader(r, hashes)
+
+		retryable, err := uploadS3(httpx.WithAttempt(ctx, attempt), client, timeouts, u, filename, readAndHash, size)
+		if err == nil {
+			return hashes, nil
+		}
+		if !retryable || attempt >= maxAttempts || ctx.Err() != nil {
+			return nil, err
+		}
+
+		if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
 }
 
-func uploadS3(ctx context.Context, client httpx.Client, u uploadNixplayResponse, filename string, r io.Reader) (err error) {
+// uploadS3 uploads the photo to S3 using a single multipart form POST. If the
+// upload fails then retryable indicates if it may succeed if attempted again.
+func uploadS3(ctx context.Context, client httpx.Client, timeouts Timeouts, u rawapi.PhotoUploadResponse, filename string, r io.Reader, size int64) (retryable bool, err error) {
 	defer errorx.WrapWithFuncNameIfError(&err)
 
-	reqBody := &bytes.Buffer{}
-	writer := multipart.NewWriter(reqBody)
+	ctx, cancel := withTimeout(ctx, timeouts.Upload)
+	defer cancel()
+
+	// Rather than buffering the entire photo into memory to build the form we
+	// build the parts of the form that come before and after the photo and
+	// then stream the photo between them. Since we know the size of all three
+	// parts we can still provide S3 with the Content-Length that it requires.
+	formHead := &bytes.Buffer{}
+	writer := multipart.NewWriter(formHead)
 
 	formValues := map[string]string{
 		"key":                        u.Key,
@@ -206,68 +361,49 @@ func uploadS3(ctx context.Context, client httpx.Client, u uploadNixplayResponse,
 	for k, v := range formValues {
 		w, err := writer.CreateFormField(k)
 		if err != nil {
-			return err
+			return false, err
 		}
 		io.WriteString(w, v)
 	}
 
-	w, err := writer.CreateFormFile("file", filename)
-	if err != nil {
-		return err
+	if _, err := writer.CreateFormFile("file", filename); err != nil {
+		return false, err
 	}
-
-	_, err = io.Copy(w, r)
-	if err != nil {
-		return err
+	head := make([]byte, formHead.Len())
+	copy(head, formHead.Bytes())
+
+	// Closing the writer writes the closing boundary, so reuse the buffer to
+	// capture the tail of the form.
+	formHead.Reset()
+	if err := writer.Close(); err != nil {
+		return false, err
 	}
-	writer.Close()
+	tail := formHead.Bytes()
 
+	reqBody := io.MultiReader(bytes.NewReader(head), r, bytes.NewReader(tail))
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.S3UploadURL, reqBody)
 	if err != nil {
-		return err
+		return false, err
 	}
+	req.ContentLength = int64(len(head)) + size + int64(len(tail))
 	req.Header.Set("accept", "application/json, text/plain, */*")
 	req.Header.Set("content-type", fmt.Sprintf("multipart/form-data; boundary=%s", writer.Boundary()))
 	req.Header.Set("origin", "https://app.nixplay.com")
 	req.Header.Set("referer", "https://app.nixplay.com")
 	resp, err := client.Do(req)
 	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != 201 {
-		return fmt.Errorf("error uploading: %s", resp.Status)
-	}
-	return nil
-}
-
-func monitorUpload(ctx context.Context, client httpx.Client, monitorID string) (err error) {
-	defer errorx.WrapWithFuncNameIfError(&err)
-
-	url := fmt.Sprintf("https://upload-monitor.nixplay.com/status?id=%s", monitorID)
-	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, http.NoBody)
-	if err != nil {
-		return err
-	}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
+		// Errors from the client are generally network errors that may go
+		// away if we try again.
+		return true, err
 	}
 	defer resp.Body.Close()
 	defer io.Copy(io.Discard, resp.Body)
-
-	// Special logic to detect duplicate uploads. See comments in
-	// container.AddPhoto.
-	if resp.StatusCode == 400 {
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return err
-		}
-		if string(body) == "Error: image-exists" {
-			return errDuplicateImage
-		}
-		return fmt.Errorf("http status: %s: body: %s", resp.Status, body)
+	if resp.StatusCode != http.StatusCreated {
+		// 4xx errors mean S3 rejected the upload (for example the policy
+		// expired) so trying again won't help, but 5xx errors and throttling
+		// may be transient.
+		retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retryable, fmt.Errorf("error uploading: %s", resp.Status)
 	}
-
-	return httpx.StatusError(resp)
+	return false, nil
 }
@@ -0,0 +1,91 @@
+package nixplay
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// uploadHandle is the type that implements the UploadHandle interface.
+type uploadHandle struct {
+	done   chan struct{}
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	status types.UploadStatus
+	photo  Photo
+	err    error
+}
+
+var _ = (UploadHandle)((*uploadHandle)(nil))
+
+// newUploadHandle returns an UploadHandle for a photo that Nixplay is
+// processing. cancel stops monitoring the upload in the background.
+func newUploadHandle(cancel context.CancelFunc) *uploadHandle {
+	return &uploadHandle{
+		done:   make(chan struct{}),
+		cancel: cancel,
+		status: types.UploadProcessingStatus,
+	}
+}
+
+// newCompletedUploadHandle returns an UploadHandle for a photo that does not
+// need to be processed by Nixplay, for example because it already existed.
+func newCompletedUploadHandle(p Photo) *uploadHandle {
+	h := newUploadHandle(func() {})
+	h.complete(p, nil)
+	return h
+}
+
+// complete records the result of processing the photo and wakes up anyone
+// waiting on the handle. It must only be called once.
+func (h *uploadHandle) complete(p Photo, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.photo = p
+	h.err = err
+	if err != nil {
+		h.status = types.UploadFailedStatus
+	} else {
+		h.status = types.UploadCompleteStatus
+	}
+	close(h.done)
+}
+
+func (h *uploadHandle) Wait(ctx context.Context) (Photo, error) {
+	select {
+	case <-h.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.photo, h.err
+}
+
+func (h *uploadHandle) Status() types.UploadStatus {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.status
+}
+
+func (h *uploadHandle) Cancel() {
+	h.cancel()
+}
+
+// detachedContext is a context with the values of its parent that is never
+// canceled and has no deadline. It is used to keep monitoring an upload after
+// the context passed to Container.AddPhotoAsync is done. It is the same as
+// context.WithoutCancel, which is not available in go 1.18.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (c detachedContext) Done() <-chan struct{}       { return nil }
+func (c detachedContext) Err() error                  { return nil }
+func (c detachedContext) Value(key any) any           { return c.parent.Value(key) }
@@ -0,0 +1,141 @@
+package nixplay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/internal/errorx"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// defaultUploadMonitorPollInterval is the time we wait between requests to the
+// upload monitor if UploadMonitorOptions.PollInterval is not specified.
+const defaultUploadMonitorPollInterval = time.Second
+
+// ErrUploadProcessingTimeout is the error returned when Nixplay does not finish
+// processing an uploaded photo within UploadMonitorOptions.MaxWait. The content
+// of the photo was uploaded so Nixplay may still finish processing it later.
+// The error returned by Container.AddPhoto will be an
+// *UploadProcessingTimeoutError which can be used to check on the photo later.
+var ErrUploadProcessingTimeout = errors.New("timed out waiting for Nixplay to finish processing uploaded photo")
+
+// UploadProcessingTimeoutError is the error returned by Container.AddPhoto
+// when Nixplay does not finish processing the photo in time. errors.Is(err,
+// ErrUploadProcessingTimeout) will return true for an
+// UploadProcessingTimeoutError.
+type UploadProcessingTimeoutError struct {
+	// ID is the ID the photo will have once Nixplay finishes processing it. It
+	// can be passed to Container.PhotoWithID after calling
+	// Container.ResetCache to check if the photo has since been added. The ID
+	// is not known, and is left as the zero ID, for photos uploaded to a
+	// playlist when using types.PlaylistItemPhotoIdentity.
+	ID types.ID
+
+	// MD5Hash is the MD5 hash of the content that was uploaded.
+	MD5Hash types.MD5Hash
+}
+
+func (e *UploadProcessingTimeoutError) Error() string {
+	return ErrUploadProcessingTimeout.Error()
+}
+
+func (e *UploadProcessingTimeoutError) Unwrap() error {
+	return ErrUploadProcessingTimeout
+}
+
+// UploadMonitorOptions controls how the client waits for Nixplay to finish
+// processing a photo after its content has been uploaded.
+type UploadMonitorOptions struct {
+	// PollInterval is the time to wait before asking the upload monitor again
+	// if a request to it fails with a transient error or times out (see
+	// Timeouts.UploadMonitor). If zero a default of one second is used.
+	PollInterval time.Duration
+
+	// MaxWait is the maximum total time to wait for Nixplay to finish
+	// processing the photo. If zero then the upload monitor is only asked
+	// once, which matches the behavior of older versions of this package.
+	MaxWait time.Duration
+
+	// OnTimeout controls what happens when MaxWait elapses before Nixplay
+	// finishes processing the photo. By default ErrUploadProcessingTimeout is
+	// returned.
+	OnTimeout types.UploadTimeoutBehavior
+}
+
+// monitorUpload waits for Nixplay to finish processing the uploaded photo.
+func monitorUpload(ctx context.Context, client httpx.Client, timeouts Timeouts, opts UploadMonitorOptions, monitorID string) (err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	pollInterval := opts.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultUploadMonitorPollInterval
+	}
+	var deadline time.Time
+	if opts.MaxWait > 0 {
+		deadline = time.Now().Add(opts.MaxWait)
+	}
+
+	for attempt := 1; ; attempt++ {
+		retryable, err := monitorUploadOnce(httpx.WithAttempt(ctx, attempt), client, timeouts, monitorID)
+		if err == nil || !retryable || ctx.Err() != nil {
+			return err
+		}
+		if deadline.IsZero() {
+			return err
+		}
+		if time.Now().Add(pollInterval).After(deadline) {
+			if opts.OnTimeout == types.SucceedUploadTimeoutBehavior {
+				return nil
+			}
+			return fmt.Errorf("%w: %s", ErrUploadProcessingTimeout, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// monitorUploadOnce makes a single request to the upload monitor. If the
+// request fails then retryable indicates if asking again may succeed.
+func monitorUploadOnce(ctx context.Context, client httpx.Client, timeouts Timeouts, monitorID string) (retryable bool, err error) {
+	reqCtx, cancel := withTimeout(ctx, timeouts.UploadMonitor)
+	defer cancel()
+
+	url := fmt.Sprintf("https://upload-monitor.nixplay.com/status?id=%s", monitorID)
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Timing out waiting on a single request or a network error are both
+		// worth asking the upload monitor again, as long as the caller's
+		// context is still alive.
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	defer io.Copy(io.Discard, resp.Body)
+
+	// Special logic to detect duplicate uploads. See comments in
+	// container.finishUpload.
+	if resp.StatusCode == 400 {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return false, err
+		}
+		if string(body) == "Error: image-exists" {
+			return false, ErrDuplicateImage
+		}
+		return false, fmt.Errorf("http status: %s: body: %s", resp.Status, body)
+	}
+
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, httpx.StatusError(resp)
+}
@@ -0,0 +1,122 @@
+package nixplay
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitorUpload(t *testing.T) {
+	duplicateResponse := func() *http.Response {
+		resp := newTestResponse(http.StatusBadRequest)
+		resp.Body = io.NopCloser(bytes.NewReader([]byte("Error: image-exists")))
+		return resp
+	}
+
+	type testData struct {
+		name        string
+		opts        UploadMonitorOptions
+		responses   []func() *http.Response
+		expRequests int
+		expErr      error
+		expAnyErr   bool
+	}
+
+	tests := []testData{
+		{
+			name:        "Success",
+			opts:        UploadMonitorOptions{MaxWait: time.Second, PollInterval: time.Millisecond},
+			responses:   []func() *http.Response{func() *http.Response { return newTestResponse(http.StatusOK) }},
+			expRequests: 1,
+		},
+		{
+			name: "RetryTransientFailure",
+			opts: UploadMonitorOptions{MaxWait: time.Second, PollInterval: time.Millisecond},
+			responses: []func() *http.Response{
+				func() *http.Response { return newTestResponse(http.StatusBadGateway) },
+				func() *http.Response { return newTestResponse(http.StatusOK) },
+			},
+			expRequests: 2,
+		},
+		{
+			name:        "NoRetryWithoutMaxWait",
+			responses:   []func() *http.Response{func() *http.Response { return newTestResponse(http.StatusBadGateway) }},
+			expRequests: 1,
+			expAnyErr:   true,
+		},
+		{
+			name:        "Duplicate",
+			opts:        UploadMonitorOptions{MaxWait: time.Second, PollInterval: time.Millisecond},
+			responses:   []func() *http.Response{duplicateResponse},
+			expRequests: 1,
+			expErr:      ErrDuplicateImage,
+		},
+		{
+			name:        "Timeout",
+			opts:        UploadMonitorOptions{MaxWait: 20 * time.Millisecond, PollInterval: 5 * time.Millisecond},
+			responses:   []func() *http.Response{func() *http.Response { return newTestResponse(http.StatusServiceUnavailable) }},
+			expRequests: -1,
+			expErr:      ErrUploadProcessingTimeout,
+		},
+		{
+			name:        "TimeoutSucceed",
+			opts:        UploadMonitorOptions{MaxWait: 20 * time.Millisecond, PollInterval: 5 * time.Millisecond, OnTimeout: types.SucceedUploadTimeoutBehavior},
+			responses:   []func() *http.Response{func() *http.Response { return newTestResponse(http.StatusServiceUnavailable) }},
+			expRequests: -1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+			client := clientFunc(func(req *http.Request) (*http.Response, error) {
+				i := requests
+				if i >= len(tc.responses) {
+					i = len(tc.responses) - 1
+				}
+				requests++
+				return tc.responses[i](), nil
+			})
+
+			err := monitorUpload(context.Background(), client, Timeouts{}, tc.opts, "monitorID")
+			switch {
+			case tc.expAnyErr:
+				assert.Error(t, err)
+			case tc.expErr != nil:
+				assert.ErrorIs(t, err, tc.expErr)
+			default:
+				assert.NoError(t, err)
+			}
+			if tc.expRequests >= 0 {
+				assert.Equal(t, tc.expRequests, requests)
+			} else {
+				assert.Greater(t, requests, 1)
+			}
+		})
+	}
+}
+
+func TestMonitorUpload_InjectedFaults(t *testing.T) {
+	faults := []httpx.Fault{httpx.TimeoutFault, httpx.ConnectionResetFault, httpx.TooManyRequestsFault, httpx.ServerErrorFault, httpx.TruncatedBodyFault}
+	for _, fault := range faults {
+		t.Run(fault.String(), func(t *testing.T) {
+			sent := 0
+			client := httpx.NewFaultInjectingClient(clientFunc(func(req *http.Request) (*http.Response, error) {
+				sent++
+				return newTestResponse(http.StatusOK), nil
+			}), httpx.FaultSequence(fault, fault))
+
+			opts := UploadMonitorOptions{MaxWait: time.Second, PollInterval: time.Millisecond}
+			err := monitorUpload(context.Background(), client, Timeouts{}, opts, "monitorID")
+			assert.NoError(t, err)
+			assert.GreaterOrEqual(t, sent, 1)
+		})
+	}
+}
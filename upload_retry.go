@@ -0,0 +1,107 @@
+package nixplay
+
+import (
+	"context"
+	"errors"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/internal/errorx"
+	"github.com/anitschke/go-nixplay/rawapi"
+)
+
+// UploadRetryPolicy controls how failures during an upload are retried.
+//
+// Uploading a photo happens in three phases: getting an upload token and
+// registering the photo with Nixplay, uploading the content of the photo to
+// S3, and waiting for the upload monitor to report that Nixplay has finished
+// processing the photo (see UploadMonitorOptions). Each phase is retried on
+// its own so a failure in a later phase never repeats an earlier phase that
+// already succeeded. Retrying the whole of Container.AddPhoto instead is
+// likely to upload the same content again, which Nixplay reports as a
+// duplicate image.
+type UploadRetryPolicy struct {
+	// TokenAttempts is the maximum number of attempts made to get an upload
+	// token and register the photo with Nixplay when these fail with what
+	// looks like a transient error. The upload token is reused for further
+	// attempts unless Nixplay rejects the registration, in which case a new
+	// token is requested. If zero a single attempt is made.
+	TokenAttempts int
+
+	// S3Attempts is the maximum number of attempts made to upload the
+	// content of the photo to S3 when the upload fails with what looks like a
+	// transient error. The upload can only be retried if the io.Reader
+	// passed to Container.AddPhoto can seek, or has been buffered, otherwise
+	// a single attempt is made. If zero a default of 3 attempts is used.
+	S3Attempts int
+
+	// LookupOnMonitorTimeout specifies that when the upload monitor times
+	// out, see UploadMonitorOptions.MaxWait, the photos in the container
+	// should be loaded again and searched for a new photo with the MD5 hash
+	// of the content that was uploaded. If Nixplay did finish processing the
+	// photo then it is returned rather than an *UploadProcessingTimeoutError,
+	// so that the caller does not upload the photo again.
+	LookupOnMonitorTimeout bool
+}
+
+func (p UploadRetryPolicy) tokenAttempts() int {
+	if p.TokenAttempts <= 0 {
+		return 1
+	}
+	return p.TokenAttempts
+}
+
+func (p UploadRetryPolicy) s3Attempts() int {
+	if p.S3Attempts <= 0 {
+		return maxS3UploadAttempts
+	}
+	return p.S3Attempts
+}
+
+// registerUpload gets an upload token and uses it to register the photo with
+// Nixplay, retrying as specified by policy.
+func registerUpload(ctx context.Context, raw *rawapi.Client, timeouts Timeouts, policy UploadRetryPolicy, containerID uploadContainerID, photoData uploadPhotoData) (retResponse rawapi.PhotoUploadResponse, err error) {
+	defer errorx.WrapWithFuncNameIfError(&err)
+
+	maxAttempts := policy.tokenAttempts()
+	token := ""
+	for attempt := 1; ; attempt++ {
+		attemptCtx := httpx.WithAttempt(ctx, attempt)
+
+		if token == "" {
+			token, err = getUploadToken(attemptCtx, raw, timeouts, containerID)
+			if err != nil {
+				if !isTransientUploadError(ctx, err) || attempt >= maxAttempts {
+					return rawapi.PhotoUploadResponse{}, err
+				}
+				continue
+			}
+		}
+
+		resp, err := uploadNixplay(attemptCtx, raw, timeouts, containerID, photoData, token)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= maxAttempts || ctx.Err() != nil {
+			return rawapi.PhotoUploadResponse{}, err
+		}
+		if !isTransientUploadError(ctx, err) {
+			// Nixplay rejected the registration, the token may no longer be
+			// valid so get a new one before trying again.
+			token = ""
+		}
+	}
+}
+
+// isTransientUploadError returns true if err looks like a failure that may go
+// away if the request is made again.
+func isTransientUploadError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var respErr *httpx.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.Retryable()
+	}
+	// Errors that are not from the response are generally network errors.
+	return true
+}
@@ -0,0 +1,166 @@
+package nixplay
+
+import (
+	"context"
+	"crypto/md5"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/rawapi"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterUpload(t *testing.T) {
+	ctx := context.Background()
+
+	type response struct {
+		statusCode int
+		body       string
+	}
+	token := func(token string) response {
+		return response{http.StatusOK, `{"token":"` + token + `"}`}
+	}
+	registered := response{http.StatusOK, `{"data":{"userUploadIds":["upload"]}}`}
+
+	type testData struct {
+		name        string
+		policy      UploadRetryPolicy
+		responses   []response
+		expRequests []string
+		expErr      bool
+	}
+
+	tests := []testData{
+		{
+			name:        "Success",
+			responses:   []response{token("t1"), registered},
+			expRequests: []string{"token", "register t1"},
+		},
+		{
+			name:        "SingleAttemptByDefault",
+			responses:   []response{token("t1"), {http.StatusBadGateway, ""}},
+			expRequests: []string{"token", "register t1"},
+			expErr:      true,
+		},
+		{
+			name:        "TransientRegisterFailureReusesToken",
+			policy:      UploadRetryPolicy{TokenAttempts: 3},
+			responses:   []response{token("t1"), {http.StatusBadGateway, ""}, registered},
+			expRequests: []string{"token", "register t1", "register t1"},
+		},
+		{
+			name:        "RejectedRegisterGetsNewToken",
+			policy:      UploadRetryPolicy{TokenAttempts: 3},
+			responses:   []response{token("t1"), {http.StatusBadRequest, ""}, token("t2"), registered},
+			expRequests: []string{"token", "register t1", "token", "register t2"},
+		},
+		{
+			name:        "TransientTokenFailure",
+			policy:      UploadRetryPolicy{TokenAttempts: 3},
+			responses:   []response{{http.StatusServiceUnavailable, ""}, token("t1"), registered},
+			expRequests: []string{"token", "token", "register t1"},
+		},
+		{
+			name:        "TokenRejected",
+			policy:      UploadRetryPolicy{TokenAttempts: 3},
+			responses:   []response{{http.StatusForbidden, ""}},
+			expRequests: []string{"token"},
+			expErr:      true,
+		},
+		{
+			name:        "AttemptsExhausted",
+			policy:      UploadRetryPolicy{TokenAttempts: 2},
+			responses:   []response{token("t1"), {http.StatusBadGateway, ""}, {http.StatusBadGateway, ""}},
+			expRequests: []string{"token", "register t1", "register t1"},
+			expErr:      true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests []string
+			var attempts []int
+			client := clientFunc(func(req *http.Request) (*http.Response, error) {
+				require.Less(t, len(requests), len(tc.responses), "unexpected request")
+				require.NoError(t, req.ParseForm())
+				switch req.URL.Path {
+				case "/v3/upload/receivers/":
+					requests = append(requests, "token")
+				case "/v3/photo/upload/":
+					requests = append(requests, "register "+req.PostForm.Get("uploadToken"))
+				default:
+					require.Fail(t, "unexpected request", req.URL.String())
+				}
+				attempts = append(attempts, httpx.Attempt(req.Context()))
+
+				resp := tc.responses[len(requests)-1]
+				return &http.Response{
+					StatusCode: resp.statusCode,
+					Status:     http.StatusText(resp.statusCode),
+					Header:     http.Header{},
+					Body:       io.NopCloser(strings.NewReader(resp.body)),
+				}, nil
+			})
+
+			raw := rawapi.New(client, rawapi.Options{})
+			containerID := uploadContainerID{idName: albumAddIDName, id: "1"}
+			resp, err := registerUpload(ctx, raw, Timeouts{}, tc.policy, containerID, uploadPhotoData{Name: "photo.jpg"})
+			if tc.expErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"upload"}, resp.UserUploadIDs)
+			}
+			assert.Equal(t, tc.expRequests, requests)
+			assert.Equal(t, 1, attempts[0])
+		})
+	}
+}
+
+func TestFinishUpload_LookupOnMonitorTimeout(t *testing.T) {
+	ctx := context.Background()
+	content := []byte("photo")
+	h := types.MD5Hash(md5.Sum(content))
+
+	// Nixplay finished processing the photo even though the upload monitor
+	// timed out.
+	pageFunc := func(ctx context.Context, client httpx.Client, container Container, nixplayID uint64, page uint64, pageSize uint64) ([]Photo, error) {
+		if page > 0 {
+			return nil, nil
+		}
+		p, err := newPhoto(container, client, "photo.jpg", &h, 1, "", int64(len(content)), "")
+		require.NoError(t, err)
+		return []Photo{p}, nil
+	}
+	newTestAlbum := func(policy UploadRetryPolicy) *container {
+		settings := &clientSettings{
+			metrics:     nopMetrics{},
+			changes:     &changeNotifier{},
+			uploadRetry: policy,
+		}
+		return newContainer(noRequestClient{t: t}, nil, settings, types.AlbumContainerType, "album", 1234, 0, pageFunc, (*rawapi.Client).DeleteAlbum, albumAddIDName)
+	}
+	photoData := uploadedPhoto{name: "photo.jpg", md5Hash: h, size: int64(len(content))}
+
+	t.Run("Lookup", func(t *testing.T) {
+		c := newTestAlbum(UploadRetryPolicy{LookupOnMonitorTimeout: true})
+		p, err := c.finishUpload(ctx, photoData, false, nil, ErrUploadProcessingTimeout)
+		require.NoError(t, err)
+		assert.Equal(t, photoID(c.ID(), h), p.ID())
+		assert.Equal(t, int64(1), c.photoCount)
+	})
+
+	t.Run("NoLookup", func(t *testing.T) {
+		c := newTestAlbum(UploadRetryPolicy{})
+		_, err := c.finishUpload(ctx, photoData, false, nil, ErrUploadProcessingTimeout)
+		var timeoutErr *UploadProcessingTimeoutError
+		require.ErrorAs(t, err, &timeoutErr)
+		assert.Equal(t, h, timeoutErr.MD5Hash)
+		assert.Equal(t, int64(0), c.photoCount)
+	})
+}
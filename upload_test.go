@@ -0,0 +1,290 @@
+package nixplay
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/anitschke/go-nixplay/httpx"
+	"github.com/anitschke/go-nixplay/rawapi"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type clientFunc func(req *http.Request) (*http.Response, error)
+
+func (f clientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func newTestResponse(statusCode int) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Status:     http.StatusText(statusCode),
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+	}
+}
+
+// readUploadedFile reads the content of the "file" part of the multipart form
+// that was uploaded to S3.
+func readUploadedFile(t *testing.T, req *http.Request) []byte {
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.Equal(t, req.ContentLength, int64(len(body)))
+
+	_, params, err := mime.ParseMediaType(req.Header.Get("content-type"))
+	require.NoError(t, err)
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		require.NoError(t, err)
+		if part.FormName() == "file" {
+			content, err := io.ReadAll(part)
+			require.NoError(t, err)
+			return content
+		}
+	}
+}
+
+func TestUploadS3WithRetry(t *testing.T) {
+	content := []byte("this is not really a photo")
+	expHash := types.MD5Hash(md5.Sum(content))
+
+	type testData struct {
+		name        string
+		reader      func() io.Reader
+		responses   []func() (*http.Response, error)
+		expAttempts int
+		expError    bool
+	}
+
+	serverError := func() (*http.Response, error) { return newTestResponse(http.StatusInternalServerError), nil }
+	forbidden := func() (*http.Response, error) { return newTestResponse(http.StatusForbidden), nil }
+	networkError := func() (*http.Response, error) { return nil, errors.New("connection reset") }
+	created := func() (*http.Response, error) { return newTestResponse(http.StatusCreated), nil }
+
+	testCases := []testData{
+		{
+			name:        "firstTry",
+			reader:      func() io.Reader { return bytes.NewReader(content) },
+			responses:   []func() (*http.Response, error){created},
+			expAttempts: 1,
+		},
+		{
+			name:        "retryAfterServerError",
+			reader:      func() io.Reader { return bytes.NewReader(content) },
+			responses:   []func() (*http.Response, error){serverError, networkError, created},
+			expAttempts: 3,
+		},
+		{
+			name:        "tooManyFailures",
+			reader:      func() io.Reader { return bytes.NewReader(content) },
+			responses:   []func() (*http.Response, error){serverError, serverError, serverError},
+			expAttempts: maxS3UploadAttempts,
+			expError:    true,
+		},
+		{
+			name:        "noRetryOnClientError",
+			reader:      func() io.Reader { return bytes.NewReader(content) },
+			responses:   []func() (*http.Response, error){forbidden},
+			expAttempts: 1,
+			expError:    true,
+		},
+		{
+			name:        "noRetryWithoutSeek",
+			reader:      func() io.Reader { return io.MultiReader(bytes.NewReader(content)) },
+			responses:   []func() (*http.Response, error){serverError},
+			expAttempts: 1,
+			expError:    true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			client := clientFunc(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, content, readUploadedFile(t, req))
+				resp := tc.responses[attempts]
+				attempts++
+				return resp()
+			})
+
+			u := rawapi.PhotoUploadResponse{S3UploadURL: "https://example.com/upload"}
+			hashPolicy := HashPolicy{Upload: []types.HashType{types.SHA256HashType}}
+			hashes, err := uploadS3WithRetry(context.Background(), client, Timeouts{}, maxS3UploadAttempts, hashPolicy, u, "photo.jpg", tc.reader(), int64(len(content)))
+			assert.Equal(t, tc.expAttempts, attempts)
+			if tc.expError {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, expHash, hashes.md5Hash())
+				// The hashes only cover the content of the final attempt.
+				expSHA256 := sha256.Sum256(content)
+				assert.Equal(t, map[types.HashType]string{types.SHA256HashType: hex.EncodeToString(expSHA256[:])}, hashes.sums())
+			}
+		})
+	}
+}
+
+func TestBufferUpload(t *testing.T) {
+	content := []byte("this is not really a photo")
+
+	type testData struct {
+		name      string
+		maxMemory int64
+		expFile   bool
+	}
+
+	testCases := []testData{
+		{name: "defaultLimit", maxMemory: 0, expFile: false},
+		{name: "exactlyAtLimit", maxMemory: int64(len(content)), expFile: false},
+		{name: "overLimit", maxMemory: int64(len(content)) - 1, expFile: true},
+		{name: "alwaysFile", maxMemory: -1, expFile: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Hide the bytes.Reader behind an io.MultiReader so that it can not
+			// seek.
+			r, size, cleanup, err := bufferUpload(io.MultiReader(bytes.NewReader(content)), tc.maxMemory)
+			require.NoError(t, err)
+
+			f, isFile := r.(*os.File)
+			assert.Equal(t, tc.expFile, isFile)
+			assert.Equal(t, int64(len(content)), size)
+
+			actContent, err := io.ReadAll(r)
+			assert.NoError(t, err)
+			assert.Equal(t, content, actContent)
+
+			cleanup()
+			if isFile {
+				_, err := os.Stat(f.Name())
+				assert.True(t, os.IsNotExist(err))
+			}
+		})
+	}
+}
+
+func TestGetUploadPhotoData_MaxFileSize(t *testing.T) {
+	content := []byte("this is not really a photo")
+
+	// There is no limit unless one is asked for.
+	data, _, cleanup, err := getUploadPhotoData("photo.jpg", bytes.NewReader(content), AddPhotoOptions{})
+	require.NoError(t, err)
+	cleanup()
+	assert.Equal(t, int64(len(content)), data.FileSize)
+
+	_, _, cleanup, err = getUploadPhotoData("photo.jpg", bytes.NewReader(content), AddPhotoOptions{MaxFileSize: int64(len(content))})
+	require.NoError(t, err)
+	cleanup()
+
+	_, _, _, err = getUploadPhotoData("photo.jpg", bytes.NewReader(content), AddPhotoOptions{MaxFileSize: int64(len(content)) - 1})
+	assert.ErrorIs(t, err, types.ErrFileTooLarge)
+}
+
+func TestUploadS3WithRetry_InjectedFaults(t *testing.T) {
+	content := []byte("this is not really a photo")
+
+	faults := []httpx.Fault{httpx.TimeoutFault, httpx.ConnectionResetFault, httpx.TooManyRequestsFault, httpx.ServerErrorFault}
+	for _, fault := range faults {
+		t.Run(fault.String(), func(t *testing.T) {
+			sent := 0
+			client := httpx.NewFaultInjectingClient(clientFunc(func(req *http.Request) (*http.Response, error) {
+				sent++
+				assert.Equal(t, content, readUploadedFile(t, req))
+				return newTestResponse(http.StatusCreated), nil
+			}), httpx.FaultSequence(fault, fault))
+
+			u := rawapi.PhotoUploadResponse{S3UploadURL: "https://example.com/upload"}
+			hashes, err := uploadS3WithRetry(context.Background(), client, Timeouts{}, maxS3UploadAttempts, HashPolicy{}, u, "photo.jpg", bytes.NewReader(content), int64(len(content)))
+			require.NoError(t, err)
+			assert.Equal(t, types.MD5Hash(md5.Sum(content)), hashes.md5Hash())
+			assert.Equal(t, 1, sent)
+		})
+	}
+
+	t.Run("tooManyFaults", func(t *testing.T) {
+		client := httpx.NewFaultInjectingClient(clientFunc(func(req *http.Request) (*http.Response, error) {
+			return newTestResponse(http.StatusCreated), nil
+		}), httpx.FaultEvery(1, httpx.ServerErrorFault))
+
+		u := rawapi.PhotoUploadResponse{S3UploadURL: "https://example.com/upload"}
+		_, err := uploadS3WithRetry(context.Background(), client, Timeouts{}, maxS3UploadAttempts, HashPolicy{}, u, "photo.jpg", bytes.NewReader(content), int64(len(content)))
+		assert.Error(t, err)
+	})
+}
+
+func TestContainer_AddPhotoAsync(t *testing.T) {
+	content := []byte("this is not really a photo")
+
+	// newTestAlbum returns an album whose upload monitor does not respond
+	// until release is closed.
+	newTestAlbum := func(release chan struct{}) *container {
+		client := clientFunc(func(req *http.Request) (*http.Response, error) {
+			resp := newTestResponse(http.StatusOK)
+			switch {
+			case req.URL.Path == "/v3/upload/receivers/":
+				resp.Body = io.NopCloser(strings.NewReader(`{"token":"t"}`))
+			case req.URL.Path == "/v3/photo/upload/":
+				resp.Body = io.NopCloser(strings.NewReader(`{"data":{"userUploadIds":["upload"],"s3UploadUrl":"https://s3.example.com/"}}`))
+			case req.URL.Host == "s3.example.com":
+				io.Copy(io.Discard, req.Body)
+				resp = newTestResponse(http.StatusCreated)
+			case req.URL.Host == "upload-monitor.nixplay.com":
+				select {
+				case <-release:
+				case <-req.Context().Done():
+					return nil, req.Context().Err()
+				}
+			default:
+				require.Fail(t, "unexpected request", req.URL.String())
+			}
+			return resp, nil
+		})
+		settings := &clientSettings{
+			metrics: nopMetrics{},
+			changes: &changeNotifier{},
+		}
+		return newContainer(client, nil, settings, types.AlbumContainerType, "album", 1234, 0, nil, (*rawapi.Client).DeleteAlbum, albumAddIDName)
+	}
+
+	t.Run("CallerContextDone", func(t *testing.T) {
+		// The upload keeps being monitored after the context passed to
+		// AddPhotoAsync is done.
+		release := make(chan struct{})
+		c := newTestAlbum(release)
+		ctx, cancel := context.WithCancel(context.Background())
+		h, err := c.AddPhotoAsync(ctx, "photo.jpg", bytes.NewReader(content), AddPhotoOptions{})
+		require.NoError(t, err)
+		cancel()
+		assert.Equal(t, types.UploadProcessingStatus, h.Status())
+
+		close(release)
+		p, err := h.Wait(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, photoID(c.ID(), types.MD5Hash(md5.Sum(content))), p.ID())
+		assert.Equal(t, types.UploadCompleteStatus, h.Status())
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		c := newTestAlbum(make(chan struct{}))
+		h, err := c.AddPhotoAsync(context.Background(), "photo.jpg", bytes.NewReader(content), AddPhotoOptions{})
+		require.NoError(t, err)
+		h.Cancel()
+		_, err = h.Wait(context.Background())
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, types.UploadFailedStatus, h.Status())
+	})
+}
@@ -0,0 +1,121 @@
+package nixplay
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Nixplay does not report the length of videos, so it is read from the video
+// itself. Videos are MP4 files, which are made up of a sequence of boxes that
+// each start with a 32 bit size and a 4 character type. The length of the
+// video is in the "mvhd" box inside of the "moov" box. Only the headers of
+// the boxes are read, so finding the length of a video only downloads a few
+// small ranges of it no matter if the "moov" box is at the start or the end of
+// the file.
+
+// readRangeFunc reads exactly length bytes starting at offset.
+type readRangeFunc func(ctx context.Context, offset int64, length int64) ([]byte, error)
+
+// mp4Duration returns the length of the MP4 video of the given size that is
+// read using readRange.
+func mp4Duration(ctx context.Context, readRange readRangeFunc, size int64) (time.Duration, error) {
+	moovStart, moovEnd, err := findMP4Box(ctx, readRange, 0, size, "moov")
+	if err != nil {
+		return 0, err
+	}
+	mvhdStart, mvhdEnd, err := findMP4Box(ctx, readRange, moovStart, moovEnd, "mvhd")
+	if err != nil {
+		return 0, err
+	}
+
+	// The mvhd box starts with a 1 byte version and 3 bytes of flags. For
+	// version 0 the creation and modification times, time scale and duration
+	// that follow are 32 bit, for version 1 all but the time scale are 64 bit.
+	length := mvhdEnd - mvhdStart
+	if length > 32 {
+		length = 32
+	}
+	mvhd, err := readRange(ctx, mvhdStart, length)
+	if err != nil {
+		return 0, err
+	}
+	var timeScale, duration uint64
+	switch {
+	case len(mvhd) >= 20 && mvhd[0] == 0:
+		timeScale = uint64(binary.BigEndian.Uint32(mvhd[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+	case len(mvhd) >= 32 && mvhd[0] == 1:
+		timeScale = uint64(binary.BigEndian.Uint32(mvhd[20:24]))
+		duration = binary.BigEndian.Uint64(mvhd[24:32])
+	default:
+		return 0, errors.New("unsupported mvhd box")
+	}
+	if timeScale == 0 {
+		return 0, errors.New("mvhd box has a time scale of 0")
+	}
+
+	// Split into whole seconds and the remainder to avoid overflowing when
+	// converting to nanoseconds.
+	seconds := duration / timeScale
+	remainder := duration % timeScale
+	return time.Duration(seconds)*time.Second + time.Duration(remainder)*time.Second/time.Duration(timeScale), nil
+}
+
+// findMP4Box finds the box with the given type among the boxes between start
+// and end and returns the range of the content of the box.
+func findMP4Box(ctx context.Context, readRange readRangeFunc, start int64, end int64, boxType string) (contentStart int64, contentEnd int64, err error) {
+	for offset := start; offset+8 <= end; {
+		headerLength := int64(16)
+		if end-offset < headerLength {
+			headerLength = 8
+		}
+		header, err := readRange(ctx, offset, headerLength)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		boxSize := int64(binary.BigEndian.Uint32(header[0:4]))
+		headerLength = 8
+		switch boxSize {
+		case 0:
+			// The box extends to the end.
+			boxSize = end - offset
+		case 1:
+			// The size is the 64 bit value that follows the type.
+			if len(header) < 16 {
+				return 0, 0, errors.New("truncated MP4 box header")
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerLength = 16
+		}
+		if boxSize < headerLength || boxSize > end-offset {
+			return 0, 0, fmt.Errorf("invalid size of MP4 box at offset %d", offset)
+		}
+
+		if string(header[4:8]) == boxType {
+			return offset + headerLength, offset + boxSize, nil
+		}
+		offset += boxSize
+	}
+	return 0, 0, fmt.Errorf("no %q box found in MP4 video", boxType)
+}
+
+// readRange reads exactly length bytes of the content of the photo starting at
+// offset.
+func (p *photo) readRange(ctx context.Context, offset int64, length int64) ([]byte, error) {
+	r, err := p.OpenRange(ctx, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,131 @@
+package nixplay
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func uint32Bytes(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func uint64Bytes(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+// mp4Box returns an MP4 box with a 32 bit size.
+func mp4Box(boxType string, content ...[]byte) []byte {
+	body := concat(content...)
+	return concat(uint32Bytes(uint32(8+len(body))), []byte(boxType), body)
+}
+
+// mp4LargeBox returns an MP4 box with a 64 bit size.
+func mp4LargeBox(boxType string, body []byte) []byte {
+	return concat(uint32Bytes(1), []byte(boxType), uint64Bytes(uint64(16+len(body))), body)
+}
+
+// mvhdBox returns a version 0 mvhd box.
+func mvhdBox(timeScale uint32, duration uint32) []byte {
+	return mp4Box("mvhd", make([]byte, 12), uint32Bytes(timeScale), uint32Bytes(duration), make([]byte, 80))
+}
+
+// mvhdBoxV1 returns a version 1 mvhd box.
+func mvhdBoxV1(timeScale uint32, duration uint64) []byte {
+	return mp4Box("mvhd", []byte{1}, make([]byte, 19), uint32Bytes(timeScale), uint64Bytes(duration), make([]byte, 80))
+}
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+// readRangeOf returns a readRangeFunc that reads from data and counts the
+// number of bytes read.
+func readRangeOf(t *testing.T, data []byte, read *int64) readRangeFunc {
+	return func(ctx context.Context, offset int64, length int64) ([]byte, error) {
+		require.LessOrEqual(t, offset+length, int64(len(data)))
+		*read += length
+		return data[offset : offset+length], nil
+	}
+}
+
+func TestMP4Duration(t *testing.T) {
+	ctx := context.Background()
+
+	ftyp := mp4Box("ftyp", []byte("isom\x00\x00\x02\x00isomiso2mp41"))
+	mdat := mp4Box("mdat", make([]byte, 10000))
+
+	tests := []struct {
+		name     string
+		video    []byte
+		duration time.Duration
+	}{
+		{
+			name:     "MoovAtStart",
+			video:    concat(ftyp, mp4Box("moov", mvhdBox(1000, 2500)), mdat),
+			duration: 2500 * time.Millisecond,
+		},
+		{
+			name:     "MoovAtEnd",
+			video:    concat(ftyp, mdat, mp4Box("moov", mvhdBox(600, 900))),
+			duration: 1500 * time.Millisecond,
+		},
+		{
+			name:     "Version1",
+			video:    concat(ftyp, mp4Box("moov", mp4Box("udta"), mvhdBoxV1(90000, 90000*3600)), mdat),
+			duration: time.Hour,
+		},
+		{
+			name:     "LargeSize",
+			video:    concat(ftyp, mp4LargeBox("mdat", make([]byte, 10000)), mp4Box("moov", mvhdBox(1, 7))),
+			duration: 7 * time.Second,
+		},
+		{
+			name:     "SizeToEnd",
+			video:    concat(ftyp, mp4Box("free"), []byte{0, 0, 0, 0, 'm', 'o', 'o', 'v'}, mvhdBox(1000, 42)),
+			duration: 42 * time.Millisecond,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var read int64
+			duration, err := mp4Duration(ctx, readRangeOf(t, tc.video, &read), int64(len(tc.video)))
+			require.NoError(t, err)
+			assert.Equal(t, tc.duration, duration)
+
+			// Only the headers of the boxes should be read, not the media
+			// data.
+			assert.Less(t, read, int64(200))
+		})
+	}
+
+	errorTests := []struct {
+		name  string
+		video []byte
+	}{
+		{name: "NotMP4", video: []byte("this is not a video at all")},
+		{name: "NoMoov", video: concat(ftyp, mdat)},
+		{name: "NoMvhd", video: concat(ftyp, mp4Box("moov", mp4Box("trak")))},
+		{name: "Truncated", video: concat(ftyp, mp4Box("moov", mvhdBox(1000, 2500)))[:40]},
+		{name: "ZeroTimeScale", video: concat(ftyp, mp4Box("moov", mvhdBox(0, 2500)))},
+	}
+	for _, tc := range errorTests {
+		t.Run(tc.name, func(t *testing.T) {
+			var read int64
+			_, err := mp4Duration(ctx, readRangeOf(t, tc.video, &read), int64(len(tc.video)))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,211 @@
+// Package watch provides a Watcher that polls a Nixplay account for changes,
+// such as photos being added to a shared playlist, and emits events for them.
+package watch
+
+import (
+	"context"
+	"time"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/diff"
+	"github.com/anitschke/go-nixplay/export"
+	"github.com/anitschke/go-nixplay/types"
+)
+
+// defaultInterval is the time between polls if Options.Interval is not
+// specified.
+const defaultInterval = 5 * time.Minute
+
+// EventType is the type of change an Event describes.
+type EventType string
+
+const (
+	ContainerAdded   EventType = "containerAdded"
+	ContainerRemoved EventType = "containerRemoved"
+	ContainerRenamed EventType = "containerRenamed"
+	PhotoAdded       EventType = "photoAdded"
+	PhotoRemoved     EventType = "photoRemoved"
+	PhotoRenamed     EventType = "photoRenamed"
+)
+
+// Event describes a single change that was detected by the Watcher.
+type Event struct {
+	Type EventType
+
+	// Container is the container that was changed, or the container of the
+	// photo that was changed. The Photos of Container are not populated.
+	Container export.Container
+
+	// Photo is the photo that was changed for photo events.
+	Photo export.Photo
+
+	// OldName is the previous name of the container or photo for rename
+	// events.
+	OldName string
+}
+
+// Options are optional arguments that may be specified when creating a
+// Watcher.
+type Options struct {
+	// Interval is the time between polls of the account. If zero a default of
+	// 5 minutes is used.
+	//
+	// Each poll lists all of the watched containers and their photos again so
+	// polling very frequently is not recommended.
+	Interval time.Duration
+
+	// ContainerIDs limits the watcher to only the containers with the
+	// specified IDs. If empty all albums and playlists are watched, including
+	// containers that are created while watching.
+	ContainerIDs []types.ID
+
+	// OnError is called if polling the account fails. The watcher continues
+	// and tries again at the next interval. If OnError is nil errors are
+	// ignored.
+	OnError func(err error)
+
+	// EventBuffer is the capacity of the channel returned by
+	// Watcher.Events.
+	EventBuffer int
+}
+
+// Watcher periodically polls a Nixplay account and emits an Event for every
+// change it detects between polls.
+type Watcher struct {
+	client nixplay.Client
+	opts   Options
+	events chan Event
+
+	last *export.Manifest
+}
+
+// New creates a new Watcher. Polling does not start until Run is called.
+func New(client nixplay.Client, opts Options) *Watcher {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+	return &Watcher{
+		client: client,
+		opts:   opts,
+		events: make(chan Event, opts.EventBuffer),
+	}
+}
+
+// Events returns the channel that events are sent on. The channel is closed
+// when Run returns.
+//
+// Events must be received for the watcher to make progress. When a container
+// is added or removed only a single container event is sent, not an event for
+// every photo within the container.
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Run polls the account until ctx is done and then returns ctx.Err(). The
+// first poll establishes the initial state of the account and does not emit
+// any events.
+func (w *Watcher) Run(ctx context.Context) error {
+	defer close(w.events)
+
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.poll(ctx); err != nil && ctx.Err() == nil && w.opts.OnError != nil {
+			w.opts.OnError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Watcher) poll(ctx context.Context) error {
+	m, err := w.snapshot(nixplay.WithFreshData(ctx))
+	if err != nil {
+		return err
+	}
+
+	last := w.last
+	w.last = m
+	if last == nil {
+		return nil
+	}
+
+	for _, e := range events(diff.Compute(last, m)) {
+		select {
+		case w.events <- e:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (w *Watcher) snapshot(ctx context.Context) (*export.Manifest, error) {
+	if len(w.opts.ContainerIDs) == 0 {
+		return export.Snapshot(ctx, w.client)
+	}
+
+	watched := make(map[types.ID]bool, len(w.opts.ContainerIDs))
+	for _, id := range w.opts.ContainerIDs {
+		watched[id] = true
+	}
+
+	var containers []nixplay.Container
+	for _, containerType := range []types.ContainerType{types.AlbumContainerType, types.PlaylistContainerType} {
+		cs, err := w.client.Containers(ctx, containerType)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range cs {
+			if watched[c.ID()] {
+				containers = append(containers, c)
+			}
+		}
+	}
+	return export.SnapshotContainers(ctx, containers)
+}
+
+// events converts the result of a diff into a list of events.
+func events(r diff.Result) []Event {
+	var events []Event
+	for _, c := range r.RemovedContainers {
+		c.Photos = nil
+		events = append(events, Event{Type: ContainerRemoved, Container: c})
+	}
+	for _, c := range r.AddedContainers {
+		c.Photos = nil
+		events = append(events, Event{Type: ContainerAdded, Container: c})
+	}
+	for _, rename := range r.RenamedContainers {
+		c := rename.New
+		c.Photos = nil
+		events = append(events, Event{Type: ContainerRenamed, Container: c, OldName: rename.Old.Name})
+	}
+
+	changedContainers := make(map[string]bool, len(r.AddedContainers)+len(r.RemovedContainers))
+	for _, c := range r.AddedContainers {
+		changedContainers[string(c.Type)+"/"+c.ID] = true
+	}
+	for _, c := range r.RemovedContainers {
+		changedContainers[string(c.Type)+"/"+c.ID] = true
+	}
+	for _, p := range r.RemovedPhotos {
+		if !changedContainers[string(p.Container.Type)+"/"+p.Container.ID] {
+			events = append(events, Event{Type: PhotoRemoved, Container: p.Container, Photo: p.Photo})
+		}
+	}
+	for _, p := range r.AddedPhotos {
+		if !changedContainers[string(p.Container.Type)+"/"+p.Container.ID] {
+			events = append(events, Event{Type: PhotoAdded, Container: p.Container, Photo: p.Photo})
+		}
+	}
+	for _, rename := range r.RenamedPhotos {
+		events = append(events, Event{Type: PhotoRenamed, Container: rename.Container, Photo: rename.New, OldName: rename.Old.Name})
+	}
+	return events
+}
@@ -0,0 +1,157 @@
+package watch
+
+import (
+	"context"
+	"crypto/md5"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	nixplay "github.com/anitschke/go-nixplay"
+	"github.com/anitschke/go-nixplay/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient, fakeContainer and fakePhoto implement just enough of the nixplay
+// interfaces to test this package. Calling any other method panics.
+type fakeClient struct {
+	nixplay.Client
+	mu         sync.Mutex
+	containers []nixplay.Container
+	err        error
+	lists      int
+}
+
+func (c *fakeClient) Containers(ctx context.Context, containerType types.ContainerType) ([]nixplay.Container, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.lists++
+	var containers []nixplay.Container
+	for _, container := range c.containers {
+		if container.ContainerType() == containerType {
+			containers = append(containers, container)
+		}
+	}
+	return containers, nil
+}
+
+type fakeContainer struct {
+	nixplay.Container
+	containerType types.ContainerType
+	id            types.ID
+	name          string
+	photos        []nixplay.Photo
+}
+
+func (c *fakeContainer) ContainerType() types.ContainerType       { return c.containerType }
+func (c *fakeContainer) ID() types.ID                             { return c.id }
+func (c *fakeContainer) Name(ctx context.Context) (string, error) { return c.name, nil }
+func (c *fakeContainer) Photos(ctx context.Context) ([]nixplay.Photo, error) {
+	return c.photos, nil
+}
+
+type fakePhoto struct {
+	nixplay.Photo
+	id   types.ID
+	name string
+}
+
+func (p *fakePhoto) ID() types.ID                                    { return p.id }
+func (p *fakePhoto) Name(ctx context.Context) (string, error)        { return p.name, nil }
+func (p *fakePhoto) Size(ctx context.Context) (int64, error)         { return 1, nil }
+func (p *fakePhoto) URL(ctx context.Context) (string, error)         { return "", nil }
+func (p *fakePhoto) Duration(context.Context) (time.Duration, error) { return 0, nil }
+func (p *fakePhoto) MD5Hash(ctx context.Context) (types.MD5Hash, error) {
+	return md5.Sum([]byte(p.name)), nil
+}
+func (p *fakePhoto) MediaType(ctx context.Context) (types.MediaType, error) {
+	return types.PhotoMediaType, nil
+}
+
+func TestWatcher_Poll(t *testing.T) {
+	ctx := context.Background()
+
+	playlist := &fakeContainer{containerType: types.PlaylistContainerType, id: types.ID{1}, name: "shared"}
+	album := &fakeContainer{containerType: types.AlbumContainerType, id: types.ID{2}, name: "album"}
+	client := &fakeClient{containers: []nixplay.Container{playlist, album}}
+
+	w := New(client, Options{EventBuffer: 10, ContainerIDs: []types.ID{playlist.id}})
+	require.NoError(t, w.poll(ctx))
+	assert.Empty(t, w.events)
+
+	playlist.photos = []nixplay.Photo{&fakePhoto{id: types.ID{3}, name: "new.jpg"}}
+	playlist.name = "renamed"
+	album.photos = []nixplay.Photo{&fakePhoto{id: types.ID{4}, name: "ignored.jpg"}}
+	require.NoError(t, w.poll(ctx))
+
+	require.Len(t, w.events, 2)
+	e := <-w.events
+	assert.Equal(t, ContainerRenamed, e.Type)
+	assert.Equal(t, "renamed", e.Container.Name)
+	assert.Equal(t, "shared", e.OldName)
+	e = <-w.events
+	assert.Equal(t, PhotoAdded, e.Type)
+	assert.Equal(t, "renamed", e.Container.Name)
+	assert.Equal(t, "new.jpg", e.Photo.Name)
+
+	require.NoError(t, w.poll(ctx))
+	assert.Empty(t, w.events)
+}
+
+func TestWatcher_Run(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	expErr := errors.New("poll failed")
+	client := &fakeClient{err: expErr}
+
+	errs := make(chan error, 1)
+	w := New(client, Options{
+		Interval: time.Millisecond,
+		OnError: func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		},
+	})
+
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+
+	// Errors are reported and polling continues until there is a
+	// successful poll to establish the initial state.
+	assert.ErrorIs(t, <-errs, expErr)
+	client.mu.Lock()
+	client.err = nil
+	client.containers = []nixplay.Container{&fakeContainer{containerType: types.AlbumContainerType, id: types.ID{1}, name: "album"}}
+	client.mu.Unlock()
+
+	// Each poll lists albums and playlists, so once there have been three
+	// successful lists the initial state has been recorded.
+	assert.Eventually(t, func() bool {
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		return client.lists >= 3
+	}, 10*time.Second, time.Millisecond)
+
+	client.mu.Lock()
+	client.containers = nil
+	client.mu.Unlock()
+
+	for e := range w.Events() {
+		if e.Type == ContainerRemoved {
+			break
+		}
+	}
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+	_, ok := <-w.Events()
+	assert.False(t, ok)
+}